@@ -0,0 +1,197 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ik5/smudh/charset"
+)
+
+const (
+	// addressHeaderLength is the length of the address length and type of address octets of an address field
+	addressHeaderLength = 2
+
+	// maxAddressValueLength is the maximum length in octets of the value of an address field
+	maxAddressValueLength = 10
+
+	// toaExtension is the bit that is always set at the type of address octet
+	toaExtension = 0x80
+
+	// semiOctetFiller pads the last octet of a semi-octet address value holding an odd number of digits
+	semiOctetFiller = 0x0F
+)
+
+// semiOctetDigits holds the characters of the semi-octet values of a numeric address value.
+const semiOctetDigits = "0123456789*#abc"
+
+// DecodeAlphanumericAddress decodes an alphanumeric address field of 3GPP TS 23.040, such as the TP-OA of a
+// deliver TPDU holding a sender ID like "MyBank": the address length in semi-octets, the type of address with a
+// type of number of 5, and the septet packed GSM 7-bit characters.
+//
+// Returns an error wrapping ErrInvalidAddress when the field is truncated, or its type of number is not
+// alphanumeric.
+func DecodeAlphanumericAddress(field []byte) (string, error) {
+	if len(field) < addressHeaderLength {
+		return "", fmt.Errorf("%w: %d octets are too short for an address field", ErrInvalidAddress, len(field))
+	}
+
+	semiOctets, toa := int(field[0]), field[1]
+
+	if ton, _ := DecodeTypeOfAddress(toa); ton != TONAlphanumeric {
+		return "", fmt.Errorf("%w: type of number %d is not alphanumeric", ErrInvalidAddress, ton)
+	}
+
+	length := (semiOctets + 1) / 2
+	if len(field) < addressHeaderLength+length {
+		return "", fmt.Errorf("%w: %d semi-octets exceed the %d octets of the field", ErrInvalidAddress, semiOctets,
+			len(field))
+	}
+
+	return DecodeAlphanumeric(field[addressHeaderLength:addressHeaderLength+length], semiOctets)
+}
+
+// DecodeAlphanumeric decodes the septet packed GSM 7-bit characters of the value of an alphanumeric address,
+// given the address length in semi-octets, for callers that already split the address field.
+func DecodeAlphanumeric(value []byte, semiOctets int) (string, error) {
+	count := semiOctets * 4 / 7
+	if count > len(value)*8/7 {
+		return "", fmt.Errorf("%w: %d semi-octets exceed the %d octets of the value", ErrInvalidAddress, semiOctets,
+			len(value))
+	}
+
+	septets := UnpackGSM7(value, 0)
+	if len(septets) < count {
+		// a last @ dropped by UnpackGSM7 as the padding of the octet
+		septets = append(septets, make([]byte, count-len(septets))...)
+	}
+
+	text, err := charset.Decode(context.Background(), GSM, septets[:count])
+	if err != nil {
+		return "", fmt.Errorf("%w", err)
+	}
+
+	return text, nil
+}
+
+// EncodeAlphanumericAddress encodes sender as an alphanumeric address field, the reverse of
+// DecodeAlphanumericAddress, using a numbering plan indicator of 0. Returns an error wrapping
+// ErrCharacterNotRepresentable for characters without a GSM 7-bit representation, or ErrInvalidAddress when the
+// packed sender is longer than the 10 octets of an address value.
+func EncodeAlphanumericAddress(sender string) ([]byte, error) {
+	septets, err := EncodeText(sender, GSM)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	value := PackGSM7(septets, 0)
+	if len(value) > maxAddressValueLength {
+		return nil, fmt.Errorf("%w: %q takes %d octets", ErrInvalidAddress, sender, len(value))
+	}
+
+	semiOctets := (len(septets)*7 + 3) / 4
+
+	return append([]byte{byte(semiOctets), toaExtension | byte(TONAlphanumeric)<<4}, value...), nil
+}
+
+// DecodeAddress decodes an address field of 3GPP TS 23.040, either alphanumeric as done by
+// DecodeAlphanumericAddress, or numeric with its digits coded as semi-octets.
+//
+// Returns an error wrapping ErrInvalidAddress when the field is truncated.
+func DecodeAddress(field []byte) (Address, error) {
+	if len(field) < addressHeaderLength {
+		return Address{}, fmt.Errorf("%w: %d octets are too short for an address field", ErrInvalidAddress,
+			len(field))
+	}
+
+	semiOctets := int(field[0])
+	ton, npi := DecodeTypeOfAddress(field[1])
+
+	if ton == TONAlphanumeric {
+		number, err := DecodeAlphanumericAddress(field)
+		if err != nil {
+			return Address{}, err
+		}
+
+		return Address{TON: ton, NPI: npi, Number: number}, nil
+	}
+
+	length := (semiOctets + 1) / 2
+	if len(field) < addressHeaderLength+length {
+		return Address{}, fmt.Errorf("%w: %d semi-octets exceed the %d octets of the field", ErrInvalidAddress,
+			semiOctets, len(field))
+	}
+
+	number := make([]byte, 0, semiOctets)
+
+	for idx := range semiOctets {
+		value := field[addressHeaderLength+idx/2]
+		if idx%2 == 1 {
+			value >>= 4
+		}
+
+		value &= 0x0F
+		if value == semiOctetFiller {
+			return Address{}, fmt.Errorf("%w: filler at semi-octet %d", ErrInvalidAddress, idx+1)
+		}
+
+		number = append(number, semiOctetDigits[value])
+	}
+
+	return Address{TON: ton, NPI: npi, Number: string(number)}, nil
+}
+
+// EncodeAddress encodes addr as an address field of 3GPP TS 23.040, the reverse of DecodeAddress. A leading plus
+// sign of the number is dropped, and its type of number is set to TONInternational.
+//
+// Returns an error wrapping ErrInvalidAddress when the type of number or the numbering plan indicator does not fit
+// the type of address octet, the number holds characters that cannot be coded as semi-octets, or the value is
+// longer than 10 octets. Alphanumeric addresses may also return the errors of EncodeAlphanumericAddress.
+func EncodeAddress(addr Address) ([]byte, error) {
+	number := addr.Number
+	if strings.HasPrefix(number, "+") {
+		number, addr.TON = number[1:], TONInternational
+	}
+
+	toa, err := EncodeTypeOfAddress(addr.TON, addr.NPI)
+	if err != nil {
+		return nil, err
+	}
+
+	if addr.TON == TONAlphanumeric {
+		field, err := EncodeAlphanumericAddress(number)
+		if err != nil {
+			return nil, err
+		}
+
+		field[1] = toa
+
+		return field, nil
+	}
+
+	if len(number) > maxAddressValueLength*2 {
+		return nil, fmt.Errorf("%w: %q takes %d semi-octets", ErrInvalidAddress, number, len(number))
+	}
+
+	field := make([]byte, addressHeaderLength, addressHeaderLength+(len(number)+1)/2)
+	field[0], field[1] = byte(len(number)), toa
+
+	for idx := range len(number) {
+		value := strings.IndexByte(semiOctetDigits, number[idx])
+		if value < 0 {
+			return nil, fmt.Errorf("%w: %q cannot be coded as semi-octets", ErrInvalidAddress, number)
+		}
+
+		if idx%2 == 0 {
+			field = append(field, semiOctetFiller<<4|byte(value))
+		} else {
+			field[len(field)-1] = field[len(field)-1]&0x0F | byte(value)<<4
+		}
+	}
+
+	return field, nil
+}
@@ -0,0 +1,222 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestAlphanumericAddress(t *testing.T) {
+	tests := []struct {
+		name   string
+		sender string
+		field  []byte
+	}{
+		{name: "bank", sender: "MyBank", field: []byte{0x0B, 0xD0, 0xCD, 0xBC, 0x30, 0xEC, 0x5E, 0x03}},
+		{name: "seven characters", sender: "Example", field: []byte{0x0D, 0xD0, 0x45, 0x7C, 0xB8, 0x0D, 0x67, 0x97, 0x01}},
+		{name: "trailing @", sender: "Shop@", field: []byte{0x09, 0xD0, 0x53, 0xF4, 0x1B, 0x0E, 0x00}},
+		{name: "eleven characters", sender: "ElevenChars", field: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			field, err := udh.EncodeAlphanumericAddress(test.sender)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if test.field != nil {
+				if diff := cmp.Diff(test.field, field); diff != "" {
+					t2.Errorf("unexpected address field (-want +got):\n%s", diff)
+				}
+			}
+
+			sender, err := udh.DecodeAlphanumericAddress(field)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if sender != test.sender {
+				t2.Errorf("have sender %q, expected %q", sender, test.sender)
+			}
+		})
+	}
+
+	for name, field := range map[string][]byte{
+		"numeric":   {0x0B, 0x91, 0x21, 0x43, 0x65, 0x87, 0x09, 0xF1},
+		"truncated": {0x0B, 0xD0, 0xCD, 0x3C},
+		"empty":     {},
+	} {
+		_, err := udh.DecodeAlphanumericAddress(field)
+		if !errors.Is(err, udh.ErrInvalidAddress) {
+			t.Errorf("%s: expected an error wrapping ErrInvalidAddress, got %v", name, err)
+		}
+	}
+
+	_, err := udh.EncodeAlphanumericAddress("TooLongSenderID")
+	if !errors.Is(err, udh.ErrInvalidAddress) {
+		t.Errorf("expected an error wrapping ErrInvalidAddress for a long sender, got %v", err)
+	}
+}
+
+func TestAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  udh.Address
+		field    []byte
+		expected udh.Address
+	}{
+		{
+			name:    "international",
+			address: udh.Address{TON: udh.TONInternational, NPI: udh.NPIISDN, Number: "12345678901"},
+			field:   []byte{0x0B, 0x91, 0x21, 0x43, 0x65, 0x87, 0x09, 0xF1},
+		},
+		{
+			name:     "plus sign",
+			address:  udh.Address{NPI: udh.NPIISDN, Number: "+972501234567"},
+			field:    []byte{0x0C, 0x91, 0x79, 0x52, 0x10, 0x32, 0x54, 0x76},
+			expected: udh.Address{TON: udh.TONInternational, NPI: udh.NPIISDN, Number: "972501234567"},
+		},
+		{
+			name:    "short code",
+			address: udh.Address{TON: udh.TONNetworkSpecific, NPI: udh.NPIUnknown, Number: "*100#"},
+			field:   []byte{0x05, 0xB0, 0x1A, 0x00, 0xFB},
+		},
+		{
+			name:    "alphanumeric",
+			address: udh.Address{TON: udh.TONAlphanumeric, NPI: udh.NPIUnknown, Number: "MyBank"},
+			field:   []byte{0x0B, 0xD0, 0xCD, 0xBC, 0x30, 0xEC, 0x5E, 0x03},
+		},
+		{
+			name:    "empty",
+			address: udh.Address{TON: udh.TONUnknown, NPI: udh.NPIISDN},
+			field:   []byte{0x00, 0x81},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			field, err := udh.EncodeAddress(test.address)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.field, field); diff != "" {
+				t2.Errorf("unexpected address field (-want +got):\n%s", diff)
+			}
+
+			address, err := udh.DecodeAddress(field)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			expected := test.expected
+			if expected == (udh.Address{}) {
+				expected = test.address
+			}
+
+			if diff := cmp.Diff(expected, address); diff != "" {
+				t2.Errorf("unexpected address (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	for name, address := range map[string]udh.Address{
+		"WAP client plan": {TON: udh.TONUnknown, NPI: udh.NPIWAPClient, Number: "1"},
+		"not a digit":     {TON: udh.TONNational, NPI: udh.NPIISDN, Number: "05x"},
+		"too long":        {TON: udh.TONInternational, NPI: udh.NPIISDN, Number: "123456789012345678901"},
+	} {
+		_, err := udh.EncodeAddress(address)
+		if !errors.Is(err, udh.ErrInvalidAddress) {
+			t.Errorf("%s: expected an error wrapping ErrInvalidAddress, got %v", name, err)
+		}
+	}
+
+	for name, field := range map[string][]byte{
+		"truncated": {0x0B, 0x91, 0x21, 0x43},
+		"filler":    {0x02, 0x81, 0xF1},
+		"empty":     {0x0B},
+	} {
+		_, err := udh.DecodeAddress(field)
+		if !errors.Is(err, udh.ErrInvalidAddress) {
+			t.Errorf("%s: expected an error wrapping ErrInvalidAddress, got %v", name, err)
+		}
+	}
+}
+
+func TestNormalizeE164(t *testing.T) {
+	tests := []struct {
+		name        string
+		number      string
+		ton         udh.TON
+		countryCode string
+		expected    string
+	}{
+		{name: "plus sign", number: "+972 50-123-4567", ton: udh.TONUnknown, expected: "+972501234567"},
+		{name: "international", number: "972501234567", ton: udh.TONInternational, expected: "+972501234567"},
+		{name: "international prefix", number: "00972501234567", ton: udh.TONUnknown, expected: "+972501234567"},
+		{
+			name: "national trunk prefix", number: "050-1234567", ton: udh.TONNational, countryCode: "972",
+			expected: "+972501234567",
+		},
+		{
+			name: "national with parentheses", number: "(212) 555.0100", ton: udh.TONNational, countryCode: "+1",
+			expected: "+12125550100",
+		},
+		{name: "national without a country code", number: "0501234567", ton: udh.TONNational},
+		{name: "alphanumeric", number: "MyBank", ton: udh.TONAlphanumeric, countryCode: "972"},
+		{name: "letters", number: "+97250ABC", ton: udh.TONUnknown},
+		{name: "too long", number: "+1234567890123456", ton: udh.TONInternational},
+		{name: "empty", number: "+", ton: udh.TONInternational},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			address := udh.Address{TON: test.ton, NPI: udh.NPIISDN, Number: test.number}
+
+			number, err := address.E164(test.countryCode)
+			if test.expected == "" {
+				if !errors.Is(err, udh.ErrInvalidAddress) {
+					t2.Errorf("expected an error wrapping ErrInvalidAddress, got %q, %v", number, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if number != test.expected {
+				t2.Errorf("have %q, expected %q", number, test.expected)
+			}
+		})
+	}
+}
+
+func TestPDUAddresses(t *testing.T) {
+	pdu, err := udh.ParsePDU(deliverSM(0x00, 0x00, []byte("A")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := udh.Address{TON: udh.TONInternational, NPI: udh.NPIISDN, Number: "972501234567"}
+	if diff := cmp.Diff(expected, pdu.Source()); diff != "" {
+		t.Errorf("unexpected source (-want +got):\n%s", diff)
+	}
+
+	expected = udh.Address{TON: udh.TONAlphanumeric, NPI: udh.NPIUnknown, Number: "Bank"}
+	if diff := cmp.Diff(expected, pdu.Destination()); diff != "" {
+		t.Errorf("unexpected destination (-want +got):\n%s", diff)
+	}
+
+	if ton, npi := pdu.Source().TON, pdu.Source().NPI; ton.String() != "international" || npi.String() != "ISDN" {
+		t.Errorf("have %s %s, expected international ISDN", ton, npi)
+	}
+}
@@ -0,0 +1,143 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// AssembledMessage is a fully received message, in the form handed over to other systems.
+type AssembledMessage struct {
+	// Hex encoded reference number, empty for single messages
+	Reference string `json:"reference"`
+
+	// Encoding of the message
+	Encoding Encoding `json:"encoding"`
+
+	// Number of parts the message was received in
+	Parts int `json:"parts"`
+
+	// The assembled text, which is the hex encoded payload for the binary encodings
+	Text string `json:"text"`
+
+	// The payload of the binary encodings, nil for the text encodings
+	Binary *BinaryMessage `json:"binary,omitempty"`
+
+	// Application port addressing of the message, nil when there is none
+	Ports *Ports `json:"ports,omitempty"`
+
+	// Content type detected using DetectContentType
+	ContentType ContentType `json:"content_type,omitempty"`
+
+	// Parsed content, set only when using WithContentParsing for a content type that has a parser
+	Content any `json:"content,omitempty"`
+
+	// True for the partial text of a message that expired before all of its fragments arrived, as delivered by
+	// ExpiryDeliverPartial
+	Incomplete bool `json:"incomplete,omitempty"`
+
+	// The part numbers missing from an incomplete message
+	MissingParts []byte `json:"missing_parts,omitempty"`
+
+	// True when the numbering of the fragments was repaired, see MessageFragmentations.Repaired
+	Repaired bool `json:"repaired,omitempty"`
+
+	// Message class of the message, see MessageFragmentations.MessageClass
+	MessageClass MessageClass `json:"message_class,omitempty"`
+
+	// Metadata attached to the fragments when they were added, see MessageFragmentations.Metadata
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// ISO 639-1 code of the language of the text, set only when using WithLanguageDetection and the language was
+	// detected
+	Language string `json:"language,omitempty"`
+
+	// Confidence of the detected language, between 0 and 1
+	LanguageConfidence float64 `json:"language_confidence,omitempty"`
+}
+
+// AssembleOption is a functional option for NewAssembledMessage.
+type AssembleOption func(*assembleConfig)
+
+// assembleConfig holds the settings of NewAssembledMessage.
+type assembleConfig struct {
+	parseContent     bool
+	languageDetector LanguageDetector
+}
+
+// WithContentParsing parses the payload of the message using ParseContent, such as the vCard of a phone book push,
+// and sets the result at AssembledMessage.Content.
+func WithContentParsing() AssembleOption {
+	return func(config *assembleConfig) {
+		config.parseContent = true
+	}
+}
+
+// NewAssembledMessage returns the AssembledMessage of fragments, without modifying fragments.
+// The content type is always detected, using the application ports of the first fragment that has them.
+// Returns ErrMessageNotComplete if not all of the fragments exist, or ErrInvalidContent when using
+// WithContentParsing and the content cannot be parsed.
+func NewAssembledMessage(fragments MessageFragmentations, options ...AssembleOption) (AssembledMessage, error) {
+	if !fragments.HaveAllFragments() {
+		return AssembledMessage{}, ErrMessageNotComplete
+	}
+
+	config := assembleConfig{}
+	for _, option := range options {
+		option(&config)
+	}
+
+	return assemble(fragments, config)
+}
+
+// newPartialAssembledMessage returns the AssembledMessage of the fragments received so far, flagged as incomplete.
+func newPartialAssembledMessage(fragments MessageFragmentations) AssembledMessage {
+	// content is not parsed, so there is no error to handle
+	assembled, _ := assemble(fragments, assembleConfig{})
+	assembled.Incomplete = true
+	assembled.MissingParts = fragments.MissingParts()
+
+	return assembled
+}
+
+// assemble builds the AssembledMessage of fragments, which must not be empty.
+func assemble(fragments MessageFragmentations, config assembleConfig) (AssembledMessage, error) {
+	assembled := AssembledMessage{
+		Reference:    hex.EncodeToString(fragments.Reference()),
+		Encoding:     fragments[0].Encoding,
+		Parts:        len(fragments),
+		Text:         fragments.Assembled(),
+		MessageClass: fragments.MessageClass(),
+		Metadata:     fragments.Metadata(),
+	}
+
+	for _, info := range fragments.ordered() {
+		if info.Ports != nil && assembled.Ports == nil {
+			assembled.Ports = info.Ports
+		}
+
+		assembled.Repaired = assembled.Repaired || info.Repaired
+	}
+
+	payload := fragments.PayloadBytes()
+	assembled.ContentType = DetectContentType(assembled.Ports, payload)
+	assembled.Binary = newBinaryMessage(assembled.Encoding, assembled.Ports, payload)
+
+	if config.parseContent {
+		content, err := ParseContent(assembled.ContentType, payload)
+		if err != nil {
+			return AssembledMessage{}, fmt.Errorf("%w", err)
+		}
+
+		assembled.Content = content
+	}
+
+	if config.languageDetector != nil && assembled.Binary == nil {
+		assembled.Language, assembled.LanguageConfidence = config.languageDetector.DetectLanguage(assembled.Text)
+	}
+
+	return assembled, nil
+}
@@ -0,0 +1,178 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+// AuditOutcome is the result of an attempt to add a fragment, as recorded by an AuditRecord.
+type AuditOutcome string
+
+const (
+	// AuditAccepted is recorded for a fragment that was added
+	AuditAccepted AuditOutcome = "accepted"
+
+	// AuditDuplicate is recorded for a fragment that was added while a fragment with the same part number already
+	// existed for its reference
+	AuditDuplicate AuditOutcome = "duplicate"
+
+	// AuditRejected is recorded for a fragment that failed to parse, was rejected by middleware, or failed to be
+	// added or stored
+	AuditRejected AuditOutcome = "rejected"
+
+	// AuditRetransmission is recorded for a fragment that was ignored by WithDeduplication, being the same as the
+	// fragment already held for its part number
+	AuditRetransmission AuditOutcome = "retransmission"
+
+	// AuditConflict is recorded for a fragment that was rejected by WithDeduplication, having a different payload
+	// than the fragment already held for its part number
+	AuditConflict AuditOutcome = "conflict"
+)
+
+// Source identifies where fragments come from, such as an SMPP bind and its originating address. Attach it to
+// the context given to AddContext or AddMessageElementsContext using ContextWithSource.
+type Source struct {
+	// Identifier of the source
+	ID string `json:"id"`
+
+	// Additional details about the source
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// sourceKey is the context key of the Source.
+type sourceKey struct{}
+
+// AuditRecord describes a single attempt to add a fragment to Messages.
+type AuditRecord struct {
+	// When the attempt took place
+	Time time.Time `json:"time"`
+
+	// Reference number of the fragment, nil when it failed to parse
+	Reference []byte `json:"reference,omitempty"`
+
+	// Total number of parts of the fragment
+	TotalParts byte `json:"total_parts,omitempty"`
+
+	// Part number of the fragment
+	CurrentPart byte `json:"current_part,omitempty"`
+
+	// Encoding of the fragment
+	Encoding Encoding `json:"encoding"`
+
+	// Namespace of the fragment, empty for the default namespace
+	Namespace string `json:"namespace,omitempty"`
+
+	// Source of the fragment, as attached to the context, nil when there is none
+	Source *Source `json:"source,omitempty"`
+
+	// The outcome of the attempt
+	Outcome AuditOutcome `json:"outcome"`
+
+	// The reason of a rejection
+	Error string `json:"error,omitempty"`
+}
+
+// AuditSink receives the AuditRecord of every attempt to add a fragment, in the order of the attempts.
+type AuditSink interface {
+	// Audit records a single attempt. Failures are logged using the logger set by WithLogger.
+	Audit(record AuditRecord) error
+}
+
+// JSONAuditSink is an AuditSink appending every record as a line of JSON to an io.Writer.
+type JSONAuditSink struct {
+	writer io.Writer
+	mtx    sync.Mutex
+}
+
+// NewJSONAuditSink returns a JSONAuditSink writing to writer, such as a file opened for appending.
+func NewJSONAuditSink(writer io.Writer) *JSONAuditSink {
+	return &JSONAuditSink{writer: writer}
+}
+
+// Audit implements AuditSink. Every record is written using a single Write call.
+func (sink *JSONAuditSink) Audit(record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	sink.mtx.Lock()
+	defer sink.mtx.Unlock()
+
+	_, err = sink.writer.Write(append(line, '\n'))
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// WithAudit sets the sink receiving a record of every attempt to add a fragment, using Add, AddContext,
+// AddMessageElements or AddMessageElementsContext.
+func WithAudit(sink AuditSink) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.audit = sink
+	}
+}
+
+// ContextWithSource returns a copy of ctx carrying source.
+func ContextWithSource(ctx context.Context, source Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFromContext returns the Source attached to ctx using ContextWithSource.
+func SourceFromContext(ctx context.Context) (Source, bool) {
+	source, found := ctx.Value(sourceKey{}).(Source)
+	return source, found
+}
+
+// recordAttempt counts an attempt to add info at the stats of its namespace, and records it using the sink set by
+// WithAudit. info is nil when the fragment failed to parse.
+func (msgs *Messages) recordAttempt(
+	ctx context.Context, encoding Encoding, info *MessageElements, outcome AuditOutcome, err error,
+) {
+	namespace := NamespaceFromContext(ctx)
+	msgs.countAttempt(namespace, outcome)
+
+	if msgs.audit == nil {
+		return
+	}
+
+	record := AuditRecord{Time: msgs.now(), Namespace: namespace, Encoding: encoding, Outcome: outcome}
+
+	if info != nil {
+		record.Reference = bytes.Clone(info.Reference)
+		record.TotalParts = info.TotalParts
+		record.CurrentPart = info.CurrentPart
+	}
+
+	if source, found := SourceFromContext(ctx); found {
+		source.Metadata = maps.Clone(source.Metadata)
+		record.Source = &source
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	auditErr := msgs.audit.Audit(record)
+	if auditErr != nil && msgs.logger != nil {
+		msgs.logger.Warn("unable to record audit record", slog.Any("error", auditErr))
+	}
+}
+
+// containsPart reports whether fragments hold a fragment with the part number of info.
+func containsPart(fragments MessageFragmentations, info *MessageElements) bool {
+	return fragments.Get(info.CurrentPart) != nil
+}
@@ -0,0 +1,85 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	udh "github.com/ik5/smudh"
+)
+
+func TestAudit(t *testing.T) {
+	output := bytes.Buffer{}
+	messages := udh.InitMessages(
+		udh.WithAudit(udh.NewJSONAuditSink(&output)),
+		udh.WithMiddleware(func(_ context.Context, elements *udh.MessageElements) error {
+			if elements.Reference[0] == 0xB7 {
+				return udh.ErrRejected
+			}
+
+			return nil
+		}),
+	)
+
+	source := udh.Source{ID: "bind-1", Metadata: map[string]string{"system_id": "smsc"}}
+	ctx := udh.ContextWithSource(t.Context(), source)
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50201546869732069732061206C"), // part 1 of 2
+		udh.Message("050003A50201546869732069732061206C"), // part 1 of 2 again
+		udh.Message("050003B70502002005E905DC"),           // part 2 of 5, rejected by the middleware
+		udh.Message("05000"),                              // invalid
+	} {
+		_ = messages.AddContext(ctx, udh.ASCII, msg)
+	}
+
+	info, err := udh.Message("050003A5020265722074657374696E67").ParseElements(udh.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = messages.AddMessageElements(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []udh.AuditRecord{
+		{Reference: []byte{0xA5}, TotalParts: 2, CurrentPart: 1, Source: &source, Outcome: udh.AuditAccepted},
+		{Reference: []byte{0xA5}, TotalParts: 2, CurrentPart: 1, Source: &source, Outcome: udh.AuditDuplicate},
+		{
+			Reference: []byte{0xB7}, TotalParts: 5, CurrentPart: 2, Source: &source, Outcome: udh.AuditRejected,
+			Error: udh.ErrRejected.Error(),
+		},
+		{Source: &source, Outcome: udh.AuditRejected, Error: udh.ErrHexStringMustHaveAnEvenNumberOfChars.Error()},
+		{Reference: []byte{0xA5}, TotalParts: 2, CurrentPart: 2, Outcome: udh.AuditAccepted},
+	}
+
+	records := []udh.AuditRecord{}
+	decoder := json.NewDecoder(&output)
+
+	for decoder.More() {
+		var record udh.AuditRecord
+
+		err = decoder.Decode(&record)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if record.Time.IsZero() || record.Encoding != udh.ASCII {
+			t.Errorf("record %+v is missing its time or encoding", record)
+		}
+
+		records = append(records, record)
+	}
+
+	if diff := cmp.Diff(expected, records, cmpopts.IgnoreFields(udh.AuditRecord{}, "Time", "Encoding")); diff != "" {
+		t.Errorf("unexpected audit records: %s", diff)
+	}
+}
@@ -0,0 +1,113 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// BatchFailure is the failure of a single Message of a batch.
+type BatchFailure struct {
+	// Index of the Message at the batch
+	Index int
+
+	// The reason of the failure
+	Err error
+}
+
+// BatchError is returned by AddAll when some of the messages of a batch failed to be added. The other messages of
+// the batch were added.
+type BatchError struct {
+	// Number of messages at the batch
+	Total int
+
+	// The failures, ordered by index
+	Failures []BatchFailure
+}
+
+// Error implements the error interface.
+func (batchErr *BatchError) Error() string {
+	builder := strings.Builder{}
+	fmt.Fprintf(&builder, "%d of %d messages failed", len(batchErr.Failures), batchErr.Total)
+
+	for idx, failure := range batchErr.Failures {
+		separator := "; "
+		if idx == 0 {
+			separator = ": "
+		}
+
+		fmt.Fprintf(&builder, "%smessage %d: %v", separator, failure.Index, failure.Err)
+	}
+
+	return builder.String()
+}
+
+// Unwrap returns the errors of the failures, so errors.Is and errors.As match any of them.
+func (batchErr *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(batchErr.Failures))
+
+	for _, failure := range batchErr.Failures {
+		errs = append(errs, failure.Err)
+	}
+
+	return errs
+}
+
+// AddAll parses a batch of raw messages using the specified encoding and adds them to the Messages container,
+// locking it once for the whole batch.
+// Messages that fail are skipped, and reported by a *BatchError, which is returned only when at least one of the
+// messages failed.
+// The function does not re-order the elements.
+func (msgs *Messages) AddAll(encoding Encoding, messages []Message) error {
+	return msgs.AddAllContext(context.Background(), encoding, messages)
+}
+
+// AddAllContext is the same as AddAll, with ctx used the same way as AddContext does. Messages that were not parsed
+// before ctx is done fail with the context error.
+func (msgs *Messages) AddAllContext(ctx context.Context, encoding Encoding, messages []Message) error {
+	batchErr := &BatchError{Total: len(messages)}
+	parsed := make([]*MessageElements, len(messages))
+
+	for idx, message := range messages {
+		if ctx.Err() != nil {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{Index: idx, Err: fmt.Errorf("%w", ctx.Err())})
+			continue
+		}
+
+		info, err := msgs.prepareFragment(ctx, encoding, message)
+		if err != nil {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{Index: idx, Err: err})
+			continue
+		}
+
+		parsed[idx] = info
+	}
+
+	msgs.mtx.Lock()
+
+	for idx, info := range parsed {
+		if info == nil {
+			continue
+		}
+
+		err := msgs.addMessageElements(ctx, info)
+		if err != nil {
+			batchErr.Failures = append(batchErr.Failures, BatchFailure{Index: idx, Err: err})
+		}
+	}
+
+	msgs.mtx.Unlock()
+
+	if len(batchErr.Failures) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(batchErr.Failures, func(a, b BatchFailure) int { return a.Index - b.Index })
+
+	return batchErr
+}
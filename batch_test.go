@@ -0,0 +1,93 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	udh "github.com/ik5/smudh"
+)
+
+func TestAddAll(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		messages []udh.Message
+		failures []int
+		err      error
+		complete int
+	}{
+		{
+			name: "all valid",
+			ctx:  context.Background(),
+			messages: []udh.Message{
+				udh.Message("050003A50201546869732069732061206C"),
+				udh.Message("050003A5020265722074657374696E67"),
+			},
+			complete: 1,
+		},
+		{
+			name: "some invalid",
+			ctx:  context.Background(),
+			messages: []udh.Message{
+				udh.Message("05000"),
+				udh.Message("050003A50201546869732069732061206C"),
+				udh.Message("050003A5020265722074657374696E67"),
+				udh.Message("ZZ"),
+			},
+			failures: []int{0, 3},
+			err:      udh.ErrHexStringMustHaveAnEvenNumberOfChars,
+			complete: 1,
+		},
+		{
+			name:     "canceled",
+			ctx:      canceled,
+			messages: []udh.Message{udh.Message("050003A50201546869732069732061206C")},
+			failures: []int{0},
+			err:      context.Canceled,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			messages := udh.InitMessages()
+
+			err := messages.AddAllContext(test.ctx, udh.ASCII, test.messages)
+			if !errors.Is(err, test.err) {
+				t2.Errorf("have error %v, expected %v", err, test.err)
+			}
+
+			failures := []int{}
+
+			var batchErr *udh.BatchError
+			if errors.As(err, &batchErr) {
+				if batchErr.Total != len(test.messages) {
+					t2.Errorf("have total %d, expected %d", batchErr.Total, len(test.messages))
+				}
+
+				for _, failure := range batchErr.Failures {
+					failures = append(failures, failure.Index)
+				}
+			} else if err != nil {
+				t2.Errorf("have error %T, expected *BatchError", err)
+			}
+
+			if diff := cmp.Diff(test.failures, failures, cmpopts.EquateEmpty()); diff != "" {
+				t2.Errorf("failed indexes diff: %s", diff)
+			}
+
+			if complete := messages.Complete(); len(complete) != test.complete {
+				t2.Errorf("have %d complete messages, expected %d", len(complete), test.complete)
+			}
+		})
+	}
+}
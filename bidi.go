@@ -0,0 +1,160 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// Directional isolate characters, see Unicode Standard Annex #9.
+const (
+	rightToLeftIsolate    = '\u2067'
+	popDirectionalIsolate = '\u2069'
+)
+
+// TextDirection is the writing direction of a DirectionRun.
+type TextDirection byte
+
+const (
+	// NeutralDirection is text without strong directional characters, such as spaces, digits and punctuation
+	NeutralDirection TextDirection = iota
+
+	// LeftToRight is text such as Latin, Greek and Cyrillic
+	LeftToRight
+
+	// RightToLeft is text such as Hebrew and Arabic
+	RightToLeft
+)
+
+// String returns the name of the direction.
+func (direction TextDirection) String() string {
+	switch direction {
+	case LeftToRight:
+		return "LTR"
+	case RightToLeft:
+		return "RTL"
+	}
+
+	return "Neutral"
+}
+
+// DirectionRun is a part of a text having a single writing direction.
+type DirectionRun struct {
+	// Byte offset of the first character of the run
+	Start int `json:"start"`
+
+	// Byte offset just after the last character of the run
+	End int `json:"end"`
+
+	// Direction of the run
+	Direction TextDirection `json:"direction"`
+}
+
+// DirectionRuns splits text into runs of a single direction, covering the whole text in order.
+//
+// A run starts at a strong directional character and ends at the last character of the same direction before a
+// character of the opposite direction, so digits and punctuation between Hebrew words belong to the Hebrew run.
+// Text before the first, after the last, and between runs of different directions is neutral.
+func DirectionRuns(text string) []DirectionRun {
+	runs := []DirectionRun{}
+	current := DirectionRun{Direction: NeutralDirection}
+
+	for offset, ch := range text {
+		direction := runeDirection(ch)
+		if direction == NeutralDirection {
+			continue
+		}
+
+		end := offset + len(string(ch))
+
+		if direction == current.Direction {
+			current.End = end
+			continue
+		}
+
+		if current.Direction != NeutralDirection {
+			runs = append(runs, current)
+		}
+
+		previousEnd := 0
+		if len(runs) > 0 {
+			previousEnd = runs[len(runs)-1].End
+		}
+
+		if previousEnd < offset {
+			runs = append(runs, DirectionRun{Start: previousEnd, End: offset, Direction: NeutralDirection})
+		}
+
+		current = DirectionRun{Start: offset, End: end, Direction: direction}
+	}
+
+	if current.Direction != NeutralDirection {
+		runs = append(runs, current)
+	}
+
+	if last := lastEnd(runs); last < len(text) {
+		runs = append(runs, DirectionRun{Start: last, End: len(text), Direction: NeutralDirection})
+	}
+
+	return runs
+}
+
+// lastEnd returns the end of the last run, or zero when there are no runs.
+func lastEnd(runs []DirectionRun) int {
+	if len(runs) == 0 {
+		return 0
+	}
+
+	return runs[len(runs)-1].End
+}
+
+// runeDirection returns the strong direction of ch, or NeutralDirection for weak and neutral characters.
+func runeDirection(ch rune) TextDirection {
+	properties, _ := bidi.LookupRune(ch)
+
+	switch properties.Class() {
+	case bidi.L:
+		return LeftToRight
+	case bidi.R, bidi.AL:
+		return RightToLeft
+	}
+
+	return NeutralDirection
+}
+
+// WithBidiIsolation wraps every right-to-left run of the decoded text (see DirectionRuns) with the Unicode
+// RIGHT-TO-LEFT ISOLATE and POP DIRECTIONAL ISOLATE characters, so Hebrew or Arabic text mixed with Latin text and
+// digits is displayed in the right order by left-to-right user interfaces.
+// Isolation takes place after the other text options.
+func WithBidiIsolation() ParseOption {
+	return func(config *parseConfig) {
+		config.bidiIsolation = true
+	}
+}
+
+// isolateRightToLeft wraps the right-to-left runs of text with directional isolates, and returns how many runs
+// were wrapped.
+func isolateRightToLeft(text string) (string, int) {
+	runs := DirectionRuns(text)
+
+	builder := strings.Builder{}
+	wrapped := 0
+
+	for _, run := range runs {
+		if run.Direction != RightToLeft {
+			_, _ = builder.WriteString(text[run.Start:run.End])
+			continue
+		}
+
+		_, _ = builder.WriteRune(rightToLeftIsolate)
+		_, _ = builder.WriteString(text[run.Start:run.End])
+		_, _ = builder.WriteRune(popDirectionalIsolate)
+		wrapped++
+	}
+
+	return builder.String(), wrapped
+}
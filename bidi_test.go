@@ -0,0 +1,61 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestDirectionRuns(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []udh.DirectionRun
+	}{
+		{name: "empty", text: "", expected: []udh.DirectionRun{}},
+		{
+			name: "neutral", text: "123 !",
+			expected: []udh.DirectionRun{{Start: 0, End: 5, Direction: udh.NeutralDirection}},
+		},
+		{
+			name: "latin", text: "hello world",
+			expected: []udh.DirectionRun{{Start: 0, End: 11, Direction: udh.LeftToRight}},
+		},
+		{
+			// "code: " followed by "שלום 123 עולם", and "!"
+			name: "mixed", text: "code: שלום 123 עולם!",
+			expected: []udh.DirectionRun{
+				{Start: 0, End: 4, Direction: udh.LeftToRight},
+				{Start: 4, End: 6, Direction: udh.NeutralDirection},
+				{Start: 6, End: 27, Direction: udh.RightToLeft},
+				{Start: 27, End: 28, Direction: udh.NeutralDirection},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		if diff := cmp.Diff(test.expected, udh.DirectionRuns(test.text)); diff != "" {
+			t.Errorf("%s: unexpected runs (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestWithBidiIsolation(t *testing.T) {
+	// "OTP " followed by the Hebrew word "קוד" (code), a space and "1234"
+	msg := udh.Message("004F00540050002005E705D505D300200031003200330034")
+
+	elements, err := msg.ParseElements(udh.UCS2, udh.WithBidiIsolation())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "OTP \u2067קוד\u2069 1234"
+	if elements.Message != expected {
+		t.Errorf("have %q, expected %q", elements.Message, expected)
+	}
+}
@@ -0,0 +1,77 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// BinaryMessage is the payload of a message using one of the 8-bit binary encodings, which has no text
+// representation: MessageElements.Message and AssembledMessage.Text only hold its hex encoding.
+type BinaryMessage struct {
+	// Encoding of the message, Binary8Bit1 or Binary8Bit2
+	Encoding Encoding `json:"encoding"`
+
+	// The raw payload octets, without the UDH
+	Payload []byte `json:"payload"`
+
+	// Application port addressing of the message, nil when there is none
+	Ports *Ports `json:"ports,omitempty"`
+
+	// Content type hint detected using DetectContentType, using the destination port first
+	ContentType ContentType `json:"content_type,omitempty"`
+}
+
+// Hex returns the payload as an upper case hex string.
+func (binary BinaryMessage) Hex() string {
+	return strings.ToUpper(hex.EncodeToString(binary.Payload))
+}
+
+// newBinaryMessage returns the BinaryMessage of payload, or nil when enc is not a binary encoding.
+func newBinaryMessage(enc Encoding, ports *Ports, payload []byte) *BinaryMessage {
+	if enc != Binary8Bit1 && enc != Binary8Bit2 {
+		return nil
+	}
+
+	var portsCopy *Ports
+	if ports != nil {
+		copied := *ports
+		portsCopy = &copied
+	}
+
+	return &BinaryMessage{
+		Encoding: enc, Payload: payload, Ports: portsCopy, ContentType: DetectContentType(ports, payload),
+	}
+}
+
+// Binary returns the payload of a message using one of the binary encodings, and false for the text encodings.
+func (elem MessageElements) Binary() (*BinaryMessage, bool) {
+	binary := newBinaryMessage(elem.Encoding, elem.Ports, elem.PayloadBytes())
+
+	return binary, binary != nil
+}
+
+// Binary returns the payload of the full ordered MessageFragmentations when they use one of the binary encodings,
+// with the application ports of the first fragment that has them, and false for the text encodings or an empty
+// slice. The slice is not modified, the same as Assembled.
+func (msgs MessageFragmentations) Binary() (*BinaryMessage, bool) {
+	if len(msgs) == 0 {
+		return nil, false
+	}
+
+	var ports *Ports
+
+	for _, info := range msgs.ordered() {
+		if info.Ports != nil {
+			ports = info.Ports
+			break
+		}
+	}
+
+	binary := newBinaryMessage(msgs[0].Encoding, ports, msgs.PayloadBytes())
+
+	return binary, binary != nil
+}
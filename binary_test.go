@@ -0,0 +1,86 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestBinaryMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding udh.Encoding
+		parts    []string
+		expected *udh.BinaryMessage
+		hex      string
+	}{
+		{
+			name:     "ringing tone",
+			encoding: udh.Binary8Bit2,
+			parts:    []string{"0B0504158100000003010202" + "0306", "0B0504158100000003010201" + "0106"},
+			expected: &udh.BinaryMessage{
+				Encoding: udh.Binary8Bit2, Payload: []byte{0x01, 0x06, 0x03, 0x06},
+				Ports:       &udh.Ports{Destination: udh.PortRingingTone},
+				ContentType: udh.ContentTypeRingingTone,
+			},
+			hex: "01060306",
+		},
+		{
+			name:     "without ports",
+			encoding: udh.Binary8Bit1,
+			parts:    []string{"CAFE"},
+			expected: &udh.BinaryMessage{Encoding: udh.Binary8Bit1, Payload: []byte{0xCA, 0xFE}},
+			hex:      "CAFE",
+		},
+		{name: "text", encoding: udh.Latin1, parts: []string{"4142"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			fragments := udh.MessageFragmentations{}
+
+			for _, part := range test.parts {
+				err := fragments.Add(test.encoding, udh.Message(part))
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			binary, found := fragments.Binary()
+			if found != (test.expected != nil) {
+				t2.Fatalf("have found %t, expected %t", found, test.expected != nil)
+			}
+
+			if diff := cmp.Diff(test.expected, binary); diff != "" {
+				t2.Errorf("unexpected binary message (-want +got):\n%s", diff)
+			}
+
+			if binary != nil && binary.Hex() != test.hex {
+				t2.Errorf("have hex %s, expected %s", binary.Hex(), test.hex)
+			}
+
+			assembled, err := udh.NewAssembledMessage(fragments)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.expected, assembled.Binary); diff != "" {
+				t2.Errorf("unexpected assembled binary message (-want +got):\n%s", diff)
+			}
+
+			if redacted := assembled.Redacted(); redacted.Binary != nil && redacted.Binary.Payload != nil {
+				t2.Errorf("have redacted payload %X, expected none", redacted.Binary.Payload)
+			}
+
+			_, found = fragments[0].Binary()
+			if found != (test.expected != nil) {
+				t2.Errorf("have fragment found %t, expected %t", found, test.expected != nil)
+			}
+		})
+	}
+}
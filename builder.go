@@ -0,0 +1,76 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "github.com/ik5/smudh/header"
+
+// UDHBuilder composes a User Data Header out of information elements, using header.Builder.
+//
+// The builder methods can be chained, and the first error encountered is kept and returned when the header is
+// rendered using Bytes or Hex.
+type UDHBuilder struct {
+	builder header.Builder
+}
+
+// NewUDHBuilder returns a new empty UDHBuilder.
+func NewUDHBuilder() *UDHBuilder {
+	return &UDHBuilder{}
+}
+
+// AddConcatenation adds a concatenation IE.
+// A single byte reference produces an 8-bit reference IE (0x00), while a two bytes reference produces a 16-bit
+// reference IE (0x08). Any other reference length is an error.
+func (builder *UDHBuilder) AddConcatenation(reference []byte, totalParts, currentPart byte) *UDHBuilder {
+	builder.builder.AddConcatenation(reference, totalParts, currentPart)
+	return builder
+}
+
+// AddPorts adds a 16-bit application port addressing IE (0x05) with the given destination and source ports.
+func (builder *UDHBuilder) AddPorts(destination, source uint16) *UDHBuilder {
+	builder.builder.AddPorts(destination, source)
+	return builder
+}
+
+// AddNationalShift adds the national language single shift (0x24) and locking shift (0x25) IEs of the given
+// languages. DefaultLanguage adds no IE.
+func (builder *UDHBuilder) AddNationalShift(lockingShift, singleShift NationalLanguage) *UDHBuilder {
+	builder.builder.AddNationalShift(lockingShift, singleShift)
+	return builder
+}
+
+// AddCustomIE adds an arbitrary IE with the given identifier and data.
+// The data is copied, and must not be longer than 255 octets.
+func (builder *UDHBuilder) AddCustomIE(identifier byte, data []byte) *UDHBuilder {
+	builder.builder.AddCustomIE(identifier, data)
+	return builder
+}
+
+// Elements returns a copy of the information elements added so far, in insertion order.
+func (builder *UDHBuilder) Elements() []InformationElement {
+	return builder.builder.Elements()
+}
+
+// Len returns the full length of the header in octets, including the UDH Length octet.
+// An empty builder has a length of 0.
+func (builder *UDHBuilder) Len() int {
+	return builder.builder.Len()
+}
+
+// Bytes renders the header, starting with the UDH Length octet.
+// Returns the first error encountered while building, or an error if the header is too long.
+// An empty builder renders to an empty slice.
+func (builder *UDHBuilder) Bytes() ([]byte, error) {
+	return builder.builder.Bytes()
+}
+
+// Hex renders the header as an upper case hex encoded Message.
+func (builder *UDHBuilder) Hex() (Message, error) {
+	udh, err := builder.builder.Hex()
+	if err != nil {
+		return nil, err
+	}
+
+	return Message(udh), nil
+}
@@ -0,0 +1,102 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestUDHBuilder(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *udh.UDHBuilder
+		expected udh.Message
+		err      error
+	}{
+		{
+			name:     "empty",
+			builder:  udh.NewUDHBuilder(),
+			expected: udh.Message(""),
+		},
+		{
+			name:     "8-bit concatenation",
+			builder:  udh.NewUDHBuilder().AddConcatenation([]byte{0x0F}, 3, 3),
+			expected: udh.Message("0500030F0303"),
+		},
+		{
+			name:     "16-bit concatenation",
+			builder:  udh.NewUDHBuilder().AddConcatenation([]byte{0x75, 0x39}, 4, 4),
+			expected: udh.Message("06080475390404"),
+		},
+		{
+			name:     "concatenation and ports",
+			builder:  udh.NewUDHBuilder().AddConcatenation([]byte{0x12}, 2, 1).AddPorts(0x0B84, 0x23F0),
+			expected: udh.Message("0B000312020105040B8423F0"),
+		},
+		{
+			name:     "custom IE",
+			builder:  udh.NewUDHBuilder().AddCustomIE(0x70, []byte{0xAA}),
+			expected: udh.Message("037001AA"),
+		},
+		{
+			name:    "invalid reference length",
+			builder: udh.NewUDHBuilder().AddConcatenation([]byte{0x01, 0x02, 0x03}, 2, 1),
+			err:     udh.ErrInvalidReferenceLength,
+		},
+		{
+			name:    "current part above total",
+			builder: udh.NewUDHBuilder().AddConcatenation([]byte{0x01}, 2, 3),
+			err:     udh.ErrInvalidPartNumber,
+		},
+		{
+			name:    "duplicate concatenation",
+			builder: udh.NewUDHBuilder().AddConcatenation([]byte{0x01}, 2, 1).AddConcatenation([]byte{0x01, 0x02}, 2, 1),
+			err:     udh.ErrDuplicateIE,
+		},
+		{
+			name:    "IE data too long",
+			builder: udh.NewUDHBuilder().AddCustomIE(0x70, make([]byte, 256)),
+			err:     udh.ErrIEDataTooLong,
+		},
+		{
+			name:    "header too long",
+			builder: udh.NewUDHBuilder().AddCustomIE(0x70, make([]byte, 100)).AddCustomIE(0x71, make([]byte, 40)),
+			err:     udh.ErrUDHTooLong,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			result, err := test.builder.Hex()
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			if diff := cmp.Diff(string(test.expected), string(result)); diff != "" {
+				t2.Errorf("header diff: %s", diff)
+			}
+		})
+	}
+}
+
+func TestUDHBuilderParseRoundTrip(t *testing.T) {
+	header, err := udh.NewUDHBuilder().AddConcatenation([]byte{0xA5}, 2, 2).Hex()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elements, err := append(header, udh.Message("65722074657374696E67")...).ParseElements(udh.GSM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Message != "er testing" || elements.TotalParts != 2 || elements.CurrentPart != 2 {
+		t.Errorf("unexpected elements: %+v", *elements)
+	}
+}
@@ -0,0 +1,54 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// AssembledText returns the assembled text of a complete message held by the container, the same as
+// MessageFragmentations.Assembled returns.
+// The text is assembled once, and cached until another fragment is added to the message, so repeated calls on hot
+// paths do not join the fragments every time. Changes made directly to the slice returned by GetMessageFragments
+// are not detected.
+// Returns ErrMessageNotFound when the reference is not found, or ErrMessageNotComplete if not all of the fragments
+// exist.
+func (msgs *Messages) AssembledText(reference []byte) (string, error) {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	return msgs.assembledText(msgs.referenceKey(reference))
+}
+
+// AssembledText is the same as Messages.AssembledText, for a reference of the namespace.
+func (ns Namespace) AssembledText(reference []byte) (string, error) {
+	ns.msgs.mtx.Lock()
+	defer ns.msgs.mtx.Unlock()
+
+	return ns.msgs.assembledText(ns.msgs.namespacedKey(ns.name, reference))
+}
+
+// AssembledText is the same as Messages.AssembledText.
+func (sharded *ShardedMessages) AssembledText(reference []byte) (string, error) {
+	return sharded.shard("", reference).AssembledText(reference)
+}
+
+// assembledText returns the cached text of the message of key, assembling it when needed. The caller must hold the
+// lock.
+func (msgs *Messages) assembledText(key string) (string, error) {
+	set, found := msgs.fragments[key]
+	if !found {
+		return "", ErrMessageNotFound
+	}
+
+	if set.cached {
+		return set.text, nil
+	}
+
+	if !set.fragments.HaveAllFragments() {
+		return "", ErrMessageNotComplete
+	}
+
+	set.text = set.fragments.Assembled()
+	set.cached = true
+
+	return set.text, nil
+}
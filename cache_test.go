@@ -0,0 +1,53 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestAssembledText(t *testing.T) {
+	messages := udh.InitMessages()
+
+	tests := []struct {
+		name     string
+		msg      udh.Message
+		expected string
+		err      error
+	}{
+		{name: "part 2", msg: udh.Message("050003A5020265722074657374696E67"), err: udh.ErrMessageNotComplete},
+		{name: "part 1", msg: udh.Message("050003A50201546869732069732061206C"), expected: "This is a ler testing"},
+		{name: "part 1 again", msg: udh.Message("050003A50201546869732069732061206C"), err: udh.ErrMessageNotComplete},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			err := messages.Add(udh.ASCII, test.msg)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			// the second call is served from the cache
+			for range 2 {
+				text, err := messages.AssembledText([]byte{0xA5})
+				if !errors.Is(err, test.err) {
+					t2.Fatalf("have error %v, expected %v", err, test.err)
+				}
+
+				if text != test.expected {
+					t2.Errorf("have text %q, expected %q", text, test.expected)
+				}
+			}
+		})
+	}
+
+	_, err := messages.AssembledText([]byte{0xB7})
+	if !errors.Is(err, udh.ErrMessageNotFound) {
+		t.Errorf("have error %v, expected %v", err, udh.ErrMessageNotFound)
+	}
+}
@@ -0,0 +1,324 @@
+package charset_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ik5/smudh/charset"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding charset.Encoding
+		raw      []byte
+		err      error
+	}{
+		{name: "GSM", text: "hello", encoding: charset.GSM, raw: []byte("hello")},
+		{name: "ASCII", text: "hi", encoding: charset.ASCII, raw: []byte("hi")},
+		{name: "UCS2", text: "שלום", encoding: charset.UCS2, raw: []byte{0x05, 0xE9, 0x05, 0xDC, 0x05, 0xD5, 0x05, 0xDD}},
+		{name: "Latin1", text: "café", encoding: charset.Latin1, raw: []byte{'c', 'a', 'f', 0xE9}},
+		{name: "binary", text: "cafe", encoding: charset.Binary8Bit2, raw: []byte{0xCA, 0xFE}},
+		{name: "not representable", text: "ש", encoding: charset.GSM, err: charset.ErrCharacterNotRepresentable},
+		{name: "unsupported", text: "x", encoding: charset.Pictogram, err: charset.ErrUnsupportedEncoding},
+		{name: "unknown", text: "x", encoding: charset.UTF8 + 1, err: charset.ErrUnknownEncoding},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			raw, err := charset.Encode(test.text, test.encoding)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have error %v, expected %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if string(raw) != string(test.raw) {
+				t2.Errorf("have raw % X, expected % X", raw, test.raw)
+			}
+
+			text, err := charset.Decode(context.Background(), test.encoding, raw)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if text != test.text {
+				t2.Errorf("have text %q, expected %q", text, test.text)
+			}
+		})
+	}
+}
+
+func TestDecodeOddUCS2(t *testing.T) {
+	_, err := charset.Decode(context.Background(), charset.UCS2, []byte{0x00})
+	if !errors.Is(err, charset.ErrBinaryTextLengthIsNotEvenForUTF16Decoding) {
+		t.Errorf("have error %v, expected %v", err, charset.ErrBinaryTextLengthIsNotEvenForUTF16Decoding)
+	}
+}
+
+func TestEncodingJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		encoding charset.Encoding
+		marshal  bool
+		err      error
+	}{
+		{name: "GSM", json: `"GSM-7"`, encoding: charset.GSM, marshal: true},
+		{name: "UCS2", json: `"UCS2"`, encoding: charset.UCS2, marshal: true},
+		{name: "extended", json: `"GSM-7-Extended"`, encoding: charset.GSMExtended, marshal: true},
+		{name: "no name", json: `17`, encoding: charset.UTF8 + 1, marshal: true},
+		{name: "numeric", json: `8`, encoding: charset.UCS2},
+		{name: "unknown name", json: `"EBCDIC"`, err: charset.ErrUnknownEncoding},
+		{name: "out of range", json: `256`, err: charset.ErrUnknownEncoding},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if test.marshal {
+				data, err := json.Marshal(test.encoding)
+				if err != nil {
+					t2.Fatal(err)
+				}
+
+				if string(data) != test.json {
+					t2.Errorf("have JSON %s, expected %s", data, test.json)
+				}
+			}
+
+			var encoding charset.Encoding
+
+			err := json.Unmarshal([]byte(test.json), &encoding)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have error %v, expected %v", err, test.err)
+			}
+
+			if err == nil && encoding != test.encoding {
+				t2.Errorf("have encoding %s, expected %s", encoding, test.encoding)
+			}
+		})
+	}
+}
+
+func TestAllEncodings(t *testing.T) {
+	encodings := charset.AllEncodings()
+	if len(encodings) != int(charset.UTF8)+1 {
+		t.Fatalf("have %d encodings, expected %d", len(encodings), charset.UTF8+1)
+	}
+
+	textual := []charset.Encoding{}
+
+	for _, encoding := range encodings {
+		if !encoding.Valid() {
+			t.Errorf("encoding %s is not valid", encoding)
+		}
+
+		if encoding.IsTextual() {
+			textual = append(textual, encoding)
+		}
+	}
+
+	expected := []charset.Encoding{
+		charset.GSM, charset.ASCII, charset.Latin1, charset.JIS, charset.Cyrillic, charset.Hebrew, charset.UCS2,
+		charset.Pictogram, charset.ISO2022JP, charset.EXTJIS, charset.KSC5601, charset.GSMExtended, charset.UTF8,
+	}
+
+	if diff := cmp.Diff(expected, textual); diff != "" {
+		t.Errorf("textual encodings diff: %s", diff)
+	}
+
+	if invalid := charset.UTF8 + 1; invalid.Valid() || invalid.IsTextual() {
+		t.Errorf("encoding %s is valid", invalid)
+	}
+}
+
+func TestTextEncoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding charset.Encoding
+		raw      []byte
+		err      error
+	}{
+		{name: "GSM", text: "a{1}€", encoding: charset.GSM, raw: []byte{'a', 0x1B, 0x28, '1', 0x1B, 0x29, 0x1B, 0x65}},
+		{name: "Hebrew", text: "שלום", encoding: charset.Hebrew, raw: []byte{0xF9, 0xEC, 0xE5, 0xED}},
+		{name: "UTF-8", text: "שלום", encoding: charset.UTF8, raw: []byte("שלום")},
+		{name: "not representable", text: "aש", encoding: charset.GSM, err: charset.ErrCharacterNotRepresentable},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			textEncoding := test.encoding.TextEncoding()
+
+			raw, err := textEncoding.NewEncoder().Bytes([]byte(test.text))
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have error %v, expected %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if diff := cmp.Diff(test.raw, raw); diff != "" {
+				t2.Errorf("raw diff: %s", diff)
+			}
+
+			text, err := textEncoding.NewDecoder().String(string(raw))
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if text != test.text {
+				t2.Errorf("have text %q, expected %q", text, test.text)
+			}
+		})
+	}
+
+	for _, encoding := range []charset.Encoding{charset.ASCII, charset.Binary8Bit2, charset.Pictogram} {
+		if encoding.TextEncoding() != nil {
+			t.Errorf("encoding %s has a text encoding", encoding)
+		}
+	}
+}
+
+func TestPackGSM7(t *testing.T) {
+	tests := []struct {
+		name         string
+		septets      []byte
+		headerLength int
+		fillBits     int
+		packed       []byte
+	}{
+		{
+			name:    "without UDH",
+			septets: []byte("hellohello"),
+			packed:  []byte{0xE8, 0x32, 0x9B, 0xFD, 0x46, 0x97, 0xD9, 0xEC, 0x37},
+		},
+		{
+			name:    "septets filling the last octet",
+			septets: []byte("12345678"),
+			packed:  []byte{0x31, 0xD9, 0x8C, 0x56, 0xB3, 0xDD, 0x70},
+		},
+		{
+			name:    "last octet with 7 bits of padding",
+			septets: []byte("1234567"),
+			packed:  []byte{0x31, 0xD9, 0x8C, 0x56, 0xB3, 0xDD, 0x00},
+		},
+		{
+			name:    "trailing @ kept",
+			septets: []byte{'h', 'i', 0x00},
+			packed:  []byte{0xE8, 0x34, 0x00},
+		},
+		{
+			name:    "6 octets UDH",
+			septets: []byte("hi"), headerLength: 6, fillBits: 1,
+			packed: []byte{0xD0, 0x69},
+		},
+		{
+			name:    "7 octets UDH",
+			septets: []byte("hi"), headerLength: 7,
+			packed: []byte{0xE8, 0x34},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if have := charset.GSM7FillBits(test.headerLength); have != test.fillBits {
+				t2.Errorf("have %d fill bits, expected %d", have, test.fillBits)
+			}
+
+			packed := charset.PackGSM7(test.septets, test.headerLength)
+			if diff := cmp.Diff(test.packed, packed); diff != "" {
+				t2.Errorf("unexpected packed septets (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(test.septets, charset.UnpackGSM7(packed, test.headerLength)); diff != "" {
+				t2.Errorf("unexpected unpacked septets (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUSSD(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		packed []byte
+	}{
+		{name: "short code", text: "*100#", packed: []byte{0xAA, 0x18, 0x0C, 0x36, 0x02}},
+		{
+			name: "7 spare bits padded by carriage return", text: "1234567",
+			packed: []byte{0x31, 0xD9, 0x8C, 0x56, 0xB3, 0xDD, 0x1A},
+		},
+		{
+			name: "carriage return on an octet boundary", text: "1234567\r",
+			packed: []byte{0x31, 0xD9, 0x8C, 0x56, 0xB3, 0xDD, 0x1A, 0x0D},
+		},
+		{name: "carriage return before spare bits", text: "ab\r", packed: []byte{0x61, 0x71, 0x03}},
+		{name: "empty", text: "", packed: []byte{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			packed, err := charset.EncodeUSSD(test.text)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.packed, packed); diff != "" {
+				t2.Errorf("unexpected packed string (-want +got):\n%s", diff)
+			}
+
+			if have := charset.DecodeUSSD(packed); have != test.text {
+				t2.Errorf("have %q, expected %q", have, test.text)
+			}
+		})
+	}
+
+	// strings padded using zeros, the same as SMS, are accepted too
+	if have := charset.DecodeUSSD(charset.PackGSM7([]byte("1234567"), 0)); have != "1234567" {
+		t.Errorf("have %q from a zero padded string, expected %q", have, "1234567")
+	}
+
+	_, err := charset.EncodeUSSD("שלום")
+	if !errors.Is(err, charset.ErrCharacterNotRepresentable) {
+		t.Errorf("expected an error wrapping ErrCharacterNotRepresentable, got %v", err)
+	}
+}
+
+func TestUSSDRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{name: "euro sign", text: "€10"},
+		{name: "braces", text: "a{b}"},
+		{name: "extension table", text: "[~]|^\\"},
+		{name: "extension character on an octet boundary", text: "123456€"},
+		{name: "Greek", text: "ΔΦΓΛΩΠΨΣΘΞ"},
+		{name: "accented letters", text: "ÅåÆæßÉÄÖÑÜ§¿äöñüà"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			packed, err := charset.EncodeUSSD(test.text)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if have := charset.DecodeUSSD(packed); have != test.text {
+				t2.Errorf("have %q, expected %q", have, test.text)
+			}
+		})
+	}
+}
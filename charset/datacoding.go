@@ -0,0 +1,209 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "fmt"
+
+// InterfaceVersion is the SMPP interface version, as sent on the bind PDUs.
+type InterfaceVersion byte
+
+// Supported SMPP interface versions.
+const (
+	// SMPP 3.3
+	SMPP33 InterfaceVersion = 0x33
+
+	// SMPP 3.4
+	SMPP34 InterfaceVersion = 0x34
+
+	// SMPP 5.0
+	SMPP50 InterfaceVersion = 0x50
+)
+
+// DataCodingTable maps SMPP data_coding values to an Encoding.
+//
+// A table returned by InterfaceVersion.DataCodingTable is a copy, and can be modified to match vendor deviations,
+// such as SMSCs that use 0x00 for ASCII:
+//
+//	table := charset.SMPP34.DataCodingTable()
+//	table[0x00] = charset.ASCII
+type DataCodingTable map[byte]Encoding
+
+var (
+	smpp33DataCoding = func() DataCodingTable {
+		table := DataCodingTable{
+			0x00: GSM,
+			0x01: ASCII,
+			0x02: Binary8Bit1,
+			0x03: Latin1,
+			0x04: Binary8Bit2,
+			0x08: UCS2,
+		}
+		addDCSGroups(table)
+
+		return table
+	}()
+
+	smpp34DataCoding = func() DataCodingTable {
+		table := DataCodingTable{}
+		for enc := GSM; enc <= KSC5601; enc++ {
+			table[byte(enc)] = enc
+		}
+		addDCSGroups(table)
+
+		return table
+	}()
+
+	smpp50DataCoding = func() DataCodingTable {
+		table := DataCodingTable{}
+		for enc := GSM; enc <= KSC5601; enc++ {
+			table[byte(enc)] = enc
+		}
+		// SMPP 5.0 does not define the reserved values anymore
+		delete(table, byte(Reserved1))
+		delete(table, byte(Reserved2))
+		addDCSGroups(table)
+
+		return table
+	}()
+)
+
+// addDCSGroups adds the GSM 03.38 message waiting indication (0xC0-0xEF) and message class (0xF0-0xFF) coding
+// groups to table.
+func addDCSGroups(table DataCodingTable) {
+	for dataCoding := 0xC0; dataCoding <= 0xDF; dataCoding++ {
+		table[byte(dataCoding)] = GSM
+	}
+
+	for dataCoding := 0xE0; dataCoding <= 0xEF; dataCoding++ {
+		table[byte(dataCoding)] = UCS2
+	}
+
+	for dataCoding := 0xF0; dataCoding <= 0xFF; dataCoding++ {
+		if dataCoding&0x04 == 0 {
+			table[byte(dataCoding)] = GSM
+		} else {
+			table[byte(dataCoding)] = Binary8Bit2
+		}
+	}
+}
+
+// String returns the version in its dotted form.
+func (version InterfaceVersion) String() string {
+	switch version {
+	case SMPP33:
+		return "3.3"
+	case SMPP34:
+		return "3.4"
+	case SMPP50:
+		return "5.0"
+	}
+
+	return fmt.Sprintf("0x%02X", byte(version))
+}
+
+// DataCodingTable returns a copy of the data_coding table of the version.
+// Returns nil for an unknown version.
+func (version InterfaceVersion) DataCodingTable() DataCodingTable {
+	source := version.builtinDataCodingTable()
+	if source == nil {
+		return nil
+	}
+
+	table := make(DataCodingTable, len(source))
+	for dataCoding, enc := range source {
+		table[dataCoding] = enc
+	}
+
+	return table
+}
+
+// builtinDataCodingTable returns the shared table of the version, which must not be modified.
+func (version InterfaceVersion) builtinDataCodingTable() DataCodingTable {
+	switch version {
+	case SMPP33:
+		return smpp33DataCoding
+	case SMPP34:
+		return smpp34DataCoding
+	case SMPP50:
+		return smpp50DataCoding
+	}
+
+	return nil
+}
+
+// Encoding returns the Encoding of a data_coding value.
+// Returns an error if the value is not part of the table.
+func (table DataCodingTable) Encoding(dataCoding byte) (Encoding, error) {
+	enc, found := table[dataCoding]
+	if !found {
+		return 0, ErrUnknownEncoding
+	}
+
+	return enc, nil
+}
+
+// DataCoding returns the lowest data_coding value mapped to enc.
+// Returns an error if enc is not part of the table.
+func (table DataCodingTable) DataCoding(enc Encoding) (byte, error) {
+	for dataCoding := 0; dataCoding <= 0xFF; dataCoding++ {
+		if current, found := table[byte(dataCoding)]; found && current == enc {
+			return byte(dataCoding), nil
+		}
+	}
+
+	return 0, ErrUnsupportedEncoding
+}
+
+// DataCoding returns the SMPP 3.4 data_coding value of the encoding.
+// GSMExtended and UTF8 do not have a data_coding value of their own, and an error is returned for them.
+func (enc Encoding) DataCoding() (byte, error) {
+	return enc.DataCodingVersion(SMPP34)
+}
+
+// DataCodingVersion returns the data_coding value of the encoding for the given SMPP interface version.
+func (enc Encoding) DataCodingVersion(version InterfaceVersion) (byte, error) {
+	table := version.builtinDataCodingTable()
+	if table == nil {
+		return 0, ErrUnknownInterfaceVersion
+	}
+
+	return table.DataCoding(enc)
+}
+
+// EncodingFromDataCoding returns the Encoding of an SMPP 3.4 data_coding value.
+// Returns an error for data_coding values that are reserved or not supported.
+func EncodingFromDataCoding(dataCoding byte) (Encoding, error) {
+	return EncodingFromDataCodingVersion(dataCoding, SMPP34)
+}
+
+// EncodingFromDataCodingVersion returns the Encoding of a data_coding value for the given SMPP interface version.
+func EncodingFromDataCodingVersion(dataCoding byte, version InterfaceVersion) (Encoding, error) {
+	table := version.builtinDataCodingTable()
+	if table == nil {
+		return 0, ErrUnknownInterfaceVersion
+	}
+
+	return table.Encoding(dataCoding)
+}
+
+// RecommendDataCoding returns the Encoding to send text with, and its data_coding value for the given SMPP
+// interface version, using the same table EncodingFromDataCodingVersion uses for the inbound direction.
+//
+// GSM is chosen when the default alphabet and its extension table can represent the whole text, and UCS2
+// otherwise. Latin-1 and the other 8-bit encodings are never recommended, since their support varies between SMSCs
+// and handsets.
+func RecommendDataCoding(text string, version InterfaceVersion) (Encoding, byte, error) {
+	enc := UCS2
+	if compatible, _ := IsGSMCompatible(text); compatible {
+		enc = GSM
+	}
+
+	dataCoding, err := enc.DataCodingVersion(version)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return enc, dataCoding, nil
+}
@@ -0,0 +1,98 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// Decoder returns the x/text decoder of the encodings that are decoded by a charset transform, or the decoder set
+// for enc by SetDecoder. Returns nil for the other encodings.
+func Decoder(enc Encoding) *encoding.Decoder {
+	if override := DecoderOverride(enc); override != nil {
+		return override.NewDecoder()
+	}
+
+	textEncoding, found := textEncodings[enc]
+	if !found {
+		return nil
+	}
+
+	return textEncoding.NewDecoder()
+}
+
+// Decode converts the raw bytes of the given encoding into UTF-8 text.
+//
+// GSM 7-bit input is expected unpacked (one septet per byte), and is decoded using DefaultGSM7Table. Binary
+// encodings are returned hex encoded, since they have no text representation.
+// At this time the Pictogram encoding is not supported, as well as the Reserved1 and Reserved2 encoding.
+// A decoder set for enc by SetDecoder replaces the default decoding.
+// Returns an error for unsupported or unknown encodings, for input that the decoder rejects, or the context error
+// when ctx is canceled or its deadline is exceeded while decoding.
+func Decode(ctx context.Context, enc Encoding, raw []byte) (string, error) {
+	if override := DecoderOverride(enc); override != nil {
+		return DecodeUsing(ctx, override, raw)
+	}
+
+	switch enc {
+	case GSM, GSMExtended:
+		return DefaultGSM7Table.Decode(raw), nil
+
+	case ASCII, UTF8:
+		return string(raw), nil
+
+	case Binary8Bit1, Binary8Bit2:
+		return hex.EncodeToString(raw), nil
+
+	case UCS2:
+		if len(raw)%2 != 0 {
+			return "", ErrBinaryTextLengthIsNotEvenForUTF16Decoding
+		}
+
+		return transformString(ctx, raw, Decoder(UCS2))
+
+	case Latin1, Cyrillic, Hebrew, ISO2022JP, KSC5601, JIS, EXTJIS:
+		return transformString(ctx, raw, Decoder(enc))
+
+	case Pictogram, Reserved1, Reserved2:
+		// TODO: support these as well
+		return "", ErrUnsupportedEncoding
+	}
+
+	return "", ErrUnknownEncoding
+}
+
+// transformString translates raw using decoder, and stops once ctx is done.
+func transformString(ctx context.Context, raw []byte, decoder *encoding.Decoder) (string, error) {
+	reader := transform.NewReader(bytes.NewReader(raw), decoder)
+
+	utf8Bytes, err := io.ReadAll(contextReader{ctx: ctx, reader: reader})
+	if err != nil {
+		return "", fmt.Errorf("%w", err)
+	}
+
+	return string(utf8Bytes), nil
+}
+
+// contextReader is an io.Reader that stops reading once its context is done.
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+func (reader contextReader) Read(p []byte) (int, error) {
+	if err := reader.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	return reader.reader.Read(p)
+}
@@ -0,0 +1,16 @@
+/*
+Package charset converts the text of short messages between UTF-8 and the encodings selected by the SMPP
+data_coding field.
+
+	payload, err := charset.Encode("hello", charset.GSM)
+	text, err := charset.Decode(ctx, charset.GSM, payload)
+
+GSM 7-bit text is handled as unpacked septets, one septet per byte, using the default alphabet and extension
+table, or the national language tables of NationalGSM7Table. PackGSM7 and UnpackGSM7 convert them to and from the
+septet packed form used over the air, including the fill bits that follow a UDH, and EncodeUSSD and DecodeUSSD
+handle USSD strings, which are padded using carriage returns. The data_coding values of every SMPP interface
+version are available using DataCodingTable.
+
+Package smudh re-exports the types and functions of this package, so most applications do not need to import it.
+*/
+package charset
@@ -0,0 +1,61 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+)
+
+// Encode converts UTF-8 text into the raw bytes of the given encoding - the counterpart of Decode.
+//
+// GSM 7-bit text is returned unpacked (one septet per byte), and binary encodings expect the text to be hex
+// encoded, the same way Decode represents them.
+// Returns an error if the text cannot be represented in the encoding.
+func Encode(text string, enc Encoding) ([]byte, error) {
+	var encoder *encoding.Encoder
+
+	switch enc {
+	case GSM, GSMExtended:
+		return encodeGSM7(text)
+
+	case ASCII:
+		for idx := 0; idx < len(text); idx++ {
+			if text[idx] >= utf8.RuneSelf {
+				return nil, ErrCharacterNotRepresentable
+			}
+		}
+		return []byte(text), nil
+
+	case UTF8:
+		return []byte(text), nil
+
+	case Binary8Bit1, Binary8Bit2:
+		result, err := hex.DecodeString(text)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+		return result, nil
+
+	case Latin1, UCS2, Cyrillic, Hebrew, ISO2022JP, KSC5601, JIS, EXTJIS:
+		encoder = textEncodings[enc].NewEncoder()
+
+	case Pictogram, Reserved1, Reserved2:
+		return nil, ErrUnsupportedEncoding
+
+	default:
+		return nil, ErrUnknownEncoding
+	}
+
+	result, err := encoder.Bytes([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCharacterNotRepresentable, err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,16 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "errors"
+
+var (
+	ErrBinaryTextLengthIsNotEvenForUTF16Decoding = errors.New("binary text length is not even for UTF-16 decoding")
+	ErrUnsupportedEncoding                       = errors.New("unsupported encoding")
+	ErrUnknownEncoding                           = errors.New("unknown encoding")
+	ErrCharacterNotRepresentable                 = errors.New("character cannot be represented in the requested encoding")
+	ErrUnknownInterfaceVersion                   = errors.New("unknown SMPP interface version")
+	ErrUnsupportedNationalLanguage               = errors.New("unsupported national language table")
+)
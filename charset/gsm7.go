@@ -0,0 +1,119 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"slices"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+)
+
+// gsm7Escape is the escape septet that prefixes characters from the extension table.
+const gsm7Escape byte = 0x1B
+
+// gsm7Basic is the GSM 03.38 default alphabet, indexed by septet value.
+// The escape septet (0x1B) is kept as a placeholder and never matched.
+var gsm7Basic = []rune(
+	"@£$¥èéùìòÇ\nØø\rÅå" +
+		"Δ_ΦΓΛΩΠΨΣΘΞ\x1bÆæßÉ" +
+		" !\"#¤%&'()*+,-./" +
+		"0123456789:;<=>?" +
+		"¡ABCDEFGHIJKLMNO" +
+		"PQRSTUVWXYZÄÖÑÜ§" +
+		"¿abcdefghijklmno" +
+		"pqrstuvwxyzäöñüà",
+)
+
+// gsm7Extension is the GSM 03.38 default extension table, the value is the septet that follows the escape septet.
+var gsm7Extension = map[rune]byte{
+	'\f': 0x0A,
+	'^':  0x14,
+	'{':  0x28,
+	'}':  0x29,
+	'\\': 0x2F,
+	'[':  0x3C,
+	'~':  0x3D,
+	']':  0x3E,
+	'|':  0x40,
+	'€':  0x65,
+}
+
+var gsm7BasicLookup = septetLookup(gsm7Basic)
+
+// encodeGSM7Rune returns the unpacked septets representing ch, or nil if ch has no GSM 03.38 representation.
+func encodeGSM7Rune(ch rune) []byte {
+	return DefaultGSM7Table.EncodeRune(ch)
+}
+
+// encodeGSM7 converts text into unpacked GSM 03.38 septets, one septet per byte.
+func encodeGSM7(text string) ([]byte, error) {
+	return DefaultGSM7Table.Encode(text)
+}
+
+// gsm7ExtensionDecode is the reverse of gsm7Extension.
+var gsm7ExtensionDecode = func() map[byte]rune {
+	lookup := make(map[byte]rune, len(gsm7Extension))
+	for ch, septet := range gsm7Extension {
+		lookup[septet] = ch
+	}
+
+	return lookup
+}()
+
+// GSM7Decoder is a transform.Transformer converting unpacked GSM 03.38 septets into UTF-8.
+//
+// An escape septet followed by a septet that is not in the extension table decodes as the basic character of the
+// second septet, as GSM 03.38 requires, a trailing escape septet decodes as a space, and bytes above 0x7F decode
+// as utf8.RuneError.
+type GSM7Decoder struct {
+	transform.NopResetter
+}
+
+// Transform implements transform.Transformer.
+func (GSM7Decoder) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	nDst, nSrc := 0, 0
+
+	for nSrc < len(src) {
+		septet := src[nSrc]
+		size := 1
+
+		if septet == gsm7Escape {
+			if nSrc+1 == len(src) && !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+
+			if nSrc+1 < len(src) {
+				septet = src[nSrc+1]
+				size = 2
+			}
+		}
+
+		ch := DefaultGSM7Table.decodeSeptet(septet, size == 2)
+
+		if nDst+utf8.RuneLen(ch) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+
+		nDst += utf8.EncodeRune(dst[nDst:], ch)
+		nSrc += size
+	}
+
+	return nDst, nSrc, nil
+}
+
+// IsGSMCompatible reports whether text can be encoded using the GSM 03.38 default alphabet and its extension
+// table, and returns the characters that cannot, each listed once in order of first appearance.
+func IsGSMCompatible(text string) (bool, []rune) {
+	var offending []rune
+
+	for _, ch := range text {
+		if encodeGSM7Rune(ch) == nil && !slices.Contains(offending, ch) {
+			offending = append(offending, ch)
+		}
+	}
+
+	return len(offending) == 0, offending
+}
@@ -0,0 +1,70 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// encodingNames holds the symbolic names of the encodings, as used by their JSON form.
+var encodingNames = [...]string{
+	GSM:         "GSM-7",
+	ASCII:       "ASCII",
+	Binary8Bit1: "BINARY-1",
+	Latin1:      "Latin1",
+	Binary8Bit2: "BINARY-2",
+	JIS:         "JIS",
+	Cyrillic:    "Cyrillic",
+	Hebrew:      "Hebrew",
+	UCS2:        "UCS2",
+	Pictogram:   "Pictogram",
+	ISO2022JP:   "ISO2022JP",
+	Reserved1:   "Reserved1",
+	Reserved2:   "Reserved2",
+	EXTJIS:      "EXTJIS",
+	KSC5601:     "KSC5601",
+	GSMExtended: "GSM-7-Extended",
+	UTF8:        "UTF-8",
+}
+
+// MarshalJSON implements json.Marshaler, encoding the symbolic name of the encoding, such as "UCS2" or "GSM-7".
+// Values without a name are encoded as a number.
+func (enc Encoding) MarshalJSON() ([]byte, error) {
+	if int(enc) >= len(encodingNames) {
+		return []byte(strconv.Itoa(int(enc))), nil
+	}
+
+	return json.Marshal(encodingNames[enc])
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting both the symbolic name of an encoding and its number, as
+// encoded by earlier versions.
+func (enc *Encoding) UnmarshalJSON(data []byte) error {
+	var name string
+
+	if err := json.Unmarshal(data, &name); err != nil {
+		var number byte
+
+		if err := json.Unmarshal(data, &number); err != nil {
+			return fmt.Errorf("%w: %s", ErrUnknownEncoding, data)
+		}
+
+		*enc = Encoding(number)
+
+		return nil
+	}
+
+	for value, known := range encodingNames {
+		if known == name {
+			*enc = Encoding(value)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q", ErrUnknownEncoding, name)
+}
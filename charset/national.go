@@ -0,0 +1,242 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// NationalLanguage is a national language identifier of 3GPP TS 23.038, used as the value of the national
+// language single shift (0x24) and locking shift (0x25) IEs.
+type NationalLanguage byte
+
+const (
+	// DefaultLanguage selects the GSM 03.38 default alphabet or extension table, and adds no IE
+	DefaultLanguage NationalLanguage = 0x00
+
+	// Turkish national language tables
+	Turkish NationalLanguage = 0x01
+
+	// Spanish national language tables
+	Spanish NationalLanguage = 0x02
+)
+
+// gsm7TurkishLocking is the Turkish locking shift table, indexed by septet value.
+var gsm7TurkishLocking = []rune(
+	"@£$¥€éùıòÇ\nĞğ\rÅå" +
+		"Δ_ΦΓΛΩΠΨΣΘΞ\x1bŞşßÉ" +
+		" !\"#¤%&'()*+,-./" +
+		"0123456789:;<=>?" +
+		"İABCDEFGHIJKLMNO" +
+		"PQRSTUVWXYZÄÖÑÜ§" +
+		"çabcdefghijklmno" +
+		"pqrstuvwxyzäöñüà",
+)
+
+// gsm7TurkishSingle is the Turkish single shift table, the value is the septet that follows the escape septet.
+var gsm7TurkishSingle = map[rune]byte{
+	'\f': 0x0A,
+	'^':  0x14,
+	'{':  0x28,
+	'}':  0x29,
+	'\\': 0x2F,
+	'[':  0x3C,
+	'~':  0x3D,
+	']':  0x3E,
+	'|':  0x40,
+	'Ğ':  0x47,
+	'İ':  0x49,
+	'Ş':  0x53,
+	'ç':  0x63,
+	'€':  0x65,
+	'ğ':  0x67,
+	'ı':  0x69,
+	'ş':  0x73,
+}
+
+// gsm7SpanishSingle is the Spanish single shift table, the value is the septet that follows the escape septet.
+// Spanish has no locking shift table of its own.
+var gsm7SpanishSingle = map[rune]byte{
+	'ç':  0x09,
+	'\f': 0x0A,
+	'^':  0x14,
+	'{':  0x28,
+	'}':  0x29,
+	'\\': 0x2F,
+	'[':  0x3C,
+	'~':  0x3D,
+	']':  0x3E,
+	'|':  0x40,
+	'Á':  0x41,
+	'Í':  0x49,
+	'Ó':  0x4F,
+	'Ú':  0x55,
+	'á':  0x61,
+	'€':  0x65,
+	'í':  0x69,
+	'ó':  0x6F,
+	'ú':  0x75,
+}
+
+// gsm7LockingAlphabets holds the supported locking shift tables, indexed by septet value.
+var gsm7LockingAlphabets = map[NationalLanguage][]rune{
+	DefaultLanguage: gsm7Basic,
+	Turkish:         gsm7TurkishLocking,
+}
+
+// gsm7LockingTables holds the supported locking shift tables, as lookups from character to septet.
+var gsm7LockingTables = map[NationalLanguage]map[rune]byte{
+	DefaultLanguage: gsm7BasicLookup,
+	Turkish:         septetLookup(gsm7TurkishLocking),
+}
+
+// gsm7SingleTables holds the supported single shift tables.
+var gsm7SingleTables = map[NationalLanguage]map[rune]byte{
+	DefaultLanguage: gsm7Extension,
+	Turkish:         gsm7TurkishSingle,
+	Spanish:         gsm7SpanishSingle,
+}
+
+// GSM7Table is the pair of tables used for encoding and decoding GSM 7-bit text: the basic (locking shift) table,
+// and the extension (single shift) table reached through the escape septet.
+type GSM7Table struct {
+	basic     map[rune]byte
+	extension map[rune]byte
+
+	alphabet          []rune
+	extensionDecoding map[byte]rune
+}
+
+// DefaultGSM7Table is the GSM 03.38 default alphabet and extension table.
+var DefaultGSM7Table = GSM7Table{
+	basic: gsm7BasicLookup, extension: gsm7Extension, alphabet: gsm7Basic, extensionDecoding: gsm7ExtensionDecode,
+}
+
+// String returns the name of the language.
+func (language NationalLanguage) String() string {
+	switch language {
+	case DefaultLanguage:
+		return "Default"
+	case Turkish:
+		return "Turkish"
+	case Spanish:
+		return "Spanish"
+	}
+
+	return "Unknown"
+}
+
+// NationalGSM7Table returns the tables selected by the locking shift and single shift languages.
+// Returns ErrUnsupportedNationalLanguage if either table is not supported.
+func NationalGSM7Table(lockingShift, singleShift NationalLanguage) (GSM7Table, error) {
+	basic, found := gsm7LockingTables[lockingShift]
+	if !found {
+		return GSM7Table{}, ErrUnsupportedNationalLanguage
+	}
+
+	extension, found := gsm7SingleTables[singleShift]
+	if !found {
+		return GSM7Table{}, ErrUnsupportedNationalLanguage
+	}
+
+	return GSM7Table{
+		basic:             basic,
+		extension:         extension,
+		alphabet:          gsm7LockingAlphabets[lockingShift],
+		extensionDecoding: reverseLookup(extension),
+	}, nil
+}
+
+// reverseLookup returns the lookup from septet to character of a lookup from character to septet.
+func reverseLookup(lookup map[rune]byte) map[byte]rune {
+	result := make(map[byte]rune, len(lookup))
+	for ch, septet := range lookup {
+		result[septet] = ch
+	}
+
+	return result
+}
+
+// septetLookup returns the reverse lookup of a table indexed by septet value, skipping the escape septet.
+func septetLookup(table []rune) map[rune]byte {
+	lookup := make(map[rune]byte, len(table))
+	for idx, ch := range table {
+		if byte(idx) == gsm7Escape {
+			continue
+		}
+		lookup[ch] = byte(idx)
+	}
+
+	return lookup
+}
+
+// EncodeRune returns the unpacked septets representing ch, or nil if the tables have no representation for it.
+func (table GSM7Table) EncodeRune(ch rune) []byte {
+	if septet, found := table.basic[ch]; found {
+		return []byte{septet}
+	}
+
+	if septet, found := table.extension[ch]; found {
+		return []byte{gsm7Escape, septet}
+	}
+
+	return nil
+}
+
+// Encode converts text into unpacked septets using the tables, one septet per byte.
+// Returns ErrCharacterNotRepresentable if the tables have no representation for a character.
+func (table GSM7Table) Encode(text string) ([]byte, error) {
+	result := make([]byte, 0, len(text))
+
+	for _, ch := range text {
+		septets := table.EncodeRune(ch)
+		if septets == nil {
+			return nil, ErrCharacterNotRepresentable
+		}
+
+		result = append(result, septets...)
+	}
+
+	return result, nil
+}
+
+// Decode converts unpacked septets, one septet per byte, into UTF-8 text using the tables. Invalid septets are
+// handled the same way as GSM7Decoder handles them.
+func (table GSM7Table) Decode(septets []byte) string {
+	var result strings.Builder
+
+	result.Grow(len(septets))
+
+	for idx := 0; idx < len(septets); idx++ {
+		escaped := septets[idx] == gsm7Escape && idx+1 < len(septets)
+		if escaped {
+			idx++
+		}
+
+		result.WriteRune(table.decodeSeptet(septets[idx], escaped))
+	}
+
+	return result.String()
+}
+
+// decodeSeptet returns the character of septet, looking the extension table up first when it follows an escape
+// septet.
+func (table GSM7Table) decodeSeptet(septet byte, escaped bool) rune {
+	if escaped {
+		if ch, found := table.extensionDecoding[septet]; found {
+			return ch
+		}
+	}
+
+	switch {
+	case septet == gsm7Escape: // a trailing escape septet
+		return ' '
+	case int(septet) < len(table.alphabet):
+		return table.alphabet[septet]
+	}
+
+	return utf8.RuneError
+}
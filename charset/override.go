@@ -0,0 +1,49 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/text/encoding"
+)
+
+// decoderOverrides holds the decoders set by SetDecoder.
+var decoderOverrides = struct {
+	mtx      sync.RWMutex
+	decoders map[Encoding]encoding.Encoding
+}{decoders: map[Encoding]encoding.Encoding{}}
+
+// SetDecoder overrides the decoding of enc for the whole package, with the decoder of textEncoding, such as
+// mapping Latin1 to charmap.Windows1252 or Hebrew to charmap.Windows1255 for carriers that deviate from the
+// standard. A nil textEncoding restores the default decoding of enc.
+//
+// The override is used by Decode and Decoder. Encode and Encoding.TextEncoding keep using the default encoding.
+func SetDecoder(enc Encoding, textEncoding encoding.Encoding) {
+	decoderOverrides.mtx.Lock()
+	defer decoderOverrides.mtx.Unlock()
+
+	if textEncoding == nil {
+		delete(decoderOverrides.decoders, enc)
+		return
+	}
+
+	decoderOverrides.decoders[enc] = textEncoding
+}
+
+// DecoderOverride returns the decoder set for enc by SetDecoder, or nil when the decoding of enc was not overridden.
+func DecoderOverride(enc Encoding) encoding.Encoding {
+	decoderOverrides.mtx.RLock()
+	defer decoderOverrides.mtx.RUnlock()
+
+	return decoderOverrides.decoders[enc]
+}
+
+// DecodeUsing converts raw into UTF-8 text using the decoder of textEncoding, and stops with the context error
+// when ctx is done.
+func DecodeUsing(ctx context.Context, textEncoding encoding.Encoding, raw []byte) (string, error) {
+	return transformString(ctx, raw, textEncoding.NewDecoder())
+}
@@ -0,0 +1,86 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// septetBits is the number of bits of a GSM 7-bit character.
+const septetBits = 7
+
+// GSM7FillBits returns the number of fill bits that follow a UDH of headerLength octets, including the UDHL octet,
+// so the packed text starts on a septet boundary of the user data, as required by 3GPP TS 23.040. A headerLength
+// of 0 means no UDH, and needs no fill bits.
+func GSM7FillBits(headerLength int) int {
+	if headerLength <= 0 {
+		return 0
+	}
+
+	return (septetBits - headerLength*8%septetBits) % septetBits
+}
+
+// PackGSM7 packs unpacked septets, one septet per byte, into octets, eight septets per seven octets. The packed
+// text is meant to follow a UDH of headerLength octets, so it starts with the fill bits of GSM7FillBits, which are
+// zero. Use a headerLength of 0 for text without a UDH.
+func PackGSM7(septets []byte, headerLength int) []byte {
+	fillBits := GSM7FillBits(headerLength)
+	packed := make([]byte, (len(septets)*septetBits+fillBits+7)/8)
+
+	for idx, septet := range septets {
+		position := fillBits + idx*septetBits
+		septet &= 0x7F
+
+		packed[position/8] |= septet << (position % 8)
+
+		if position%8 > 8-septetBits {
+			packed[position/8+1] |= septet >> (8 - position%8)
+		}
+	}
+
+	return packed
+}
+
+// UnpackGSM7 unpacks the septets held by packed, the user data that follows a UDH of headerLength octets, skipping
+// the fill bits of GSM7FillBits. Use a headerLength of 0 for text without a UDH.
+//
+// The number of septets is not known without the user data length, so when the bits left after the last septet
+// could hold the padding of a text one septet shorter, a last septet of 0x00 is dropped as the zero padding of
+// the octet, the same as most handsets do.
+func UnpackGSM7(packed []byte, headerLength int) []byte {
+	fillBits := GSM7FillBits(headerLength)
+	septets := unpackSeptets(packed, fillBits)
+
+	if len(septets) > 0 && septets[len(septets)-1] == 0 && paddingSeptet(len(septets), fillBits, len(packed)) {
+		septets = septets[:len(septets)-1]
+	}
+
+	return septets
+}
+
+// unpackSeptets unpacks every septet that fits in packed after fillBits.
+func unpackSeptets(packed []byte, fillBits int) []byte {
+	bits := len(packed)*8 - fillBits
+	if bits < septetBits {
+		return []byte{}
+	}
+
+	septets := make([]byte, bits/septetBits)
+
+	for idx := range septets {
+		position := fillBits + idx*septetBits
+		value := uint16(packed[position/8]) >> (position % 8)
+
+		if position/8+1 < len(packed) {
+			value |= uint16(packed[position/8+1]) << (8 - position%8)
+		}
+
+		septets[idx] = byte(value) & 0x7F
+	}
+
+	return septets
+}
+
+// paddingSeptet reports whether the last of count septets could be the padding of the octets, since a text one
+// septet shorter would need the same number of octets.
+func paddingSeptet(count, fillBits, octets int) bool {
+	return ((count-1)*septetBits+fillBits+7)/8 == octets
+}
@@ -0,0 +1,91 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// textEncodings holds the x/text encodings of the encodings that are converted by a charset transform.
+var textEncodings = map[Encoding]encoding.Encoding{
+	Latin1:    charmap.ISO8859_1,
+	UCS2:      unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	Cyrillic:  charmap.ISO8859_5,
+	Hebrew:    charmap.ISO8859_8,
+	ISO2022JP: japanese.ISO2022JP,
+	KSC5601:   korean.EUCKR,
+	JIS:       japanese.EUCJP,
+	EXTJIS:    japanese.EUCJP,
+}
+
+// gsm7Encoding is the x/text encoding of unpacked GSM 03.38 septets, using the default alphabet and extension table.
+type gsm7Encoding struct{}
+
+// NewDecoder implements encoding.Encoding.
+func (gsm7Encoding) NewDecoder() *encoding.Decoder {
+	return &encoding.Decoder{Transformer: GSM7Decoder{}}
+}
+
+// NewEncoder implements encoding.Encoding.
+func (gsm7Encoding) NewEncoder() *encoding.Encoder {
+	return &encoding.Encoder{Transformer: gsm7Encoder{}}
+}
+
+// gsm7Encoder is a transform.Transformer converting UTF-8 into unpacked GSM 03.38 septets. Characters without a
+// GSM 03.38 representation fail with ErrCharacterNotRepresentable.
+type gsm7Encoder struct {
+	transform.NopResetter
+}
+
+// Transform implements transform.Transformer.
+func (gsm7Encoder) Transform(dst, src []byte, atEOF bool) (int, int, error) {
+	nDst, nSrc := 0, 0
+
+	for nSrc < len(src) {
+		if !atEOF && !utf8.FullRune(src[nSrc:]) {
+			return nDst, nSrc, transform.ErrShortSrc
+		}
+
+		ch, size := utf8.DecodeRune(src[nSrc:])
+
+		septets := encodeGSM7Rune(ch)
+		if septets == nil || (ch == utf8.RuneError && size == 1) {
+			return nDst, nSrc, ErrCharacterNotRepresentable
+		}
+
+		if nDst+len(septets) > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+
+		nDst += copy(dst[nDst:], septets)
+		nSrc += size
+	}
+
+	return nDst, nSrc, nil
+}
+
+// TextEncoding returns the x/text encoding used for enc, so the same conversion can be used outside of the package,
+// such as for address fields or vendor TLVs using the charset of the message.
+//
+// GSM 7-bit encodings convert to and from unpacked septets (one septet per byte), using the default alphabet and
+// extension table. Returns nil for the encodings that have no x/text equivalent, such as ASCII, the binary, and the
+// unsupported encodings. Decoders set by SetDecoder are not taken into account.
+func (enc Encoding) TextEncoding() encoding.Encoding {
+	switch enc {
+	case GSM, GSMExtended:
+		return gsm7Encoding{}
+	case UTF8:
+		return unicode.UTF8
+	}
+
+	return textEncodings[enc]
+}
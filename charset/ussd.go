@@ -0,0 +1,63 @@
+package charset
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "fmt"
+
+// ussdPadding is the septet that pads USSD strings, carriage return, as defined by 3GPP TS 23.038.
+const ussdPadding byte = 0x0D
+
+// PackUSSD packs the unpacked septets of a USSD string, which has no UDH. When the last octet would have 7 spare
+// bits, they are filled with a carriage return instead of zeros, and a string ending with a carriage return on an
+// octet boundary gets another one, so the receiver can tell the padding from the text, as 3GPP TS 23.038 requires.
+func PackUSSD(septets []byte) []byte {
+	switch {
+	case len(septets)*septetBits%8 == 1:
+		septets = append(septets[:len(septets):len(septets)], ussdPadding)
+	case len(septets) > 0 && len(septets)*septetBits%8 == 0 && septets[len(septets)-1] == ussdPadding:
+		septets = append(septets[:len(septets):len(septets)], ussdPadding)
+	}
+
+	return PackGSM7(septets, 0)
+}
+
+// UnpackUSSD unpacks the septets of a packed USSD string, dropping its padding: a last septet that falls in the
+// spare bits of the last octet when it is a carriage return or zero, and the carriage return added after a string
+// ending with a carriage return on an octet boundary.
+func UnpackUSSD(packed []byte) []byte {
+	septets := unpackSeptets(packed, 0)
+	count := len(septets)
+
+	if count == 0 || septets[count-1] != ussdPadding && septets[count-1] != 0 {
+		return septets
+	}
+
+	if paddingSeptet(count, 0, len(packed)) {
+		return septets[:count-1]
+	}
+
+	if count > 1 && septets[count-2] == ussdPadding && (count-1)*septetBits%8 == 0 {
+		return septets[:count-1]
+	}
+
+	return septets
+}
+
+// DecodeUSSD decodes a packed USSD string using the GSM 03.38 default alphabet and its extension table, after
+// dropping its padding as UnpackUSSD does.
+func DecodeUSSD(packed []byte) string {
+	return DefaultGSM7Table.Decode(UnpackUSSD(packed))
+}
+
+// EncodeUSSD encodes text using the GSM 03.38 default alphabet and its extension table, and packs it as PackUSSD
+// does. It returns an error wrapping ErrCharacterNotRepresentable for characters without a representation.
+func EncodeUSSD(text string) ([]byte, error) {
+	septets, err := encodeGSM7(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return PackUSSD(septets), nil
+}
@@ -0,0 +1,111 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// MessageClass is the GSM 03.38 message class of a short message, set by its data coding scheme. It tells where the
+// message is meant to be kept, such as flash messages that are only displayed.
+type MessageClass byte
+
+const (
+	// MessageClassNone is used when the data coding scheme does not set a message class
+	MessageClassNone MessageClass = iota
+
+	// MessageClass0 messages (flash messages) are displayed immediately, and not stored
+	MessageClass0
+
+	// MessageClass1 messages are stored by the mobile equipment
+	MessageClass1
+
+	// MessageClass2 messages are SIM specific, such as OTA updates
+	MessageClass2
+
+	// MessageClass3 messages are for the terminal equipment
+	MessageClass3
+)
+
+// dcsClassBit is the bit of the general data coding groups that marks bits 1-0 as the message class.
+const dcsClassBit = 0x10
+
+// MessageClassFromDataCoding returns the message class of a data_coding value: the message class coding group
+// (0xF0-0xFF), and the general data coding groups (0x10-0x3F and 0x50-0x7F) that have their class bit set. Other
+// values, including the SMPP specific values 0x00-0x0F, have no message class.
+func MessageClassFromDataCoding(dataCoding byte) MessageClass {
+	switch {
+	case dataCoding&0xF0 == 0xF0, dataCoding&0x80 == 0 && dataCoding&dcsClassBit != 0:
+		return MessageClass0 + MessageClass(dataCoding&0x03)
+	}
+
+	return MessageClassNone
+}
+
+// WithMessageClass sets the MessageClass of the parsed message, which is not part of the short message itself, but of
+// the data_coding of its PDU. See MessageClassFromDataCoding.
+func WithMessageClass(class MessageClass) ParseOption {
+	return func(config *parseConfig) {
+		config.messageClass = class
+	}
+}
+
+// Number returns the number of the class, 0 to 3, and false for MessageClassNone.
+func (class MessageClass) Number() (int, bool) {
+	if class == MessageClassNone || class > MessageClass3 {
+		return 0, false
+	}
+
+	return int(class - MessageClass0), true
+}
+
+// String implements fmt.Stringer.
+func (class MessageClass) String() string {
+	number, found := class.Number()
+	if !found {
+		return "none"
+	}
+
+	return "class " + strconv.Itoa(number)
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding the number of the class.
+func (class MessageClass) MarshalText() ([]byte, error) {
+	number, found := class.Number()
+	if !found {
+		return []byte("none"), nil
+	}
+
+	return []byte(strconv.Itoa(number)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (class *MessageClass) UnmarshalText(text []byte) error {
+	if string(text) == "none" {
+		*class = MessageClassNone
+		return nil
+	}
+
+	number, err := strconv.Atoi(string(text))
+	if err != nil || number < 0 || number > 3 {
+		return fmt.Errorf("%w: message class %q", ErrInvalidContent, text)
+	}
+
+	*class = MessageClass0 + MessageClass(number)
+
+	return nil
+}
+
+// MessageClass returns the class of the first part of the message that has one.
+func (msgs MessageFragmentations) MessageClass() MessageClass {
+	for _, info := range msgs.ordered() {
+		if info.MessageClass != MessageClassNone {
+			return info.MessageClass
+		}
+	}
+
+	return MessageClassNone
+}
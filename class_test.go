@@ -0,0 +1,90 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestMessageClassFromDataCoding(t *testing.T) {
+	tests := []struct {
+		name       string
+		dataCoding byte
+		expected   udh.MessageClass
+		text       string
+	}{
+		{name: "SMPP default", dataCoding: 0x00, expected: udh.MessageClassNone, text: "none"},
+		{name: "SMPP UCS2", dataCoding: 0x08, expected: udh.MessageClassNone, text: "none"},
+		{name: "flash GSM", dataCoding: 0xF0, expected: udh.MessageClass0, text: "class 0"},
+		{name: "SIM specific 8-bit", dataCoding: 0xF6, expected: udh.MessageClass2, text: "class 2"},
+		{name: "general group UCS2 class 1", dataCoding: 0x19, expected: udh.MessageClass1, text: "class 1"},
+		{name: "general group without class", dataCoding: 0x08, expected: udh.MessageClassNone, text: "none"},
+		{name: "auto deletion class 3", dataCoding: 0x53, expected: udh.MessageClass3, text: "class 3"},
+		{name: "message waiting", dataCoding: 0xD1, expected: udh.MessageClassNone, text: "none"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			class := udh.MessageClassFromDataCoding(test.dataCoding)
+			if class != test.expected || class.String() != test.text {
+				t2.Errorf("have %s, expected %s", class, test.text)
+			}
+		})
+	}
+}
+
+func TestMessageClassPropagation(t *testing.T) {
+	messages := udh.InitMessages()
+
+	err := messages.AddPDU(context.Background(),
+		deliverSM(udh.ESMClassUDHI, 0xF0, []byte{0x05, 0x00, 0x03, 0x2A, 0x02, 0x02, 0x42}), udh.SMPP34)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = messages.Add(udh.GSM, udh.Message("0500032A020141"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fragments := messages.Snapshot([]byte{0x2A})
+	if class := fragments.MessageClass(); class != udh.MessageClass0 {
+		t.Fatalf("have %s, expected %s", class, udh.MessageClass0)
+	}
+
+	assembled, err := udh.NewAssembledMessage(fragments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := json.Marshal(assembled)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded udh.AssembledMessage
+
+	err = json.Unmarshal(content, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.MessageClass != udh.MessageClass0 {
+		t.Errorf("have %s after a JSON round trip of %s, expected %s", decoded.MessageClass, content, udh.MessageClass0)
+	}
+
+	elements, err := udh.Message("41").ParseElements(udh.GSM, udh.WithMessageClass(udh.MessageClass2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.MessageClass != udh.MessageClass2 {
+		t.Errorf("have %s, expected %s", elements.MessageClass, udh.MessageClass2)
+	}
+}
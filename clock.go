@@ -0,0 +1,79 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "time"
+
+// Clock is the source of time used by Messages: the TTL and reference disambiguation windows, the timestamps of
+// fragments, events and audit records, the rate limits, and the ticks of RunJanitor.
+// It is replaced using WithClock, usually with smudhtest.FakeClock, for testing time dependent behavior without
+// waiting.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// NewTicker returns a Ticker that ticks every d
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers ticks of a Clock, such as time.Ticker does.
+type Ticker interface {
+	// Chan returns the channel the ticks are delivered on
+	Chan() <-chan time.Time
+
+	// Stop turns off the ticker
+	Stop()
+}
+
+// SystemClock is the Clock used when no other Clock was set using WithClock, reading the time using time.Now.
+var SystemClock Clock = systemClock{}
+
+// systemClock is a Clock using the time package.
+type systemClock struct{}
+
+// Now implements Clock.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker implements Clock.
+func (systemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{Ticker: time.NewTicker(d)}
+}
+
+// systemTicker is a Ticker wrapping time.Ticker.
+type systemTicker struct {
+	*time.Ticker
+}
+
+// Chan implements Ticker.
+func (ticker systemTicker) Chan() <-chan time.Time {
+	return ticker.C
+}
+
+// WithClock sets the Clock used by Messages.
+func WithClock(clock Clock) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.clock = clock
+	}
+}
+
+// now returns the current time of the Clock.
+func (msgs *Messages) now() time.Time {
+	if msgs.clock == nil {
+		return SystemClock.Now()
+	}
+
+	return msgs.clock.Now()
+}
+
+// newTicker returns a Ticker of the Clock.
+func (msgs *Messages) newTicker(d time.Duration) Ticker {
+	if msgs.clock == nil {
+		return SystemClock.NewTicker(d)
+	}
+
+	return msgs.clock.NewTicker(d)
+}
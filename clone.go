@@ -0,0 +1,56 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+)
+
+// Clone returns a deep copy of the MessageElements, including its byte slices, so the copy can be modified without
+// affecting the original.
+// The values stored at Extensions are copied as is.
+// Returns nil for a nil receiver.
+func (elem *MessageElements) Clone() *MessageElements {
+	if elem == nil {
+		return nil
+	}
+
+	result := *elem
+	result.buffer = nil
+	result.ieis = bytes.Clone(elem.ieis)
+	result.Reference = bytes.Clone(elem.Reference)
+	result.RawMessage = bytes.Clone(elem.RawMessage)
+
+	result.Extensions = maps.Clone(elem.Extensions)
+	result.Warnings = slices.Clone(elem.Warnings)
+	result.Metadata = maps.Clone(elem.Metadata)
+
+	if elem.Ports != nil {
+		ports := *elem.Ports
+		result.Ports = &ports
+	}
+
+	if elem.Trace != nil {
+		result.Trace = &Trace{Steps: slices.Clone(elem.Trace.Steps)}
+	}
+
+	return &result
+}
+
+// Clone returns a deep copy of the MessageFragmentations, cloning every MessageElements it holds.
+func (msgs MessageFragmentations) Clone() MessageFragmentations {
+	if msgs == nil {
+		return nil
+	}
+
+	result := make(MessageFragmentations, 0, len(msgs))
+	for _, info := range msgs {
+		result = append(result, info.Clone())
+	}
+
+	return result
+}
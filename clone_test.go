@@ -0,0 +1,40 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	udh "github.com/ik5/smudh"
+)
+
+func TestClone(t *testing.T) {
+	fragments := udh.MessageFragmentations{}
+
+	err := fragments.Add(udh.GSM, udh.Message("050003A5020265722074657374696E67"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cloned := fragments.Clone()
+	if diff := cmp.Diff(fragments, cloned, cmpopts.IgnoreUnexported(udh.MessageElements{})); diff != "" {
+		t.Fatalf("clone diff: %s", diff)
+	}
+
+	cloned[0].Reference[0] = 0xFF
+	cloned[0].RawMessage[0] = 'E'
+	cloned[0].Message = "changed"
+
+	if fragments[0].Reference[0] != 0xA5 || fragments[0].RawMessage[0] != 'e' || fragments[0].Message != "er testing" {
+		t.Errorf("original was modified: %+v", *fragments[0])
+	}
+
+	var nilElements *udh.MessageElements
+	if nilElements.Clone() != nil {
+		t.Error("expected nil clone for nil elements")
+	}
+}
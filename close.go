@@ -0,0 +1,91 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Close shuts the container down gracefully, for rolling deploys:
+//
+//   - new fragments are refused with an error wrapping ErrClosed
+//   - janitors started by RunJanitor and watchers created by Watch are stopped, closing the channels of the
+//     watchers
+//   - the messages held by the container are saved to the Store set by WithStore, and the write-ahead log set by
+//     WithWAL is synced to disk, so no accepted fragment is lost
+//
+// Close waits for the janitors to return until ctx is done, returning the context error. The messages stay in
+// memory, and can still be read and drained. The Store and the WAL are not closed, since they are owned by the
+// caller. Calling Close again does nothing.
+func (msgs *Messages) Close(ctx context.Context) error {
+	msgs.mtx.Lock()
+
+	if msgs.closed {
+		msgs.mtx.Unlock()
+		return nil
+	}
+
+	msgs.closed = true
+	close(msgs.closing)
+	msgs.closeWatchers()
+
+	err := msgs.flush(ctx)
+	msgs.mtx.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		msgs.janitor.wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return errors.Join(err, fmt.Errorf("%w", ctx.Err()))
+	}
+
+	return err
+}
+
+// flush saves every message to the Store, and syncs the WAL. The caller must hold the lock.
+func (msgs *Messages) flush(ctx context.Context) error {
+	var errs []error
+
+	if msgs.store != nil {
+		for _, key := range slices.Sorted(maps.Keys(msgs.fragments)) {
+			if err := ctx.Err(); err != nil {
+				return errors.Join(append(errs, fmt.Errorf("%w", err))...)
+			}
+
+			err := msgs.saveSet(key, msgs.fragments[key])
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if msgs.wal != nil {
+		err := msgs.wal.sync()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", ErrStore, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkOpen returns an error wrapping ErrClosed once Close was called. The caller must hold the lock.
+func (msgs *Messages) checkOpen() error {
+	if msgs.closed {
+		return fmt.Errorf("%w", ErrClosed)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+func TestMessagesClose(t *testing.T) {
+	store := udh.NewMemoryStore()
+
+	wal, err := udh.OpenWAL(filepath.Join(t.TempDir(), "wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = wal.Close() })
+
+	messages := udh.InitMessages(udh.WithStore(store), udh.WithWAL(wal))
+
+	err = messages.Add(udh.ASCII, udh.Message("050003A50201546869732069732061206C"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := messages.Watch(t.Context())
+	janitorDone := make(chan struct{})
+
+	go func() {
+		defer close(janitorDone)
+		messages.RunJanitor(t.Context(), time.Hour)
+	}()
+
+	for messages.Health(context.Background()).Janitors == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	err = messages.Close(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-janitorDone:
+	default:
+		t.Error("expected the janitor to be stopped by Close")
+	}
+
+	if _, ok := <-events; ok {
+		t.Error("expected the watcher channel to be closed")
+	}
+
+	if _, ok := <-messages.Watch(t.Context()); ok {
+		t.Error("expected a watcher created after Close to be closed")
+	}
+
+	err = messages.Add(udh.ASCII, udh.Message("050003A5020265722074657374696E67"))
+	if !errors.Is(err, udh.ErrClosed) {
+		t.Errorf("expected an error wrapping ErrClosed, got %v", err)
+	}
+
+	sets, err := store.LoadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sets) != 1 {
+		t.Errorf("have %d stored messages, expected 1", len(sets))
+	}
+
+	if have := messages.Snapshot([]byte{0xA5}); len(have) != 1 {
+		t.Errorf("have %d fragments after Close, expected them to stay readable", len(have))
+	}
+
+	if !messages.Health(context.Background()).Closed {
+		t.Error("expected Health to report the container as closed")
+	}
+
+	err = messages.Close(context.Background())
+	if err != nil {
+		t.Errorf("expected closing again to do nothing, got %v", err)
+	}
+}
+
+func TestMessagesCloseContext(t *testing.T) {
+	clock := smudhtest.NewFakeClock(time.Now())
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+
+	// the janitor is kept busy by its expiry handler, so it cannot return
+	messages := udh.InitMessages(
+		udh.WithClock(clock),
+		udh.WithTTL(time.Minute),
+		udh.WithExpiryPolicy(udh.ExpiryReport, func(udh.IncompleteMessage, *udh.AssembledMessage) {
+			entered <- struct{}{}
+			<-release
+		}),
+	)
+
+	err := messages.Add(udh.ASCII, udh.Message("050003B70502"+"6669727374"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	janitorDone := make(chan struct{})
+
+	go func() {
+		defer close(janitorDone)
+		messages.RunJanitor(t.Context(), time.Minute)
+	}()
+
+	for busy := false; !busy; {
+		clock.Advance(time.Minute)
+
+		select {
+		case <-entered:
+			busy = true
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = messages.Close(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected an error wrapping context.DeadlineExceeded, got %v", err)
+	}
+
+	close(release)
+	<-janitorDone
+}
+
+func TestRunJanitorDuringClose(t *testing.T) {
+	for range 100 {
+		messages := udh.InitMessages()
+		janitors := sync.WaitGroup{}
+
+		for range 4 {
+			janitors.Add(1)
+
+			go func() {
+				defer janitors.Done()
+				messages.RunJanitor(t.Context(), time.Hour)
+			}()
+		}
+
+		err := messages.Close(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// janitors started after Close return at once, and the ones started before are stopped by it
+		janitors.Wait()
+
+		if health := messages.Health(t.Context()); health.Janitors != 0 {
+			t.Fatalf("have %d running janitors after Close", health.Janitors)
+		}
+	}
+
+	messages := udh.InitMessages()
+
+	err := messages.Close(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages.RunJanitor(t.Context(), time.Hour)
+
+	if health := messages.Health(t.Context()); !health.LastSweep.IsZero() {
+		t.Errorf("a janitor started after Close was registered at %s", health.LastSweep)
+	}
+}
+
+func TestShardedMessagesClose(t *testing.T) {
+	sharded, err := udh.NewShardedMessages(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sharded.Close(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50201546869732069732061206C"),
+		udh.Message("050003B70502002005E905DC"),
+	} {
+		err := sharded.Add(udh.ASCII, msg)
+		if !errors.Is(err, udh.ErrClosed) {
+			t.Errorf("expected an error wrapping ErrClosed, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Command smudh runs the smudh reassembly sidecar.
+//
+// Usage:
+//
+//	smudh serve [-addr :8080] [-ttl 10m] [-store dir] [-max-backlog 0]
+//
+// Besides the endpoints of the server package, including the /healthz and /readyz probes, Prometheus metrics are
+// served at /metrics.
+package main
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ik5/smudh"
+	"github.com/ik5/smudh/server"
+	"github.com/ik5/smudh/smudhprom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "serve" {
+		fmt.Fprintln(os.Stderr, "usage: smudh serve [flags]")
+		os.Exit(2)
+	}
+
+	err := serve(os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", ":8080", "address to listen on")
+	ttl := flags.Duration("ttl", 10*time.Minute, "time before incomplete messages are evicted, 0 disables eviction")
+	storeDir := flags.String("store", "", "directory for persisting fragments, empty keeps them in memory")
+	maxBacklog := flags.Int("max-backlog", 0, "number of held messages above which /readyz fails, 0 disables the limit")
+
+	_ = flags.Parse(args)
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	collector := smudhprom.NewCollector("")
+	prometheus.MustRegister(collector)
+
+	options := []smudh.MessagesOption{smudh.WithLogger(logger), smudh.WithTTL(*ttl), collector.Option()}
+
+	if *storeDir != "" {
+		store, err := smudh.NewDirStore(*storeDir)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		options = append(options, smudh.WithStore(store))
+	}
+
+	messages := smudh.InitMessages(options...)
+
+	err := messages.Restore()
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *ttl > 0 {
+		go messages.RunJanitor(ctx, *ttl/2)
+	}
+
+	srv := server.New(messages, server.WithMaxBacklog(*maxBacklog))
+
+	mux := http.NewServeMux()
+	mux.Handle("/", srv)
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	httpServer := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	shutdown := make(chan error, 1)
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		_ = httpServer.Shutdown(shutdownCtx)
+		shutdown <- srv.Close(shutdownCtx)
+	}()
+
+	logger.Info("listening", slog.String("addr", *addr))
+
+	err = httpServer.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("%w", err)
+	}
+
+	err = <-shutdown
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ContentType is the MIME type of the content of an assembled message, as detected by DetectContentType.
+type ContentType string
+
+// Content types detected by DetectContentType.
+const (
+	// ContentTypeUnknown is used when the content type cannot be detected
+	ContentTypeUnknown ContentType = ""
+
+	// ContentTypeVCard is a vCard (phone book entry)
+	ContentTypeVCard ContentType = "text/x-vcard"
+
+	// ContentTypeVCalendar is a vCalendar (calendar event)
+	ContentTypeVCalendar ContentType = "text/x-vcalendar"
+
+	// ContentTypeMMSNotification is a WAP push carrying an MMS m-notification-ind
+	ContentTypeMMSNotification ContentType = "application/vnd.wap.mms-message"
+
+	// ContentTypeRingingTone is a Nokia Smart Messaging ringing tone
+	ContentTypeRingingTone ContentType = "application/vnd.nokia.ringing-tone"
+
+	// ContentTypeOperatorLogo is a Nokia Smart Messaging operator logo
+	ContentTypeOperatorLogo ContentType = "image/vnd.nok-oplogo"
+
+	// ContentTypeCLIIcon is a Nokia Smart Messaging caller line identification icon
+	ContentTypeCLIIcon ContentType = "image/vnd.nok-cli-icon"
+)
+
+// Well known destination ports of application port addressing.
+const (
+	// PortVCard is the WAP port of vCard content
+	PortVCard uint16 = 9204
+
+	// PortVCalendar is the WAP port of vCalendar content
+	PortVCalendar uint16 = 9205
+)
+
+// DetectContentType returns the content type of payload, using the destination port of ports when it is a well
+// known one, and the start of payload otherwise. ports may be nil.
+// For WAP push, the content type declared by the push PDU is returned.
+func DetectContentType(ports *Ports, payload []byte) ContentType {
+	if ports != nil {
+		switch ports.Destination {
+		case PortVCard:
+			return ContentTypeVCard
+		case PortVCalendar:
+			return ContentTypeVCalendar
+		case PortRingingTone:
+			return ContentTypeRingingTone
+		case PortOperatorLogo:
+			return ContentTypeOperatorLogo
+		case PortCLIIcon:
+			return ContentTypeCLIIcon
+		case PortWAPPush:
+			if push, err := ParseWAPPush(payload); err == nil {
+				return ContentType(push.ContentType)
+			}
+		}
+	}
+
+	trimmed := bytes.TrimLeft(payload, " \t\r\n")
+
+	switch {
+	case hasPrefixFold(trimmed, "BEGIN:VCARD"):
+		return ContentTypeVCard
+	case hasPrefixFold(trimmed, "BEGIN:VCALENDAR"):
+		return ContentTypeVCalendar
+	}
+
+	return ContentTypeUnknown
+}
+
+// ParseContent parses payload according to contentType, returning a *VCard, a *VCalendar, an *MMSNotification, a
+// *RingingTone, an *OperatorLogo or a *Bitmap for a CLI icon.
+// Returns nil without an error for content types that have no parser, or ErrInvalidContent if the content cannot
+// be parsed.
+func ParseContent(contentType ContentType, payload []byte) (any, error) {
+	var (
+		content any
+		err     error
+	)
+
+	switch contentType {
+	case ContentTypeVCard:
+		content, err = ParseVCard(payload)
+	case ContentTypeVCalendar:
+		content, err = ParseVCalendar(payload)
+	case ContentTypeMMSNotification:
+		content, err = ParseMMSNotification(payload)
+	case ContentTypeRingingTone:
+		content, err = ParseRingingTone(payload)
+	case ContentTypeOperatorLogo:
+		content, err = ParseOperatorLogo(payload)
+	case ContentTypeCLIIcon:
+		content, err = ParseCLIIcon(payload)
+	default:
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return content, nil
+}
+
+// hasPrefixFold reports whether data starts with prefix, ignoring ASCII case.
+func hasPrefixFold(data []byte, prefix string) bool {
+	return len(data) >= len(prefix) && bytes.EqualFold(data[:len(prefix)], []byte(prefix))
+}
@@ -0,0 +1,211 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+const testVCard = "BEGIN:VCARD\r\n" +
+	"VERSION:2.1\r\n" +
+	"N:Doe;John\r\n" +
+	"FN:John Doe\r\n" +
+	"TEL;CELL:+15551234\r\n" +
+	"TEL;WORK;VOICE:+15556789\r\n" +
+	"EMAIL;INTERNET:john@example.com\r\n" +
+	"ORG:Example\r\n" +
+	"NOTE:a long note that is folded\r\n over two lines\r\n" +
+	"END:VCARD\r\n"
+
+const testVCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:1.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Team meeting\r\n" +
+	"DTSTART:20240131T090000Z\r\n" +
+	"DTEND:20240131T100000Z\r\n" +
+	"LOCATION:Room 1\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name     string
+		ports    *udh.Ports
+		payload  string
+		expected udh.ContentType
+	}{
+		{name: "vCard port", ports: &udh.Ports{Destination: udh.PortVCard}, expected: udh.ContentTypeVCard},
+		{name: "vCalendar port", ports: &udh.Ports{Destination: udh.PortVCalendar}, expected: udh.ContentTypeVCalendar},
+		{name: "vCard content", payload: testVCard, expected: udh.ContentTypeVCard},
+		{name: "vCalendar content", payload: "\r\nbegin:vcalendar\r\n", expected: udh.ContentTypeVCalendar},
+		{name: "other port", ports: &udh.Ports{Destination: 1234}, payload: "hello", expected: udh.ContentTypeUnknown},
+		{name: "text", payload: "hello", expected: udh.ContentTypeUnknown},
+	}
+
+	for _, test := range tests {
+		result := udh.DetectContentType(test.ports, []byte(test.payload))
+		if result != test.expected {
+			t.Errorf("%s: have %q, expected %q", test.name, result, test.expected)
+		}
+	}
+}
+
+func TestParseVCard(t *testing.T) {
+	card, err := udh.ParseVCard([]byte(testVCard))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &udh.VCard{
+		Version:       "2.1",
+		Name:          "Doe;John",
+		FormattedName: "John Doe",
+		Phones:        []string{"+15551234", "+15556789"},
+		Emails:        []string{"john@example.com"},
+		Organization:  "Example",
+	}
+
+	if diff := cmp.Diff(expected, card); diff != "" {
+		t.Errorf("mismatch (-expected +have):\n%s", diff)
+	}
+
+	_, err = udh.ParseVCard([]byte("BEGIN:VCARD\r\nFN:John Doe\r\n"))
+	if !errors.Is(err, udh.ErrInvalidContent) {
+		t.Errorf("have err %v, expected %v", err, udh.ErrInvalidContent)
+	}
+}
+
+func TestParseVCalendar(t *testing.T) {
+	calendar, err := udh.ParseVCalendar([]byte(testVCalendar))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &udh.VCalendar{
+		Version: "1.0",
+		Events: []udh.VEvent{{
+			Summary:  "Team meeting",
+			Start:    "20240131T090000Z",
+			End:      "20240131T100000Z",
+			Location: "Room 1",
+		}},
+	}
+
+	if diff := cmp.Diff(expected, calendar); diff != "" {
+		t.Errorf("mismatch (-expected +have):\n%s", diff)
+	}
+
+	_, err = udh.ParseVCalendar([]byte("hello"))
+	if !errors.Is(err, udh.ErrInvalidContent) {
+		t.Errorf("have err %v, expected %v", err, udh.ErrInvalidContent)
+	}
+}
+
+func TestAssembledMessageContent(t *testing.T) {
+	text := strings.Replace(testVCard, "ORG:Example\r\n", "ORG:"+strings.Repeat("x", 100)+"\r\n", 1)
+
+	segments, err := udh.SegmentText(text, udh.ASCII, udh.SegmentOptions{
+		Reference: []byte{0x10},
+		Ports:     &udh.Ports{Destination: udh.PortVCard, Source: udh.PortVCard},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fragments := udh.MessageFragmentations{}
+	for _, segment := range segments {
+		err = fragments.Add(udh.ASCII, segment.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assembled, err := udh.NewAssembledMessage(fragments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assembled.ContentType != udh.ContentTypeVCard || assembled.Content != nil {
+		t.Errorf("have content type %q and content %v", assembled.ContentType, assembled.Content)
+	}
+
+	if diff := cmp.Diff(&udh.Ports{Destination: udh.PortVCard, Source: udh.PortVCard}, assembled.Ports); diff != "" {
+		t.Errorf("ports mismatch (-expected +have):\n%s", diff)
+	}
+
+	assembled, err = udh.NewAssembledMessage(fragments, udh.WithContentParsing())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card, ok := assembled.Content.(*udh.VCard)
+	if !ok {
+		t.Fatalf("have content %T, expected *smudh.VCard", assembled.Content)
+	}
+
+	if card.FormattedName != "John Doe" {
+		t.Errorf("have formatted name %q", card.FormattedName)
+	}
+}
+
+func TestSinglePartPortsContent(t *testing.T) {
+	elements, err := udh.Message("06050423F4000042454749").ParseElements(udh.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Standalone || elements.TotalParts != 1 || elements.CurrentPart != 1 || elements.Message != "BEGI" {
+		t.Errorf("have standalone %t, part %d of %d, message %q, expected a single part message",
+			elements.Standalone, elements.CurrentPart, elements.TotalParts, elements.Message)
+	}
+
+	fragments := udh.MessageFragmentations{}
+
+	err = fragments.Add(udh.ASCII, udh.Message("06050423F40000"+hex.EncodeToString([]byte(testVCard))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assembled, err := udh.NewAssembledMessage(fragments, udh.WithContentParsing())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assembled.ContentType != udh.ContentTypeVCard {
+		t.Errorf("have content type %q, expected %q", assembled.ContentType, udh.ContentTypeVCard)
+	}
+
+	if diff := cmp.Diff(&udh.Ports{Destination: udh.PortVCard}, assembled.Ports); diff != "" {
+		t.Errorf("ports mismatch (-expected +have):\n%s", diff)
+	}
+
+	if card, ok := assembled.Content.(*udh.VCard); !ok || card.FormattedName != "John Doe" {
+		t.Errorf("have content %#v, expected the vCard of John Doe", assembled.Content)
+	}
+}
+
+func FuzzParseContent(f *testing.F) {
+	contentTypes := []udh.ContentType{
+		udh.ContentTypeVCard, udh.ContentTypeVCalendar, udh.ContentTypeMMSNotification, udh.ContentTypeRingingTone,
+		udh.ContentTypeOperatorLogo, udh.ContentTypeCLIIcon,
+	}
+
+	for idx := range contentTypes {
+		f.Add(byte(idx), []byte{})
+		f.Add(byte(idx), []byte{0x00, 0x48, 0x1C, 0x01, 0xFF})
+		f.Add(byte(idx), []byte("BEGIN:VCARD\r\nFN:Test\r\nEND:VCARD\r\n"))
+	}
+
+	f.Fuzz(func(t *testing.T, contentType byte, payload []byte) {
+		// any payload must either parse or fail with an error, without panicking
+		_, _ = udh.ParseContent(contentTypes[int(contentType)%len(contentTypes)], payload)
+	})
+}
@@ -0,0 +1,43 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AssembleContext returns the full ordered text of the MessageFragmentations.
+// Returns an error if not all of the fragments exist, or the context error when ctx is canceled or its deadline is
+// exceeded while assembling.
+func (msgs *MessageFragmentations) AssembleContext(ctx context.Context) (text string, err error) {
+	// assembly has no options of its own, it joins the trace of ctx, when there is one
+	tracer := trace.SpanFromContext(ctx).TracerProvider().Tracer(instrumentationName)
+
+	ctx, span := tracer.Start(ctx, "smudh.Assemble", trace.WithAttributes(AttributeReceived.Int(len(*msgs))))
+	defer func() { endSpan(span, err) }()
+
+	if !msgs.HaveAllFragments() {
+		return "", ErrMessageNotComplete
+	}
+
+	span.SetAttributes(AttributeReference.String(hex.EncodeToString(msgs.Reference())))
+
+	buffer := bytes.Buffer{}
+
+	for _, info := range msgs.ordered() {
+		if err := ctx.Err(); err != nil {
+			return "", fmt.Errorf("%w", err)
+		}
+
+		_, _ = buffer.WriteString(info.Message)
+	}
+
+	return buffer.String(), nil
+}
@@ -0,0 +1,68 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestParseElementsContext(t *testing.T) {
+	msg := udh.Message("05000313010105E905DC05D505DD002005E205D505DC05DD")
+
+	elements, err := msg.ParseElementsContext(context.Background(), udh.UCS2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Message != "שלום עולם" {
+		t.Errorf("have %q", elements.Message)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = msg.ParseElementsContext(ctx, udh.UCS2)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("have err: %v, expected: %v", err, context.Canceled)
+	}
+}
+
+func TestAssembleContext(t *testing.T) {
+	fragments := udh.MessageFragmentations{}
+
+	err := fragments.Add(udh.GSM, udh.Message("050003A5020265722074657374696E67"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = fragments.AssembleContext(context.Background())
+	if !errors.Is(err, udh.ErrMessageNotComplete) {
+		t.Errorf("have err: %v, expected: %v", err, udh.ErrMessageNotComplete)
+	}
+
+	single := udh.MessageFragmentations{}
+
+	err = single.Add(udh.GSM, udh.Message("776F726C64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := single.AssembleContext(context.Background())
+	if err != nil || result != "world" {
+		t.Errorf("have %q, err: %v", result, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = single.AssembleContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("have err: %v, expected: %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,49 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "github.com/ik5/smudh/charset"
+
+// InterfaceVersion is the SMPP interface version, as sent on the bind PDUs. It is the same type as
+// charset.InterfaceVersion.
+type InterfaceVersion = charset.InterfaceVersion
+
+// Supported SMPP interface versions.
+const (
+	// SMPP 3.3
+	SMPP33 = charset.SMPP33
+
+	// SMPP 3.4
+	SMPP34 = charset.SMPP34
+
+	// SMPP 5.0
+	SMPP50 = charset.SMPP50
+)
+
+// DataCodingTable maps SMPP data_coding values to an Encoding. It is the same type as charset.DataCodingTable.
+//
+// A table returned by InterfaceVersion.DataCodingTable is a copy, and can be modified to match vendor deviations,
+// such as SMSCs that use 0x00 for ASCII:
+//
+//	table := smudh.SMPP34.DataCodingTable()
+//	table[0x00] = smudh.ASCII
+type DataCodingTable = charset.DataCodingTable
+
+// EncodingFromDataCoding returns the Encoding of an SMPP 3.4 data_coding value.
+// Returns an error for data_coding values that are reserved or not supported.
+func EncodingFromDataCoding(dataCoding byte) (Encoding, error) {
+	return charset.EncodingFromDataCoding(dataCoding)
+}
+
+// EncodingFromDataCodingVersion returns the Encoding of a data_coding value for the given SMPP interface version.
+func EncodingFromDataCodingVersion(dataCoding byte, version InterfaceVersion) (Encoding, error) {
+	return charset.EncodingFromDataCodingVersion(dataCoding, version)
+}
+
+// RecommendDataCoding returns the Encoding to send text with, and its data_coding value for the given SMPP
+// interface version. It is the same as charset.RecommendDataCoding.
+func RecommendDataCoding(text string, version InterfaceVersion) (Encoding, byte, error) {
+	return charset.RecommendDataCoding(text, version)
+}
@@ -0,0 +1,110 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestEncodingFromDataCodingVersion(t *testing.T) {
+	tests := []struct {
+		dataCoding byte
+		version    udh.InterfaceVersion
+		expected   udh.Encoding
+		err        error
+	}{
+		{dataCoding: 0x00, version: udh.SMPP34, expected: udh.GSM},
+		{dataCoding: 0x08, version: udh.SMPP34, expected: udh.UCS2},
+		{dataCoding: 0x0E, version: udh.SMPP34, expected: udh.KSC5601},
+		{dataCoding: 0x0F, version: udh.SMPP34, err: udh.ErrUnknownEncoding},
+		{dataCoding: 0xF0, version: udh.SMPP34, expected: udh.GSM},
+		{dataCoding: 0xF4, version: udh.SMPP34, expected: udh.Binary8Bit2},
+		{dataCoding: 0xE1, version: udh.SMPP34, expected: udh.UCS2},
+		{dataCoding: 0x05, version: udh.SMPP33, err: udh.ErrUnknownEncoding},
+		{dataCoding: 0x08, version: udh.SMPP33, expected: udh.UCS2},
+		{dataCoding: 0x0B, version: udh.SMPP50, err: udh.ErrUnknownEncoding},
+		{dataCoding: 0x0B, version: udh.SMPP34, expected: udh.Reserved1},
+		{dataCoding: 0x00, version: udh.InterfaceVersion(0x10), err: udh.ErrUnknownInterfaceVersion},
+	}
+
+	for idx, test := range tests {
+		enc, err := udh.EncodingFromDataCodingVersion(test.dataCoding, test.version)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d. have err: %v, expected: %v", idx, err, test.err)
+			continue
+		}
+
+		if err == nil && enc != test.expected {
+			t.Errorf("%d. have %s, expected %s", idx, enc, test.expected)
+		}
+	}
+}
+
+func TestDataCodingTableOverride(t *testing.T) {
+	table := udh.SMPP34.DataCodingTable()
+	table[0x00] = udh.ASCII
+
+	enc, err := table.Encoding(0x00)
+	if err != nil || enc != udh.ASCII {
+		t.Errorf("have %s, err: %v", enc, err)
+	}
+
+	enc, err = udh.EncodingFromDataCoding(0x00)
+	if err != nil || enc != udh.GSM {
+		t.Errorf("builtin table was modified: have %s, err: %v", enc, err)
+	}
+
+	dataCoding, err := udh.UCS2.DataCoding()
+	if err != nil || dataCoding != 0x08 {
+		t.Errorf("have 0x%02X, err: %v", dataCoding, err)
+	}
+
+	_, err = udh.UTF8.DataCoding()
+	if !errors.Is(err, udh.ErrUnsupportedEncoding) {
+		t.Errorf("have err: %v, expected: %v", err, udh.ErrUnsupportedEncoding)
+	}
+}
+
+func TestRecommendDataCoding(t *testing.T) {
+	tests := []struct {
+		text       string
+		version    udh.InterfaceVersion
+		expected   udh.Encoding
+		dataCoding byte
+		err        error
+	}{
+		{text: "hello {world} €5", version: udh.SMPP34, expected: udh.GSM, dataCoding: 0x00},
+		{text: "", version: udh.SMPP34, expected: udh.GSM, dataCoding: 0x00},
+		{text: "שלום", version: udh.SMPP34, expected: udh.UCS2, dataCoding: 0x08},
+		{text: "café á", version: udh.SMPP33, expected: udh.UCS2, dataCoding: 0x08},
+		{text: "hi 😀", version: udh.SMPP50, expected: udh.UCS2, dataCoding: 0x08},
+		{text: "hello", version: udh.InterfaceVersion(0x10), err: udh.ErrUnknownInterfaceVersion},
+	}
+
+	for idx, test := range tests {
+		enc, dataCoding, err := udh.RecommendDataCoding(test.text, test.version)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d. have err: %v, expected: %v", idx, err, test.err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if enc != test.expected || dataCoding != test.dataCoding {
+			t.Errorf("%d. have %s (0x%02X), expected %s (0x%02X)", idx, enc, dataCoding, test.expected, test.dataCoding)
+		}
+
+		// the inbound mapping must agree with the recommendation
+		inbound, err := udh.EncodingFromDataCodingVersion(dataCoding, test.version)
+		if err != nil || inbound != enc {
+			t.Errorf("%d. inbound mapping returned %s, err: %v", idx, inbound, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+
+	"github.com/ik5/smudh/charset"
+	"golang.org/x/text/encoding"
+)
+
+// SetDecoder overrides the decoding of enc for the whole package with the decoder of textEncoding, such as mapping
+// Latin1 to charmap.Windows1252 or Hebrew to charmap.Windows1255 for carriers that deviate from the standard.
+// A nil textEncoding restores the default decoding of enc. See charset.SetDecoder.
+func SetDecoder(enc Encoding, textEncoding encoding.Encoding) {
+	charset.SetDecoder(enc, textEncoding)
+}
+
+// WithDecoder overrides the decoding of enc with the decoder of textEncoding, for the parsing done using the
+// option only. Use it with WithParseOptions for overriding the decoding of a Messages container.
+// It takes precedence over SetDecoder.
+func WithDecoder(enc Encoding, textEncoding encoding.Encoding) ParseOption {
+	return func(config *parseConfig) {
+		if config.decoders == nil {
+			config.decoders = map[Encoding]encoding.Encoding{}
+		}
+
+		config.decoders[enc] = textEncoding
+	}
+}
+
+// decoderOverride returns the decoder set for enc by WithDecoder or SetDecoder, or nil when the decoding of enc was
+// not overridden.
+func (config parseConfig) decoderOverride(enc Encoding) encoding.Encoding {
+	if textEncoding, found := config.decoders[enc]; found {
+		return textEncoding
+	}
+
+	return charset.DecoderOverride(enc)
+}
+
+// overrideName returns a description of an overriding decoder.
+func overrideName(textEncoding encoding.Encoding) string {
+	if stringer, ok := textEncoding.(fmt.Stringer); ok {
+		return stringer.String() + " (override)"
+	}
+
+	return "an override"
+}
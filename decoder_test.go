@@ -0,0 +1,70 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	udh "github.com/ik5/smudh"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestDecoderOverride(t *testing.T) {
+	// 0x80 is a control character at ISO-8859-1, and the euro sign at Windows-1252
+	msg := udh.Message("3580")
+
+	tests := []struct {
+		name     string
+		encoding udh.Encoding
+		options  []udh.ParseOption
+		expected string
+	}{
+		{name: "default", encoding: udh.Latin1, expected: "5\u0080"},
+		{
+			name: "parse option", encoding: udh.Latin1, expected: "5€",
+			options: []udh.ParseOption{udh.WithDecoder(udh.Latin1, charmap.Windows1252)},
+		},
+		{
+			name: "other encoding", encoding: udh.Latin1, expected: "5\u0080",
+			options: []udh.ParseOption{udh.WithDecoder(udh.Hebrew, charmap.Windows1255)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			elements, err := msg.ParseElements(test.encoding, test.options...)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if elements.Message != test.expected {
+				t2.Errorf("have message %q, expected %q", elements.Message, test.expected)
+			}
+		})
+	}
+
+	udh.SetDecoder(udh.Latin1, charmap.Windows1252)
+	defer udh.SetDecoder(udh.Latin1, nil)
+
+	messages := udh.InitMessages(udh.WithParseOptions(udh.WithDecoder(udh.Latin1, charmap.ISO8859_15)))
+
+	err := messages.Add(udh.Latin1, udh.Message("0500030A0101A4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fragments := messages.Snapshot([]byte{0x0A}); len(fragments) != 1 || fragments[0].Message != "€" {
+		t.Errorf("have fragments %v, expected the euro sign of ISO-8859-15", fragments)
+	}
+
+	elements, err := msg.ParseElements(udh.Latin1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Message != "5€" {
+		t.Errorf("have message %q using the package decoder, expected %q", elements.Message, "5€")
+	}
+}
@@ -0,0 +1,59 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// WithDeduplication makes Messages check every fragment against the fragment already held for its part number, if
+// any. SMSCs commonly deliver a fragment again after an acknowledgment timed out, so a fragment whose hash of
+// reference, part number and payload matches the held fragment is ignored without an error, and audited as
+// AuditRetransmission. A fragment with a different payload for the same part is rejected with an error wrapping
+// ErrConflictingFragment, and audited as AuditConflict.
+//
+// Without the option, every fragment is added, and fragments of a part number that already exists are audited as
+// AuditDuplicate.
+func WithDeduplication() MessagesOption {
+	return func(msgs *Messages) {
+		msgs.deduplicate = true
+	}
+}
+
+// fragmentDigest returns the hash of the reference, part number, encoding and payload of a fragment. Fragments that
+// were not parsed from a Message have no RawMessage, so their decoded text is hashed instead.
+func fragmentDigest(info *MessageElements) [sha256.Size]byte {
+	hash := sha256.New()
+
+	_, _ = hash.Write([]byte{byte(len(info.Reference))})
+	_, _ = hash.Write(info.Reference)
+	_, _ = hash.Write([]byte{info.CurrentPart, byte(info.Encoding)})
+
+	if info.RawMessage != nil {
+		_, _ = hash.Write(info.RawMessage)
+	} else {
+		_, _ = hash.Write([]byte(info.Message))
+	}
+
+	return [sha256.Size]byte(hash.Sum(nil))
+}
+
+// checkRetransmission compares info with the fragment held for its part number. Returns AuditRetransmission when
+// info is an exact retransmission, and an error wrapping ErrConflictingFragment when its payload is different.
+// Returns AuditAccepted when there is no fragment for the part number.
+func checkRetransmission(fragments MessageFragmentations, info *MessageElements) (AuditOutcome, error) {
+	existing := fragments.Get(info.CurrentPart)
+
+	switch {
+	case existing == nil:
+		return AuditAccepted, nil
+	case fragmentDigest(existing) == fragmentDigest(info):
+		return AuditRetransmission, nil
+	}
+
+	return AuditConflict, fmt.Errorf("%w: part %d of reference %X", ErrConflictingFragment, info.CurrentPart,
+		info.Reference)
+}
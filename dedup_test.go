@@ -0,0 +1,132 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestDeduplication(t *testing.T) {
+	messages := udh.InitMessages(udh.WithDeduplication())
+
+	tests := []struct {
+		name string
+		msg  udh.Message
+		err  error
+	}{
+		{name: "part 1", msg: udh.Message("050003A50201546869732069732061206C")},
+		{name: "retransmission", msg: udh.Message("050003A50201546869732069732061206C")},
+		{name: "conflict", msg: udh.Message("050003A502015468697320697320"), err: udh.ErrConflictingFragment},
+		{name: "other reference", msg: udh.Message("050003B70201546869732069732061206C")},
+		{name: "part 2", msg: udh.Message("050003A5020265722074657374696E67")},
+		{name: "retransmission of part 2", msg: udh.Message("050003A5020265722074657374696E67")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			err := messages.Add(udh.ASCII, test.msg)
+			if !errors.Is(err, test.err) {
+				t2.Errorf("have error %v, expected %v", err, test.err)
+			}
+		})
+	}
+
+	complete := messages.DrainComplete()
+	if len(complete) != 1 {
+		t.Fatalf("have %d complete messages, expected 1", len(complete))
+	}
+
+	if text := complete[0].String(); text != "This is a ler testing" {
+		t.Errorf("have text %q", text)
+	}
+
+	expected := udh.NamespaceStats{Pending: 1, Fragments: 3, Completed: 1, Rejected: 1, Retransmissions: 2}
+	if diff := cmp.Diff(expected, messages.Namespace("").Stats()); diff != "" {
+		t.Errorf("stats diff: %s", diff)
+	}
+}
+
+func TestDuplicatePartNotComplete(t *testing.T) {
+	messages := udh.InitMessages()
+
+	for range 2 {
+		err := messages.Add(udh.ASCII, udh.Message("050003A5020148656C6C6F"))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if complete := messages.Complete(); len(complete) != 0 {
+		t.Errorf("have %d complete messages, expected none: %q", len(complete), complete[0].String())
+	}
+
+	if complete := messages.DrainComplete(); len(complete) != 0 {
+		t.Errorf("drained %d complete messages, expected none", len(complete))
+	}
+
+	incomplete := messages.Incomplete()
+	if len(incomplete) != 1 {
+		t.Fatalf("have %d incomplete messages, expected 1", len(incomplete))
+	}
+
+	if diff := cmp.Diff([]byte{2}, incomplete[0].MissingParts); diff != "" {
+		t.Errorf("missing parts diff: %s", diff)
+	}
+
+	if diff := cmp.Diff([]byte{1}, incomplete[0].ExtraParts); diff != "" {
+		t.Errorf("extra parts diff: %s", diff)
+	}
+
+	if messages.Snapshot([]byte{0xA5}).HaveAllFragments() {
+		t.Error("expected the message to miss fragments")
+	}
+}
+
+func TestExtraParts(t *testing.T) {
+	part := func(current byte) *udh.MessageElements {
+		return &udh.MessageElements{Reference: []byte{0x0A}, TotalParts: 2, CurrentPart: current, Message: "a"}
+	}
+
+	tests := []struct {
+		name      string
+		fragments udh.MessageFragmentations
+		missing   []byte
+		extra     []byte
+		complete  bool
+	}{
+		{name: "complete", fragments: udh.MessageFragmentations{part(1), part(2)}, complete: true},
+		{name: "missing", fragments: udh.MessageFragmentations{part(1)}, missing: []byte{2}},
+		{
+			name: "duplicate", fragments: udh.MessageFragmentations{part(1), part(1)},
+			missing: []byte{2}, extra: []byte{1},
+		},
+		{
+			name: "duplicate of a complete", fragments: udh.MessageFragmentations{part(1), part(2), part(2)},
+			extra: []byte{2},
+		},
+		{name: "out of range", fragments: udh.MessageFragmentations{part(1), part(2), part(3)}, extra: []byte{3}},
+		{name: "standalone", fragments: udh.MessageFragmentations{{Standalone: true, Message: "a"}}, complete: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if diff := cmp.Diff(test.missing, test.fragments.MissingParts()); diff != "" {
+				t2.Errorf("missing parts diff: %s", diff)
+			}
+
+			if diff := cmp.Diff(test.extra, test.fragments.ExtraParts()); diff != "" {
+				t2.Errorf("extra parts diff: %s", diff)
+			}
+
+			if complete := test.fragments.HaveAllFragments(); complete != test.complete {
+				t2.Errorf("have complete %t, expected %t", complete, test.complete)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// FieldDifference describes a single field that differs between two MessageElements.
+type FieldDifference struct {
+	// Name of the field
+	Field string `json:"field"`
+
+	// Value of the field at the first MessageElements
+	A string `json:"a"`
+
+	// Value of the field at the second MessageElements
+	B string `json:"b"`
+}
+
+// Differences is the result of Diff, empty when there are no differences.
+type Differences []FieldDifference
+
+// Diff compares a and b field by field, and returns the fields that differ.
+// The Trace and Extensions fields are not compared.
+func Diff(a, b *MessageElements) Differences {
+	if a == nil || b == nil {
+		if a == b {
+			return nil
+		}
+
+		return Differences{{Field: "MessageElements", A: nilOrSet(a), B: nilOrSet(b)}}
+	}
+
+	var result Differences
+
+	compare := func(field, valueA, valueB string) {
+		if valueA != valueB {
+			result = append(result, FieldDifference{Field: field, A: valueA, B: valueB})
+		}
+	}
+
+	compare("HeaderLength", fmt.Sprintf("0x%02X", a.HeaderLength), fmt.Sprintf("0x%02X", b.HeaderLength))
+	compare("Element", fmt.Sprintf("0x%02X", a.Element), fmt.Sprintf("0x%02X", b.Element))
+	compare("ElementLength", fmt.Sprintf("0x%02X", a.ElementLength), fmt.Sprintf("0x%02X", b.ElementLength))
+	compare("Reference", fmt.Sprintf("%X", a.Reference), fmt.Sprintf("%X", b.Reference))
+	compare("TotalParts", fmt.Sprintf("%d", a.TotalParts), fmt.Sprintf("%d", b.TotalParts))
+	compare("CurrentPart", fmt.Sprintf("%d", a.CurrentPart), fmt.Sprintf("%d", b.CurrentPart))
+	compare("RawMessage", fmt.Sprintf("%X", a.RawMessage), fmt.Sprintf("%X", b.RawMessage))
+	compare("Message", fmt.Sprintf("%q", a.Message), fmt.Sprintf("%q", b.Message))
+	compare("Encoding", a.Encoding.String(), b.Encoding.String())
+	compare("Standalone", fmt.Sprintf("%t", a.Standalone), fmt.Sprintf("%t", b.Standalone))
+	compare("Warnings", strings.Join(a.Warnings, "; "), strings.Join(b.Warnings, "; "))
+	compare("Ports", portsValue(a.Ports), portsValue(b.Ports))
+	compare("Repaired", fmt.Sprintf("%t", a.Repaired), fmt.Sprintf("%t", b.Repaired))
+	compare("MessageClass", a.MessageClass.String(), b.MessageClass.String())
+	compare("Metadata", metadataValue(a.Metadata), metadataValue(b.Metadata))
+
+	return result
+}
+
+// Equal returns true when there are no differences.
+func (diffs Differences) Equal() bool {
+	return len(diffs) == 0
+}
+
+// String returns a report of the differences, one field per line.
+func (diffs Differences) String() string {
+	builder := strings.Builder{}
+
+	for _, diff := range diffs {
+		_, _ = fmt.Fprintf(&builder, "%s: %s != %s\n", diff.Field, diff.A, diff.B)
+	}
+
+	return builder.String()
+}
+
+func nilOrSet(elem *MessageElements) string {
+	if elem == nil {
+		return "<nil>"
+	}
+
+	return "<set>"
+}
+
+func portsValue(ports *Ports) string {
+	if ports == nil {
+		return "<nil>"
+	}
+
+	return fmt.Sprintf("%d/%d", ports.Destination, ports.Source)
+}
+
+func metadataValue(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", key, metadata[key]))
+	}
+
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,90 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := udh.Message("050003A5020265722074657374696E67").ParseElements(udh.GSM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := udh.Message("050003A6020265722074657374696E67").ParseElements(udh.GSM, udh.WithTrace())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diffs := udh.Diff(a, a.Clone()); !diffs.Equal() {
+		t.Errorf("expected no differences, have:\n%s", diffs)
+	}
+
+	expected := udh.Differences{{Field: "Reference", A: "A5", B: "A6"}}
+	if diff := cmp.Diff(expected, udh.Diff(a, b)); diff != "" {
+		t.Errorf("differences diff: %s", diff)
+	}
+
+	expected = udh.Differences{{Field: "MessageElements", A: "<set>", B: "<nil>"}}
+	if diff := cmp.Diff(expected, udh.Diff(a, nil)); diff != "" {
+		t.Errorf("differences diff: %s", diff)
+	}
+
+	if report := udh.Diff(a, b).String(); report != "Reference: A5 != A6\n" {
+		t.Errorf("unexpected report: %q", report)
+	}
+}
+
+func TestDiffPortsAndMessageClass(t *testing.T) {
+	a, err := udh.Message("0605040B8423F068656C6C6F").ParseElements(udh.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := a.Clone()
+	b.Ports = &udh.Ports{Destination: 0x0B84, Source: 0x23F1}
+	b.MessageClass = udh.MessageClass0
+
+	expected := udh.Differences{
+		{Field: "Ports", A: "2948/9200", B: "2948/9201"},
+		{Field: "MessageClass", A: "none", B: "class 0"},
+	}
+	if diff := cmp.Diff(expected, udh.Diff(a, b)); diff != "" {
+		t.Errorf("differences diff: %s", diff)
+	}
+
+	b = a.Clone()
+	b.Ports = nil
+
+	expected = udh.Differences{{Field: "Ports", A: "2948/9200", B: "<nil>"}}
+	if diff := cmp.Diff(expected, udh.Diff(a, b)); diff != "" {
+		t.Errorf("differences diff: %s", diff)
+	}
+}
+
+func TestDiffMetadata(t *testing.T) {
+	a, err := udh.Message("0500030A020168656C6C6F20").ParseElements(udh.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a.Metadata = map[string]string{udh.MetadataSMSC: "smsc-1", udh.MetadataBind: "bind-a"}
+
+	if diffs := udh.Diff(a, a.Clone()); !diffs.Equal() {
+		t.Errorf("expected no differences, have:\n%s", diffs)
+	}
+
+	b := a.Clone()
+	b.Metadata = map[string]string{udh.MetadataSMSC: "smsc-2"}
+
+	expected := udh.Differences{{Field: "Metadata", A: `bind="bind-a", smsc="smsc-1"`, B: `smsc="smsc-2"`}}
+	if diff := cmp.Diff(expected, udh.Diff(a, b)); diff != "" {
+		t.Errorf("differences diff: %s", diff)
+	}
+}
@@ -0,0 +1,141 @@
+package dispatch
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/ik5/smudh"
+)
+
+// ErrNoHandler is returned when no handler is registered for a message.
+var ErrNoHandler = errors.New("no handler for the message")
+
+// Handler receives a completed message. The error it returns is passed on by Dispatch, and logged by Run.
+type Handler func(ctx context.Context, message smudh.AssembledMessage) error
+
+// Option configures a Dispatcher.
+type Option func(*Dispatcher)
+
+// WithAssembleOptions sets the options used for building the AssembledMessage of completed messages during Run,
+// such as smudh.WithContentParsing.
+func WithAssembleOptions(options ...smudh.AssembleOption) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.assembleOptions = options
+	}
+}
+
+// WithLogger sets the logger used for reporting messages that were not handled during Run.
+func WithLogger(logger *slog.Logger) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.logger = logger
+	}
+}
+
+// Dispatcher routes completed messages to the handler registered for their destination port.
+// It is safe for concurrent use, and handlers may be registered while it runs.
+type Dispatcher struct {
+	handlers        map[uint16]Handler
+	fallback        Handler
+	assembleOptions []smudh.AssembleOption
+	logger          *slog.Logger
+	mtx             sync.RWMutex
+}
+
+// New returns a Dispatcher without any handler.
+func New(options ...Option) *Dispatcher {
+	dispatcher := &Dispatcher{handlers: map[uint16]Handler{}}
+
+	for _, option := range options {
+		option(dispatcher)
+	}
+
+	return dispatcher
+}
+
+// Handle registers handler for messages sent to the destination port, replacing the previous handler of the port.
+// A nil handler removes the registration.
+func (dispatcher *Dispatcher) Handle(port uint16, handler Handler) {
+	dispatcher.mtx.Lock()
+	defer dispatcher.mtx.Unlock()
+
+	if handler == nil {
+		delete(dispatcher.handlers, port)
+		return
+	}
+
+	dispatcher.handlers[port] = handler
+}
+
+// HandleDefault registers handler for messages without port addressing, which are plain text messages.
+func (dispatcher *Dispatcher) HandleDefault(handler Handler) {
+	dispatcher.mtx.Lock()
+	defer dispatcher.mtx.Unlock()
+
+	dispatcher.fallback = handler
+}
+
+// Dispatch calls the handler matching message, and returns its error.
+// Returns ErrNoHandler if no handler is registered for the destination port of the message, or when the message
+// has no port addressing and there is no default handler.
+func (dispatcher *Dispatcher) Dispatch(ctx context.Context, message smudh.AssembledMessage) error {
+	handler, err := dispatcher.handler(message)
+	if err != nil {
+		return err
+	}
+
+	return handler(ctx, message)
+}
+
+// Run dispatches every message completed at messages until ctx is done, using Messages.Watch.
+//
+// Messages are assembled using Messages.Assemble, and the ones rejected by its middleware are not dispatched.
+// Messages are dispatched one after the other, and dispatching failures are logged. Since Watch drops events once
+// its buffer is full, size the buffer using smudh.WithWatchBuffer according to the latency of the handlers.
+func (dispatcher *Dispatcher) Run(ctx context.Context, messages *smudh.Messages) {
+	for event := range messages.Watch(ctx) {
+		if event.Type != smudh.MessageCompleted {
+			continue
+		}
+
+		assembled, err := messages.Assemble(ctx, event.Fragments, dispatcher.assembleOptions...)
+		if err == nil {
+			err = dispatcher.Dispatch(ctx, assembled)
+		}
+
+		if err != nil && !errors.Is(err, smudh.ErrRejected) && dispatcher.logger != nil {
+			dispatcher.logger.Error("message dispatch failed",
+				slog.String("reference", hex.EncodeToString(event.Reference)),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+// handler returns the handler matching message.
+func (dispatcher *Dispatcher) handler(message smudh.AssembledMessage) (Handler, error) {
+	dispatcher.mtx.RLock()
+	defer dispatcher.mtx.RUnlock()
+
+	if message.Ports == nil {
+		if dispatcher.fallback == nil {
+			return nil, fmt.Errorf("%w: no default handler", ErrNoHandler)
+		}
+
+		return dispatcher.fallback, nil
+	}
+
+	handler, found := dispatcher.handlers[message.Ports.Destination]
+	if !found {
+		return nil, fmt.Errorf("%w: port %d", ErrNoHandler, message.Ports.Destination)
+	}
+
+	return handler, nil
+}
@@ -0,0 +1,79 @@
+package dispatch_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/dispatch"
+)
+
+func TestDispatch(t *testing.T) {
+	var handled string
+
+	handler := func(name string) dispatch.Handler {
+		return func(_ context.Context, _ udh.AssembledMessage) error {
+			handled = name
+			return nil
+		}
+	}
+
+	errHandler := errors.New("handler failed")
+
+	dispatcher := dispatch.New()
+	dispatcher.Handle(udh.PortVCard, handler("vcard"))
+	dispatcher.Handle(udh.PortWAPPush, handler("push"))
+	dispatcher.Handle(udh.PortRingingTone, func(_ context.Context, _ udh.AssembledMessage) error {
+		return errHandler
+	})
+
+	toPort := func(port uint16) udh.AssembledMessage {
+		return udh.AssembledMessage{Ports: &udh.Ports{Destination: port}}
+	}
+
+	tests := []struct {
+		name     string
+		message  udh.AssembledMessage
+		fallback bool
+		expected string
+		err      error
+	}{
+		{name: "vCard port", message: toPort(udh.PortVCard), expected: "vcard"},
+		{name: "WAP push port", message: toPort(udh.PortWAPPush), expected: "push"},
+		{name: "handler error", message: toPort(udh.PortRingingTone), err: errHandler},
+		{name: "unknown port", message: toPort(1), err: dispatch.ErrNoHandler},
+		{name: "text without default", message: udh.AssembledMessage{Text: "hello"}, err: dispatch.ErrNoHandler},
+		{name: "text", message: udh.AssembledMessage{Text: "hello"}, fallback: true, expected: "text"},
+		{name: "unknown port with default", message: toPort(1), fallback: true, err: dispatch.ErrNoHandler},
+	}
+
+	for _, test := range tests {
+		handled = ""
+
+		dispatcher.HandleDefault(nil)
+		if test.fallback {
+			dispatcher.HandleDefault(handler("text"))
+		}
+
+		err := dispatcher.Dispatch(context.Background(), test.message)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: have err %v, expected %v", test.name, err, test.err)
+		}
+
+		if handled != test.expected {
+			t.Errorf("%s: handled by %q, expected %q", test.name, handled, test.expected)
+		}
+	}
+
+	dispatcher.Handle(udh.PortVCard, nil)
+
+	err := dispatcher.Dispatch(context.Background(), toPort(udh.PortVCard))
+	if !errors.Is(err, dispatch.ErrNoHandler) {
+		t.Errorf("have err %v after removing the handler, expected %v", err, dispatch.ErrNoHandler)
+	}
+}
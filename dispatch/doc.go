@@ -0,0 +1,14 @@
+/*
+Package dispatch routes completed messages to handlers by the destination port of their application port
+addressing, turning the reassembly of smudh into a small application layer for SMS borne protocols:
+
+	dispatcher := dispatch.New(dispatch.WithAssembleOptions(smudh.WithContentParsing()))
+	dispatcher.Handle(smudh.PortVCard, saveContact)
+	dispatcher.Handle(smudh.PortWAPPush, fetchMMS)
+	dispatcher.HandleDefault(storeText)
+
+	go dispatcher.Run(ctx, messages)
+
+Messages without port addressing are plain text, and are routed to the default handler.
+*/
+package dispatch
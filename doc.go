@@ -3,7 +3,7 @@ Package smudh provides functionality to parse and manage Short Message (SM) User
 
 This package addresses issues with certain Short Message Service Centers (SMSCs) that fail to properly handle fragmented or non-fragmented text messages. If your SMSC correctly processes short messages and delivers proper text, this package may not be necessary, and you can rely on standard SMSC APIs.
 
-When the short_message field contains raw hexadecimal UDH, the package provides a Message type (a byte slice) to represent the message. The ParseElements method detects and parses both UDH-structured and standalone messages.
+When the short_message field contains raw hexadecimal UDH, the package provides a Message type (a byte slice) to represent the message. The ParseElements method detects and parses both UDH-structured and standalone messages. When the UDHI bit of esm_class is known, ParseStandalone and ParseUDH parse the message in the asserted form instead of guessing it.
 
 UDH structure example:
 
@@ -25,9 +25,13 @@ Represents the word "world" (in ASCII/GSM03.38/UTF-8) without UDH.
 
 The parsing of both for UDH and stand alone are detected and parsed using the ParseElements method.
 
+A UDH is detected when the first element is a known IEI (see IEIRegistry), and the information elements fill the header length exactly. The detection used by earlier versions, based only on the first element being below 0x20, is available using the WithLegacyUDHDetection option.
+
 UDH and standalone messages do not include encoding details, which must be provided via another SMPP field accompanying the `short_message`.
 
 The package uses functional naming for elements rather than official UDH terminology.
+
+The building blocks live in subpackages, for applications that need only part of the package: header parses and builds the UDH and holds the IEI registry, and charset converts text between UTF-8 and the SMPP encodings. This package re-exports their types, and adds the parsing of complete messages and their reassembly using Messages. Reassembly is kept in this package rather than in a subpackage of its own: Messages stores the MessageElements returned by ParseElements, and runs the parse options, middleware and hooks of this package on every fragment, so a reassembly subpackage would have to import this package, which could not re-export it in turn. The extract subpackage finds URLs, short links and phone numbers in assembled text, for phishing screening.
 */
 package smudh
 
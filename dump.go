@@ -0,0 +1,143 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// dumpField is a range of bytes inside a message, annotated by DumpHex.
+type dumpField struct {
+	offset int
+	label  string
+}
+
+// DumpHex returns the hex bytes of msg annotated with the UDH fields and payload each byte range belongs to, in
+// the same form as the diagram found at the package documentation:
+//
+//	0500030F030368656C6C6F20776F726C64
+//	| | | | | | |
+//	| | | | | | |- Payload (11 bytes)
+//	| | | | | |- Current Part (03)
+//	| | | | |- Total Parts (03)
+//	| | | |- Reference (0F)
+//	| | |- Element Length (03)
+//	| |- Element (00)
+//	|- Header Length (05)
+//
+// UDH detection follows the same rules as ParseElements using the DefaultIEIRegistry. Use WithRedaction for
+// replacing the payload bytes with asterisks.
+// Returns an error if msg is not a valid hex string.
+func DumpHex(msg Message, options ...OutputOption) (string, error) {
+	binary, err := msg.decode(parseConfig{})
+	if err != nil {
+		return "", err
+	}
+
+	fields := dumpFields(binary)
+
+	dump := strings.ToUpper(hex.EncodeToString(binary))
+	if len(fields) > 0 && newOutputConfig(options).redact {
+		dump = redactPayload(dump, fields[len(fields)-1].offset)
+	}
+
+	builder := strings.Builder{}
+	_, _ = builder.WriteString(dump)
+	_ = builder.WriteByte('\n')
+
+	if len(fields) == 0 {
+		return builder.String(), nil
+	}
+
+	width := fields[len(fields)-1].offset*2 + 1
+	line := []byte(strings.Repeat(" ", width))
+
+	for _, field := range fields {
+		line[field.offset*2] = '|'
+	}
+
+	_, _ = builder.WriteString(strings.TrimRight(string(line), " "))
+	_ = builder.WriteByte('\n')
+
+	for idx := len(fields) - 1; idx >= 0; idx-- {
+		line = []byte(strings.Repeat(" ", fields[idx].offset*2))
+		for _, field := range fields[:idx] {
+			line[field.offset*2] = '|'
+		}
+
+		_, _ = builder.Write(line)
+		_, _ = builder.WriteString("|- ")
+		_, _ = builder.WriteString(fields[idx].label)
+		_ = builder.WriteByte('\n')
+	}
+
+	return builder.String(), nil
+}
+
+// dumpFields splits binary into annotated fields.
+func dumpFields(binary []byte) []dumpField {
+	if len(binary) == 0 {
+		return nil
+	}
+
+	if detected, _ := (parseConfig{}).detectUDH(binary); !detected {
+		return []dumpField{payloadField(0, len(binary))}
+	}
+
+	headerLength := int(binary[0])
+
+	fields := []dumpField{{offset: 0, label: fmt.Sprintf("Header Length (%02X)", binary[0])}}
+	end := headerLength + 1
+	offset := 1
+
+	for offset < end {
+		fields = append(fields, dumpField{offset: offset, label: fmt.Sprintf("Element (%02X)", binary[offset])})
+		offset++
+
+		if offset >= end {
+			break
+		}
+
+		elementLength := int(binary[offset])
+		fields = append(fields, dumpField{offset: offset, label: fmt.Sprintf("Element Length (%02X)", binary[offset])})
+		offset++
+
+		dataEnd := min(offset+elementLength, end)
+		fields = append(fields, elementFields(binary[offset-2], binary[offset:dataEnd], offset)...)
+		offset = dataEnd
+	}
+
+	return append(fields, payloadField(end, len(binary)-end))
+}
+
+// elementFields annotates the data of a single IE starting at offset.
+func elementFields(identifier byte, data []byte, offset int) []dumpField {
+	if len(data) == 0 {
+		return nil
+	}
+
+	referenceLength := 0
+
+	switch {
+	case identifier == IEIConcatenated8Bit && len(data) == 3:
+		referenceLength = 1
+	case identifier == IEIConcatenated16Bit && len(data) == 4:
+		referenceLength = 2
+	default:
+		return []dumpField{{offset: offset, label: fmt.Sprintf("Element Data (%X)", data)}}
+	}
+
+	return []dumpField{
+		{offset: offset, label: fmt.Sprintf("Reference (%X)", data[:referenceLength])},
+		{offset: offset + referenceLength, label: fmt.Sprintf("Total Parts (%02X)", data[referenceLength])},
+		{offset: offset + referenceLength + 1, label: fmt.Sprintf("Current Part (%02X)", data[referenceLength+1])},
+	}
+}
+
+func payloadField(offset, length int) dumpField {
+	return dumpField{offset: offset, label: fmt.Sprintf("Payload (%d bytes)", length)}
+}
@@ -0,0 +1,74 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestDumpHex(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    udh.Message
+		expected string
+		err      error
+	}{
+		{
+			name:  "8-bit reference",
+			input: udh.Message("0500030F030368656C6C6F20776F726C64"),
+			expected: "0500030F030368656C6C6F20776F726C64\n" +
+				"| | | | | | |\n" +
+				"| | | | | | |- Payload (11 bytes)\n" +
+				"| | | | | |- Current Part (03)\n" +
+				"| | | | |- Total Parts (03)\n" +
+				"| | | |- Reference (0F)\n" +
+				"| | |- Element Length (03)\n" +
+				"| |- Element (00)\n" +
+				"|- Header Length (05)\n",
+		},
+		{
+			name:  "16-bit reference",
+			input: udh.Message("060804753904046869"),
+			expected: "060804753904046869\n" +
+				"| | | |   | | |\n" +
+				"| | | |   | | |- Payload (2 bytes)\n" +
+				"| | | |   | |- Current Part (04)\n" +
+				"| | | |   |- Total Parts (04)\n" +
+				"| | | |- Reference (7539)\n" +
+				"| | |- Element Length (04)\n" +
+				"| |- Element (08)\n" +
+				"|- Header Length (06)\n",
+		},
+		{
+			name:  "standalone",
+			input: udh.Message("776F726C64"),
+			expected: "776F726C64\n" +
+				"|\n" +
+				"|- Payload (5 bytes)\n",
+		},
+		{
+			name:  "odd length",
+			input: udh.Message("776"),
+			err:   udh.ErrHexStringMustHaveAnEvenNumberOfChars,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			result, err := udh.DumpHex(test.input)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			if diff := cmp.Diff(test.expected, result); diff != "" {
+				t2.Errorf("dump diff: %s", diff)
+			}
+		})
+	}
+}
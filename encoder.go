@@ -0,0 +1,32 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "github.com/ik5/smudh/charset"
+
+// EncodeText converts UTF-8 text into the raw bytes of the given encoding - the outbound counterpart of the
+// decoding done by ParseElements. It is the same as charset.Encode.
+//
+// GSM 7-bit text is returned unpacked (one septet per byte), and binary encodings expect the text to be hex
+// encoded, the same way ParseElements represents them.
+// Returns an error if the text cannot be represented in the encoding.
+func EncodeText(text string, enc Encoding) ([]byte, error) {
+	return charset.Encode(text, enc)
+}
+
+// NewMessageFromText encodes text as a single standalone (non-fragmented) Message in its hex form.
+// Returns an error if the text cannot be encoded, or does not fit into a single short message.
+func NewMessageFromText(text string, enc Encoding) (Message, error) {
+	payload, err := EncodeText(text, enc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(payload) > segmentCapacity(enc, maxUserDataLength, 0) {
+		return nil, ErrTextTooLong
+	}
+
+	return Segment{Payload: payload}.Hex(), nil
+}
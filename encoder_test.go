@@ -0,0 +1,60 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestNewMessageFromText(t *testing.T) {
+	tests := []struct {
+		text     string
+		encoding udh.Encoding
+		expected udh.Message
+		err      error
+	}{
+		{text: "world", encoding: udh.GSM, expected: udh.Message("776F726C64")},
+		{text: "Hello", encoding: udh.UTF8, expected: udh.Message("48656C6C6F")},
+		{text: "עברית", encoding: udh.UCS2, expected: udh.Message("05E205D105E805D905EA")},
+		{text: "עברית", encoding: udh.Hebrew, expected: udh.Message("F2E1F8E9FA")},
+		{text: "€", encoding: udh.GSM, expected: udh.Message("1B65")},
+		{text: strings.Repeat("a", 161), encoding: udh.GSM, err: udh.ErrTextTooLong},
+		{text: strings.Repeat("ש", 71), encoding: udh.UCS2, err: udh.ErrTextTooLong},
+		{text: "עברית", encoding: udh.ASCII, err: udh.ErrCharacterNotRepresentable},
+		{text: "hello", encoding: udh.Pictogram, err: udh.ErrUnsupportedEncoding},
+	}
+
+	for idx, test := range tests {
+		result, err := udh.NewMessageFromText(test.text, test.encoding)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%d. have err: %v, expected: %v", idx, err, test.err)
+			continue
+		}
+
+		if string(result) != string(test.expected) {
+			t.Errorf("%d. have %s, expected %s", idx, result, test.expected)
+		}
+	}
+}
+
+func TestNewMessageFromTextRoundTrip(t *testing.T) {
+	msg, err := udh.NewMessageFromText("עברית קשה שפה", udh.UCS2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elements, err := msg.ParseElements(udh.UCS2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !elements.Standalone || elements.Message != "עברית קשה שפה" {
+		t.Errorf("unexpected elements: %+v", *elements)
+	}
+}
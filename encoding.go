@@ -4,99 +4,66 @@ package smudh
 // License, v. 2.0. If a copy of the MPL was not distributed with this
 // file, You can obtain one at https://mozilla.org/MPL/2.0/.
 
-import "fmt"
+import "github.com/ik5/smudh/charset"
 
-// Encoding define a unique SMPP text encoding code.
-type Encoding byte
+// Encoding define a unique SMPP text encoding code. It is the same type as charset.Encoding.
+type Encoding = charset.Encoding
 
 // Encoding defines the encoding type for message content.
 const (
 	// GSM 7-bit encoding
-	GSM Encoding = iota
+	GSM = charset.GSM
 
 	// ASCII/IA5 encoding
-	ASCII
+	ASCII = charset.ASCII
 
 	// 8-bit binary encoding
-	Binary8Bit1
+	Binary8Bit1 = charset.Binary8Bit1
 
 	// ISO-8859-1 encoding
-	Latin1
+	Latin1 = charset.Latin1
 
 	// 8-bit binary encoding
-	Binary8Bit2
+	Binary8Bit2 = charset.Binary8Bit2
 
 	// JIS (X 0208-1990) encoding
-	JIS
+	JIS = charset.JIS
 
 	// ISO-8859-5 encoding
-	Cyrillic
+	Cyrillic = charset.Cyrillic
 
 	// ISO-8859-8 encoding
-	Hebrew
+	Hebrew = charset.Hebrew
 
 	// UCS2 (UTF-16BE) encoding
-	UCS2
+	UCS2 = charset.UCS2
 
 	// Cellular pictogram icons encoding support
-	Pictogram
+	Pictogram = charset.Pictogram
 
 	// ISO-2022-JP (Music Codes) encoding
-	ISO2022JP
+	ISO2022JP = charset.ISO2022JP
 
 	// Not commonly used
-	Reserved1
+	Reserved1 = charset.Reserved1
 
 	// Not commonly used
-	Reserved2
+	Reserved2 = charset.Reserved2
 
 	// Extended Kanji JIS (X 0212-1990) encoding
-	EXTJIS
+	EXTJIS = charset.EXTJIS
 
 	// KS C 5601 encoding
-	KSC5601
+	KSC5601 = charset.KSC5601
 
 	//GSM 7-bit (extended) - GSM 7-bit with national language extensions
-	GSMExtended
+	GSMExtended = charset.GSMExtended
 
 	// UTF-8 encoding (rarely used)
-	UTF8
+	UTF8 = charset.UTF8
 )
 
-// Returns the string representation of the Encoding type.
-func (enc Encoding) String() string {
-	switch enc {
-	case GSM:
-		return "GSM-7"
-	case ASCII:
-		return "ASCII"
-	case Binary8Bit1:
-		return "BINARY-1"
-	case Latin1:
-		return "Latin1"
-	case Binary8Bit2:
-		return "BINARY-2"
-	case JIS:
-		return "JIS"
-	case Cyrillic:
-		return "ISO8859-5 (Cyrillic)"
-	case Hebrew:
-		return "ISO8859-8 (Hebrew)"
-	case UCS2:
-		return "UCS2 (UTF-16BE)"
-	case Pictogram:
-		return "Pictogram"
-	case ISO2022JP:
-		return "ISO2022JP (music)"
-	case EXTJIS:
-		return "EXT-JS (X 0212-1990)"
-	case KSC5601:
-		return "KSC-5601"
-	case GSMExtended:
-		return "GSM-7 (Extended)"
-	case UTF8:
-		return "UTF-8"
-	}
-
-	return fmt.Sprintf("%d", enc)
+// AllEncodings returns every encoding defined by the package, in ascending order.
+func AllEncodings() []Encoding {
+	return charset.AllEncodings()
 }
@@ -0,0 +1,252 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// sealedVersion is the first octet of content sealed by EncryptedStore.
+const sealedVersion byte = 1
+
+// KeyProvider supplies the AES keys used by EncryptedStore. Keys are 16, 24 or 32 bytes long, selecting AES-128,
+// AES-192 or AES-256.
+type KeyProvider interface {
+	// EncryptionKey returns the key used for encrypting new content, and its identifier, which is stored next to
+	// the content. The identifier is at most 255 bytes long.
+	EncryptionKey() (id string, key []byte, err error)
+
+	// DecryptionKey returns the key of an identifier returned by EncryptionKey, so content encrypted before a key
+	// rotation can still be read
+	DecryptionKey(id string) ([]byte, error)
+}
+
+// StaticKey is a KeyProvider that always uses the same key, with an empty identifier.
+type StaticKey []byte
+
+// EncryptedStore is a Store that encrypts the RawMessage and Message of every fragment using AES-GCM, before
+// handing them over to another Store. The values of the Metadata are encrypted as well, since they may identify
+// the subscriber, while its keys are stored as is.
+//
+// The Extensions of the fragments, holding the content decoded by IE handlers, are not saved at all, so fragments
+// loaded by LoadAll have none. The other fields of the fragments, such as the reference and part numbers, are
+// stored as is. The content is bound to the key of its message, so it cannot be moved to another message. All of
+// the content of the other Store must have been written by an EncryptedStore.
+type EncryptedStore struct {
+	store Store
+	keys  KeyProvider
+}
+
+// NewEncryptedStore returns an EncryptedStore writing to store, using the keys of keys.
+func NewEncryptedStore(store Store, keys KeyProvider) *EncryptedStore {
+	return &EncryptedStore{store: store, keys: keys}
+}
+
+// EncryptionKey implements KeyProvider.
+func (key StaticKey) EncryptionKey() (string, []byte, error) {
+	return "", key, nil
+}
+
+// DecryptionKey implements KeyProvider.
+func (key StaticKey) DecryptionKey(string) ([]byte, error) {
+	return key, nil
+}
+
+// Ping implements StorePinger, by pinging the other Store when it implements StorePinger.
+func (store *EncryptedStore) Ping(ctx context.Context) error {
+	pinger, ok := store.store.(StorePinger)
+	if !ok {
+		return nil
+	}
+
+	return pinger.Ping(ctx)
+}
+
+// Save implements Store.
+func (store *EncryptedStore) Save(key string, set StoredSet) error {
+	id, secret, err := store.keys.EncryptionKey()
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	if len(id) > 0xFF {
+		return fmt.Errorf("%w: key identifier is too long", ErrEncryption)
+	}
+
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return err
+	}
+
+	encrypted := set
+	encrypted.Fragments = set.Fragments.Clone()
+
+	for _, info := range encrypted.Fragments {
+		raw, err := seal(aead, id, info.RawMessage, key)
+		if err != nil {
+			return err
+		}
+
+		message, err := sealString(aead, id, info.Message, key)
+		if err != nil {
+			return err
+		}
+
+		info.RawMessage = raw
+		info.Message = message
+		info.Extensions = nil
+
+		for name, value := range info.Metadata {
+			info.Metadata[name], err = sealString(aead, id, value, key)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return store.store.Save(key, encrypted)
+}
+
+// Delete implements Store.
+func (store *EncryptedStore) Delete(key string) error {
+	return store.store.Delete(key)
+}
+
+// LoadAll implements Store.
+// Returns an error wrapping ErrEncryption if some of the content cannot be decrypted.
+func (store *EncryptedStore) LoadAll() (map[string]StoredSet, error) {
+	sets, err := store.store.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	for key, set := range sets {
+		// the fragments may be shared with the other Store
+		set.Fragments = set.Fragments.Clone()
+		sets[key] = set
+
+		for _, info := range set.Fragments {
+			raw, err := store.open(info.RawMessage, key)
+			if err != nil {
+				return nil, err
+			}
+
+			message, err := store.openString(info.Message, key)
+			if err != nil {
+				return nil, err
+			}
+
+			info.RawMessage = raw
+			info.Message = message
+
+			for name, value := range info.Metadata {
+				info.Metadata[name], err = store.openString(value, key)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return sets, nil
+}
+
+// open decrypts content sealed by seal.
+func (store *EncryptedStore) open(sealed []byte, key string) ([]byte, error) {
+	if len(sealed) < 2 || sealed[0] != sealedVersion || len(sealed) < 2+int(sealed[1]) {
+		return nil, fmt.Errorf("%w: invalid sealed content", ErrEncryption)
+	}
+
+	idLength := int(sealed[1])
+	id := string(sealed[2 : 2+idLength])
+
+	secret, err := store.keys.DecryptionKey(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncryption, err)
+	}
+
+	aead, err := newAEAD(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	content := sealed[2+idLength:]
+	if len(content) < aead.NonceSize() {
+		return nil, fmt.Errorf("%w: invalid sealed content", ErrEncryption)
+	}
+
+	nonce, ciphertext := content[:aead.NonceSize()], content[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncryption, err)
+	}
+
+	return plaintext, nil
+}
+
+// openString decrypts content sealed by sealString.
+func (store *EncryptedStore) openString(sealed, key string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrEncryption, err)
+	}
+
+	plaintext, err := store.open(decoded, key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// sealString encrypts plaintext as seal does, encoding the result using base64 so it can be kept at a string.
+func sealString(aead cipher.AEAD, id, plaintext, key string) (string, error) {
+	sealed, err := seal(aead, id, []byte(plaintext), key)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// seal encrypts plaintext, bound to the message key. The result holds the version, the key identifier, the nonce
+// and the ciphertext.
+func seal(aead cipher.AEAD, id string, plaintext []byte, key string) ([]byte, error) {
+	sealed := make([]byte, 0, 2+len(id)+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	sealed = append(sealed, sealedVersion, byte(len(id)))
+	sealed = append(sealed, id...)
+
+	nonce := make([]byte, aead.NonceSize())
+
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	sealed = append(sealed, nonce...)
+
+	return aead.Seal(sealed, nonce, plaintext, []byte(key)), nil
+}
+
+// newAEAD returns the AES-GCM cipher of secret.
+func newAEAD(secret []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncryption, err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEncryption, err)
+	}
+
+	return aead, nil
+}
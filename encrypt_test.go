@@ -0,0 +1,180 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+// rotatingKeys is a KeyProvider encrypting using the key of current.
+type rotatingKeys struct {
+	current string
+	keys    map[string][]byte
+}
+
+func (keys rotatingKeys) EncryptionKey() (string, []byte, error) {
+	return keys.current, keys.keys[keys.current], nil
+}
+
+func (keys rotatingKeys) DecryptionKey(id string) ([]byte, error) {
+	key, found := keys.keys[id]
+	if !found {
+		return nil, errors.New("unknown key " + id)
+	}
+
+	return key, nil
+}
+
+func TestEncryptedStore(t *testing.T) {
+	inner := udh.NewMemoryStore()
+	keys := rotatingKeys{
+		current: "2024",
+		keys:    map[string][]byte{"2024": bytes.Repeat([]byte{1}, 32), "2025": bytes.Repeat([]byte{2}, 16)},
+	}
+
+	messages := udh.InitMessages(udh.WithStore(udh.NewEncryptedStore(inner, keys)))
+
+	err := messages.Add(udh.ASCII, udh.Message("050003A50201546869732069732061206C")) // part 1 of 2
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys.current = "2025"
+	messages = udh.InitMessages(udh.WithStore(udh.NewEncryptedStore(inner, keys)))
+
+	err = messages.Add(udh.ASCII, udh.Message("050003B70502002005E905DC")) // part 2 of 5
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain, err := inner.LoadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, set := range plain {
+		for _, info := range set.Fragments {
+			if strings.Contains(info.Message, "This") || bytes.Contains(info.RawMessage, []byte("This")) {
+				t.Errorf("stored fragment %X is not encrypted", info.Reference)
+			}
+		}
+	}
+
+	restored := udh.InitMessages(udh.WithStore(udh.NewEncryptedStore(inner, keys)))
+
+	err = restored.Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fragments := restored.Snapshot([]byte{0xA5})
+	if text := fragments.String(); text != "This is a l" {
+		t.Errorf("have %q, expected %q", text, "This is a l")
+	}
+
+	if raw := fragments[0].RawMessage; string(raw) != "This is a l" {
+		t.Errorf("have raw message %q", raw)
+	}
+
+	moved := udh.NewMemoryStore()
+	_ = moved.Save("\x00\xB7", plain["\xA5"])
+
+	unencrypted := udh.NewMemoryStore()
+	_ = unencrypted.Save("\xA5", udh.StoredSet{Fragments: fragments})
+
+	tests := []struct {
+		name  string
+		store udh.Store
+	}{
+		{name: "wrong key", store: udh.NewEncryptedStore(inner, udh.StaticKey(bytes.Repeat([]byte{3}, 32)))},
+		{name: "invalid key", store: udh.NewEncryptedStore(inner, udh.StaticKey(nil))},
+		{name: "moved content", store: udh.NewEncryptedStore(moved, keys)},
+		{name: "unencrypted content", store: udh.NewEncryptedStore(unencrypted, keys)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			_, err := test.store.LoadAll()
+			if !errors.Is(err, udh.ErrEncryption) {
+				t2.Errorf("have error %v, expected ErrEncryption", err)
+			}
+		})
+	}
+}
+
+func TestEncryptedStoreSealsMetadata(t *testing.T) {
+	dir := t.TempDir()
+
+	inner, err := udh.NewDirStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := udh.NewEncryptedStore(inner, udh.StaticKey(bytes.Repeat([]byte{1}, 32)))
+	messages := udh.InitMessages(udh.WithStore(store))
+
+	info, err := udh.Message("050003A50201546869732069732061206C").ParseElements(udh.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info.Extensions = map[byte]any{0x70: "Secret extension"}
+
+	ctx := udh.ContextWithMetadata(context.Background(), map[string]string{udh.MetadataSMSC: "Secret SMSC"})
+
+	err = messages.AddMessageElementsContext(ctx, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) == 0 {
+		t.Fatal("nothing was stored")
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if bytes.Contains(content, []byte("Secret")) || bytes.Contains(content, []byte("This")) {
+			t.Errorf("%s holds plaintext: %s", file.Name(), content)
+		}
+	}
+
+	restored := udh.InitMessages(udh.WithStore(store))
+
+	err = restored.Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fragments := restored.Snapshot([]byte{0xA5})
+	if len(fragments) != 1 {
+		t.Fatalf("have %d restored fragments, expected 1", len(fragments))
+	}
+
+	if diff := cmp.Diff(map[string]string{udh.MetadataSMSC: "Secret SMSC"}, fragments[0].Metadata); diff != "" {
+		t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+	}
+
+	if fragments[0].Extensions != nil {
+		t.Errorf("expected no extensions, have %v", fragments[0].Extensions)
+	}
+}
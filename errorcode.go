@@ -0,0 +1,140 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+
+	"github.com/ik5/smudh/charset"
+	"github.com/ik5/smudh/header"
+)
+
+// ErrorCode is a stable, machine-readable identifier of a package error, for mapping failures to API responses and
+// metrics labels without matching error messages. Use CodeOf for finding the code of an error.
+type ErrorCode string
+
+// Error codes returned by CodeOf.
+const (
+	CodeNone                        ErrorCode = ""
+	CodeUnknown                     ErrorCode = "unknown"
+	CodeCanceled                    ErrorCode = "canceled"
+	CodeDeadlineExceeded            ErrorCode = "deadline_exceeded"
+	CodeOddHexLength                ErrorCode = "odd_hex_length"
+	CodeInvalidHex                  ErrorCode = "invalid_hex"
+	CodeNonCanonicalHex             ErrorCode = "non_canonical_hex"
+	CodeOddUTF16Length              ErrorCode = "odd_utf16_length"
+	CodeInputTooShortForUDH         ErrorCode = "input_too_short"
+	CodeUDHLengthExceedsInput       ErrorCode = "udh_length_exceeds_input"
+	CodeUDHExpected                 ErrorCode = "udh_expected"
+	CodeUnsupportedIEI              ErrorCode = "unsupported_iei"
+	CodeLengthLimitExceeded         ErrorCode = "length_limit_exceeded"
+	CodeUnsupportedEncoding         ErrorCode = "unsupported_encoding"
+	CodeUnknownEncoding             ErrorCode = "unknown_encoding"
+	CodeUnknownInterfaceVersion     ErrorCode = "unknown_interface_version"
+	CodeUnsupportedNationalLanguage ErrorCode = "unsupported_national_language"
+	CodeCharacterNotRepresentable   ErrorCode = "character_not_representable"
+	CodeMessageNotComplete          ErrorCode = "message_not_complete"
+	CodeMessageNotFound             ErrorCode = "message_not_found"
+	CodeMissingPart                 ErrorCode = "missing_part"
+	CodeInvalidReferenceNumber      ErrorCode = "invalid_reference_number"
+	CodeInvalidReferenceLength      ErrorCode = "invalid_reference_length"
+	CodeInvalidPartNumber           ErrorCode = "invalid_part_number"
+	CodeConflictingFragment         ErrorCode = "conflicting_fragment"
+	CodeDuplicateIE                 ErrorCode = "duplicate_ie"
+	CodeIEDataTooLong               ErrorCode = "ie_data_too_long"
+	CodeUDHTooLong                  ErrorCode = "udh_too_long"
+	CodeReferenceRequired           ErrorCode = "reference_required"
+	CodeTooManySegments             ErrorCode = "too_many_segments"
+	CodeTextTooLong                 ErrorCode = "text_too_long"
+	CodeInvalidSegmentSize          ErrorCode = "invalid_segment_size"
+	CodeInvalidUCS2Options          ErrorCode = "invalid_ucs2_options"
+	CodeInvalidContent              ErrorCode = "invalid_content"
+	CodeInvalidCommandPacket        ErrorCode = "invalid_command_packet"
+	CodeInvalidSCTS                 ErrorCode = "invalid_scts"
+	CodeInvalidAddress              ErrorCode = "invalid_address"
+	CodeInvalidPDU                  ErrorCode = "invalid_pdu"
+	CodeUnsupportedPDU              ErrorCode = "unsupported_pdu"
+	CodeStore                       ErrorCode = "store_failed"
+	CodeEncryption                  ErrorCode = "encryption_failed"
+	CodeRejected                    ErrorCode = "rejected"
+	CodeRateLimited                 ErrorCode = "rate_limited"
+	CodeQuotaExceeded               ErrorCode = "quota_exceeded"
+	CodeClosed                      ErrorCode = "closed"
+	CodeInvalidQuirkProfile         ErrorCode = "invalid_quirk_profile"
+	CodeUnknownQuirkProfile         ErrorCode = "unknown_quirk_profile"
+	CodeUnsupportedShardOption      ErrorCode = "unsupported_shard_option"
+)
+
+// Error is the type of the sentinel errors of the package, carrying their ErrorCode. The sentinels are compared
+// using errors.Is as before, and errors.As finds the Error of a sentinel wrapped by a returned error.
+//
+// The sentinels that are shared with the charset and header packages, such as ErrUnsupportedEncoding, keep the
+// types of those packages, and their codes are found by CodeOf.
+type Error struct {
+	// Code of the error
+	Code ErrorCode
+
+	message string
+}
+
+// newError returns a new sentinel error with the given code and message.
+func newError(code ErrorCode, message string) error {
+	return &Error{Code: code, message: message}
+}
+
+// Error implements error.
+func (err *Error) Error() string {
+	return err.message
+}
+
+// sharedErrorCodes maps the sentinels shared with the charset and header packages, and the standard library errors
+// returned by the package, to their codes.
+var sharedErrorCodes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{err: charset.ErrBinaryTextLengthIsNotEvenForUTF16Decoding, code: CodeOddUTF16Length},
+	{err: charset.ErrUnsupportedEncoding, code: CodeUnsupportedEncoding},
+	{err: charset.ErrUnknownEncoding, code: CodeUnknownEncoding},
+	{err: charset.ErrCharacterNotRepresentable, code: CodeCharacterNotRepresentable},
+	{err: charset.ErrUnknownInterfaceVersion, code: CodeUnknownInterfaceVersion},
+	{err: charset.ErrUnsupportedNationalLanguage, code: CodeUnsupportedNationalLanguage},
+	{err: header.ErrInvalidReferenceLength, code: CodeInvalidReferenceLength},
+	{err: header.ErrInvalidPartNumber, code: CodeInvalidPartNumber},
+	{err: header.ErrDuplicateIE, code: CodeDuplicateIE},
+	{err: header.ErrIEDataTooLong, code: CodeIEDataTooLong},
+	{err: header.ErrUDHTooLong, code: CodeUDHTooLong},
+	{err: hex.ErrLength, code: CodeOddHexLength},
+	{err: context.Canceled, code: CodeCanceled},
+	{err: context.DeadlineExceeded, code: CodeDeadlineExceeded},
+}
+
+// CodeOf returns the ErrorCode of err: the code of the outermost package sentinel it wraps, CodeNone for a nil
+// error, and CodeUnknown for errors that are not returned by the package.
+func CodeOf(err error) ErrorCode {
+	if err == nil {
+		return CodeNone
+	}
+
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+
+	for _, shared := range sharedErrorCodes {
+		if errors.Is(err, shared.err) {
+			return shared.code
+		}
+	}
+
+	var invalidByte hex.InvalidByteError
+	if errors.As(err, &invalidByte) {
+		return CodeInvalidHex
+	}
+
+	return CodeUnknown
+}
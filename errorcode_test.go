@@ -0,0 +1,53 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestCodeOf(t *testing.T) {
+	parseErr := func(input string, encoding udh.Encoding) error {
+		_, err := udh.Message(input).ParseElements(encoding)
+		return err
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected udh.ErrorCode
+	}{
+		{name: "nil", err: nil, expected: udh.CodeNone},
+		{name: "sentinel", err: udh.ErrMessageNotFound, expected: udh.CodeMessageNotFound},
+		{name: "wrapped", err: fmt.Errorf("%w: disk full", udh.ErrStore), expected: udh.CodeStore},
+		{name: "outermost sentinel", err: fmt.Errorf("%w: %w", udh.ErrStore, udh.ErrEncryption), expected: udh.CodeStore},
+		{name: "shared sentinel", err: fmt.Errorf("%w", udh.ErrUnsupportedEncoding), expected: udh.CodeUnsupportedEncoding},
+		{name: "odd hex length", err: parseErr("ABC", udh.ASCII), expected: udh.CodeOddHexLength},
+		{name: "invalid hex", err: parseErr("ZZ", udh.ASCII), expected: udh.CodeInvalidHex},
+		{name: "odd UTF-16 length", err: parseErr("414243", udh.UCS2), expected: udh.CodeOddUTF16Length},
+		{name: "context", err: fmt.Errorf("%w", context.Canceled), expected: udh.CodeCanceled},
+		{name: "foreign", err: errors.New("something else"), expected: udh.CodeUnknown},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if code := udh.CodeOf(test.err); code != test.expected {
+				t2.Errorf("have %q for %v, expected %q", code, test.err, test.expected)
+			}
+		})
+	}
+
+	_, err := udh.Message("0A0003").ParseUDH(udh.ASCII)
+
+	var coded *udh.Error
+	if !errors.As(err, &coded) || coded.Code != udh.CodeUDHExpected || !errors.Is(err, udh.ErrUDHExpected) {
+		t.Errorf("have %v, expected an *Error with the code %q", err, udh.CodeUDHExpected)
+	}
+}
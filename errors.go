@@ -4,17 +4,54 @@ package smudh
 // License, v. 2.0. If a copy of the MPL was not distributed with this
 // file, You can obtain one at https://mozilla.org/MPL/2.0/.
 
-import "errors"
+import (
+	"github.com/ik5/smudh/charset"
+	"github.com/ik5/smudh/header"
+)
 
+// Sentinel errors of the package. The ones created by the package are of type *Error, carrying their ErrorCode.
 var (
-	ErrHexStringMustHaveAnEvenNumberOfChars      = errors.New("hex string must have an even number of characters")
-	ErrBinaryTextLengthIsNotEvenForUTF16Decoding = errors.New("binary text length is not even for UTF-16 decoding")
-	ErrInputTooShortForUDH                       = errors.New("input too short for UDH")
-	ErrUDHLengthExceedsInputLength               = errors.New("UDH length exceeds input length")
-	ErrMessageNotComplete                        = errors.New("message is not complete yet")
-	ErrMissingPart                               = errors.New("missing part")
-	ErrInvalidReferenceNumber                    = errors.New("invalid reference number")
-	ErrUnsupportedIEI                            = errors.New("unsupported IEI")
-	ErrUnsupportedEncoding                       = errors.New("unsupported encoding")
-	ErrUnknownEncoding                           = errors.New("unknown encoding")
+	ErrHexStringMustHaveAnEvenNumberOfChars      = newError(CodeOddHexLength, "hex string must have an even number of characters")
+	ErrBinaryTextLengthIsNotEvenForUTF16Decoding = charset.ErrBinaryTextLengthIsNotEvenForUTF16Decoding
+	ErrInputTooShortForUDH                       = newError(CodeInputTooShortForUDH, "input too short for UDH")
+	ErrUDHLengthExceedsInputLength               = newError(CodeUDHLengthExceedsInput, "UDH length exceeds input length")
+	ErrMessageNotComplete                        = newError(CodeMessageNotComplete, "message is not complete yet")
+	ErrMessageNotFound                           = newError(CodeMessageNotFound, "message not found")
+	ErrMissingPart                               = newError(CodeMissingPart, "missing part")
+	ErrInvalidReferenceNumber                    = newError(CodeInvalidReferenceNumber, "invalid reference number")
+	ErrUnsupportedIEI                            = newError(CodeUnsupportedIEI, "unsupported IEI")
+	ErrUnsupportedEncoding                       = charset.ErrUnsupportedEncoding
+	ErrUnknownEncoding                           = charset.ErrUnknownEncoding
+	ErrInvalidReferenceLength                    = header.ErrInvalidReferenceLength
+	ErrInvalidPartNumber                         = header.ErrInvalidPartNumber
+	ErrDuplicateIE                               = header.ErrDuplicateIE
+	ErrIEDataTooLong                             = header.ErrIEDataTooLong
+	ErrUDHTooLong                                = header.ErrUDHTooLong
+	ErrCharacterNotRepresentable                 = charset.ErrCharacterNotRepresentable
+	ErrReferenceRequired                         = newError(CodeReferenceRequired, "a reference is required for fragmented messages")
+	ErrTooManySegments                           = newError(CodeTooManySegments, "text requires too many segments")
+	ErrTextTooLong                               = newError(CodeTextTooLong, "text is too long for a single message")
+	ErrUnknownInterfaceVersion                   = charset.ErrUnknownInterfaceVersion
+	ErrStore                                     = newError(CodeStore, "store operation failed")
+	ErrNonCanonicalHex                           = newError(CodeNonCanonicalHex, "hex string is not in canonical form")
+	ErrLengthLimitExceeded                       = newError(CodeLengthLimitExceeded, "message exceeds the length limit")
+	ErrUnsupportedNationalLanguage               = charset.ErrUnsupportedNationalLanguage
+	ErrInvalidUCS2Options                        = newError(CodeInvalidUCS2Options, "invalid UCS2 byte order or BOM policy")
+	ErrInvalidContent                            = newError(CodeInvalidContent, "invalid message content")
+	ErrInvalidCommandPacket                      = newError(CodeInvalidCommandPacket, "invalid 03.48 command packet")
+	ErrRejected                                  = newError(CodeRejected, "rejected by middleware")
+	ErrEncryption                                = newError(CodeEncryption, "stored content encryption failed")
+	ErrRateLimited                               = newError(CodeRateLimited, "fragment rate limit exceeded")
+	ErrQuotaExceeded                             = newError(CodeQuotaExceeded, "namespace quota exceeded")
+	ErrConflictingFragment                       = newError(CodeConflictingFragment, "fragment conflicts with the payload held for its part")
+	ErrClosed                                    = newError(CodeClosed, "messages container is closed")
+	ErrInvalidQuirkProfile                       = newError(CodeInvalidQuirkProfile, "invalid quirk profile")
+	ErrUnknownQuirkProfile                       = newError(CodeUnknownQuirkProfile, "unknown quirk profile")
+	ErrInvalidSCTS                               = newError(CodeInvalidSCTS, "invalid service centre time stamp")
+	ErrInvalidAddress                            = newError(CodeInvalidAddress, "invalid address")
+	ErrInvalidSegmentSize                        = newError(CodeInvalidSegmentSize, "invalid maximum segment size")
+	ErrInvalidPDU                                = newError(CodeInvalidPDU, "invalid SMPP PDU")
+	ErrUnsupportedPDU                            = newError(CodeUnsupportedPDU, "SMPP PDU does not carry a short message")
+	ErrUDHExpected                               = newError(CodeUDHExpected, "message does not start with a well formed UDH")
+	ErrUnsupportedShardOption                    = newError(CodeUnsupportedShardOption, "option is not supported by ShardedMessages")
 )
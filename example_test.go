@@ -59,3 +59,13 @@ func ExampleMessageFragmentations_Add() {
 	fmt.Printf("%s\n", fragmentation.String())
 
 }
+
+func ExampleNewMessageFromText() {
+	msg, err := smudh.NewMessageFromText("world", smudh.GSM)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("%s\n", msg)
+	// Output: 776F726C64
+}
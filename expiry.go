@@ -0,0 +1,156 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"time"
+)
+
+// WithTTL sets how long a message may wait for its fragments, counting from the arrival of its first fragment.
+// Expired messages are removed by EvictExpired or RunJanitor. A zero TTL, the default, never expires messages.
+func WithTTL(ttl time.Duration) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.ttl = ttl
+	}
+}
+
+// ExpiryPolicy controls what EvictExpired does with the messages that expired before all of their fragments
+// arrived.
+type ExpiryPolicy byte
+
+const (
+	// ExpiryDrop removes partial messages silently. This is the default.
+	ExpiryDrop ExpiryPolicy = iota
+
+	// ExpiryDeliverPartial hands the text received so far over to the ExpiredHandler, as an AssembledMessage
+	// flagged as Incomplete
+	ExpiryDeliverPartial
+
+	// ExpiryReport hands only the IncompleteMessage report, holding the MissingParts, over to the ExpiredHandler
+	ExpiryReport
+)
+
+// ExpiredHandler receives a message that expired before all of its fragments arrived. partial is set only when
+// using ExpiryDeliverPartial.
+type ExpiredHandler func(report IncompleteMessage, partial *AssembledMessage)
+
+// WithExpiryPolicy sets what EvictExpired does with partial messages, and the handler receiving them. The handler
+// is called after the container was unlocked, so it may use the container. Messages that expired while complete
+// are always dropped.
+func WithExpiryPolicy(policy ExpiryPolicy, handler ExpiredHandler) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.expiryPolicy = policy
+		msgs.expiredHandler = handler
+	}
+}
+
+// EvictExpired removes the messages that waited longer than the TTL set by WithTTL, and returns how many messages
+// were removed. Partial messages are handled according to WithExpiryPolicy.
+func (msgs *Messages) EvictExpired() int {
+	expired, now := msgs.evictExpired()
+
+	if msgs.expiryPolicy == ExpiryDrop || msgs.expiredHandler == nil {
+		return len(expired)
+	}
+
+	for _, set := range expired {
+		if set.fragments.HaveAllFragments() {
+			continue
+		}
+
+		var partial *AssembledMessage
+
+		if msgs.expiryPolicy == ExpiryDeliverPartial {
+			assembled := newPartialAssembledMessage(*set.fragments)
+			partial = &assembled
+		}
+
+		msgs.expiredHandler(set.report(now), partial)
+	}
+
+	return len(expired)
+}
+
+// evictExpired removes the expired messages, and returns them together with the time used for deciding.
+func (msgs *Messages) evictExpired() ([]*fragmentSet, time.Time) {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	now := msgs.now()
+
+	if msgs.ttl <= 0 {
+		return nil, now
+	}
+
+	var expired []*fragmentSet
+
+	for key, set := range msgs.fragments {
+		if now.Sub(set.firstSeen) < msgs.ttl {
+			continue
+		}
+
+		msgs.untrackSet(key)
+		msgs.deleteSet(key)
+		expired = append(expired, set)
+
+		reference := bytes.Clone(set.fragments.Reference())
+
+		msgs.debug("message evicted",
+			slog.String("reference", hex.EncodeToString(reference)),
+			slog.Int("received", len(*set.fragments)),
+			slog.Duration("age", now.Sub(set.firstSeen)),
+		)
+
+		msgs.publish(Event{
+			Type: SetEvicted, Namespace: keyNamespace(key), Reference: reference, Fragments: set.fragments.Clone(),
+			Time: now,
+		})
+	}
+
+	if len(expired) > 0 {
+		for _, hooks := range msgs.metrics {
+			hooks.Evicted(len(expired))
+		}
+	}
+
+	return expired, now
+}
+
+// RunJanitor calls EvictExpired every interval until ctx is done or the container is closed.
+// It blocks, and is meant to run in its own goroutine. Its sweeps are reported by Health. It returns at once when
+// called after Close.
+func (msgs *Messages) RunJanitor(ctx context.Context, interval time.Duration) {
+	// the janitor is registered while holding the lock, so Close either waits for it, or it never starts
+	msgs.mtx.Lock()
+
+	if msgs.closed {
+		msgs.mtx.Unlock()
+		return
+	}
+
+	msgs.janitor.start(msgs.now(), interval)
+	msgs.mtx.Unlock()
+
+	defer msgs.janitor.stop()
+
+	ticker := msgs.newTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-msgs.closing:
+			return
+		case <-ticker.Chan():
+			msgs.EvictExpired()
+			msgs.janitor.swept(msgs.now())
+		}
+	}
+}
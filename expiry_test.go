@@ -0,0 +1,141 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+func TestExpiryPolicy(t *testing.T) {
+	type expired struct {
+		Reference    []byte
+		MissingParts []byte
+		Partial      *udh.AssembledMessage
+	}
+
+	tests := []struct {
+		name     string
+		policy   udh.ExpiryPolicy
+		expected []expired
+	}{
+		{name: "drop"},
+		{
+			name:   "deliver partial",
+			policy: udh.ExpiryDeliverPartial,
+			expected: []expired{{
+				Reference:    []byte{0xB7},
+				MissingParts: []byte{1, 3, 5},
+				Partial: &udh.AssembledMessage{
+					Reference:    "b7",
+					Encoding:     udh.ASCII,
+					Parts:        2,
+					Text:         "first part",
+					Incomplete:   true,
+					MissingParts: []byte{1, 3, 5},
+				},
+			}},
+		},
+		{
+			name:   "report",
+			policy: udh.ExpiryReport,
+			expected: []expired{{
+				Reference:    []byte{0xB7},
+				MissingParts: []byte{1, 3, 5},
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			var have []expired
+
+			clock := smudhtest.NewFakeClock(time.Now())
+			messages := udh.InitMessages(
+				udh.WithClock(clock),
+				udh.WithTTL(time.Minute),
+				udh.WithExpiryPolicy(test.policy, func(report udh.IncompleteMessage, partial *udh.AssembledMessage) {
+					have = append(have, expired{
+						Reference: report.Reference, MissingParts: report.MissingParts, Partial: partial,
+					})
+				}),
+			)
+
+			for _, msg := range []udh.Message{
+				udh.Message("050003B70502" + "6669727374"),       // part 2 of 5
+				udh.Message("050003B70504" + "2070617274"),       // part 4 of 5
+				udh.Message("050003A50101" + "636F6D706C657465"), // part 1 of 1, complete but not drained
+			} {
+				err := messages.Add(udh.ASCII, msg)
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			clock.Advance(time.Minute - time.Nanosecond)
+
+			if evicted := messages.EvictExpired(); evicted != 0 {
+				t2.Errorf("have %d evicted messages before the TTL, expected none", evicted)
+			}
+
+			clock.Advance(time.Nanosecond)
+
+			if evicted := messages.EvictExpired(); evicted != 2 {
+				t2.Errorf("have %d evicted messages, expected 2", evicted)
+			}
+
+			if !cmp.Equal(have, test.expected) {
+				t2.Errorf("unexpected expired messages: %s", cmp.Diff(test.expected, have))
+			}
+		})
+	}
+}
+
+func TestRunJanitorWithClock(t *testing.T) {
+	clock := smudhtest.NewFakeClock(time.Now())
+	evicted := make(chan []byte, 1)
+
+	messages := udh.InitMessages(
+		udh.WithClock(clock),
+		udh.WithTTL(time.Minute),
+		udh.WithExpiryPolicy(udh.ExpiryReport, func(report udh.IncompleteMessage, _ *udh.AssembledMessage) {
+			evicted <- report.Reference
+		}),
+	)
+
+	err := messages.Add(udh.ASCII, udh.Message("050003B70502"+"6669727374"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		messages.RunJanitor(ctx, 30*time.Second)
+	}()
+
+	// the janitor creates its ticker once it runs, so keep advancing until the message is evicted
+	for reference := []byte(nil); reference == nil; {
+		clock.Advance(30 * time.Second)
+
+		select {
+		case reference = <-evicted:
+			if !cmp.Equal(reference, []byte{0xB7}) {
+				t.Errorf("have evicted reference % X, expected B7", reference)
+			}
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,88 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// expvarMetrics is a MetricsHooks that publishes the counters using the expvar package.
+type expvarMetrics struct {
+	prefix      string
+	fragments   *expvar.Int
+	completed   *expvar.Int
+	parseErrors *expvar.Int
+	evictions   *expvar.Int
+	encodings   *expvar.Map
+	ieis        *expvar.Map
+}
+
+// WithExpvar publishes the counters of the container as an expvar map named prefix, served by the /debug/vars
+// handler of the expvar package:
+//
+//	fragments_received, messages_completed, parse_errors, evictions, incomplete_messages
+//
+// and the maps of parsed messages counted by their encoding name, and by the IEIs of their UDH, such as "0x00":
+//
+//	messages_parsed, information_elements
+//
+// When a map with the same name was already published, for example by another container, it is replaced. The
+// counters of a ShardedMessages are published once, covering every shard.
+func WithExpvar(prefix string) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.metrics = append(msgs.metrics, &expvarMetrics{
+			prefix:      prefix,
+			fragments:   new(expvar.Int),
+			completed:   new(expvar.Int),
+			parseErrors: new(expvar.Int),
+			evictions:   new(expvar.Int),
+			encodings:   new(expvar.Map),
+			ieis:        new(expvar.Map),
+		})
+	}
+}
+
+// Observe publishes the counters, with the incomplete messages of container.
+func (metrics *expvarMetrics) Observe(container IncompleteLister) {
+	vars, ok := expvar.Get(metrics.prefix).(*expvar.Map)
+	if !ok {
+		vars = expvar.NewMap(metrics.prefix)
+	}
+
+	vars.Set("fragments_received", metrics.fragments)
+	vars.Set("messages_completed", metrics.completed)
+	vars.Set("parse_errors", metrics.parseErrors)
+	vars.Set("evictions", metrics.evictions)
+	vars.Set("messages_parsed", metrics.encodings)
+	vars.Set("information_elements", metrics.ieis)
+	vars.Set("incomplete_messages", expvar.Func(func() any {
+		return len(container.Incomplete())
+	}))
+}
+
+func (metrics *expvarMetrics) FragmentReceived(Encoding) {
+	metrics.fragments.Add(1)
+}
+
+func (metrics *expvarMetrics) MessageCompleted(Encoding) {
+	metrics.completed.Add(1)
+}
+
+func (metrics *expvarMetrics) ParseError(error) {
+	metrics.parseErrors.Add(1)
+}
+
+func (metrics *expvarMetrics) Evicted(count int) {
+	metrics.evictions.Add(int64(count))
+}
+
+func (metrics *expvarMetrics) MessageParsed(encoding Encoding, ieis []byte) {
+	metrics.encodings.Add(encoding.String(), 1)
+
+	for _, iei := range ieis {
+		metrics.ieis.Add(fmt.Sprintf("0x%02X", iei), 1)
+	}
+}
@@ -0,0 +1,84 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestWithExpvar(t *testing.T) {
+	messages := udh.InitMessages(udh.WithExpvar("smudh_test"))
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50201546869732069732061206C"), // part 1 of 2
+		udh.Message("050003A5020265722074657374696E67"),   // part 2 of 2
+		udh.Message("050003B70502002005E905DC"),           // part 2 of 5
+		udh.Message("050"),                                // odd length
+	} {
+		_ = messages.Add(udh.ASCII, msg)
+	}
+
+	var have map[string]any
+
+	err := json.Unmarshal([]byte(expvar.Get("smudh_test").String()), &have)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{
+		"fragments_received":   3.0,
+		"messages_completed":   1.0,
+		"parse_errors":         1.0,
+		"evictions":            0.0,
+		"incomplete_messages":  1.0,
+		"messages_parsed":      map[string]any{"ASCII": 3.0},
+		"information_elements": map[string]any{"0x00": 3.0},
+	}
+
+	if diff := cmp.Diff(expected, have); diff != "" {
+		t.Errorf("unexpected counters (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithExpvarSharded(t *testing.T) {
+	messages, err := udh.NewShardedMessages(8, udh.WithExpvar("smudh_sharded_test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for reference := range 50 {
+		err = messages.Add(udh.ASCII, udh.Message(fmt.Sprintf("050003%02X020161", reference)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var have map[string]any
+
+	err = json.Unmarshal([]byte(expvar.Get("smudh_sharded_test").String()), &have)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{
+		"fragments_received":   50.0,
+		"messages_completed":   0.0,
+		"parse_errors":         0.0,
+		"evictions":            0.0,
+		"incomplete_messages":  50.0,
+		"messages_parsed":      map[string]any{"ASCII": 50.0},
+		"information_elements": map[string]any{"0x00": 50.0},
+	}
+
+	if diff := cmp.Diff(expected, have); diff != "" {
+		t.Errorf("unexpected counters (-want +got):\n%s", diff)
+	}
+}
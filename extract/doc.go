@@ -0,0 +1,11 @@
+/*
+Package extract finds the URLs, short links and phone numbers in the text of short messages, such as the
+AssembledMessage.Text of a reassembled message, for screening inbound traffic for phishing.
+
+	for _, match := range extract.Extract(assembled.Text) {
+		fmt.Println(match.Kind, match.Value, match.Start, match.End)
+	}
+
+Matches are typed by Kind and located by byte offsets into the text, the same as the regexp package.
+*/
+package extract
@@ -0,0 +1,202 @@
+package extract
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Kind is the type of a Match.
+type Kind byte
+
+const (
+	// URL is a web address, with or without a scheme, such as "https://example.com/login" or "example.com/login"
+	URL Kind = iota
+
+	// ShortLink is a URL of a known link shortening service, such as "bit.ly/abc", which hides its destination
+	ShortLink
+
+	// Phone is a phone number of 7 to 15 digits, such as "+1 (555) 123-4567"
+	Phone
+)
+
+// minPhoneDigits and maxPhoneDigits bound the number of digits of a phone number, the maximum being the one of
+// E.164.
+const (
+	minPhoneDigits = 7
+	maxPhoneDigits = 15
+)
+
+// Match is a single item found by Extract.
+type Match struct {
+	// Type of the match
+	Kind Kind `json:"kind"`
+
+	// Text of the match, as found in the text
+	Value string `json:"value"`
+
+	// Host of URL and ShortLink matches, in lower case
+	Host string `json:"host,omitempty"`
+
+	// Byte offset of the start of the match in the text
+	Start int `json:"start"`
+
+	// Byte offset of the end of the match in the text, exclusive
+	End int `json:"end"`
+}
+
+// ShortLinkHosts are the hosts of the link shortening services recognized as ShortLink. It can be extended by the
+// caller before using Extract.
+var ShortLinkHosts = []string{
+	"bit.ly", "bitly.com", "buff.ly", "cutt.ly", "goo.gl", "is.gd", "ow.ly", "rb.gy", "rebrand.ly", "shorturl.at",
+	"t.co", "t.ly", "tiny.cc", "tinyurl.com", "v.gd",
+}
+
+var (
+	// urlPattern matches URLs with a scheme, URLs starting with www., and host names followed by a path or ending
+	// with a top level domain of letters
+	urlPattern = regexp.MustCompile(`(?i)\b(?:https?://[^\s<>"]+|www\.[^\s<>"]+|` +
+		`(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,24}\b(?:[/?#][^\s<>"]*)?)`)
+
+	// phonePattern matches digits that may be separated by spaces, dots, dashes and parentheses, with an optional
+	// leading plus sign
+	phonePattern = regexp.MustCompile(`\+?\(?\d[\d ().-]*\d`)
+)
+
+// String returns the name of the kind.
+func (kind Kind) String() string {
+	switch kind {
+	case URL:
+		return "URL"
+	case ShortLink:
+		return "ShortLink"
+	case Phone:
+		return "Phone"
+	}
+
+	return "Unknown"
+}
+
+// Extract returns the URLs, short links and phone numbers found in text, ordered by their position. Trailing
+// punctuation, such as the period ending a sentence, is not part of a match, and digits inside a URL are not
+// reported as a phone number.
+func Extract(text string) []Match {
+	matches := []Match{}
+
+	for _, location := range urlPattern.FindAllStringIndex(text, -1) {
+		start, end := location[0], trimPunctuation(text, location[0], location[1])
+
+		host := hostOf(text[start:end])
+		if host == "" {
+			continue
+		}
+
+		kind := URL
+		if slices.Contains(ShortLinkHosts, strings.TrimPrefix(host, "www.")) {
+			kind = ShortLink
+		}
+
+		matches = append(matches, Match{Kind: kind, Value: text[start:end], Host: host, Start: start, End: end})
+	}
+
+	urls := len(matches)
+
+	for _, location := range phonePattern.FindAllStringIndex(text, -1) {
+		start, end := location[0], location[1]
+
+		if overlaps(matches[:urls], start, end) || !isPhoneNumber(text[start:end]) || !isBoundary(text, start, end) {
+			continue
+		}
+
+		matches = append(matches, Match{Kind: Phone, Value: text[start:end], Start: start, End: end})
+	}
+
+	slices.SortFunc(matches, func(a, b Match) int {
+		return a.Start - b.Start
+	})
+
+	return matches
+}
+
+// trimPunctuation returns the end of the match at text[start:end] without its trailing punctuation. A closing
+// parenthesis is kept when the match holds its opening one.
+func trimPunctuation(text string, start, end int) int {
+	for end > start {
+		switch text[end-1] {
+		case '.', ',', ';', ':', '!', '?', '\'', '"':
+			end--
+			continue
+		case ')':
+			if strings.Count(text[start:end], "(") < strings.Count(text[start:end], ")") {
+				end--
+				continue
+			}
+		}
+
+		break
+	}
+
+	return end
+}
+
+// hostOf returns the host of a URL match in lower case, or "" when it has no host, such as a version number.
+func hostOf(value string) string {
+	if !strings.Contains(value, "://") {
+		value = "http://" + value
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return ""
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if !strings.Contains(host, ".") {
+		return ""
+	}
+
+	return host
+}
+
+// isPhoneNumber reports whether value holds an acceptable number of digits.
+func isPhoneNumber(value string) bool {
+	digits := 0
+
+	for _, ch := range value {
+		if ch >= '0' && ch <= '9' {
+			digits++
+		}
+	}
+
+	return digits >= minPhoneDigits && digits <= maxPhoneDigits
+}
+
+// overlaps reports whether the range start:end overlaps any of the matches.
+func overlaps(matches []Match, start, end int) bool {
+	for _, match := range matches {
+		if start < match.End && end > match.Start {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isBoundary reports whether the match at text[start:end] is not a part of a word.
+func isBoundary(text string, start, end int) bool {
+	if start > 0 && isAlphanumeric(text[start-1]) {
+		return false
+	}
+
+	return end == len(text) || !isAlphanumeric(text[end])
+}
+
+// isAlphanumeric reports whether ch is an ASCII letter or digit.
+func isAlphanumeric(ch byte) bool {
+	return ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z'
+}
@@ -0,0 +1,72 @@
+package extract_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ik5/smudh/extract"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []extract.Match
+	}{
+		{
+			name: "URL with a scheme",
+			text: "Your parcel is held, pay at https://parcel-fees.example/pay?id=1.",
+			expected: []extract.Match{{
+				Kind: extract.URL, Value: "https://parcel-fees.example/pay?id=1", Host: "parcel-fees.example",
+				Start: 28, End: 64,
+			}},
+		},
+		{
+			name: "short link and phone number",
+			text: "Account locked! Visit bit.ly/3xYz or call +1 (555) 123-4567",
+			expected: []extract.Match{
+				{Kind: extract.ShortLink, Value: "bit.ly/3xYz", Host: "bit.ly", Start: 22, End: 33},
+				{Kind: extract.Phone, Value: "+1 (555) 123-4567", Start: 42, End: 59},
+			},
+		},
+		{
+			name: "www and parentheses",
+			text: "(see www.Example.com/a_(b))",
+			expected: []extract.Match{
+				{Kind: extract.URL, Value: "www.Example.com/a_(b)", Host: "www.example.com", Start: 5, End: 26},
+			},
+		},
+		{
+			name: "digits inside a URL",
+			text: "http://example.com/12345678",
+			expected: []extract.Match{
+				{Kind: extract.URL, Value: "http://example.com/12345678", Host: "example.com", Start: 0, End: 27},
+			},
+		},
+		{
+			name:     "not a phone number",
+			text:     "Your code is 123456, order A12345678 costs 12.50",
+			expected: []extract.Match{},
+		},
+		{
+			name: "unicode text",
+			text: "שלום, התקשרו 03-555-1234",
+			expected: []extract.Match{
+				{Kind: extract.Phone, Value: "03-555-1234", Start: 23, End: 34},
+			},
+		},
+		{name: "plain text", text: "See you at 5. Thanks!", expected: []extract.Match{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if diff := cmp.Diff(test.expected, extract.Extract(test.text)); diff != "" {
+				t2.Errorf("unexpected matches (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
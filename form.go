@@ -0,0 +1,67 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ParseStandalone parses the hexadecimal content of a Message that is known to have no UDH, such as when the UDHI
+// bit of its esm_class is clear, without guessing its form: the whole content is the payload, whatever its leading
+// bytes are. It is the same as ParseElements using WithoutUDHDetection.
+// Returns an error for invalid hex content, or content that cannot be decoded.
+func (msg Message) ParseStandalone(encoding Encoding, options ...ParseOption) (*MessageElements, error) {
+	return msg.ParseElements(encoding, slices.Concat(options, []ParseOption{WithoutUDHDetection()})...)
+}
+
+// ParseUDH parses the hexadecimal content of a Message that is known to start with a UDH, such as when the UDHI
+// bit of its esm_class is set, without guessing its form: the UDH is parsed even when its first IEI is not known
+// to the IEI registry.
+// Returns an error wrapping ErrUDHExpected when the content does not start with a well formed UDH, whose
+// information elements fill the UDH Length exactly, and the errors of ParseElements otherwise.
+func (msg Message) ParseUDH(encoding Encoding, options ...ParseOption) (*MessageElements, error) {
+	return msg.ParseElements(encoding, slices.Concat(options, []ParseOption{requireUDH()})...)
+}
+
+// requireUDH makes the parser treat the content as starting with a UDH, failing when it is not well formed.
+func requireUDH() ParseOption {
+	return func(config *parseConfig) {
+		config.udhRequired = true
+	}
+}
+
+// checkUDHForm returns an error wrapping ErrUDHExpected when the parser requires a UDH and binary does not start
+// with a well formed one.
+func (config parseConfig) checkUDHForm(binary []byte) error {
+	if !config.udhRequired {
+		return nil
+	}
+
+	if len(binary) < 3 {
+		return fmt.Errorf("%w: input of %d bytes is too short", ErrUDHExpected, len(binary))
+	}
+
+	headerLength := int(binary[0])
+	if headerLength < 2 || headerLength+1 > len(binary) {
+		return fmt.Errorf("%w: header length %d does not fit an input of %d bytes", ErrUDHExpected, headerLength,
+			len(binary))
+	}
+
+	offset := 1
+	for offset < headerLength+1 {
+		if offset+1 >= headerLength+1 {
+			return fmt.Errorf("%w: element 0x%02X at offset %d has no length", ErrUDHExpected, binary[offset], offset)
+		}
+
+		offset += 2 + int(binary[offset+1])
+	}
+
+	if offset != headerLength+1 {
+		return fmt.Errorf("%w: information elements exceed the header length %d", ErrUDHExpected, headerLength)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestParseStandaloneAndUDH(t *testing.T) {
+	registry := udh.NewIEIRegistry()
+	registry.Unregister(udh.IEIConcatenated8Bit)
+
+	tests := []struct {
+		name       string
+		input      string
+		udh        bool
+		options    []udh.ParseOption
+		message    string
+		standalone bool
+		err        error
+	}{
+		{
+			name: "standalone text that looks like a UDH", input: "0500030A020141", message: "\x05\x00\x03\n\x02\x01A",
+			standalone: true,
+		},
+		{name: "standalone text", input: "414243", message: "ABC", standalone: true},
+		{name: "UDH", input: "0500030A020141", udh: true, message: "A"},
+		{
+			name: "UDH with an IEI missing from the registry", input: "0500030A020141", udh: true, message: "A",
+			options: []udh.ParseOption{udh.WithIEIRegistry(registry)},
+		},
+		{name: "UDH without a payload", input: "0500030A0201", udh: true},
+		{name: "text as UDH", input: "41424344", udh: true, err: udh.ErrUDHExpected},
+		{name: "header length beyond the input", input: "0A0003", udh: true, err: udh.ErrUDHExpected},
+		{name: "elements exceed the header", input: "0300050A0201", udh: true, err: udh.ErrUDHExpected},
+		{name: "too short", input: "05", udh: true, err: udh.ErrUDHExpected},
+		{name: "UDH without concatenation", input: "06050400E2000041", udh: true, message: "A"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			parse := udh.Message(test.input).ParseStandalone
+			if test.udh {
+				parse = udh.Message(test.input).ParseUDH
+			}
+
+			elements, err := parse(udh.ASCII, test.options...)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if elements.Message != test.message || elements.Standalone != test.standalone {
+				t2.Errorf("have %q with standalone %t, expected %q with standalone %t",
+					elements.Message, elements.Standalone, test.message, test.standalone)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// DefaultFuzzyThreshold is the confidence used by WithFuzzyReassembly when no threshold was set.
+const DefaultFuzzyThreshold = 0.75
+
+// The weights of the signals making the confidence of merging a fragment into a message.
+const (
+	fuzzyAdjacentWeight = 0.35
+	fuzzyTimingWeight   = 0.25
+	fuzzySourceWeight   = 0.25
+	fuzzyContentWeight  = 0.15
+)
+
+// FuzzyReassembly configures the merging of fragments whose reference number was rewritten by a store-and-forward
+// SMSC in the middle of a message, set using WithFuzzyReassembly.
+type FuzzyReassembly struct {
+	// Maximum time between the last fragment of a message and a fragment merged into it
+	Window time.Duration
+
+	// Minimum confidence for merging a fragment, between 0 and 1, DefaultFuzzyThreshold when zero
+	Threshold float64
+}
+
+// WithFuzzyReassembly merges a fragment that would start a new message into an incomplete message of another
+// reference, when the fragment is most likely its missing part.
+//
+// A fragment is considered only for incomplete messages of its namespace that have the same number of parts and
+// encoding, miss its part number, and received their last fragment within the window. The confidence is made of
+// the fragment being a consecutive part of the message, its arrival time, having the same Source (see
+// ContextWithSource), and a payload length that fits the other parts. Fragments from different sources are never
+// merged, and without sources the confidence is at most 0.75.
+//
+// The fragment is merged into the message of highest confidence, when it reaches the threshold and no other
+// message has the same confidence. A merged fragment is a copy of the fragment, with the reference number of the
+// message, and a warning naming its original reference.
+//
+// Not supported by ShardedMessages.
+func WithFuzzyReassembly(config FuzzyReassembly) MessagesOption {
+	return func(msgs *Messages) {
+		if config.Threshold == 0 {
+			config.Threshold = DefaultFuzzyThreshold
+		}
+
+		msgs.fuzzy = &config
+	}
+}
+
+// mergeOrphan finds the message that info most likely belongs to, and returns its key, together with the copy of
+// info to add. Returns a nil set when there is no such message. The caller must hold the lock.
+func (msgs *Messages) mergeOrphan(
+	ctx context.Context, namespace string, info *MessageElements, now time.Time,
+) (string, *fragmentSet, *MessageElements) {
+	source := sourceID(ctx)
+
+	var (
+		bestKey   string
+		best      *fragmentSet
+		bestScore float64
+		tie       bool
+	)
+
+	for key, set := range msgs.fragments {
+		if keyNamespace(key) != namespace {
+			continue
+		}
+
+		score := msgs.fuzzy.confidence(set, source, info, now)
+
+		switch {
+		case score < msgs.fuzzy.Threshold || score < bestScore:
+			continue
+		case score == bestScore:
+			tie = true
+			continue
+		}
+
+		bestKey, best, bestScore, tie = key, set, score, false
+	}
+
+	if best == nil || tie {
+		return "", nil, nil
+	}
+
+	merged := info.Clone()
+	merged.Reference = bytes.Clone(best.fragments.Reference())
+	merged.Warnings = append(merged.Warnings, fmt.Sprintf(
+		"reference %s merged into %s by fuzzy reassembly, confidence %.2f",
+		hex.EncodeToString(info.Reference), hex.EncodeToString(merged.Reference), bestScore,
+	))
+
+	msgs.debug("fragment merged by fuzzy reassembly",
+		slog.String("reference", hex.EncodeToString(info.Reference)),
+		slog.String("merged_into", hex.EncodeToString(merged.Reference)),
+		slog.Int("current_part", int(info.CurrentPart)),
+		slog.Float64("confidence", bestScore),
+	)
+
+	return bestKey, best, merged
+}
+
+// confidence returns how likely info is a missing part of set, between 0 and 1, where 0 means it cannot be.
+func (config FuzzyReassembly) confidence(
+	set *fragmentSet, source string, info *MessageElements, now time.Time,
+) float64 {
+	fragments := *set.fragments
+	if len(fragments) == 0 || fragments.HaveAllFragments() {
+		return 0
+	}
+
+	first := fragments[0]
+
+	switch {
+	case first.Standalone || info.Standalone:
+		return 0
+	case first.TotalParts != info.TotalParts || first.Encoding != info.Encoding:
+		return 0
+	case fragments.Get(info.CurrentPart) != nil:
+		return 0
+	case set.source != "" && source != "" && set.source != source:
+		return 0
+	}
+
+	gap := now.Sub(set.lastSeen)
+	if gap < 0 || gap > config.Window {
+		return 0
+	}
+
+	score := fuzzyTimingWeight
+	if config.Window > 0 {
+		score *= 1 - float64(gap)/float64(config.Window)
+	}
+
+	if fragments.Get(info.CurrentPart-1) != nil || fragments.Get(info.CurrentPart+1) != nil {
+		score += fuzzyAdjacentWeight
+	}
+
+	if set.source != "" && set.source == source {
+		score += fuzzySourceWeight
+	}
+
+	return score + fuzzyContentWeight*contentFit(fragments, info)
+}
+
+// contentFit returns how well the payload length of info fits the other parts: 1 when it matches the length of the
+// parts that are not the last, 0 when it does not, and 0.5 when there is nothing to compare with.
+func contentFit(fragments MessageFragmentations, info *MessageElements) float64 {
+	segment := -1
+
+	for _, existing := range fragments {
+		if existing.CurrentPart != existing.TotalParts {
+			segment = len(existing.RawMessage)
+			break
+		}
+	}
+
+	switch {
+	case segment < 0:
+		return 0.5
+	case info.CurrentPart == info.TotalParts && len(info.RawMessage) <= segment:
+		return 1
+	case len(info.RawMessage) == segment:
+		return 1
+	}
+
+	return 0
+}
+
+// sourceID returns the identifier of the Source attached to ctx, or an empty string.
+func sourceID(ctx context.Context) string {
+	source, _ := SourceFromContext(ctx)
+	return source.ID
+}
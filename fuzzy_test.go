@@ -0,0 +1,116 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+func TestFuzzyReassembly(t *testing.T) {
+	type fragment struct {
+		source string
+		wait   time.Duration
+		msg    udh.Message
+	}
+
+	tests := []struct {
+		name      string
+		fragments []fragment
+		complete  []string
+		buckets   int
+	}{
+		{
+			name: "rewritten reference",
+			fragments: []fragment{
+				{source: "bind-1", msg: udh.Message("050003A50301" + "68656C6C6F")},
+				{source: "bind-1", wait: time.Second, msg: udh.Message("050003B70302" + "20776F726C")},
+				{source: "bind-1", wait: time.Second, msg: udh.Message("050003C10303" + "64")},
+			},
+			complete: []string{"hello world"},
+			buckets:  1,
+		},
+		{
+			name: "other source",
+			fragments: []fragment{
+				{source: "bind-1", msg: udh.Message("050003A50301" + "68656C6C6F")},
+				{source: "bind-2", msg: udh.Message("050003B70302" + "20776F726C")},
+			},
+			buckets: 2,
+		},
+		{
+			name: "outside of the window",
+			fragments: []fragment{
+				{source: "bind-1", msg: udh.Message("050003A50301" + "68656C6C6F")},
+				{source: "bind-1", wait: time.Minute, msg: udh.Message("050003B70302" + "20776F726C")},
+			},
+			buckets: 2,
+		},
+		{
+			name: "no source, late arrival",
+			fragments: []fragment{
+				{msg: udh.Message("050003A50301" + "68656C6C6F")},
+				{wait: 5 * time.Second, msg: udh.Message("050003B70302" + "20776F726C")},
+			},
+			buckets: 2,
+		},
+		{
+			name: "ambiguous",
+			fragments: []fragment{
+				{source: "bind-1", msg: udh.Message("050003A50301" + "68656C6C6F")},
+				{source: "bind-1", msg: udh.Message("050003B70301" + "776F726C64")},
+				{source: "bind-1", msg: udh.Message("050003C10302" + "20776F726C")},
+			},
+			buckets: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			clock := smudhtest.NewFakeClock(time.Now())
+			messages := udh.InitMessages(
+				udh.WithClock(clock),
+				udh.WithFuzzyReassembly(udh.FuzzyReassembly{Window: 10 * time.Second}),
+			)
+
+			for _, fragment := range test.fragments {
+				clock.Advance(fragment.wait)
+
+				ctx := context.Background()
+				if fragment.source != "" {
+					ctx = udh.ContextWithSource(ctx, udh.Source{ID: fragment.source})
+				}
+
+				err := messages.AddContext(ctx, udh.ASCII, fragment.msg)
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			if buckets := len(messages.ListAll()); buckets != test.buckets {
+				t2.Errorf("have %d buckets, expected %d", buckets, test.buckets)
+			}
+
+			complete := []string{}
+			for _, fragments := range messages.DrainComplete() {
+				complete = append(complete, fragments.Assembled())
+
+				for _, info := range (*fragments)[1:] {
+					if len(info.Warnings) == 0 {
+						t2.Errorf("merged part %d has no warning", info.CurrentPart)
+					}
+				}
+			}
+
+			if len(complete) != len(test.complete) || (len(complete) > 0 && complete[0] != test.complete[0]) {
+				t2.Errorf("have complete messages %q, expected %q", complete, test.complete)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "github.com/ik5/smudh/charset"
+
+// IsGSMCompatible reports whether text can be encoded using the GSM 03.38 default alphabet and its extension
+// table, and returns the characters that cannot, each listed once in order of first appearance.
+func IsGSMCompatible(text string) (bool, []rune) {
+	return charset.IsGSMCompatible(text)
+}
+
+// GSM7FillBits returns the number of fill bits that follow a UDH of headerLength octets, including the UDHL octet,
+// so packed GSM 7-bit text starts on a septet boundary. See charset.GSM7FillBits.
+func GSM7FillBits(headerLength int) int {
+	return charset.GSM7FillBits(headerLength)
+}
+
+// PackGSM7 packs unpacked septets that follow a UDH of headerLength octets, 0 for none, starting with the fill
+// bits. See charset.PackGSM7.
+func PackGSM7(septets []byte, headerLength int) []byte {
+	return charset.PackGSM7(septets, headerLength)
+}
+
+// UnpackGSM7 unpacks the septets of the user data that follows a UDH of headerLength octets, 0 for none, skipping
+// the fill bits. See charset.UnpackGSM7.
+func UnpackGSM7(packed []byte, headerLength int) []byte {
+	return charset.UnpackGSM7(packed, headerLength)
+}
+
+// WithPackedGSM7 reads the payloads of the GSM encodings as septet packed, the form used over the air and by some
+// SMSCs, instead of one septet per octet. The payload is unpacked after skipping the fill bits that follow the
+// UDH, and RawMessage holds the unpacked septets.
+func WithPackedGSM7() ParseOption {
+	return func(config *parseConfig) {
+		config.packedGSM7 = true
+	}
+}
+
+// unpackGSM7 unpacks the payload when using WithPackedGSM7.
+func (elem *MessageElements) unpackGSM7(config parseConfig) {
+	if !config.packedGSM7 || (elem.Encoding != GSM && elem.Encoding != GSMExtended) {
+		return
+	}
+
+	headerLength := 0
+	if !elem.Standalone {
+		headerLength = int(elem.HeaderLength) + 1
+	}
+
+	elem.RawMessage = UnpackGSM7(elem.RawMessage, headerLength)
+	elem.Trace.add("payload", "%d septets unpacked after %d fill bits", len(elem.RawMessage),
+		GSM7FillBits(headerLength))
+}
+
+// PackUSSD packs the unpacked septets of a USSD string, padding it using carriage returns. See charset.PackUSSD.
+func PackUSSD(septets []byte) []byte {
+	return charset.PackUSSD(septets)
+}
+
+// UnpackUSSD unpacks the septets of a packed USSD string, dropping its padding. See charset.UnpackUSSD.
+func UnpackUSSD(packed []byte) []byte {
+	return charset.UnpackUSSD(packed)
+}
+
+// DecodeUSSD decodes a packed USSD string, such as the ussd_string of a USSD gateway, into UTF-8.
+// See charset.DecodeUSSD.
+func DecodeUSSD(packed []byte) string {
+	return charset.DecodeUSSD(packed)
+}
+
+// EncodeUSSD encodes text as a packed USSD string. See charset.EncodeUSSD.
+func EncodeUSSD(text string) ([]byte, error) {
+	return charset.EncodeUSSD(text)
+}
@@ -0,0 +1,80 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestIsGSMCompatible(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		compatible bool
+		offending  []rune
+	}{
+		{name: "empty", text: "", compatible: true},
+		{name: "basic", text: "Hello @ £5, Ñoño!", compatible: true},
+		{name: "extension", text: "{[€]}~^|\\", compatible: true},
+		{name: "hebrew", text: "שלום", offending: []rune{'ש', 'ל', 'ו', 'ם'}},
+		{name: "repeated", text: "it’s “it’s”", offending: []rune{'’', '“', '”'}},
+	}
+
+	for _, test := range tests {
+		compatible, offending := udh.IsGSMCompatible(test.text)
+		if compatible != test.compatible {
+			t.Errorf("%s: have compatible %t, expected %t", test.name, compatible, test.compatible)
+		}
+
+		if diff := cmp.Diff(test.offending, offending); diff != "" {
+			t.Errorf("%s: unexpected offending characters (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestWithPackedGSM7(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		reference []byte
+	}{
+		{name: "standalone", text: "hello world"},
+		{name: "8-bit reference", text: strings.Repeat("hello world ", 20), reference: []byte{0x42}},
+		{name: "16-bit reference", text: strings.Repeat("hello world ", 20), reference: []byte{0x42, 0x43}},
+		{name: "default alphabet", text: strings.Repeat("£5 @ home ", 20), reference: []byte{0x42}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			segments, err := udh.SegmentText(test.text, udh.GSM, udh.SegmentOptions{Reference: test.reference})
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			messages := udh.InitMessages(udh.WithParseOptions(udh.WithPackedGSM7()))
+
+			for _, segment := range segments {
+				err = messages.Add(udh.GSM, udh.Message(hex.EncodeToString(segment.PackedBytes())))
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			complete := messages.DrainComplete()
+			if len(complete) != 1 {
+				t2.Fatalf("have %d complete messages, expected 1", len(complete))
+			}
+
+			if have := complete[0].String(); have != test.text {
+				t2.Errorf("have %q, expected %q", have, test.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package header
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/ik5/smudh/charset"
+)
+
+// Builder composes a User Data Header out of information elements.
+//
+// The builder methods can be chained, and the first error encountered is kept and returned when the header is
+// rendered using Bytes or Hex.
+type Builder struct {
+	elements []InformationElement
+	err      error
+}
+
+// NewBuilder returns a new empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// AddConcatenation adds a concatenation IE.
+// A single byte reference produces an 8-bit reference IE (0x00), while a two bytes reference produces a 16-bit
+// reference IE (0x08). Any other reference length is an error.
+func (builder *Builder) AddConcatenation(reference []byte, totalParts, currentPart byte) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	var identifier byte
+
+	switch len(reference) {
+	case 1:
+		identifier = IEIConcatenated8Bit
+	case 2:
+		identifier = IEIConcatenated16Bit
+	default:
+		builder.err = ErrInvalidReferenceLength
+		return builder
+	}
+
+	if totalParts == 0 || currentPart == 0 || currentPart > totalParts {
+		builder.err = ErrInvalidPartNumber
+		return builder
+	}
+
+	if builder.has(IEIConcatenated8Bit) || builder.has(IEIConcatenated16Bit) {
+		builder.err = ErrDuplicateIE
+		return builder
+	}
+
+	data := make([]byte, 0, len(reference)+2)
+	data = append(data, reference...)
+	data = append(data, totalParts, currentPart)
+
+	return builder.add(InformationElement{Identifier: identifier, Data: data})
+}
+
+// AddPorts adds a 16-bit application port addressing IE (0x05) with the given destination and source ports.
+func (builder *Builder) AddPorts(destination, source uint16) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if builder.has(IEIApplicationPort8Bit) || builder.has(IEIApplicationPort16Bit) {
+		builder.err = ErrDuplicateIE
+		return builder
+	}
+
+	data := []byte{
+		byte(destination >> 8), byte(destination),
+		byte(source >> 8), byte(source),
+	}
+
+	return builder.add(InformationElement{Identifier: IEIApplicationPort16Bit, Data: data})
+}
+
+// AddNationalShift adds the national language single shift (0x24) and locking shift (0x25) IEs of the given
+// languages. DefaultLanguage adds no IE.
+func (builder *Builder) AddNationalShift(lockingShift, singleShift charset.NationalLanguage) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if builder.has(IEINationalSingleShift) || builder.has(IEINationalLockingShift) {
+		builder.err = ErrDuplicateIE
+		return builder
+	}
+
+	if singleShift != charset.DefaultLanguage {
+		builder.add(InformationElement{Identifier: IEINationalSingleShift, Data: []byte{byte(singleShift)}})
+	}
+
+	if lockingShift != charset.DefaultLanguage {
+		builder.add(InformationElement{Identifier: IEINationalLockingShift, Data: []byte{byte(lockingShift)}})
+	}
+
+	return builder
+}
+
+// AddCustomIE adds an arbitrary IE with the given identifier and data.
+// The data is copied, and must not be longer than 255 octets.
+func (builder *Builder) AddCustomIE(identifier byte, data []byte) *Builder {
+	if builder.err != nil {
+		return builder
+	}
+
+	if len(data) > 0xFF {
+		builder.err = ErrIEDataTooLong
+		return builder
+	}
+
+	return builder.add(InformationElement{Identifier: identifier, Data: append([]byte{}, data...)})
+}
+
+// Elements returns a copy of the information elements added so far, in insertion order.
+func (builder *Builder) Elements() []InformationElement {
+	return append([]InformationElement{}, builder.elements...)
+}
+
+// Len returns the full length of the header in octets, including the UDH Length octet.
+// An empty builder has a length of 0.
+func (builder *Builder) Len() int {
+	if len(builder.elements) == 0 {
+		return 0
+	}
+
+	return builder.elementsLen() + 1
+}
+
+// Bytes renders the header, starting with the UDH Length octet.
+// Returns the first error encountered while building, or an error if the header is too long.
+// An empty builder renders to an empty slice.
+func (builder *Builder) Bytes() ([]byte, error) {
+	if builder.err != nil {
+		return nil, builder.err
+	}
+
+	elementsLen := builder.elementsLen()
+	if elementsLen > MaxUDHLength {
+		return nil, ErrUDHTooLong
+	}
+
+	if elementsLen == 0 {
+		return []byte{}, nil
+	}
+
+	result := make([]byte, 0, elementsLen+1)
+	result = append(result, byte(elementsLen))
+
+	for _, element := range builder.elements {
+		result = append(result, element.Bytes()...)
+	}
+
+	return result, nil
+}
+
+// Hex renders the header as upper case hex.
+func (builder *Builder) Hex() (string, error) {
+	header, err := builder.Bytes()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.ToUpper(hex.EncodeToString(header)), nil
+}
+
+func (builder *Builder) add(element InformationElement) *Builder {
+	if builder.elementsLen()+element.Len() > MaxUDHLength {
+		builder.err = ErrUDHTooLong
+		return builder
+	}
+
+	builder.elements = append(builder.elements, element)
+	return builder
+}
+
+func (builder *Builder) has(identifier byte) bool {
+	for _, element := range builder.elements {
+		if element.Identifier == identifier {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (builder *Builder) elementsLen() int {
+	length := 0
+	for _, element := range builder.elements {
+		length += element.Len()
+	}
+
+	return length
+}
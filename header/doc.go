@@ -0,0 +1,11 @@
+/*
+Package header parses and builds the User Data Header (UDH) of short messages: its information elements, the
+registry of known Information Element Identifiers (IEI) used for detecting a UDH, and a Builder for composing one.
+
+	udh, err := header.NewBuilder().AddConcatenation([]byte{0xA5}, 2, 1).Bytes()
+	elements := header.Split(udh[1:])
+
+Package smudh re-exports the types of this package, and parses complete short messages using it, so most
+applications do not need to import it.
+*/
+package header
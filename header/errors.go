@@ -0,0 +1,15 @@
+package header
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "errors"
+
+var (
+	ErrInvalidReferenceLength = errors.New("reference must be 1 or 2 bytes long")
+	ErrInvalidPartNumber      = errors.New("invalid part number")
+	ErrDuplicateIE            = errors.New("information element already exists")
+	ErrIEDataTooLong          = errors.New("information element data is too long")
+	ErrUDHTooLong             = errors.New("UDH is too long")
+)
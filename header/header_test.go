@@ -0,0 +1,67 @@
+package header_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ik5/smudh/header"
+)
+
+func TestBuildAndSplit(t *testing.T) {
+	udh, err := header.NewBuilder().AddConcatenation([]byte{0xA5}, 2, 1).AddPorts(0x0B84, 0x23F0).Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elements := header.Split(udh[1:])
+
+	expected := []header.InformationElement{
+		{Identifier: header.IEIConcatenated8Bit, Data: []byte{0xA5, 0x02, 0x01}},
+		{Identifier: header.IEIApplicationPort16Bit, Data: []byte{0x0B, 0x84, 0x23, 0xF0}},
+	}
+
+	if diff := cmp.Diff(expected, elements); diff != "" {
+		t.Errorf("elements diff: %s", diff)
+	}
+
+	concatenation, found := header.FindConcatenation(elements)
+	if !found || concatenation.Identifier != header.IEIConcatenated8Bit {
+		t.Errorf("have concatenation %+v (found %t)", concatenation, found)
+	}
+
+	ports, found := header.FindPorts(elements)
+	if !found || ports != (header.Ports{Destination: 0x0B84, Source: 0x23F0}) {
+		t.Errorf("have ports %+v (found %t)", ports, found)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		binary   []byte
+		detected bool
+		legacy   bool
+	}{
+		{name: "concatenation", binary: []byte{0x05, 0x00, 0x03, 0xA5, 0x02, 0x01, 'h'}, detected: true, legacy: true},
+		{name: "unregistered IEI", binary: []byte{0x03, 0x1F, 0x01, 0x00, 'h'}, legacy: true},
+		{name: "elements exceed the header", binary: []byte{0x03, 0x00, 0x05, 0x00, 'h', 'i'}, legacy: true},
+		{name: "no room for a payload", binary: []byte{0x05, 0x00, 0x03, 0xA5, 0x02, 0x01}},
+		{name: "text", binary: []byte("hello")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if detected, reason := header.DefaultRegistry.Detect(test.binary); detected != test.detected {
+				t2.Errorf("have detected %t (%s), expected %t", detected, reason, test.detected)
+			}
+
+			if detected, reason := header.DetectLegacy(test.binary); detected != test.legacy {
+				t2.Errorf("have legacy detected %t (%s), expected %t", detected, reason, test.legacy)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package header
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+
+	"github.com/ik5/smudh/charset"
+)
+
+// Information Element Identifiers (IEI) that the package knows how to handle.
+const (
+	// Concatenated short messages, 8-bit reference number
+	IEIConcatenated8Bit byte = 0x00
+
+	// Special SMS message indication
+	IEISpecialSMSIndication byte = 0x01
+
+	// Application port addressing scheme, 8-bit address
+	IEIApplicationPort8Bit byte = 0x04
+
+	// Application port addressing scheme, 16-bit address
+	IEIApplicationPort16Bit byte = 0x05
+
+	// Concatenated short messages, 16-bit reference number
+	IEIConcatenated16Bit byte = 0x08
+
+	// National language single shift
+	IEINationalSingleShift byte = 0x24
+
+	// National language locking shift
+	IEINationalLockingShift byte = 0x25
+)
+
+// MaxUDHLength is the maximum number of octets the information elements of a UDH may occupy.
+// The user data of a short message is limited to 140 octets, and one of them is taken by the UDH Length field.
+const MaxUDHLength = 139
+
+// InformationElement represents a single Information Element (IE) inside a UDH.
+type InformationElement struct {
+	// IEI (Information Element Identifier)
+	Identifier byte `json:"identifier"`
+
+	// IE data, without the identifier and length octets
+	Data []byte `json:"data"`
+}
+
+// Len returns the number of octets the element occupies inside the UDH, including the identifier and length octets.
+func (ie InformationElement) Len() int {
+	return len(ie.Data) + 2
+}
+
+// Bytes returns the binary representation of the element: identifier, length and data.
+func (ie InformationElement) Bytes() []byte {
+	result := make([]byte, 0, ie.Len())
+	result = append(result, ie.Identifier, byte(len(ie.Data)))
+	result = append(result, ie.Data...)
+
+	return result
+}
+
+// Ports represents application port addressing (IEI 0x04 and 0x05).
+type Ports struct {
+	// Destination port
+	Destination uint16 `json:"destination"`
+
+	// Originator port
+	Source uint16 `json:"source"`
+}
+
+// Split splits the information elements of a header, without the UDH Length octet.
+// Splitting stops at the first element that exceeds the header.
+func Split(header []byte) []InformationElement {
+	var result []InformationElement
+
+	for offset := 0; offset+1 < len(header); {
+		end := offset + 2 + int(header[offset+1])
+		if end > len(header) {
+			break
+		}
+
+		result = append(result, InformationElement{Identifier: header[offset], Data: header[offset+2 : end]})
+		offset = end
+	}
+
+	return result
+}
+
+// FindPorts returns the application port addressing of the first well formed ports IE, preferring a 16-bit
+// addressing IE over an 8-bit one.
+func FindPorts(elements []InformationElement) (Ports, bool) {
+	if element, found := findElement(elements, IEIApplicationPort16Bit, 4); found {
+		return Ports{
+			Destination: uint16(element.Data[0])<<8 | uint16(element.Data[1]),
+			Source:      uint16(element.Data[2])<<8 | uint16(element.Data[3]),
+		}, true
+	}
+
+	if element, found := findElement(elements, IEIApplicationPort8Bit, 2); found {
+		return Ports{Destination: uint16(element.Data[0]), Source: uint16(element.Data[1])}, true
+	}
+
+	return Ports{}, false
+}
+
+// FindConcatenation returns the first well formed concatenation IE.
+// When both 8-bit and 16-bit concatenation IEs exist, the 16-bit one takes precedence.
+func FindConcatenation(elements []InformationElement) (InformationElement, bool) {
+	if element, found := findElement(elements, IEIConcatenated16Bit, 4); found {
+		return element, true
+	}
+
+	return findElement(elements, IEIConcatenated8Bit, 3)
+}
+
+// FindNationalShift returns the languages of the national language locking shift (0x25) and single shift (0x24)
+// IEs of elements. DefaultLanguage is returned for an IE that is missing.
+func FindNationalShift(elements []InformationElement) (lockingShift, singleShift charset.NationalLanguage) {
+	if element, found := findElement(elements, IEINationalLockingShift, 1); found {
+		lockingShift = charset.NationalLanguage(element.Data[0])
+	}
+
+	if element, found := findElement(elements, IEINationalSingleShift, 1); found {
+		singleShift = charset.NationalLanguage(element.Data[0])
+	}
+
+	return lockingShift, singleShift
+}
+
+// findElement returns the first IE with the given identifier and data length.
+func findElement(elements []InformationElement, identifier byte, length int) (InformationElement, bool) {
+	for _, element := range elements {
+		if element.Identifier == identifier && len(element.Data) == length {
+			return element, true
+		}
+	}
+
+	return InformationElement{}, false
+}
+
+// ConcatenationConflict returns a warning when elements hold both 8-bit and 16-bit concatenation IEs, or an empty
+// string otherwise.
+func ConcatenationConflict(elements []InformationElement) string {
+	wide, foundWide := findElement(elements, IEIConcatenated16Bit, 4)
+	narrow, foundNarrow := findElement(elements, IEIConcatenated8Bit, 3)
+
+	if !foundWide || !foundNarrow {
+		return ""
+	}
+
+	warning := "both 8-bit and 16-bit concatenation IEs found, using the 16-bit one"
+	if wide.Data[2] != narrow.Data[1] || wide.Data[3] != narrow.Data[2] {
+		warning += fmt.Sprintf(" (parts %d/%d, 8-bit IE claims %d/%d)",
+			wide.Data[3], wide.Data[2], narrow.Data[2], narrow.Data[1])
+	}
+
+	return warning
+}
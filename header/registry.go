@@ -0,0 +1,188 @@
+package header
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Registry holds the Information Element Identifiers recognized when detecting whether a message starts with
+// a UDH. It is safe for concurrent use.
+type Registry struct {
+	known    map[byte]string
+	handlers map[byte]Handler
+	mtx      sync.RWMutex
+}
+
+// Handler receives an information element found while parsing a UDH, and returns a typed value that the parser
+// attaches to the parsed message under the IEI of the element.
+// Returning an error fails the parsing.
+type Handler func(element InformationElement) (any, error)
+
+// DefaultRegistry is the registry used by the parser when no other registry was set.
+// It holds the IEIs defined by 3GPP TS 23.040, and can be extended by the caller.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns a new registry holding the IEIs defined by 3GPP TS 23.040.
+func NewRegistry() *Registry {
+	return &Registry{
+		handlers: map[byte]Handler{},
+		known: map[byte]string{
+			IEIConcatenated8Bit:     "Concatenated short messages, 8-bit reference number",
+			IEISpecialSMSIndication: "Special SMS Message Indication",
+			IEIApplicationPort8Bit:  "Application port addressing scheme, 8 bit address",
+			IEIApplicationPort16Bit: "Application port addressing scheme, 16 bit address",
+			0x06:                    "SMSC Control Parameters",
+			0x07:                    "UDH Source Indicator",
+			IEIConcatenated16Bit:    "Concatenated short messages, 16-bit reference number",
+			0x09:                    "Wireless Control Message Protocol",
+			0x0A:                    "Text Formatting",
+			0x0B:                    "Predefined Sound",
+			0x0C:                    "User Defined Sound",
+			0x0D:                    "Predefined Animation",
+			0x0E:                    "Large Animation",
+			0x0F:                    "Small Animation",
+			0x10:                    "Large Picture",
+			0x11:                    "Small Picture",
+			0x12:                    "Variable Picture",
+			0x13:                    "User prompt indicator",
+			0x14:                    "Extended Object",
+			0x15:                    "Reused Extended Object",
+			0x16:                    "Compression Control",
+			0x17:                    "Object Distribution Indicator",
+			0x18:                    "Standard WVG object",
+			0x19:                    "Character Size WVG object",
+			0x1A:                    "Extended Object Data Request Command",
+			0x20:                    "RFC 822 E-Mail Header",
+			0x21:                    "Hyperlink format element",
+			0x22:                    "Reply Address Element",
+			0x23:                    "Enhanced Voice Mail Information",
+			IEINationalSingleShift:  "National Language Single Shift",
+			IEINationalLockingShift: "National Language Locking Shift",
+		},
+	}
+}
+
+// Register adds an IEI to the registry, replacing the name of an already known IEI.
+func (registry *Registry) Register(identifier byte, name string) {
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+
+	registry.known[identifier] = name
+}
+
+// RegisterHandler adds an IEI to the registry, together with a handler that is called for every element with that
+// IEI found while parsing.
+func (registry *Registry) RegisterHandler(identifier byte, name string, handler Handler) {
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+
+	registry.known[identifier] = name
+	registry.handlers[identifier] = handler
+}
+
+// Unregister removes an IEI and its handler from the registry.
+func (registry *Registry) Unregister(identifier byte) {
+	registry.mtx.Lock()
+	defer registry.mtx.Unlock()
+
+	delete(registry.known, identifier)
+	delete(registry.handlers, identifier)
+}
+
+// Handler returns the handler registered for an IEI, or nil if there is none.
+func (registry *Registry) Handler(identifier byte) Handler {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+
+	return registry.handlers[identifier]
+}
+
+// Known returns true if the IEI is part of the registry.
+func (registry *Registry) Known(identifier byte) bool {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+
+	_, found := registry.known[identifier]
+	return found
+}
+
+// Name returns the name of a registered IEI, or an empty string if it is not part of the registry.
+func (registry *Registry) Name(identifier byte) string {
+	registry.mtx.RLock()
+	defer registry.mtx.RUnlock()
+
+	return registry.known[identifier]
+}
+
+// rfc822Element is the IEI cutoff used by the legacy UDH detection.
+const rfc822Element byte = 0x20
+
+// Detect decides whether binary starts with a UDH, and returns a description of the reason.
+//
+// A UDH is detected when the header length leaves room for a payload, the first IEI is registered, and the
+// information elements fill the header exactly.
+func (registry *Registry) Detect(binary []byte) (bool, string) {
+	headerLength, reason := payloadRoom(binary)
+	if reason != "" {
+		return false, reason
+	}
+
+	if !registry.Known(binary[1]) {
+		return false, fmt.Sprintf("element 0x%02X is not a registered IEI", binary[1])
+	}
+
+	offset := 1
+	for offset < headerLength+1 {
+		if offset+1 >= headerLength+1 {
+			return false, fmt.Sprintf("element 0x%02X at offset %d has no length", binary[offset], offset)
+		}
+
+		offset += 2 + int(binary[offset+1])
+	}
+
+	if offset != headerLength+1 {
+		return false, fmt.Sprintf("information elements exceed the header length %d", headerLength)
+	}
+
+	return true, fmt.Sprintf("header length %d, element 0x%02X is a registered IEI", headerLength, binary[1])
+}
+
+// DetectLegacy is the detection used by earlier versions of the package, where binary is considered to start with
+// a UDH when the header length leaves room for a payload and the first IEI is below 0x20, regardless of any
+// registry. Returns a description of the reason.
+func DetectLegacy(binary []byte) (bool, string) {
+	headerLength, reason := payloadRoom(binary)
+	if reason != "" {
+		return false, reason
+	}
+
+	if binary[1] >= rfc822Element {
+		return false, fmt.Sprintf("element 0x%02X is not below 0x%02X", binary[1], rfc822Element)
+	}
+
+	return true, fmt.Sprintf("header length %d, element 0x%02X is below 0x%02X",
+		headerLength, binary[1], rfc822Element)
+}
+
+// payloadRoom returns the header length of binary, or the reason it cannot start with a UDH followed by a payload.
+func payloadRoom(binary []byte) (int, string) {
+	if len(binary) < 2 {
+		return 0, fmt.Sprintf("input of %d bytes is too short", len(binary))
+	}
+
+	headerLength := int(binary[0])
+
+	switch {
+	case headerLength == 0:
+		return 0, "header length is 0"
+	case headerLength >= len(binary)-1:
+		return 0, fmt.Sprintf("header length %d does not leave room for a payload of %d bytes",
+			headerLength, len(binary))
+	}
+
+	return headerLength, ""
+}
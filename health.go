@@ -0,0 +1,106 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// janitorStalledFactor is the number of intervals without a sweep after which a running janitor is stalled.
+const janitorStalledFactor = 2
+
+// Health is a report of the state of a Messages container, for health checks and readiness probes.
+type Health struct {
+	// True when a Store was set using WithStore
+	HasStore bool
+
+	// Error returned by pinging the Store, wrapping ErrStore. It is nil when the Store is reachable, when it does
+	// not implement StorePinger, or when no Store was set.
+	StoreErr error
+
+	// Number of RunJanitor calls that are running
+	Janitors int
+
+	// Interval of the last started janitor
+	JanitorInterval time.Duration
+
+	// Time of the last sweep of a janitor, or of its start before its first sweep. Zero when no janitor ever ran.
+	LastSweep time.Time
+
+	// True when a janitor is running but did not sweep for twice its interval, such as when it is blocked
+	JanitorStalled bool
+
+	// Number of messages held by the container, complete messages that were not drained included
+	Backlog int
+
+	// True once Close was called, so the container refuses new fragments
+	Closed bool
+}
+
+// janitorState tracks the janitors started by RunJanitor.
+type janitorState struct {
+	wg        sync.WaitGroup
+	mtx       sync.Mutex
+	running   int
+	interval  time.Duration
+	lastSweep time.Time
+}
+
+func (state *janitorState) start(now time.Time, interval time.Duration) {
+	state.wg.Add(1)
+
+	state.mtx.Lock()
+	defer state.mtx.Unlock()
+
+	state.running++
+	state.interval = interval
+	state.lastSweep = now
+}
+
+func (state *janitorState) stop() {
+	defer state.wg.Done()
+
+	state.mtx.Lock()
+	defer state.mtx.Unlock()
+
+	state.running--
+}
+
+func (state *janitorState) swept(now time.Time) {
+	state.mtx.Lock()
+	defer state.mtx.Unlock()
+
+	state.lastSweep = now
+}
+
+// Health returns the state of the container: whether its Store is reachable, whether its janitors are sweeping,
+// and the size of its backlog. ctx limits the time spent pinging the Store.
+func (msgs *Messages) Health(ctx context.Context) Health {
+	health := Health{}
+
+	if msgs.store != nil {
+		health.HasStore = true
+		health.StoreErr = pingStore(ctx, msgs.store)
+	}
+
+	msgs.janitor.mtx.Lock()
+	health.Janitors = msgs.janitor.running
+	health.JanitorInterval = msgs.janitor.interval
+	health.LastSweep = msgs.janitor.lastSweep
+	msgs.janitor.mtx.Unlock()
+
+	if health.Janitors > 0 && health.JanitorInterval > 0 {
+		health.JanitorStalled = msgs.now().Sub(health.LastSweep) > janitorStalledFactor*health.JanitorInterval
+	}
+
+	msgs.mtx.Lock()
+	health.Backlog = len(msgs.fragments)
+	health.Closed = msgs.closed
+	msgs.mtx.Unlock()
+
+	return health
+}
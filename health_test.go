@@ -0,0 +1,182 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+// silentClock is a FakeClock whose tickers never fire, for janitors that never sweep.
+type silentClock struct {
+	*smudhtest.FakeClock
+}
+
+type silentTicker struct{}
+
+func (silentClock) NewTicker(time.Duration) udh.Ticker {
+	return silentTicker{}
+}
+
+func (silentTicker) Chan() <-chan time.Time {
+	return nil
+}
+
+func (silentTicker) Stop() {}
+
+func TestHealthStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+
+	store, err := udh.NewDirStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		name      string
+		options   []udh.MessagesOption
+		remove    bool
+		hasStore  bool
+		storeFail bool
+	}
+
+	tests := []testCase{
+		{name: "no store"},
+		{name: "memory store", options: []udh.MessagesOption{udh.WithStore(udh.NewMemoryStore())}, hasStore: true},
+		{name: "dir store", options: []udh.MessagesOption{udh.WithStore(store)}, hasStore: true},
+		{
+			name: "removed dir store", options: []udh.MessagesOption{udh.WithStore(store)},
+			remove: true, hasStore: true, storeFail: true,
+		},
+		{
+			name:    "removed encrypted dir store",
+			options: []udh.MessagesOption{udh.WithStore(udh.NewEncryptedStore(store, udh.StaticKey(make([]byte, 16))))},
+			remove:  true, hasStore: true, storeFail: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if test.remove {
+				err := os.RemoveAll(dir)
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			messages := udh.InitMessages(test.options...)
+
+			for _, msg := range []udh.Message{
+				udh.Message("050003A50201546869732069732061206C"),
+				udh.Message("050003B70502002005E905DC"),
+			} {
+				_ = messages.Add(udh.ASCII, msg)
+			}
+
+			health := messages.Health(context.Background())
+
+			if health.HasStore != test.hasStore {
+				t2.Errorf("have HasStore %t, expected %t", health.HasStore, test.hasStore)
+			}
+
+			if (health.StoreErr != nil) != test.storeFail || (test.storeFail && !errors.Is(health.StoreErr, udh.ErrStore)) {
+				t2.Errorf("unexpected store error: %v", health.StoreErr)
+			}
+
+			expectedBacklog := 2
+			if test.storeFail {
+				expectedBacklog = 0
+			}
+
+			if health.Backlog != expectedBacklog {
+				t2.Errorf("have backlog %d, expected %d", health.Backlog, expectedBacklog)
+			}
+		})
+	}
+}
+
+func TestHealthJanitor(t *testing.T) {
+	type testCase struct {
+		name     string
+		clock    udh.Clock
+		sweeps   bool
+		advance  time.Duration
+		expected udh.Health
+	}
+
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []testCase{
+		{
+			name:  "sweeping",
+			clock: smudhtest.NewFakeClock(start), sweeps: true, advance: 5 * time.Minute,
+			expected: udh.Health{Janitors: 1, JanitorInterval: time.Minute, LastSweep: start.Add(5 * time.Minute)},
+		},
+		{
+			name:  "stalled",
+			clock: silentClock{smudhtest.NewFakeClock(start)}, advance: 5 * time.Minute,
+			expected: udh.Health{
+				Janitors: 1, JanitorInterval: time.Minute, LastSweep: start, JanitorStalled: true,
+			},
+		},
+		{
+			name:  "within two intervals",
+			clock: silentClock{smudhtest.NewFakeClock(start)}, advance: 2 * time.Minute,
+			expected: udh.Health{Janitors: 1, JanitorInterval: time.Minute, LastSweep: start},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			messages := udh.InitMessages(udh.WithClock(test.clock))
+
+			if have := messages.Health(context.Background()); !cmp.Equal(have, udh.Health{}) {
+				t2.Errorf("unexpected health before the janitor started: %+v", have)
+			}
+
+			ctx, cancel := context.WithCancel(t2.Context())
+			done := make(chan struct{})
+
+			go func() {
+				defer close(done)
+				messages.RunJanitor(ctx, time.Minute)
+			}()
+
+			for messages.Health(context.Background()).Janitors == 0 {
+				time.Sleep(time.Millisecond)
+			}
+
+			advance := test.clock.(interface{ Advance(time.Duration) })
+
+			// advance a minute at a time, so every tick is delivered to a sweeping janitor
+			for elapsed := time.Duration(0); elapsed < test.advance; elapsed += time.Minute {
+				advance.Advance(time.Minute)
+
+				for test.sweeps && messages.Health(context.Background()).LastSweep.Before(start.Add(elapsed+time.Minute)) {
+					time.Sleep(time.Millisecond)
+				}
+			}
+
+			if diff := cmp.Diff(test.expected, messages.Health(context.Background())); diff != "" {
+				t2.Errorf("unexpected health (-want +got):\n%s", diff)
+			}
+
+			cancel()
+			<-done
+
+			if have := messages.Health(context.Background()).Janitors; have != 0 {
+				t2.Errorf("have %d janitors after stopping, expected 0", have)
+			}
+		})
+	}
+}
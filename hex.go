@@ -0,0 +1,145 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+)
+
+// invalidNibble marks the bytes of hexNibbles that are not hexadecimal digits.
+const invalidNibble = 0xFF
+
+// hexNibbles maps every byte to the value of the hexadecimal digit it holds, or to invalidNibble.
+var hexNibbles = func() (table [256]byte) {
+	for i := range table {
+		table[i] = invalidNibble
+	}
+
+	for i, ch := range "0123456789abcdef" {
+		table[ch] = byte(i)
+	}
+
+	for i, ch := range "ABCDEF" {
+		table[ch] = byte(i + 10)
+	}
+
+	return table
+}()
+
+// decodeHex decodes src into dst, which must be at least len(src)/2 bytes long, using a lookup table instead of
+// per-character branching.
+// Returns ErrHexStringMustHaveAnEvenNumberOfChars for odd length input, and hex.InvalidByteError for the first
+// character that is not a hexadecimal digit, like encoding/hex does.
+func decodeHex(dst, src []byte) (int, error) {
+	if len(src)&1 != 0 {
+		return 0, ErrHexStringMustHaveAnEvenNumberOfChars
+	}
+
+	dst = dst[:len(src)/2]
+
+	for i := range dst {
+		high := hexNibbles[src[i*2]]
+		low := hexNibbles[src[i*2+1]]
+
+		// valid digits never set the upper nibble, so a single check covers both characters
+		if (high|low)&0xF0 != 0 {
+			if high == invalidNibble {
+				return i, hex.InvalidByteError(src[i*2])
+			}
+
+			return i, hex.InvalidByteError(src[i*2+1])
+		}
+
+		dst[i] = high<<4 | low
+	}
+
+	return len(dst), nil
+}
+
+// WithStrictHex rejects input that is not canonical hex with ErrNonCanonicalHex, instead of normalizing it.
+// Canonical hex has no surrounding whitespace, no "0x" prefix, and does not mix upper and lower case digits.
+func WithStrictHex() ParseOption {
+	return func(config *parseConfig) {
+		config.strictHex = true
+	}
+}
+
+// normalizeHex removes the surrounding whitespace and the "0x" prefix that logs and APIs add to hex strings, or
+// rejects them when WithStrictHex is set. Mixed case digits are accepted by the decoder itself.
+func (config parseConfig) normalizeHex(msg Message) (Message, error) {
+	normalized := Message(bytes.TrimSpace(msg))
+	if len(normalized) >= 2 && normalized[0] == '0' && (normalized[1] == 'x' || normalized[1] == 'X') {
+		normalized = normalized[2:]
+	}
+
+	if config.strictHex && (len(normalized) != len(msg) || mixedCaseHex(msg)) {
+		return nil, ErrNonCanonicalHex
+	}
+
+	if config.octetSeparators {
+		return joinOctets(normalized)
+	}
+
+	return normalized, nil
+}
+
+// WithOctetSeparators accepts octets separated by spaces or colons, such as "05 00 03 0F" or "05:00:03:0F", as
+// found in packet capture exports. Groups of several octets ("0500 030F") are accepted as well, as long as every
+// group has an even number of hex digits.
+func WithOctetSeparators() ParseOption {
+	return func(config *parseConfig) {
+		config.octetSeparators = true
+	}
+}
+
+// octetSeparators holds the characters that separate octets for WithOctetSeparators.
+const octetSeparators = " :\t"
+
+// isOctetSeparator reports whether ch separates octets for WithOctetSeparators.
+func isOctetSeparator(ch byte) bool {
+	return strings.IndexByte(octetSeparators, ch) >= 0
+}
+
+// joinOctets removes the separators between groups of octets.
+// Returns ErrHexStringMustHaveAnEvenNumberOfChars when a group has an odd number of hex digits.
+func joinOctets(msg Message) (Message, error) {
+	if !bytes.ContainsAny(msg, octetSeparators) {
+		return msg, nil
+	}
+
+	result := make(Message, 0, len(msg))
+	group := 0
+
+	for _, ch := range msg {
+		if !isOctetSeparator(ch) {
+			result = append(result, ch)
+			group++
+
+			continue
+		}
+
+		if group%2 != 0 {
+			return nil, ErrHexStringMustHaveAnEvenNumberOfChars
+		}
+
+		group = 0
+	}
+
+	if group%2 != 0 {
+		return nil, ErrHexStringMustHaveAnEvenNumberOfChars
+	}
+
+	return result, nil
+}
+
+// mixedCaseHex reports whether msg has both upper and lower case hex digits.
+func mixedCaseHex(msg Message) bool {
+	upper := bytes.ContainsAny(msg, "ABCDEF")
+	lower := bytes.ContainsAny(msg, "abcdef")
+
+	return upper && lower
+}
@@ -0,0 +1,130 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestHexDecoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      udh.Message
+		expected string
+		err      error
+	}{
+		{name: "upper case", msg: udh.Message("48454C4C4F"), expected: "HELLO"},
+		{name: "lower case", msg: udh.Message("68656c6c6f"), expected: "hello"},
+		{name: "mixed case", msg: udh.Message("68656C6c6F"), expected: "hello"},
+		{name: "odd length", msg: udh.Message("6865C"), err: udh.ErrHexStringMustHaveAnEvenNumberOfChars},
+		{name: "invalid high nibble", msg: udh.Message("68G5"), err: hex.InvalidByteError('G')},
+		{name: "invalid low nibble", msg: udh.Message("686Z"), err: hex.InvalidByteError('Z')},
+	}
+
+	for _, test := range tests {
+		elements, err := test.msg.ParseElements(udh.ASCII)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: have error %v, expected %v", test.name, err, test.err)
+			continue
+		}
+
+		if err == nil && elements.Message != test.expected {
+			t.Errorf("%s: have %q, expected %q", test.name, elements.Message, test.expected)
+		}
+	}
+}
+
+func BenchmarkParseElementsLargePayload(b *testing.B) {
+	// a 64KB message_payload
+	msg := udh.Message(strings.Repeat("00E9", 16*1024))
+
+	b.SetBytes(int64(len(msg)))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		_, err := msg.ParseElements(udh.UCS2)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestHexNormalization(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       udh.Message
+		canonical bool
+	}{
+		{name: "canonical", msg: udh.Message("68656C6C6F"), canonical: true},
+		{name: "lower case", msg: udh.Message("68656c6c6f"), canonical: true},
+		{name: "mixed case", msg: udh.Message("68656C6c6F")},
+		{name: "whitespace", msg: udh.Message(" \t68656C6C6F\r\n")},
+		{name: "prefix", msg: udh.Message("0x68656C6C6F")},
+		{name: "upper case prefix and newline", msg: udh.Message("0X68656C6C6F\n")},
+	}
+
+	for _, test := range tests {
+		elements, err := test.msg.ParseElements(udh.ASCII)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		if elements.Message != "hello" {
+			t.Errorf("%s: have %q, expected %q", test.name, elements.Message, "hello")
+		}
+
+		_, err = test.msg.ParseElements(udh.ASCII, udh.WithStrictHex())
+		if test.canonical && err != nil {
+			t.Errorf("%s: strict mode rejected canonical input: %s", test.name, err)
+		}
+
+		if !test.canonical && !errors.Is(err, udh.ErrNonCanonicalHex) {
+			t.Errorf("%s: have error %v in strict mode, expected %v", test.name, err, udh.ErrNonCanonicalHex)
+		}
+	}
+}
+
+func TestWithOctetSeparators(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  udh.Message
+		err  error
+	}{
+		{name: "spaces", msg: udh.Message("05 00 03 0F 02 01 68 65 6C 6C 6F")},
+		{name: "colons", msg: udh.Message("05:00:03:0f:02:01:68:65:6c:6c:6f")},
+		{name: "groups", msg: udh.Message("0500 030F 0201 6865 6C6C 6F")},
+		{name: "repeated separators", msg: udh.Message("05  00 03 0F 02 01 68 65 6C 6C 6F\n")},
+		{name: "no separators", msg: udh.Message("0500030F020168656C6C6F")},
+		{name: "odd group", msg: udh.Message("05 0 03 0F"), err: udh.ErrHexStringMustHaveAnEvenNumberOfChars},
+	}
+
+	for _, test := range tests {
+		elements, err := test.msg.ParseElements(udh.ASCII, udh.WithOctetSeparators())
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: have error %v, expected %v", test.name, err, test.err)
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if elements.Message != "hello" || elements.CurrentPart != 1 || elements.TotalParts != 2 {
+			t.Errorf("%s: have %q part %d/%d, expected \"hello\" part 1/2", test.name, elements.Message,
+				elements.CurrentPart, elements.TotalParts)
+		}
+	}
+
+	_, err := udh.Message("05 00 03 0F 02 01 68").ParseElements(udh.ASCII)
+	if err == nil {
+		t.Error("separated octets were accepted without WithOctetSeparators")
+	}
+}
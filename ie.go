@@ -0,0 +1,42 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "github.com/ik5/smudh/header"
+
+// Information Element Identifiers (IEI) that the package knows how to handle.
+const (
+	// Concatenated short messages, 8-bit reference number
+	IEIConcatenated8Bit = header.IEIConcatenated8Bit
+
+	// Special SMS message indication
+	IEISpecialSMSIndication = header.IEISpecialSMSIndication
+
+	// Application port addressing scheme, 8-bit address
+	IEIApplicationPort8Bit = header.IEIApplicationPort8Bit
+
+	// Application port addressing scheme, 16-bit address
+	IEIApplicationPort16Bit = header.IEIApplicationPort16Bit
+
+	// Concatenated short messages, 16-bit reference number
+	IEIConcatenated16Bit = header.IEIConcatenated16Bit
+
+	// National language single shift
+	IEINationalSingleShift = header.IEINationalSingleShift
+
+	// National language locking shift
+	IEINationalLockingShift = header.IEINationalLockingShift
+)
+
+// MaxUDHLength is the maximum number of octets the information elements of a UDH may occupy.
+// The user data of a short message is limited to 140 octets, and one of them is taken by the UDH Length field.
+const MaxUDHLength = header.MaxUDHLength
+
+// InformationElement represents a single Information Element (IE) inside a UDH. It is the same type as
+// header.InformationElement.
+type InformationElement = header.InformationElement
+
+// Ports represents application port addressing (IEI 0x04 and 0x05). It is the same type as header.Ports.
+type Ports = header.Ports
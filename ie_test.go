@@ -0,0 +1,67 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestBothConcatenationIEs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    udh.Message
+		warnings []string
+	}{
+		{
+			name:     "matching parts",
+			input:    udh.Message("0B0003120201080475390201" + "68656C6C6F"),
+			warnings: []string{"both 8-bit and 16-bit concatenation IEs found, using the 16-bit one"},
+		},
+		{
+			name:  "conflicting parts",
+			input: udh.Message("0B0003120301080475390201" + "68656C6C6F"),
+			warnings: []string{
+				"both 8-bit and 16-bit concatenation IEs found, using the 16-bit one (parts 1/2, 8-bit IE claims 1/3)",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			elements, err := test.input.ParseElements(udh.ASCII)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if elements.Element != udh.IEIConcatenated16Bit || !cmp.Equal(elements.Reference, []byte{0x75, 0x39}) {
+				t2.Errorf("expected the 16-bit reference, have %+v", *elements)
+			}
+
+			if elements.TotalParts != 2 || elements.CurrentPart != 1 {
+				t2.Errorf("have parts %d/%d", elements.CurrentPart, elements.TotalParts)
+			}
+
+			if diff := cmp.Diff(test.warnings, elements.Warnings); diff != "" {
+				t2.Errorf("warnings diff: %s", diff)
+			}
+
+			data, err := test.input.ParseUserData(udh.ASCII)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.warnings, data.Warnings); diff != "" {
+				t2.Errorf("user data warnings diff: %s", diff)
+			}
+
+			if !cmp.Equal(data.Reference(), []byte{0x75, 0x39}) {
+				t2.Errorf("expected the 16-bit reference, have %X", data.Reference())
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"slices"
+	"time"
+)
+
+// IncompleteMessage describes a message inside Messages that still waits for some of its fragments.
+type IncompleteMessage struct {
+	// Reference number of the message
+	Reference []byte `json:"reference"`
+
+	// Total number of parts, as claimed by the fragments
+	TotalParts byte `json:"total_parts"`
+
+	// Number of fragments received so far
+	Received int `json:"received"`
+
+	// The part numbers that were not received yet
+	MissingParts []byte `json:"missing_parts"`
+
+	// The part numbers of fragments that keep the message from being complete even once the missing parts
+	// arrive, see MessageFragmentations.ExtraParts
+	ExtraParts []byte `json:"extra_parts,omitempty"`
+
+	// Arrival time of the first fragment
+	FirstSeen time.Time `json:"first_seen"`
+
+	// Arrival time of the last fragment
+	LastSeen time.Time `json:"last_seen"`
+
+	// How long the message has been waiting since its first fragment arrived
+	Age time.Duration `json:"age"`
+}
+
+// MissingParts returns the part numbers between 1 and TotalParts that do not exist in the MessageFragmentations,
+// in ascending order.
+// Returns nil for an empty or standalone MessageFragmentations.
+func (msgs MessageFragmentations) MissingParts() []byte {
+	if len(msgs) == 0 || msgs[0].Standalone {
+		return nil
+	}
+
+	var missing []byte
+
+	for part := 1; part <= int(msgs[0].TotalParts); part++ {
+		if msgs.Get(byte(part)) == nil {
+			missing = append(missing, byte(part))
+		}
+	}
+
+	return missing
+}
+
+// ExtraParts returns the part numbers of the fragments that are not needed for completing the message, in
+// ascending order: every fragment of a part number after the first one, such as the duplicates added without
+// WithDeduplication, and the fragments whose part number is outside 1 to TotalParts.
+// Returns nil for an empty or standalone MessageFragmentations.
+//
+// HaveAllFragments is true exactly when both MissingParts and ExtraParts are empty.
+func (msgs MessageFragmentations) ExtraParts() []byte {
+	if len(msgs) == 0 || msgs[0].Standalone || msgs[0].TotalParts == 0 {
+		return nil
+	}
+
+	var (
+		extra []byte
+		seen  [256]bool
+	)
+
+	for _, info := range msgs.ordered() {
+		if info.CurrentPart == 0 || info.CurrentPart > msgs[0].TotalParts || seen[info.CurrentPart] {
+			extra = append(extra, info.CurrentPart)
+		}
+
+		seen[info.CurrentPart] = true
+	}
+
+	return extra
+}
+
+// Incomplete returns the messages in the Messages container that do not have all of their fragments yet, ordered
+// from the oldest to the newest.
+func (msgs *Messages) Incomplete() []IncompleteMessage {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	now := msgs.now()
+	results := []IncompleteMessage{}
+
+	for _, set := range msgs.fragments {
+		if set.fragments.HaveAllFragments() {
+			continue
+		}
+
+		results = append(results, set.report(now))
+	}
+
+	slices.SortFunc(results, func(a, b IncompleteMessage) int {
+		if cmp := a.FirstSeen.Compare(b.FirstSeen); cmp != 0 {
+			return cmp
+		}
+
+		return bytes.Compare(a.Reference, b.Reference)
+	})
+
+	return results
+}
+
+// report returns the IncompleteMessage describing the set at now.
+func (set *fragmentSet) report(now time.Time) IncompleteMessage {
+	fragments := *set.fragments
+
+	return IncompleteMessage{
+		Reference:    bytes.Clone(fragments.Reference()),
+		TotalParts:   fragments[0].TotalParts,
+		Received:     len(fragments),
+		MissingParts: fragments.MissingParts(),
+		ExtraParts:   fragments.ExtraParts(),
+		FirstSeen:    set.firstSeen,
+		LastSeen:     set.lastSeen,
+		Age:          now.Sub(set.firstSeen),
+	}
+}
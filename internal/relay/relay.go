@@ -0,0 +1,133 @@
+// Package relay holds the logic shared by the message broker bridges: adding raw fragments to Messages, and handing
+// over completed messages.
+package relay
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ik5/smudh"
+)
+
+// defaultRetryDelay is the time waited before adding a rate limited fragment again, when the limit cannot tell.
+const defaultRetryDelay = time.Second
+
+// Relay moves fragments into Messages, and completed messages out of it.
+type Relay struct {
+	Messages *smudh.Messages
+	Version  smudh.InterfaceVersion
+}
+
+// Deliver hands over a completed message, and its JSON encoding.
+type Deliver func(ctx context.Context, assembled smudh.AssembledMessage, value []byte) error
+
+// Add parses the hex encoded short_message, and adds its fragment to Messages, with the message class of its
+// data_coding. dataCoding holds the data_coding value as a decimal or 0x prefixed hexadecimal number.
+func (relay Relay) Add(dataCoding string, shortMessage []byte) error {
+	value, err := strconv.ParseUint(strings.TrimSpace(dataCoding), 0, 8)
+	if err != nil {
+		return fmt.Errorf("data_coding: %w", err)
+	}
+
+	encoding, err := smudh.EncodingFromDataCodingVersion(byte(value), relay.Version)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	info, err := relay.Messages.Parse(encoding, smudh.Message(shortMessage))
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	info.MessageClass = smudh.MessageClassFromDataCoding(byte(value))
+
+	err = relay.Messages.AddMessageElements(info)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// Flush delivers the complete messages, and removes them from Messages once all were delivered. Messages rejected
+// by middleware using smudh.ErrRejected are removed without being delivered.
+func (relay Relay) Flush(ctx context.Context, deliver Deliver) error {
+	complete := relay.Messages.Complete()
+	if len(complete) == 0 {
+		return nil
+	}
+
+	for _, fragments := range complete {
+		assembled, err := relay.Messages.Assemble(ctx, *fragments)
+		if errors.Is(err, smudh.ErrRejected) {
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		value, err := json.Marshal(assembled)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		err = deliver(ctx, assembled, value)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+	}
+
+	relay.Messages.DrainComplete()
+
+	return nil
+}
+
+// Retryable reports whether err is a failure to add a fragment that may go away, such as a Store failure, a closed
+// container or a rate limit, so the fragment must not be committed or acknowledged.
+func Retryable(err error) bool {
+	return errors.Is(err, smudh.ErrStore) || errors.Is(err, smudh.ErrClosed) || errors.Is(err, smudh.ErrRateLimited)
+}
+
+// WaitRateLimit reports whether a fragment rejected with err should be added again, once the rate limit allows it.
+// It waits for the delay of the limit, and returns false for errors other than rate limits, or when ctx is done.
+func WaitRateLimit(ctx context.Context, err error) bool {
+	if !errors.Is(err, smudh.ErrRateLimited) {
+		return false
+	}
+
+	delay := defaultRetryDelay
+
+	var limitErr *smudh.RateLimitError
+	if errors.As(err, &limitErr) && limitErr.RetryAfter > 0 {
+		delay = limitErr.RetryAfter
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Janitor runs the Messages janitor until ctx is done, when interval is positive.
+func (relay Relay) Janitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go relay.Messages.RunJanitor(ctx, interval)
+}
@@ -0,0 +1,16 @@
+/*
+Package kafka bridges Kafka topics and a smudh Messages container: fragments are consumed from an input topic, and
+every completed message is produced to an output topic as a JSON encoded smudh.AssembledMessage.
+
+The package does not depend on a specific Kafka client. The Consumer and Producer interfaces are small enough to be
+implemented on top of any client library (segmentio/kafka-go, franz-go, confluent-kafka-go and so on).
+
+Each input record holds the hex encoded short_message as its value, and the SMPP data_coding value in a header
+(named "data_coding" by default, holding a decimal or 0x prefixed hexadecimal number).
+
+The offset of a record is committed only after its fragment was accepted by Messages. When Messages is configured
+using smudh.WithStore, this means after the fragment was durably stored. Completed messages are removed from
+Messages only after they were produced, and messages that were completed but not produced before a crash are produced
+when Run starts again, so delivery is at least once.
+*/
+package kafka
@@ -0,0 +1,181 @@
+package kafka
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ik5/smudh"
+	"github.com/ik5/smudh/internal/relay"
+)
+
+// DefaultDataCodingHeader is the name of the record header holding the data_coding value.
+const DefaultDataCodingHeader = "data_coding"
+
+// Record is a single Kafka record.
+type Record struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string][]byte
+}
+
+// Consumer reads records from the input topic.
+type Consumer interface {
+	// Fetch blocks until the next record is available, or ctx is done
+	Fetch(ctx context.Context) (Record, error)
+
+	// Commit marks the record as processed
+	Commit(ctx context.Context, record Record) error
+}
+
+// Producer writes records to the output topic.
+type Producer interface {
+	// Produce writes the record, returning only after the write was acknowledged
+	Produce(ctx context.Context, record Record) error
+}
+
+// Option configures a Bridge.
+type Option func(*Bridge)
+
+// WithDataCodingHeader sets the name of the record header holding the data_coding value.
+func WithDataCodingHeader(name string) Option {
+	return func(bridge *Bridge) {
+		bridge.dataCodingHeader = name
+	}
+}
+
+// WithInterfaceVersion sets the SMPP interface version used for mapping data_coding values. Defaults to SMPP 3.4.
+func WithInterfaceVersion(version smudh.InterfaceVersion) Option {
+	return func(bridge *Bridge) {
+		bridge.relay.Version = version
+	}
+}
+
+// WithJanitor runs the Messages janitor every interval while Run is active, evicting the messages that expired
+// according to smudh.WithTTL.
+func WithJanitor(interval time.Duration) Option {
+	return func(bridge *Bridge) {
+		bridge.janitorInterval = interval
+	}
+}
+
+// WithLogger sets the logger used for reporting records that were skipped.
+func WithLogger(logger *slog.Logger) Option {
+	return func(bridge *Bridge) {
+		bridge.logger = logger
+	}
+}
+
+// Bridge moves fragments from a Consumer into Messages, and completed messages from Messages into a Producer.
+type Bridge struct {
+	consumer         Consumer
+	producer         Producer
+	relay            relay.Relay
+	outputTopic      string
+	dataCodingHeader string
+	janitorInterval  time.Duration
+	logger           *slog.Logger
+}
+
+// NewBridge returns a Bridge reading from consumer, and writing completed messages to outputTopic using producer.
+//
+// The Bridge should be the only writer of messages, since it produces every complete message that messages holds.
+func NewBridge(
+	consumer Consumer, producer Producer, messages *smudh.Messages, outputTopic string, options ...Option,
+) *Bridge {
+	bridge := &Bridge{
+		consumer:         consumer,
+		producer:         producer,
+		relay:            relay.Relay{Messages: messages, Version: smudh.SMPP34},
+		outputTopic:      outputTopic,
+		dataCodingHeader: DefaultDataCodingHeader,
+	}
+
+	for _, option := range options {
+		option(bridge)
+	}
+
+	return bridge
+}
+
+// Run processes records until ctx is done, a Consumer, Producer or Store operation fails, or Messages is closed.
+// Returns nil when stopped by ctx.
+//
+// Records that cannot be parsed are logged and committed, since processing them again would fail the same way.
+// Records rejected by a rate limit of Messages are processed again once the limit allows it.
+func (bridge *Bridge) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	bridge.relay.Janitor(ctx, bridge.janitorInterval)
+
+	err := bridge.relay.Flush(ctx, bridge.deliver)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	for {
+		record, err := bridge.consumer.Fetch(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		err = bridge.Process(ctx, record)
+		for relay.WaitRateLimit(ctx, err) {
+			err = bridge.Process(ctx, record)
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+// Process handles a single record: the fragment is added to Messages, the record is committed, and completed
+// messages are produced.
+//
+// The record is not committed when its fragment could be accepted later: the error is returned when it wraps
+// smudh.ErrStore, smudh.ErrClosed or smudh.ErrRateLimited, so the record is processed again.
+func (bridge *Bridge) Process(ctx context.Context, record Record) error {
+	err := bridge.relay.Add(string(record.Headers[bridge.dataCodingHeader]), record.Value)
+	if relay.Retryable(err) {
+		return err
+	}
+
+	if err != nil && bridge.logger != nil {
+		bridge.logger.Warn("skipping record",
+			slog.String("topic", record.Topic),
+			slog.Int("partition", int(record.Partition)),
+			slog.Int64("offset", record.Offset),
+			slog.Any("error", err),
+		)
+	}
+
+	err = bridge.consumer.Commit(ctx, record)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return bridge.relay.Flush(ctx, bridge.deliver)
+}
+
+// deliver produces a completed message to the output topic.
+func (bridge *Bridge) deliver(ctx context.Context, assembled smudh.AssembledMessage, value []byte) error {
+	return bridge.producer.Produce(ctx, Record{Topic: bridge.outputTopic, Key: []byte(assembled.Reference), Value: value})
+}
@@ -0,0 +1,193 @@
+package kafka_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/kafka"
+)
+
+var errStoreDown = errors.New("store is down")
+
+type fakeConsumer struct {
+	records   []kafka.Record
+	committed []int64
+}
+
+func (consumer *fakeConsumer) Fetch(ctx context.Context) (kafka.Record, error) {
+	if len(consumer.records) == 0 {
+		<-ctx.Done()
+		return kafka.Record{}, ctx.Err()
+	}
+
+	record := consumer.records[0]
+	consumer.records = consumer.records[1:]
+
+	return record, nil
+}
+
+func (consumer *fakeConsumer) Commit(_ context.Context, record kafka.Record) error {
+	consumer.committed = append(consumer.committed, record.Offset)
+	return nil
+}
+
+type fakeProducer struct {
+	cancel   context.CancelFunc
+	produced []udh.AssembledMessage
+}
+
+func (producer *fakeProducer) Produce(_ context.Context, record kafka.Record) error {
+	var assembled udh.AssembledMessage
+
+	err := json.Unmarshal(record.Value, &assembled)
+	if err != nil {
+		return err
+	}
+
+	producer.produced = append(producer.produced, assembled)
+	producer.cancel()
+
+	return nil
+}
+
+type failingStore struct {
+	*udh.MemoryStore
+}
+
+func (failingStore) Save(string, udh.StoredSet) error {
+	return errStoreDown
+}
+
+func record(offset int64, dataCoding, value string) kafka.Record {
+	return kafka.Record{
+		Topic:   "fragments",
+		Offset:  offset,
+		Value:   []byte(value),
+		Headers: map[string][]byte{kafka.DefaultDataCodingHeader: []byte(dataCoding)},
+	}
+}
+
+func TestBridgeRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	consumer := &fakeConsumer{records: []kafka.Record{
+		record(1, "1", "0500030A020168656C6C6F20"),
+		record(2, "nope", "0500030B020168656C6C6F20"),
+		record(3, "0x01", "0500030A0202776F726C64"),
+	}}
+	producer := &fakeProducer{cancel: cancel}
+	messages := udh.InitMessages()
+
+	err := kafka.NewBridge(consumer, producer, messages, "assembled").Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]int64{1, 2, 3}, consumer.committed); diff != "" {
+		t.Errorf("unexpected commits (-want +got):\n%s", diff)
+	}
+
+	expected := []udh.AssembledMessage{{Reference: "0a", Encoding: udh.ASCII, Parts: 2, Text: "hello world"}}
+	if diff := cmp.Diff(expected, producer.produced); diff != "" {
+		t.Errorf("unexpected produced messages (-want +got):\n%s", diff)
+	}
+
+	if all := messages.ListAll(); len(all) != 0 {
+		t.Errorf("have %d messages left, expected none", len(all))
+	}
+}
+
+func TestBridgeStoreFailure(t *testing.T) {
+	consumer := &fakeConsumer{}
+	messages := udh.InitMessages(udh.WithStore(failingStore{udh.NewMemoryStore()}))
+	bridge := kafka.NewBridge(consumer, &fakeProducer{}, messages, "assembled")
+
+	err := bridge.Process(t.Context(), record(1, "1", "0500030A020168656C6C6F20"))
+	if !errors.Is(err, errStoreDown) {
+		t.Fatalf("have error %v, expected %v", err, errStoreDown)
+	}
+
+	if len(consumer.committed) != 0 {
+		t.Errorf("have commits %v, expected none", consumer.committed)
+	}
+}
+
+func TestBridgeRetryableErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages func(t *testing.T) *udh.Messages
+		err      error
+	}{
+		{
+			name: "closed",
+			messages: func(t *testing.T) *udh.Messages {
+				messages := udh.InitMessages()
+
+				err := messages.Close(t.Context())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return messages
+			},
+			err: udh.ErrClosed,
+		},
+		{
+			name: "rate limited",
+			messages: func(*testing.T) *udh.Messages {
+				return udh.InitMessages(udh.WithGlobalRateLimit(udh.RateLimit{Rate: 0.001, Burst: 0}))
+			},
+			err: udh.ErrRateLimited,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			consumer := &fakeConsumer{}
+			bridge := kafka.NewBridge(consumer, &fakeProducer{}, test.messages(t2), "assembled")
+
+			err := bridge.Process(t2.Context(), record(1, "1", "0500030A020168656C6C6F20"))
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have error %v, expected %v", err, test.err)
+			}
+
+			if len(consumer.committed) != 0 {
+				t2.Errorf("have commits %v, expected none", consumer.committed)
+			}
+		})
+	}
+}
+
+func TestBridgeRunRateLimited(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	consumer := &fakeConsumer{records: []kafka.Record{
+		record(1, "1", "0500030A020168656C6C6F20"),
+		record(2, "1", "0500030A0202776F726C64"),
+	}}
+	producer := &fakeProducer{cancel: cancel}
+	messages := udh.InitMessages(udh.WithGlobalRateLimit(udh.RateLimit{Rate: 50, Burst: 1}))
+
+	err := kafka.NewBridge(consumer, producer, messages, "assembled").Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]int64{1, 2}, consumer.committed); diff != "" {
+		t.Errorf("unexpected commits (-want +got):\n%s", diff)
+	}
+
+	if len(producer.produced) != 1 {
+		t.Errorf("have %d produced messages, expected 1", len(producer.produced))
+	}
+}
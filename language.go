@@ -0,0 +1,215 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// minLanguageLetters is the number of letters below which NgramDetector does not guess a language.
+const minLanguageLetters = 3
+
+// LanguageDetector detects the language of the text of an assembled message, for WithLanguageDetection.
+type LanguageDetector interface {
+	// DetectLanguage returns the ISO 639-1 code of the language of text, and the confidence of the guess between 0
+	// and 1. An empty code means the language is unknown.
+	DetectLanguage(text string) (language string, confidence float64)
+}
+
+// WithLanguageDetection detects the language of the assembled text using detector, and sets it at
+// AssembledMessage.Language, such as for routing messages to per-language processing queues.
+func WithLanguageDetection(detector LanguageDetector) AssembleOption {
+	return func(config *assembleConfig) {
+		config.languageDetector = detector
+	}
+}
+
+// scriptLanguages maps the scripts that are used by a single common language to its ISO 639-1 code. Cyrillic text
+// is reported as Russian.
+var scriptLanguages = []struct {
+	script   *unicode.RangeTable
+	language string
+}{
+	{unicode.Hebrew, "he"},
+	{unicode.Arabic, "ar"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Greek, "el"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Thai, "th"},
+	{unicode.Han, "zh"},
+}
+
+// latinTrigrams holds the most frequent trigrams of the Latin script languages known to NgramDetector, in
+// descending order of frequency. Word boundaries are spaces.
+var latinTrigrams = map[string][]string{
+	"en": {
+		" th", "the", "he ", "ing", "and", " an", "nd ", " to", "ion", "ng ", "ed ", " of", "of ", "er ", "tio", " in",
+		"to ", "is ", " is", "ent", "you", "ou ", " yo", "for", " fo", "or ", "re ", "at ", " wi", "ith", "our", "ll ",
+		"it ", " it", "not", " no", "ot ", "wit", "th ", "thi", "his", "hat", "are", " ar", "ave", "ver", "any", "one",
+	},
+	"es": {
+		" de", "de ", " la", "la ", "que", " qu", "ue ", " el", "el ", "ión", "os ", "en ", " en", "es ", " co", "ent",
+		"as ", "ar ", "aci", "ció", "su ", "con", "do ", " su", "por", " po", "ra ", "una", "ien", " es", " y ", "tu ",
+		" un", "un ", "lo ", " lo", "no ", " no", "sta", "ado", "ida", "nte", "des", "le ", "al ", " al", "ica", "cas",
+	},
+	"fr": {
+		" de", "es ", "de ", " le", "le ", "ent", " la", "la ", "on ", "ion", " et", "et ", "re ", " pa", "les", " co",
+		"que", " qu", "ue ", "tio", "ne ", " un", "our", "ous", "vou", " vo", "est", " es", "ait", " po", "pou", "ur ",
+		" ve", "ver", "ez ", "tre", " ce", "ce ", "pas", "as ", "ica", "cat", "men", "nt ", "eur", "ai ", " ne", "ons",
+	},
+	"de": {
+		"en ", "er ", " de", "der", "ich", "sch", "die", " di", "ie ", "ein", "che", " un", "und", "nd ", " ei", "cht",
+		"ung", " zu", "ten", "ine", " ge", "gen", "ist", " is", "st ", " si", "sie", " ih", "ihr", "den", "te ", " au",
+		" be", "ber", "ihn", "nic", "ach", "nen", "ter", "eit", "ges", "ben", "hen", "it ", "mit", " mi", "auf", "es ",
+	},
+	"pt": {
+		" de", "de ", "os ", " qu", "que", "ue ", " co", "ão ", "ção", "do ", "da ", " da", " do", "ent", "as ", " a ",
+		"es ", " pa", "com", "om ", "par", "ara", " em", "em ", "seu", " se", "ra ", "men", "est", "nto", "voc", "ocê",
+		" nã", "não", "nin", "gué", "uém", "ica", "ado", "ida", "ões", "çõe", "ele", " el", "sua", " su", "tem", "nte",
+	},
+	"it": {
+		" di", "di ", "che", " ch", "he ", " la", "la ", "re ", "ent", "to ", " il", "il ", "ell", "zio", " de", "del",
+		"lla", " co", "one", "ne ", "per", " pe", "er ", "ion", " un", "are", "no ", "ta ", "ato", " in", "tuo", "gli",
+		" no", "non", "on ", "ndi", "ice", "ica", "ess", "sso", "uno", "zza", "ere", "con", "nto", "ver", "ifi", "ali",
+	},
+	"nl": {
+		"en ", " de", "de ", "het", " he", "et ", "van", " va", "an ", "een", " ee", "er ", "ijn", "aar", "oor", " ge",
+		"ver", " ve", "nd ", "den", " in", "ing", "je ", "jij", "ij ", "ik ", " ik", "wor", "erd", " uw", "uw ", " is",
+		" me", "met", "eel", "ze ", "ode", "cod", "nie", "iet", "ere", "and", "ati", "tie", "ie ", "ter", "aan", " aa",
+	},
+	"tr": {
+		"lar", "ler", "ın ", "in ", "bir", " bi", "ir ", "eri", "arı", "nda", "ını", "ini", "an ", "en ", "le ", "da ",
+		"de ", "iz ", "ğın", " ve", "ve ", "ası", "esi", "içi", " iç", "ile", " il", "nız", "niz", "ız ", "sın", "ınd",
+		"kod", "odu", "unu", "nuz", "uz ", "ayı", "yın", "mak", "ama", "ış ", "rın", "la ", "ara", "dır", "mız", "cek",
+	},
+}
+
+// NgramDetector is a LanguageDetector using a small trigram model of common Latin script languages, and the script
+// of the text for languages written in their own script. It is meant for routing, and not as an accurate
+// classifier: texts shorter than a sentence are easily misdetected.
+type NgramDetector struct {
+	profiles map[string]map[string]float64
+	mtx      sync.RWMutex
+}
+
+// NewNgramDetector returns an NgramDetector using the built-in model, which recognizes English, Spanish, French,
+// German, Portuguese, Italian, Dutch and Turkish by their trigrams, and Hebrew, Arabic, Russian, Greek, Japanese,
+// Korean, Thai and Chinese by their script.
+func NewNgramDetector() *NgramDetector {
+	detector := &NgramDetector{profiles: map[string]map[string]float64{}}
+
+	for language, trigrams := range latinTrigrams {
+		detector.AddProfile(language, trigrams)
+	}
+
+	return detector
+}
+
+// AddProfile adds or replaces the trigrams of a Latin script language, in descending order of frequency, with
+// word boundaries as spaces. The weight of a trigram drops with its rank, from 1 for the first trigram.
+func (detector *NgramDetector) AddProfile(language string, trigrams []string) {
+	profile := make(map[string]float64, len(trigrams))
+
+	for rank, trigram := range trigrams {
+		if _, found := profile[trigram]; !found {
+			profile[trigram] = 1 - float64(rank)/float64(len(trigrams))
+		}
+	}
+
+	detector.mtx.Lock()
+	defer detector.mtx.Unlock()
+
+	detector.profiles[language] = profile
+}
+
+// DetectLanguage implements LanguageDetector.
+func (detector *NgramDetector) DetectLanguage(text string) (string, float64) {
+	letters, scripts := 0, map[string]int{}
+
+	var normalized strings.Builder
+
+	normalized.WriteByte(' ')
+
+	for _, ch := range strings.ToLower(text) {
+		if !unicode.IsLetter(ch) {
+			normalized.WriteByte(' ')
+			continue
+		}
+
+		letters++
+
+		for _, entry := range scriptLanguages {
+			if unicode.Is(entry.script, ch) {
+				scripts[entry.language]++
+				break
+			}
+		}
+
+		normalized.WriteRune(ch)
+	}
+
+	if letters < minLanguageLetters {
+		return "", 0
+	}
+
+	// kana is only used by Japanese, which is written using Han characters too
+	if scripts["ja"] > 0 {
+		scripts["ja"] += scripts["zh"]
+		delete(scripts, "zh")
+	}
+
+	bestScript, bestCount := "", 0
+
+	for language, count := range scripts {
+		if count > bestCount || count == bestCount && language < bestScript {
+			bestScript, bestCount = language, count
+		}
+	}
+
+	if bestCount*2 > letters {
+		return bestScript, float64(bestCount) / float64(letters)
+	}
+
+	return detector.detectLatin(strings.Join(strings.Fields(normalized.String()), " "))
+}
+
+// detectLatin scores the trigrams of text against the profiles, and returns the best language and its share of
+// all of the scores.
+func (detector *NgramDetector) detectLatin(text string) (string, float64) {
+	runes := []rune(" " + text + " ")
+
+	detector.mtx.RLock()
+	defer detector.mtx.RUnlock()
+
+	scores, total := map[string]float64{}, 0.0
+
+	for idx := 0; idx+3 <= len(runes); idx++ {
+		trigram := string(runes[idx : idx+3])
+
+		for language, profile := range detector.profiles {
+			weight := profile[trigram]
+			scores[language] += weight
+			total += weight
+		}
+	}
+
+	best, bestScore := "", 0.0
+
+	for language, score := range scores {
+		if score > bestScore || score == bestScore && score > 0 && language < best {
+			best, bestScore = language, score
+		}
+	}
+
+	if bestScore == 0 {
+		return "", 0
+	}
+
+	return best, bestScore / total
+}
@@ -0,0 +1,86 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestNgramDetector(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "Your verification code is 1234. Do not share it with anyone.", expected: "en"},
+		{text: "Tu código de verificación es 1234. No lo compartas con nadie.", expected: "es"},
+		{text: "Votre code de vérification est 1234. Ne le partagez pas.", expected: "fr"},
+		{text: "Ihr Bestätigungscode ist 1234. Bitte geben Sie ihn nicht weiter.", expected: "de"},
+		{text: "O seu código de verificação é 1234. Não o partilhe com ninguém.", expected: "pt"},
+		{text: "Il tuo codice di verifica è 1234. Non condividerlo con nessuno.", expected: "it"},
+		{text: "Uw verificatiecode is 1234. Deel deze code niet met anderen.", expected: "nl"},
+		{text: "Doğrulama kodunuz 1234. Bu kodu kimseyle paylaşmayınız.", expected: "tr"},
+		{text: "קוד האימות שלך הוא 1234", expected: "he"},
+		{text: "Ваш код подтверждения 1234", expected: "ru"},
+		{text: "رمز التحقق الخاص بك هو 1234", expected: "ar"},
+		{text: "認証コードは1234です", expected: "ja"},
+		{text: "您的验证码是1234", expected: "zh"},
+		{text: "인증 코드는 1234입니다", expected: "ko"},
+		{text: "1234", expected: ""},
+		{text: "ok", expected: ""},
+	}
+
+	detector := udh.NewNgramDetector()
+
+	for _, test := range tests {
+		t.Run(test.text, func(t2 *testing.T) {
+			language, confidence := detector.DetectLanguage(test.text)
+			if language != test.expected {
+				t2.Errorf("have language %q, expected %q", language, test.expected)
+			}
+
+			if (language == "") != (confidence == 0) || confidence < 0 || confidence > 1 {
+				t2.Errorf("unexpected confidence %f for language %q", confidence, language)
+			}
+		})
+	}
+}
+
+func TestWithLanguageDetection(t *testing.T) {
+	messages := udh.InitMessages()
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50201546869732069732061206C"),
+		udh.Message("050003A5020265722074657374696E67"),
+	} {
+		err := messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fragments := messages.Snapshot([]byte{0xA5})
+
+	assembled, err := messages.Assemble(context.Background(), fragments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assembled.Language != "" {
+		t.Errorf("expected no language without WithLanguageDetection, got %q", assembled.Language)
+	}
+
+	assembled, err = messages.Assemble(context.Background(), fragments,
+		udh.WithLanguageDetection(udh.NewNgramDetector()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assembled.Language != "en" || assembled.LanguageConfidence <= 0 {
+		t.Errorf("have language %q with confidence %f, expected en", assembled.Language, assembled.LanguageConfidence)
+	}
+}
@@ -0,0 +1,48 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+)
+
+// WithMaxInputLength rejects input longer than limit characters with ErrLengthLimitExceeded, before any decoding
+// takes place. It protects services accepting messages from public APIs against pathological inputs.
+// A limit of zero or less disables the check.
+func WithMaxInputLength(limit int) ParseOption {
+	return func(config *parseConfig) {
+		config.maxInputLength = limit
+	}
+}
+
+// WithMaxDecodedLength rejects messages whose decoded UTF-8 text is longer than limit bytes with
+// ErrLengthLimitExceeded. Since the decoded text is a few times the size of the input at most, combine it with
+// WithMaxInputLength for bounding the work done on every message.
+// A limit of zero or less disables the check.
+func WithMaxDecodedLength(limit int) ParseOption {
+	return func(config *parseConfig) {
+		config.maxDecodedLength = limit
+	}
+}
+
+// checkInputLength applies WithMaxInputLength.
+func (config parseConfig) checkInputLength(msg Message) error {
+	if config.maxInputLength > 0 && len(msg) > config.maxInputLength {
+		return fmt.Errorf("%w: input of %d characters, limit is %d",
+			ErrLengthLimitExceeded, len(msg), config.maxInputLength)
+	}
+
+	return nil
+}
+
+// checkDecodedLength applies WithMaxDecodedLength.
+func (config parseConfig) checkDecodedLength(text string) error {
+	if config.maxDecodedLength > 0 && len(text) > config.maxDecodedLength {
+		return fmt.Errorf("%w: decoded text of %d bytes, limit is %d",
+			ErrLengthLimitExceeded, len(text), config.maxDecodedLength)
+	}
+
+	return nil
+}
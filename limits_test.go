@@ -0,0 +1,49 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestLengthLimits(t *testing.T) {
+	msg := udh.Message("050003A50201546869732069732061206C") // "This is a l"
+
+	tests := []struct {
+		name    string
+		options []udh.ParseOption
+		err     error
+	}{
+		{name: "no limits"},
+		{name: "input at limit", options: []udh.ParseOption{udh.WithMaxInputLength(len(msg))}},
+		{
+			name:    "input over limit",
+			options: []udh.ParseOption{udh.WithMaxInputLength(len(msg) - 2)},
+			err:     udh.ErrLengthLimitExceeded,
+		},
+		{name: "decoded at limit", options: []udh.ParseOption{udh.WithMaxDecodedLength(11)}},
+		{
+			name:    "decoded over limit",
+			options: []udh.ParseOption{udh.WithMaxDecodedLength(10)},
+			err:     udh.ErrLengthLimitExceeded,
+		},
+		{name: "disabled", options: []udh.ParseOption{udh.WithMaxInputLength(0), udh.WithMaxDecodedLength(-1)}},
+	}
+
+	for _, test := range tests {
+		_, err := msg.ParseElements(udh.ASCII, test.options...)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%s: have error %v, expected %v", test.name, err, test.err)
+		}
+
+		_, err = msg.ParseUserData(udh.ASCII, test.options...)
+		if !errors.Is(err, test.err) || (err == nil) != (test.err == nil) {
+			t.Errorf("%s: have user data error %v, expected %v", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestMessagesComplete(t *testing.T) {
+	messages := udh.InitMessages()
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003B70502002005E905DC"),           // part 2 of 5
+		udh.Message("050003A5020265722074657374696E67"),   // part 2 of 2
+		udh.Message("050003A50201546869732069732061206C"), // part 1 of 2
+		udh.Message("05000313010105E905DC05D505DD"),       // part 1 of 1
+	} {
+		err := messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	complete := messages.Complete()
+	if len(complete) != 2 {
+		t.Fatalf("have %d complete messages, expected 2", len(complete))
+	}
+
+	if ref := complete[0].Reference(); len(ref) != 1 || ref[0] != 0x13 {
+		t.Errorf("have first reference %X, expected 13", ref)
+	}
+
+	if ref := complete[1].Reference(); len(ref) != 1 || ref[0] != 0xA5 {
+		t.Errorf("have second reference %X, expected A5", ref)
+	}
+}
+
+func TestMessagesIncomplete(t *testing.T) {
+	messages := udh.InitMessages()
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003B70502002005E905DC"),           // part 2 of 5
+		udh.Message("050003B70504002005E905DC"),           // part 4 of 5
+		udh.Message("050003A5020265722074657374696E67"),   // part 2 of 2
+		udh.Message("050003A60201546869732069732061206C"), // part 1 of 2
+		udh.Message("050003A60202546869732069732061206C"), // part 2 of 2
+	} {
+		err := messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	incomplete := messages.Incomplete()
+	if len(incomplete) != 2 {
+		t.Fatalf("have %d incomplete messages, expected 2", len(incomplete))
+	}
+
+	expected := map[byte][]byte{
+		0xB7: {1, 3, 5},
+		0xA5: {1},
+	}
+
+	for idx, info := range incomplete {
+		if !cmp.Equal(info.MissingParts, expected[info.Reference[0]]) {
+			t.Errorf("%d. have missing parts %v for reference %X", idx, info.MissingParts, info.Reference)
+		}
+
+		if info.Age < 0 || info.FirstSeen.After(info.LastSeen) {
+			t.Errorf("%d. invalid timing: %+v", idx, info)
+		}
+	}
+
+	if incomplete[0].FirstSeen.After(incomplete[1].FirstSeen) {
+		t.Error("expected the oldest message first")
+	}
+}
+
+func TestMessagesDrainComplete(t *testing.T) {
+	messages := udh.InitMessages()
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003B70502002005E905DC"),           // part 2 of 5
+		udh.Message("050003A5020265722074657374696E67"),   // part 2 of 2
+		udh.Message("050003A50201546869732069732061206C"), // part 1 of 2
+	} {
+		err := messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	drained := messages.DrainComplete()
+	if len(drained) != 1 || drained[0].Reference()[0] != 0xA5 {
+		t.Fatalf("unexpected drained messages: %v", drained)
+	}
+
+	if messages.GetMessageFragments([]byte{0xA5}) != nil {
+		t.Error("drained message is still held by the container")
+	}
+
+	if len(messages.ListAll()) != 1 {
+		t.Errorf("have %d messages, expected 1", len(messages.ListAll()))
+	}
+
+	if drained = messages.DrainComplete(); len(drained) != 0 {
+		t.Errorf("have %d drained messages on the second pass", len(drained))
+	}
+}
+
+func TestMessageFragmentationsGet(t *testing.T) {
+	fragments := udh.MessageFragmentations{}
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50303" + "6F6E65"),
+		udh.Message("050003A50301" + "74776F"),
+	} {
+		err := fragments.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		part     byte
+		expected string
+		found    bool
+	}{
+		{part: 1, expected: "two", found: true},
+		{part: 2},
+		{part: 3, expected: "one", found: true},
+		{part: 0},
+	}
+
+	for _, test := range tests {
+		info := fragments.Get(test.part)
+		if (info != nil) != test.found {
+			t.Errorf("part %d: have %v, expected found %t", test.part, info, test.found)
+			continue
+		}
+
+		if info != nil && info.Message != test.expected {
+			t.Errorf("part %d: have message %q, expected %q", test.part, info.Message, test.expected)
+		}
+	}
+}
+
+func TestMessageFragmentationsOrder(t *testing.T) {
+	fragments := udh.MessageFragmentations{}
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50303" + "21"),
+		udh.Message("050003A50301" + "68656C6C6F"),
+		udh.Message("050003A50302" + "20776F726C64"),
+	} {
+		err := fragments.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parts := []byte{}
+	for _, info := range fragments {
+		parts = append(parts, info.CurrentPart)
+	}
+
+	if diff := cmp.Diff([]byte{1, 2, 3}, parts); diff != "" {
+		t.Errorf("parts diff: %s", diff)
+	}
+
+	if text := fragments.String(); text != "hello world!" {
+		t.Errorf("have text %q, expected %q", text, "hello world!")
+	}
+}
+
+func TestMessageFragmentationsAssembled(t *testing.T) {
+	fragments := udh.MessageFragmentations{
+		{Reference: []byte{0xA5}, TotalParts: 2, CurrentPart: 2, Message: " world"},
+		{Reference: []byte{0xA5}, TotalParts: 2, CurrentPart: 1, Message: "hello"},
+	}
+
+	if text := fragments.Assembled(); text != "hello world" {
+		t.Errorf("have text %q, expected %q", text, "hello world")
+	}
+
+	if fragments[0].CurrentPart != 2 {
+		t.Error("Assembled modified the order of the fragments")
+	}
+}
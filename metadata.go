@@ -0,0 +1,84 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"maps"
+)
+
+// Well known metadata keys. Any other key can be used as well.
+const (
+	// MetadataSMSC is the identifier of the SMSC that delivered the fragment
+	MetadataSMSC = "smsc"
+
+	// MetadataBind is the name of the SMPP bind that received the fragment
+	MetadataBind = "bind"
+
+	// MetadataReceivedAt is the time the fragment was received, preferably in RFC 3339 format
+	MetadataReceivedAt = "received_at"
+
+	// MetadataTraceID is the identifier of the distributed trace the fragment belongs to
+	MetadataTraceID = "trace_id"
+)
+
+// metadataKey is the context key of the fragment metadata.
+type metadataKey struct{}
+
+// ContextWithMetadata returns a copy of ctx carrying metadata, which is attached to every fragment added using ctx,
+// such as by AddContext or AddMessageElementsContext. The metadata is kept at MessageElements.Metadata, saved by
+// the Store and the WAL, and surfaced at AssembledMessage.Metadata.
+//
+// Metadata already carried by ctx is extended, with the values of metadata replacing existing values of the same
+// keys. metadata is copied, so it can be modified once ContextWithMetadata returns.
+func ContextWithMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	merged := maps.Clone(MetadataFromContext(ctx))
+	if merged == nil {
+		merged = make(map[string]string, len(metadata))
+	}
+
+	maps.Copy(merged, metadata)
+
+	return context.WithValue(ctx, metadataKey{}, merged)
+}
+
+// MetadataFromContext returns the metadata attached to ctx using ContextWithMetadata, or nil when there is none.
+// The result must not be modified.
+func MetadataFromContext(ctx context.Context) map[string]string {
+	metadata, _ := ctx.Value(metadataKey{}).(map[string]string)
+	return metadata
+}
+
+// attachMetadata adds the metadata carried by ctx to the fragment. Values already set at the fragment are kept.
+func (elem *MessageElements) attachMetadata(ctx context.Context) {
+	metadata := MetadataFromContext(ctx)
+	if len(metadata) == 0 {
+		return
+	}
+
+	merged := maps.Clone(metadata)
+	maps.Copy(merged, elem.Metadata)
+	elem.Metadata = merged
+}
+
+// Metadata returns the metadata of all of the parts of the message merged together. When parts have different
+// values for the same key, the value of the lowest part number is returned. Returns nil when no part has metadata.
+func (msgs MessageFragmentations) Metadata() map[string]string {
+	var result map[string]string
+
+	for _, info := range msgs.ordered() {
+		for key, value := range info.Metadata {
+			if result == nil {
+				result = map[string]string{}
+			}
+
+			if _, found := result[key]; !found {
+				result[key] = value
+			}
+		}
+	}
+
+	return result
+}
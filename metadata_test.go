@@ -0,0 +1,120 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestContextWithMetadata(t *testing.T) {
+	if metadata := udh.MetadataFromContext(context.Background()); metadata != nil {
+		t.Fatalf("expected no metadata, have %v", metadata)
+	}
+
+	original := map[string]string{udh.MetadataSMSC: "smsc-1", udh.MetadataBind: "bind-a"}
+	ctx := udh.ContextWithMetadata(context.Background(), original)
+	ctx = udh.ContextWithMetadata(ctx, map[string]string{udh.MetadataBind: "bind-b", udh.MetadataTraceID: "abc"})
+
+	original[udh.MetadataSMSC] = "modified"
+
+	expected := map[string]string{udh.MetadataSMSC: "smsc-1", udh.MetadataBind: "bind-b", udh.MetadataTraceID: "abc"}
+	if diff := cmp.Diff(expected, udh.MetadataFromContext(ctx)); diff != "" {
+		t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+	}
+}
+
+func TestMetadataPreserved(t *testing.T) {
+	dirStore, err := udh.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := map[string]udh.Store{
+		"memory": udh.NewMemoryStore(),
+		"dir":    dirStore,
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t2 *testing.T) {
+			messages := udh.InitMessages(udh.WithStore(store))
+
+			ctx := udh.ContextWithMetadata(context.Background(), map[string]string{
+				udh.MetadataSMSC: "smsc-1", udh.MetadataReceivedAt: "2026-10-17T10:00:00Z",
+			})
+
+			err := messages.AddContext(ctx, udh.ASCII, udh.Message("0500030A0202776F726C64"))
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			restored := udh.InitMessages(udh.WithStore(store))
+
+			err = restored.Restore()
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			ctx = udh.ContextWithMetadata(context.Background(), map[string]string{
+				udh.MetadataSMSC: "smsc-2", udh.MetadataReceivedAt: "2026-10-17T10:00:05Z", udh.MetadataBind: "bind-a",
+			})
+
+			err = restored.AddContext(ctx, udh.ASCII, udh.Message("0500030A020168656C6C6F20"))
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			fragments := restored.Snapshot([]byte{0x0A})
+			fragments.Sort()
+
+			expectedParts := []map[string]string{
+				{udh.MetadataSMSC: "smsc-2", udh.MetadataReceivedAt: "2026-10-17T10:00:05Z", udh.MetadataBind: "bind-a"},
+				{udh.MetadataSMSC: "smsc-1", udh.MetadataReceivedAt: "2026-10-17T10:00:00Z"},
+			}
+
+			for idx, info := range fragments {
+				if diff := cmp.Diff(expectedParts[idx], info.Metadata); diff != "" {
+					t2.Errorf("unexpected metadata of part %d (-want +got):\n%s", info.CurrentPart, diff)
+				}
+			}
+
+			assembled, err := udh.NewAssembledMessage(fragments)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if diff := cmp.Diff(expectedParts[0], assembled.Metadata); diff != "" {
+				t2.Errorf("unexpected assembled metadata (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMetadataKeepsFragmentValues(t *testing.T) {
+	messages := udh.InitMessages()
+
+	info, err := udh.Message("0500030B010161").ParseElements(udh.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info.Metadata = map[string]string{udh.MetadataBind: "own"}
+
+	ctx := udh.ContextWithMetadata(context.Background(),
+		map[string]string{udh.MetadataBind: "context", udh.MetadataSMSC: "smsc-1"})
+
+	err = messages.AddMessageElementsContext(ctx, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{udh.MetadataBind: "own", udh.MetadataSMSC: "smsc-1"}
+	if diff := cmp.Diff(expected, messages.Snapshot([]byte{0x0B}).Metadata()); diff != "" {
+		t.Errorf("unexpected metadata (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,100 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// MetricsHooks receives the activity of a Messages container, for feeding a metrics system.
+//
+// The hooks are called while the container is locked, so they must be fast and must not call the container.
+// Embed NopMetrics for implementing only some of the hooks.
+type MetricsHooks interface {
+	// FragmentReceived is called for every fragment added to the container
+	FragmentReceived(encoding Encoding)
+
+	// MessageCompleted is called when a message has all of its fragments
+	MessageCompleted(encoding Encoding)
+
+	// ParseError is called when Add or Parse fail to parse a message
+	ParseError(err error)
+
+	// Evicted is called with the number of messages removed by EvictExpired, when it is not zero
+	Evicted(count int)
+}
+
+// ParseMetricsHooks is implemented by MetricsHooks that count the parsed messages by their encoding and by the
+// information elements of their UDH, for seeing which charsets and header types carriers actually send.
+//
+// Unlike the hooks of MetricsHooks, MessageParsed is called before the container is locked, and may be called
+// concurrently.
+type ParseMetricsHooks interface {
+	// MessageParsed is called for every message parsed by Add or Parse, with the IEIs of its UDH in order of
+	// appearance, empty for a message without a UDH
+	MessageParsed(encoding Encoding, ieis []byte)
+}
+
+// IncompleteLister is implemented by the containers of fragments, Messages and ShardedMessages.
+type IncompleteLister interface {
+	// Incomplete returns the messages that are still waiting for fragments
+	Incomplete() []IncompleteMessage
+}
+
+// ContainerMetricsHooks is implemented by MetricsHooks that report the state of the container they are set to,
+// such as the number of incomplete messages.
+//
+// Observe is called once the container is created: by InitMessages with the Messages, and by NewShardedMessages
+// with the ShardedMessages, so the state covers every shard.
+type ContainerMetricsHooks interface {
+	Observe(container IncompleteLister)
+}
+
+// NopMetrics is a MetricsHooks and a ParseMetricsHooks that ignores everything.
+type NopMetrics struct{}
+
+// FragmentReceived implements MetricsHooks.
+func (NopMetrics) FragmentReceived(Encoding) {}
+
+// MessageCompleted implements MetricsHooks.
+func (NopMetrics) MessageCompleted(Encoding) {}
+
+// ParseError implements MetricsHooks.
+func (NopMetrics) ParseError(error) {}
+
+// Evicted implements MetricsHooks.
+func (NopMetrics) Evicted(int) {}
+
+// MessageParsed implements ParseMetricsHooks.
+func (NopMetrics) MessageParsed(Encoding, []byte) {}
+
+// WithMetrics adds hooks that receive the container activity. It can be used more than once, for feeding several
+// metrics systems. Hooks that implement ParseMetricsHooks also receive every parsed message.
+func WithMetrics(hooks MetricsHooks) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.metrics = append(msgs.metrics, hooks)
+	}
+}
+
+// parseError reports a parsing failure to the metrics hooks.
+func (msgs *Messages) parseError(err error) {
+	for _, hooks := range msgs.metrics {
+		hooks.ParseError(err)
+	}
+}
+
+// observe hands container over to the metrics hooks that implement ContainerMetricsHooks.
+func (msgs *Messages) observe(container IncompleteLister) {
+	for _, hooks := range msgs.metrics {
+		if containerHooks, ok := hooks.(ContainerMetricsHooks); ok {
+			containerHooks.Observe(container)
+		}
+	}
+}
+
+// messageParsed reports a parsed message to the metrics hooks that implement ParseMetricsHooks.
+func (msgs *Messages) messageParsed(info *MessageElements) {
+	for _, hooks := range msgs.metrics {
+		if parseHooks, ok := hooks.(ParseMetricsHooks); ok {
+			parseHooks.MessageParsed(info.Encoding, info.ieis)
+		}
+	}
+}
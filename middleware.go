@@ -0,0 +1,79 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+)
+
+// Middleware runs on each fragment added to Messages, before it is stored. It may modify elements for enrichment,
+// or return an error to reject the fragment, in which case the error is returned by Add, AddContext or
+// AddMessageElements. Return ErrRejected (possibly wrapped) for filtering fragments out on purpose.
+type Middleware func(ctx context.Context, elements *MessageElements) error
+
+// AssembledMiddleware runs on each message assembled by Messages.Assemble, before it is delivered. It may modify
+// message, or return an error to reject it. Return ErrRejected (possibly wrapped) for filtering messages out on
+// purpose.
+type AssembledMiddleware func(ctx context.Context, message *AssembledMessage) error
+
+// WithMiddleware appends middleware that runs on each fragment added to Messages, in the order given.
+// The chain stops at the first middleware returning an error.
+func WithMiddleware(middleware ...Middleware) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.middleware = append(msgs.middleware, middleware...)
+	}
+}
+
+// WithAssembledMiddleware appends middleware that runs on each message assembled by Messages.Assemble, in the
+// order given. The chain stops at the first middleware returning an error.
+func WithAssembledMiddleware(middleware ...AssembledMiddleware) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.assembledMiddleware = append(msgs.assembledMiddleware, middleware...)
+	}
+}
+
+// Assemble returns the AssembledMessage of fragments using NewAssembledMessage, and runs the middleware set by
+// WithAssembledMiddleware on it.
+// Returns the error of NewAssembledMessage, or the error of the middleware that rejected the message.
+func (msgs *Messages) Assemble(
+	ctx context.Context, fragments MessageFragmentations, options ...AssembleOption,
+) (AssembledMessage, error) {
+	assembled, err := NewAssembledMessage(fragments, options...)
+	if err != nil {
+		return AssembledMessage{}, err
+	}
+
+	for _, middleware := range msgs.assembledMiddleware {
+		err = middleware(ctx, &assembled)
+		if err != nil {
+			msgs.debug("message rejected by middleware",
+				slog.String("reference", assembled.Reference), slog.Any("error", err),
+			)
+
+			return AssembledMessage{}, fmt.Errorf("%w", err)
+		}
+	}
+
+	return assembled, nil
+}
+
+// runMiddleware runs the middleware set by WithMiddleware on info.
+func (msgs *Messages) runMiddleware(ctx context.Context, info *MessageElements) error {
+	for _, middleware := range msgs.middleware {
+		err := middleware(ctx, info)
+		if err != nil {
+			msgs.debug("fragment rejected by middleware",
+				slog.String("reference", hex.EncodeToString(info.Reference)), slog.Any("error", err),
+			)
+
+			return fmt.Errorf("%w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestMessagesMiddleware(t *testing.T) {
+	var order []string
+
+	messages := udh.InitMessages(
+		udh.WithMiddleware(
+			func(_ context.Context, elements *udh.MessageElements) error {
+				order = append(order, "first")
+
+				if elements.Reference[0] == 0xB7 {
+					return udh.ErrRejected
+				}
+
+				return nil
+			},
+			func(_ context.Context, elements *udh.MessageElements) error {
+				order = append(order, "second")
+				elements.Message = strings.ToUpper(elements.Message)
+
+				return nil
+			},
+		),
+	)
+
+	err := messages.Add(udh.ASCII, udh.Message("050003B70502002005E905DC"))
+	if !errors.Is(err, udh.ErrRejected) {
+		t.Errorf("have error %v, expected ErrRejected", err)
+	}
+
+	if messages.Snapshot([]byte{0xB7}) != nil {
+		t.Error("rejected fragment was stored")
+	}
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50201546869732069732061206C"),
+		udh.Message("050003A5020265722074657374696E67"),
+	} {
+		err = messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectedOrder := []string{"first", "first", "second", "first", "second"}
+	if !cmp.Equal(order, expectedOrder) {
+		t.Errorf("have order %v, expected %v", order, expectedOrder)
+	}
+
+	fragments := messages.Snapshot([]byte{0xA5})
+	if text := fragments.String(); text != "THIS IS A LER TESTING" {
+		t.Errorf("have text %q", text)
+	}
+}
+
+func TestMessagesAssemble(t *testing.T) {
+	messages := udh.InitMessages(
+		udh.WithAssembledMiddleware(func(_ context.Context, message *udh.AssembledMessage) error {
+			if strings.Contains(message.Text, "spam") {
+				return udh.ErrRejected
+			}
+
+			message.Text = strings.TrimSpace(message.Text)
+
+			return nil
+		}),
+	)
+
+	tests := []struct {
+		name     string
+		message  udh.Message
+		expected string
+		err      error
+	}{
+		{name: "enriched", message: udh.Message("05000313010120546578742020"), expected: "Text"},
+		{name: "rejected", message: udh.Message("050003140101207370616D"), err: udh.ErrRejected},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info, err := test.message.ParseElements(udh.ASCII)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assembled, err := messages.Assemble(t.Context(), udh.MessageFragmentations{info})
+			if !errors.Is(err, test.err) {
+				t.Fatalf("have error %v, expected %v", err, test.err)
+			}
+
+			if assembled.Text != test.expected {
+				t.Errorf("have text %q, expected %q", assembled.Text, test.expected)
+			}
+		})
+	}
+}
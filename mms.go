@@ -0,0 +1,231 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"time"
+)
+
+// MMS header fields of OMA-MMS-ENC, with the high bit set as they appear on the wire.
+const (
+	mmsContentLocation byte = 0x83
+	mmsExpiry          byte = 0x88
+	mmsFrom            byte = 0x89
+	mmsMessageClass    byte = 0x8A
+	mmsMessageType     byte = 0x8C
+	mmsVersion         byte = 0x8D
+	mmsMessageSize     byte = 0x8E
+	mmsSubject         byte = 0x96
+	mmsTransactionID   byte = 0x98
+)
+
+// mmsNotificationInd is the X-Mms-Message-Type value of m-notification-ind.
+const mmsNotificationInd byte = 0x82
+
+// mmsMessageClasses holds the names of the X-Mms-Message-Class tokens.
+var mmsMessageClasses = map[byte]string{
+	0x80: "personal",
+	0x81: "advertisement",
+	0x82: "informational",
+	0x83: "auto",
+}
+
+// MMSNotification holds the fields of an MMS m-notification-ind, which tells the handset where to fetch a
+// multimedia message from.
+type MMSNotification struct {
+	// X-Mms-Transaction-Id
+	TransactionID string `json:"transaction_id"`
+
+	// X-Mms-MMS-Version, for example 1.2
+	Version string `json:"version"`
+
+	// Address of the sender, empty when hidden
+	From string `json:"from,omitempty"`
+
+	// Subject of the message
+	Subject string `json:"subject,omitempty"`
+
+	// X-Mms-Message-Class, for example personal
+	Class string `json:"class,omitempty"`
+
+	// Size of the message in octets
+	Size uint64 `json:"size"`
+
+	// Absolute expiry time, set when the notification carries an absolute expiry
+	Expiry time.Time `json:"expiry,omitzero"`
+
+	// Relative expiry, set when the notification carries an expiry relative to its arrival
+	ExpiryAfter time.Duration `json:"expiry_after,omitempty"`
+
+	// URL to fetch the message from
+	ContentLocation string `json:"content_location"`
+}
+
+// ParseMMSNotification parses an MMS m-notification-ind from data, which is either the full WAP push PDU or only
+// the MMS PDU it carries.
+// Header fields other than the ones held by MMSNotification are skipped.
+// Returns ErrInvalidContent if data is not a well formed m-notification-ind.
+func ParseMMSNotification(data []byte) (*MMSNotification, error) {
+	if len(data) > 1 && data[1] == wspPushPDU {
+		push, err := ParseWAPPush(data)
+		if err != nil {
+			return nil, err
+		}
+
+		data = push.Body
+	}
+
+	reader := &wspReader{data: data}
+	notification := &MMSNotification{}
+
+	field, err := reader.readOctet()
+	if err != nil {
+		return nil, err
+	}
+
+	messageType, err := reader.readOctet()
+	if err != nil {
+		return nil, err
+	}
+
+	if field != mmsMessageType || messageType != mmsNotificationInd {
+		return nil, fmt.Errorf("%w: not an m-notification-ind", ErrInvalidContent)
+	}
+
+	for !reader.done() {
+		field, err = reader.readOctet()
+		if err != nil {
+			return nil, err
+		}
+
+		err = notification.readField(reader, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if notification.ContentLocation == "" {
+		return nil, fmt.Errorf("%w: missing X-Mms-Content-Location", ErrInvalidContent)
+	}
+
+	return notification, nil
+}
+
+// readField reads the value of a single header field into the notification.
+func (notification *MMSNotification) readField(reader *wspReader, field byte) error {
+	var err error
+
+	switch field {
+	case mmsTransactionID:
+		notification.TransactionID, err = reader.textString()
+
+	case mmsContentLocation:
+		notification.ContentLocation, err = reader.textString()
+
+	case mmsSubject:
+		notification.Subject, err = reader.encodedString()
+
+	case mmsMessageSize:
+		notification.Size, err = reader.integer()
+
+	case mmsVersion:
+		var version uint64
+
+		version, err = reader.integer()
+		notification.Version = fmt.Sprintf("%d.%d", version>>4&0x07, version&0x0F)
+
+	case mmsMessageClass:
+		var class byte
+
+		if class, err = reader.peek(); err == nil && class&0x80 != 0 {
+			reader.offset++
+			notification.Class = mmsMessageClasses[class]
+		} else {
+			notification.Class, err = reader.textString()
+		}
+
+	case mmsFrom:
+		err = notification.readFrom(reader)
+
+	case mmsExpiry:
+		err = notification.readExpiry(reader)
+
+	default:
+		if field&0x80 == 0 {
+			// an application header, made of a text name followed by a text value
+			reader.offset--
+			if _, err = reader.textString(); err != nil {
+				return err
+			}
+
+			_, err = reader.textString()
+			return err
+		}
+
+		err = reader.skipValue()
+	}
+
+	return err
+}
+
+// readFrom reads the From field: a value length followed by an address present token and the address, or an
+// insert address token when the sender is hidden.
+func (notification *MMSNotification) readFrom(reader *wspReader) error {
+	length, err := reader.valueLength()
+	if err != nil {
+		return err
+	}
+
+	value, err := reader.next(length)
+	if err != nil {
+		return err
+	}
+
+	if len(value) == 0 || value[0] != 0x80 {
+		return nil
+	}
+
+	notification.From, err = (&wspReader{data: value[1:]}).encodedString()
+
+	return err
+}
+
+// readExpiry reads the X-Mms-Expiry field: a value length followed by an absolute or relative token and the time
+// in seconds.
+func (notification *MMSNotification) readExpiry(reader *wspReader) error {
+	length, err := reader.valueLength()
+	if err != nil {
+		return err
+	}
+
+	value, err := reader.next(length)
+	if err != nil {
+		return err
+	}
+
+	inner := &wspReader{data: value}
+
+	token, err := inner.readOctet()
+	if err != nil {
+		return err
+	}
+
+	seconds, err := inner.integer()
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case 0x80:
+		notification.Expiry = time.Unix(int64(seconds), 0).UTC()
+	case 0x81:
+		notification.ExpiryAfter = time.Duration(seconds) * time.Second
+	default:
+		return fmt.Errorf("%w: invalid expiry token 0x%02X", ErrInvalidContent, token)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+// mmsNotificationPush builds a WAP push of an m-notification-ind, with the given expiry field value.
+func mmsNotificationPush(expiry ...byte) []byte {
+	from := append([]byte{0x80}, "+15551234/TYPE=PLMN\x00"...)
+
+	body := []byte{0x8C, 0x82}
+	body = append(body, 0x98)
+	body = append(body, "T123\x00"...)
+	body = append(body, 0x8D, 0x92)
+	body = append(body, 0x89, byte(len(from)))
+	body = append(body, from...)
+	body = append(body, 0x96)
+	body = append(body, "Hello\x00"...)
+	body = append(body, 0x8A, 0x80)
+	body = append(body, 0x86, 0x81) // X-Mms-Delivery-Report, skipped
+	body = append(body, 0x8E, 0x03, 0x01, 0xE2, 0x40)
+	body = append(body, 0x88)
+	body = append(body, expiry...)
+	body = append(body, 0x83)
+	body = append(body, "http://mms.example.com/abc\x00"...)
+
+	// transaction id, push PDU, headers length, content type and X-Wap-Application-Id
+	push := []byte{0x01, 0x06, 0x03, 0xBE, 0xAF, 0x84}
+
+	return append(push, body...)
+}
+
+func TestParseWAPPush(t *testing.T) {
+	push, err := udh.ParseWAPPush(mmsNotificationPush(0x05, 0x81, 0x03, 0x09, 0x3A, 0x80))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if push.TransactionID != 0x01 || push.ContentType != "application/vnd.wap.mms-message" {
+		t.Errorf("unexpected push: %+v", push)
+	}
+
+	if diff := cmp.Diff([]byte{0xAF, 0x84}, push.Headers); diff != "" {
+		t.Errorf("headers mismatch (-expected +have):\n%s", diff)
+	}
+
+	_, err = udh.ParseWAPPush([]byte{0x01, 0x07, 0x00})
+	if !errors.Is(err, udh.ErrInvalidContent) {
+		t.Errorf("have err %v, expected %v", err, udh.ErrInvalidContent)
+	}
+}
+
+func TestParseMMSNotification(t *testing.T) {
+	expected := &udh.MMSNotification{
+		TransactionID:   "T123",
+		Version:         "1.2",
+		From:            "+15551234/TYPE=PLMN",
+		Subject:         "Hello",
+		Class:           "personal",
+		Size:            123456,
+		ContentLocation: "http://mms.example.com/abc",
+	}
+
+	tests := []struct {
+		name   string
+		expiry []byte
+		update func(notification *udh.MMSNotification)
+	}{
+		{
+			name:   "relative expiry",
+			expiry: []byte{0x05, 0x81, 0x03, 0x09, 0x3A, 0x80},
+			update: func(notification *udh.MMSNotification) { notification.ExpiryAfter = 7 * 24 * time.Hour },
+		},
+		{
+			name:   "absolute expiry",
+			expiry: []byte{0x06, 0x80, 0x04, 0x65, 0xB9, 0xD3, 0xD0},
+			update: func(notification *udh.MMSNotification) {
+				notification.Expiry = time.Date(2024, time.January, 31, 5, 0, 0, 0, time.UTC)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		notification, err := udh.ParseMMSNotification(mmsNotificationPush(test.expiry...))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		want := *expected
+		test.update(&want)
+
+		if diff := cmp.Diff(&want, notification); diff != "" {
+			t.Errorf("%s: mismatch (-expected +have):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestParseMMSNotificationErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "empty", input: nil},
+		{name: "not a notification", input: []byte{0x8C, 0x80, 0x83, 'x', 0x00}},
+		{name: "missing content location", input: []byte{0x8C, 0x82, 0x98, 'T', 0x00}},
+		{name: "unterminated text", input: []byte{0x8C, 0x82, 0x83, 'h', 't'}},
+		{name: "value exceeds data", input: []byte{0x8C, 0x82, 0x88, 0x10, 0x81}},
+	}
+
+	for _, test := range tests {
+		_, err := udh.ParseMMSNotification(test.input)
+		if !errors.Is(err, udh.ErrInvalidContent) {
+			t.Errorf("%s: have err %v, expected %v", test.name, err, udh.ErrInvalidContent)
+		}
+	}
+}
+
+func TestDetectContentTypeWAPPush(t *testing.T) {
+	payload := mmsNotificationPush(0x05, 0x81, 0x03, 0x09, 0x3A, 0x80)
+
+	contentType := udh.DetectContentType(&udh.Ports{Destination: udh.PortWAPPush, Source: 9200}, payload)
+	if contentType != udh.ContentTypeMMSNotification {
+		t.Fatalf("have %q, expected %q", contentType, udh.ContentTypeMMSNotification)
+	}
+
+	content, err := udh.ParseContent(contentType, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if notification, ok := content.(*udh.MMSNotification); !ok || notification.TransactionID != "T123" {
+		t.Errorf("unexpected content: %#v", content)
+	}
+}
@@ -0,0 +1,272 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// namespacePrefix starts the keys of messages that belong to a namespace. Keys of the default namespace are made
+// of the reference alone, so they are at most 2 bytes long, or followed by a NUL byte and a decimal generation once
+// retired, which cannot start with this prefix followed by a slash.
+const namespacePrefix = "\x00\x00\x00/"
+
+// namespaceKey is the context key of the namespace.
+type namespaceKey struct{}
+
+// NamespaceStats holds the counters of a single namespace.
+type NamespaceStats struct {
+	// Number of messages waiting for fragments, or complete and not drained yet
+	Pending int `json:"pending"`
+
+	// Number of fragments added
+	Fragments uint64 `json:"fragments"`
+
+	// Number of messages that received all of their fragments
+	Completed uint64 `json:"completed"`
+
+	// Number of fragments that failed to be added, including the ones over the quota
+	Rejected uint64 `json:"rejected"`
+
+	// Number of fragments ignored by WithDeduplication as retransmissions
+	Retransmissions uint64 `json:"retransmissions"`
+}
+
+// Namespace is a view of a single namespace inside Messages, such as the messages of one SMPP bind or customer.
+//
+// Every namespace has its own reference space, so the same reference used by two namespaces belongs to two
+// different messages. Methods of Messages that are not scoped to a reference, such as Complete, DrainComplete and
+// EvictExpired, cover all of the namespaces.
+type Namespace struct {
+	msgs *Messages
+	name string
+}
+
+// WithNamespaceQuota limits the number of pending messages of a namespace, "" being the default namespace. A
+// fragment that starts a new message once the quota is reached is rejected with an error wrapping
+// ErrQuotaExceeded.
+func WithNamespaceQuota(namespace string, maxPending int) MessagesOption {
+	return func(msgs *Messages) {
+		if msgs.quotas == nil {
+			msgs.quotas = map[string]int{}
+		}
+
+		msgs.quotas[namespace] = maxPending
+	}
+}
+
+// ContextWithNamespace returns a copy of ctx adding fragments to namespace, when given to AddContext or
+// AddMessageElementsContext. The name must not contain NUL bytes.
+func ContextWithNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// NamespaceFromContext returns the namespace attached to ctx using ContextWithNamespace, or "" for the default
+// namespace.
+func NamespaceFromContext(ctx context.Context) string {
+	namespace, _ := ctx.Value(namespaceKey{}).(string)
+	return namespace
+}
+
+// Namespace returns the view of a namespace, "" being the default namespace. The name must not contain NUL bytes.
+func (msgs *Messages) Namespace(name string) Namespace {
+	return Namespace{msgs: msgs, name: name}
+}
+
+// Namespaces returns the names of the namespaces that have pending messages or counters, in ascending order.
+func (msgs *Messages) Namespaces() []string {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	names := map[string]struct{}{}
+
+	msgs.statsMtx.Lock()
+	for name := range msgs.namespaceStats {
+		names[name] = struct{}{}
+	}
+	msgs.statsMtx.Unlock()
+
+	for name := range msgs.pendingCounts {
+		names[name] = struct{}{}
+	}
+
+	return slices.Sorted(maps.Keys(names))
+}
+
+// Name returns the name of the namespace.
+func (ns Namespace) Name() string {
+	return ns.name
+}
+
+// Add is the same as Messages.Add, adding the fragment to the namespace.
+func (ns Namespace) Add(encoding Encoding, message Message) error {
+	return ns.AddContext(context.Background(), encoding, message)
+}
+
+// AddContext is the same as Messages.AddContext, adding the fragment to the namespace.
+func (ns Namespace) AddContext(ctx context.Context, encoding Encoding, message Message) error {
+	return ns.msgs.AddContext(ContextWithNamespace(ctx, ns.name), encoding, message)
+}
+
+// AddMessageElements is the same as Messages.AddMessageElements, adding the fragment to the namespace.
+func (ns Namespace) AddMessageElements(info *MessageElements) error {
+	return ns.msgs.AddMessageElementsContext(ContextWithNamespace(context.Background(), ns.name), info)
+}
+
+// Snapshot is the same as Messages.Snapshot, for a reference of the namespace.
+func (ns Namespace) Snapshot(reference []byte) MessageFragmentations {
+	ns.msgs.mtx.Lock()
+	defer ns.msgs.mtx.Unlock()
+
+	return ns.msgs.snapshot(ns.msgs.namespacedKey(ns.name, reference))
+}
+
+// Complete is the same as Messages.Complete, for the messages of the namespace.
+func (ns Namespace) Complete() []*MessageFragmentations {
+	return ns.msgs.collectComplete(ns.contains, false)
+}
+
+// DrainComplete is the same as Messages.DrainComplete, for the messages of the namespace.
+func (ns Namespace) DrainComplete() []*MessageFragmentations {
+	return ns.msgs.collectComplete(ns.contains, true)
+}
+
+// Stats returns the counters of the namespace.
+func (ns Namespace) Stats() NamespaceStats {
+	ns.msgs.mtx.Lock()
+	defer ns.msgs.mtx.Unlock()
+
+	pending := ns.msgs.pending(ns.name)
+
+	ns.msgs.statsMtx.Lock()
+	defer ns.msgs.statsMtx.Unlock()
+
+	stats := NamespaceStats{}
+
+	if counters, found := ns.msgs.namespaceStats[ns.name]; found {
+		stats = *counters
+	}
+
+	stats.Pending = pending
+
+	return stats
+}
+
+// contains reports whether the message of key belongs to the namespace.
+func (ns Namespace) contains(key string) bool {
+	return keyNamespace(key) == ns.name
+}
+
+// namespacedKey returns the key of a reference number of a namespace at the fragments map.
+func (msgs *Messages) namespacedKey(namespace string, reference []byte) string {
+	if namespace == "" {
+		return msgs.referenceKey(reference)
+	}
+
+	return namespacePrefix + namespace + "\x00" + msgs.referenceKey(reference)
+}
+
+// keyNamespace returns the namespace of a key of the fragments map.
+func keyNamespace(key string) string {
+	name, found := strings.CutPrefix(key, namespacePrefix)
+	if !found {
+		return ""
+	}
+
+	name, _, _ = strings.Cut(name, "\x00")
+
+	return name
+}
+
+// pending returns the number of messages of a namespace. The caller must hold the lock.
+func (msgs *Messages) pending(namespace string) int {
+	return msgs.pendingCounts[namespace]
+}
+
+// trackSet holds the message of key, counting it as a pending message of its namespace when it is new. The caller
+// must hold the lock.
+func (msgs *Messages) trackSet(key string, set *fragmentSet) {
+	if _, found := msgs.fragments[key]; !found {
+		if msgs.pendingCounts == nil {
+			msgs.pendingCounts = map[string]int{}
+		}
+
+		msgs.pendingCounts[keyNamespace(key)]++
+	}
+
+	msgs.fragments[key] = set
+}
+
+// untrackSet drops the message of key, no longer counting it as a pending message of its namespace. The caller
+// must hold the lock.
+func (msgs *Messages) untrackSet(key string) {
+	if _, found := msgs.fragments[key]; !found {
+		return
+	}
+
+	delete(msgs.fragments, key)
+
+	namespace := keyNamespace(key)
+
+	msgs.pendingCounts[namespace]--
+	if msgs.pendingCounts[namespace] == 0 {
+		delete(msgs.pendingCounts, namespace)
+	}
+}
+
+// checkQuota returns an error wrapping ErrQuotaExceeded if a new message would exceed the quota of namespace. The
+// caller must hold the lock.
+func (msgs *Messages) checkQuota(namespace string) error {
+	quota, found := msgs.quotas[namespace]
+	if !found || msgs.pending(namespace) < quota {
+		return nil
+	}
+
+	return fmt.Errorf("%w: namespace %q has %d pending messages", ErrQuotaExceeded, namespace, quota)
+}
+
+// countAttempt updates the counters of a namespace after an attempt to add a fragment.
+func (msgs *Messages) countAttempt(namespace string, outcome AuditOutcome) {
+	msgs.statsMtx.Lock()
+	defer msgs.statsMtx.Unlock()
+
+	counters := msgs.counters(namespace)
+
+	switch outcome {
+	case AuditAccepted, AuditDuplicate:
+		counters.Fragments++
+	case AuditRetransmission:
+		counters.Retransmissions++
+	case AuditRejected, AuditConflict:
+		counters.Rejected++
+	}
+}
+
+// countCompleted updates the counters of a namespace after a message received all of its fragments.
+func (msgs *Messages) countCompleted(namespace string) {
+	msgs.statsMtx.Lock()
+	defer msgs.statsMtx.Unlock()
+
+	msgs.counters(namespace).Completed++
+}
+
+// counters returns the counters of a namespace, creating them when needed. The caller must hold statsMtx.
+func (msgs *Messages) counters(namespace string) *NamespaceStats {
+	if msgs.namespaceStats == nil {
+		msgs.namespaceStats = map[string]*NamespaceStats{}
+	}
+
+	counters, found := msgs.namespaceStats[namespace]
+	if !found {
+		counters = &NamespaceStats{}
+		msgs.namespaceStats[namespace] = counters
+	}
+
+	return counters
+}
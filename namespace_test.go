@@ -0,0 +1,176 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+func TestNamespaces(t *testing.T) {
+	messages := udh.InitMessages(udh.WithNamespaceQuota("bind-2", 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := messages.Watch(ctx)
+
+	bind1 := messages.Namespace("bind-1")
+	bind2 := messages.Namespace("bind-2")
+
+	tests := []struct {
+		name      string
+		namespace udh.Namespace
+		msg       udh.Message
+		err       error
+	}{
+		{name: "bind-1 part 1", namespace: bind1, msg: udh.Message("050003A50201546869732069732061206C")},
+		{name: "bind-2 same reference", namespace: bind2, msg: udh.Message("050003A50201546869732069732061206C")},
+		{
+			name: "bind-2 over quota", namespace: bind2, msg: udh.Message("050003B70502002005E905DC"),
+			err: udh.ErrQuotaExceeded,
+		},
+		{name: "bind-1 part 2", namespace: bind1, msg: udh.Message("050003A5020265722074657374696E67")},
+		{name: "default", namespace: messages.Namespace(""), msg: udh.Message("050003B70502002005E905DC")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			err := test.namespace.Add(udh.ASCII, test.msg)
+			if !errors.Is(err, test.err) {
+				t2.Errorf("have error %v, expected %v", err, test.err)
+			}
+		})
+	}
+
+	if snapshot := bind2.Snapshot([]byte{0xA5}); len(snapshot) != 1 {
+		t.Errorf("have %d fragments of bind-2, expected 1", len(snapshot))
+	}
+
+	if complete := bind2.DrainComplete(); len(complete) != 0 {
+		t.Errorf("have %d complete messages at bind-2, expected none", len(complete))
+	}
+
+	complete := bind1.DrainComplete()
+	if len(complete) != 1 || len(*complete[0]) != 2 {
+		t.Fatalf("have %v complete messages at bind-1, expected a single message of 2 fragments", complete)
+	}
+
+	if diff := cmp.Diff([]string{"", "bind-1", "bind-2"}, messages.Namespaces()); diff != "" {
+		t.Errorf("namespaces diff: %s", diff)
+	}
+
+	expectedStats := map[string]udh.NamespaceStats{
+		"bind-1": {Pending: 0, Fragments: 2, Completed: 1},
+		"bind-2": {Pending: 1, Fragments: 1, Rejected: 1},
+		"":       {Pending: 1, Fragments: 1},
+	}
+
+	for name, expected := range expectedStats {
+		if diff := cmp.Diff(expected, messages.Namespace(name).Stats()); diff != "" {
+			t.Errorf("stats of %q diff: %s", name, diff)
+		}
+	}
+
+	cancel()
+
+	namespaces := []string{}
+	for event := range events {
+		if event.Type == udh.FragmentAdded {
+			namespaces = append(namespaces, event.Namespace)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"bind-1", "bind-2", "bind-1", ""}, namespaces); diff != "" {
+		t.Errorf("event namespaces diff: %s", diff)
+	}
+}
+
+func TestNamespacePendingCount(t *testing.T) {
+	store := udh.NewMemoryStore()
+	clock := smudhtest.NewFakeClock(time.Now())
+	messages := udh.InitMessages(
+		udh.WithStore(store), udh.WithClock(clock), udh.WithTTL(time.Minute), udh.WithNamespaceQuota("bind", 2),
+	)
+	bind := messages.Namespace("bind")
+
+	steps := []struct {
+		name    string
+		action  func() error
+		err     error
+		pending int
+	}{
+		{
+			name:    "first message",
+			action:  func() error { return bind.Add(udh.ASCII, udh.Message("050003A50201546869732069732061206C")) },
+			pending: 1,
+		},
+		{
+			name:    "second part of the first message",
+			action:  func() error { return bind.Add(udh.ASCII, udh.Message("050003A5020265722074657374696E67")) },
+			pending: 1,
+		},
+		{
+			name:    "second message",
+			action:  func() error { return bind.Add(udh.ASCII, udh.Message("050003B70502002005E905DC")) },
+			pending: 2,
+		},
+		{
+			name:    "over quota",
+			action:  func() error { return bind.Add(udh.ASCII, udh.Message("050003C70502002005E905DC")) },
+			err:     udh.ErrQuotaExceeded,
+			pending: 2,
+		},
+		{
+			name: "drained",
+			action: func() error {
+				bind.DrainComplete()
+				return nil
+			},
+			pending: 1,
+		},
+		{
+			name: "restored",
+			action: func() error {
+				return messages.Restore()
+			},
+			pending: 1,
+		},
+		{
+			name: "evicted",
+			action: func() error {
+				clock.Advance(2 * time.Minute)
+				messages.EvictExpired()
+
+				return nil
+			},
+			pending: 0,
+		},
+		{
+			name:    "accepted once evicted",
+			action:  func() error { return bind.Add(udh.ASCII, udh.Message("050003C70502002005E905DC")) },
+			pending: 1,
+		},
+	}
+
+	for _, step := range steps {
+		err := step.action()
+		if !errors.Is(err, step.err) {
+			t.Errorf("%s: have error %v, expected %v", step.name, err, step.err)
+		}
+
+		if pending := bind.Stats().Pending; pending != step.pending {
+			t.Errorf("%s: have %d pending messages, expected %d", step.name, pending, step.pending)
+		}
+	}
+
+	if diff := cmp.Diff([]string{"bind"}, messages.Namespaces()); diff != "" {
+		t.Errorf("namespaces diff: %s", diff)
+	}
+}
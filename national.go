@@ -0,0 +1,22 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "github.com/ik5/smudh/charset"
+
+// NationalLanguage is a national language identifier of 3GPP TS 23.038, used as the value of the national
+// language single shift (0x24) and locking shift (0x25) IEs. It is the same type as charset.NationalLanguage.
+type NationalLanguage = charset.NationalLanguage
+
+const (
+	// DefaultLanguage selects the GSM 03.38 default alphabet or extension table, and adds no IE
+	DefaultLanguage = charset.DefaultLanguage
+
+	// Turkish national language tables
+	Turkish = charset.Turkish
+
+	// Spanish national language tables
+	Spanish = charset.Spanish
+)
@@ -0,0 +1,259 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestSegmentTextNationalShift(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding udh.Encoding
+		options  udh.SegmentOptions
+		header   []byte
+		payload  []byte
+		err      error
+	}{
+		{
+			name:     "turkish locking shift",
+			text:     "Şişli",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{LockingShift: udh.Turkish},
+			header:   []byte{0x03, 0x25, 0x01, 0x01},
+			payload:  []byte{0x1C, 0x69, 0x1D, 0x6C, 0x69},
+		},
+		{
+			name:     "turkish single shift",
+			text:     "Şişli",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{SingleShift: udh.Turkish},
+			header:   []byte{0x03, 0x24, 0x01, 0x01},
+			payload:  []byte{0x1B, 0x53, 0x69, 0x1B, 0x73, 0x6C, 0x69},
+		},
+		{
+			name:     "turkish locking and single shift",
+			text:     "İ€",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{LockingShift: udh.Turkish, SingleShift: udh.Turkish},
+			header:   []byte{0x06, 0x24, 0x01, 0x01, 0x25, 0x01, 0x01},
+			payload:  []byte{0x40, 0x04},
+		},
+		{
+			name:     "spanish single shift",
+			text:     "Canción",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{SingleShift: udh.Spanish},
+			header:   []byte{0x03, 0x24, 0x01, 0x02},
+			payload:  []byte{0x43, 0x61, 0x6E, 0x63, 0x69, 0x1B, 0x6F, 0x6E},
+		},
+		{
+			name:     "ignored for UCS2",
+			text:     "Ş",
+			encoding: udh.UCS2,
+			options:  udh.SegmentOptions{LockingShift: udh.Turkish},
+			header:   []byte{},
+			payload:  []byte{0x01, 0x5E},
+		},
+		{
+			name:     "not representable without the table",
+			text:     "Şişli",
+			encoding: udh.GSM,
+			err:      udh.ErrCharacterNotRepresentable,
+		},
+		{
+			name:     "unsupported locking shift",
+			text:     "Canción",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{LockingShift: udh.Spanish},
+			err:      udh.ErrUnsupportedNationalLanguage,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			segments, err := udh.SegmentText(test.text, test.encoding, test.options)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			if len(segments) != 1 {
+				t2.Fatalf("have %d segments, expected 1", len(segments))
+			}
+
+			if diff := cmp.Diff(test.header, segments[0].Header); diff != "" {
+				t2.Errorf("header mismatch (-expected +have):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(test.payload, segments[0].Payload); diff != "" {
+				t2.Errorf("payload mismatch (-expected +have):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSegmentTextNationalShiftCapacity(t *testing.T) {
+	options := udh.SegmentOptions{Reference: []byte{0x01}, LockingShift: udh.Turkish, SingleShift: udh.Turkish}
+
+	capacity, err := udh.SegmentCapacity(udh.GSM, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if capacity != 146 {
+		t.Errorf("have capacity %d, expected 146", capacity)
+	}
+
+	segments, err := udh.SegmentText(strings.Repeat("ş", 300), udh.GSM, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for idx, segment := range segments {
+		elements := segment.Hex()
+		data, err := elements.ParseUserData(udh.GSM)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, found := data.Element(udh.IEINationalLockingShift); !found {
+			t.Errorf("%d. missing locking shift IE", idx)
+		}
+
+		if _, found := data.Element(udh.IEINationalSingleShift); !found {
+			t.Errorf("%d. missing single shift IE", idx)
+		}
+	}
+
+	if len(segments) != 3 {
+		t.Errorf("have %d segments, expected 3", len(segments))
+	}
+}
+
+func TestSegmentTextNationalShiftRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		encoding udh.Encoding
+		options  udh.SegmentOptions
+	}{
+		{
+			name:     "ports only",
+			text:     "Hello [world]",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{Ports: &udh.Ports{Destination: 0x23F4, Source: 0x0000}},
+		},
+		{
+			name:     "turkish locking shift",
+			text:     "Şişli Çağrı ğşı",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{LockingShift: udh.Turkish},
+		},
+		{
+			name:     "turkish single shift",
+			text:     "Şişli ğşı {€}",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{SingleShift: udh.Turkish},
+		},
+		{
+			name:     "turkish locking and single shift",
+			text:     "İstanbul ğşı €",
+			encoding: udh.GSMExtended,
+			options:  udh.SegmentOptions{LockingShift: udh.Turkish, SingleShift: udh.Turkish},
+		},
+		{
+			name:     "spanish single shift",
+			text:     "Canción [ñandú] €",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{SingleShift: udh.Spanish},
+		},
+		{
+			name:     "ports and turkish locking shift",
+			text:     "ğşı",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{Ports: &udh.Ports{Destination: 0x1581}, LockingShift: udh.Turkish},
+		},
+	}
+
+	for _, test := range tests {
+		for _, parts := range []string{"single", "multipart"} {
+			t.Run(test.name+" "+parts, func(t2 *testing.T) {
+				text := test.text
+				if parts == "multipart" {
+					text = strings.Repeat(text+" ", 60)
+					test.options.Reference = []byte{0x5A}
+				}
+
+				segments, err := udh.SegmentText(text, test.encoding, test.options)
+				if err != nil {
+					t2.Fatal(err)
+				}
+
+				if parts == "single" && len(segments) != 1 || parts == "multipart" && len(segments) < 2 {
+					t2.Fatalf("have %d %s segments", len(segments), parts)
+				}
+
+				fragments := udh.MessageFragmentations{}
+				for _, segment := range segments {
+					info, err := segment.Hex().ParseElements(test.encoding)
+					if err != nil {
+						t2.Fatal(err)
+					}
+
+					if diff := cmp.Diff(test.options.Ports, info.Ports); diff != "" {
+						t2.Errorf("ports mismatch (-expected +have):\n%s", diff)
+					}
+
+					fragments = append(fragments, info)
+				}
+
+				if !fragments.HaveAllFragments() {
+					t2.Fatal("expected all fragments")
+				}
+
+				if result := fragments.String(); result != text {
+					t2.Errorf("have %q, expected %q", result, text)
+				}
+			})
+		}
+	}
+}
+
+func TestParseElementsUnsupportedNationalLanguage(t *testing.T) {
+	_, err := udh.Message("0325010961").ParseElements(udh.GSM)
+	if !errors.Is(err, udh.ErrUnsupportedNationalLanguage) {
+		t.Errorf("have err %v, expected %v", err, udh.ErrUnsupportedNationalLanguage)
+	}
+}
+
+func TestTransliterateNational(t *testing.T) {
+	result, replacements, err := udh.TransliterateNational("Şişli’de", udh.Turkish, udh.Turkish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != "Şişli'de" {
+		t.Errorf("have %q, expected %q", result, "Şişli'de")
+	}
+
+	if len(replacements) != 1 {
+		t.Errorf("have %d replacements, expected 1", len(replacements))
+	}
+
+	_, _, err = udh.TransliterateNational("", udh.Spanish, udh.DefaultLanguage)
+	if !errors.Is(err, udh.ErrUnsupportedNationalLanguage) {
+		t.Errorf("have err %v, expected %v", err, udh.ErrUnsupportedNationalLanguage)
+	}
+}
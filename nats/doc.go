@@ -0,0 +1,16 @@
+/*
+Package nats bridges NATS (or JetStream) subjects and a smudh Messages container: fragments are received from a
+subscription, and every completed message is published to an output subject as a JSON encoded
+smudh.AssembledMessage.
+
+The package does not depend on the NATS client. The Subscriber and Publisher interfaces can be implemented on top
+of a nats.go subscription or a JetStream consumer, where Ack maps to the JetStream acknowledgement.
+
+Each received message holds the hex encoded short_message as its data, and the SMPP data_coding value in a header
+(named "Data-Coding" by default, holding a decimal or 0x prefixed hexadecimal number).
+
+Persistence and expiry are configured on the Messages container itself, using smudh.WithStore and smudh.WithTTL,
+and WithJanitor evicts expired messages while Run is active. A message is acknowledged only after its fragment was
+accepted by Messages, and completed messages are removed from Messages only after they were published.
+*/
+package nats
@@ -0,0 +1,184 @@
+package nats
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ik5/smudh"
+	"github.com/ik5/smudh/internal/relay"
+)
+
+// DefaultDataCodingHeader is the name of the message header holding the data_coding value.
+const DefaultDataCodingHeader = "Data-Coding"
+
+// Msg is a single NATS message.
+type Msg struct {
+	Subject string
+	Data    []byte
+	Header  map[string][]string
+}
+
+// Subscriber receives the fragments.
+type Subscriber interface {
+	// Next blocks until the next message is available, or ctx is done
+	Next(ctx context.Context) (Msg, error)
+
+	// Ack acknowledges the message
+	Ack(ctx context.Context, msg Msg) error
+}
+
+// Publisher publishes completed messages.
+type Publisher interface {
+	// Publish sends the message, returning only after the publish was acknowledged
+	Publish(ctx context.Context, msg Msg) error
+}
+
+// Option configures a Bridge.
+type Option func(*Bridge)
+
+// WithDataCodingHeader sets the name of the message header holding the data_coding value.
+func WithDataCodingHeader(name string) Option {
+	return func(bridge *Bridge) {
+		bridge.dataCodingHeader = name
+	}
+}
+
+// WithInterfaceVersion sets the SMPP interface version used for mapping data_coding values. Defaults to SMPP 3.4.
+func WithInterfaceVersion(version smudh.InterfaceVersion) Option {
+	return func(bridge *Bridge) {
+		bridge.relay.Version = version
+	}
+}
+
+// WithJanitor runs the Messages janitor every interval while Run is active, evicting the messages that expired
+// according to smudh.WithTTL.
+func WithJanitor(interval time.Duration) Option {
+	return func(bridge *Bridge) {
+		bridge.janitorInterval = interval
+	}
+}
+
+// WithLogger sets the logger used for reporting messages that were skipped.
+func WithLogger(logger *slog.Logger) Option {
+	return func(bridge *Bridge) {
+		bridge.logger = logger
+	}
+}
+
+// Bridge moves fragments from a Subscriber into Messages, and completed messages from Messages into a Publisher.
+type Bridge struct {
+	subscriber       Subscriber
+	publisher        Publisher
+	relay            relay.Relay
+	outputSubject    string
+	dataCodingHeader string
+	janitorInterval  time.Duration
+	logger           *slog.Logger
+}
+
+// NewBridge returns a Bridge reading from subscriber, and publishing completed messages to outputSubject using
+// publisher.
+//
+// The Bridge should be the only writer of messages, since it publishes every complete message that messages holds.
+func NewBridge(
+	subscriber Subscriber, publisher Publisher, messages *smudh.Messages, outputSubject string, options ...Option,
+) *Bridge {
+	bridge := &Bridge{
+		subscriber:       subscriber,
+		publisher:        publisher,
+		relay:            relay.Relay{Messages: messages, Version: smudh.SMPP34},
+		outputSubject:    outputSubject,
+		dataCodingHeader: DefaultDataCodingHeader,
+	}
+
+	for _, option := range options {
+		option(bridge)
+	}
+
+	return bridge
+}
+
+// Run processes messages until ctx is done, a Subscriber, Publisher or Store operation fails, or Messages is
+// closed. Returns nil when stopped by ctx.
+//
+// Messages that cannot be parsed are logged and acknowledged, since processing them again would fail the same way.
+// Messages rejected by a rate limit of Messages are processed again once the limit allows it.
+func (bridge *Bridge) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	bridge.relay.Janitor(ctx, bridge.janitorInterval)
+
+	err := bridge.relay.Flush(ctx, bridge.deliver)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	for {
+		msg, err := bridge.subscriber.Next(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		err = bridge.Process(ctx, msg)
+		for relay.WaitRateLimit(ctx, err) {
+			err = bridge.Process(ctx, msg)
+		}
+
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+// Process handles a single message: the fragment is added to Messages, the message is acknowledged, and completed
+// messages are published.
+//
+// The message is not acknowledged when its fragment could be accepted later: the error is returned when it wraps
+// smudh.ErrStore, smudh.ErrClosed or smudh.ErrRateLimited, so the message is processed again, or redelivered by
+// JetStream.
+func (bridge *Bridge) Process(ctx context.Context, msg Msg) error {
+	var dataCoding string
+	if values := msg.Header[bridge.dataCodingHeader]; len(values) > 0 {
+		dataCoding = values[0]
+	}
+
+	err := bridge.relay.Add(dataCoding, msg.Data)
+	if relay.Retryable(err) {
+		return err
+	}
+
+	if err != nil && bridge.logger != nil {
+		bridge.logger.Warn("skipping message", slog.String("subject", msg.Subject), slog.Any("error", err))
+	}
+
+	err = bridge.subscriber.Ack(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return bridge.relay.Flush(ctx, bridge.deliver)
+}
+
+// deliver publishes a completed message to the output subject.
+func (bridge *Bridge) deliver(ctx context.Context, _ smudh.AssembledMessage, value []byte) error {
+	return bridge.publisher.Publish(ctx, Msg{
+		Subject: bridge.outputSubject,
+		Data:    value,
+		Header:  map[string][]string{"Content-Type": {"application/json"}},
+	})
+}
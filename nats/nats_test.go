@@ -0,0 +1,170 @@
+package nats_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/nats"
+)
+
+type fakeSubscriber struct {
+	msgs  []nats.Msg
+	acked []string
+}
+
+func (subscriber *fakeSubscriber) Next(ctx context.Context) (nats.Msg, error) {
+	if len(subscriber.msgs) == 0 {
+		<-ctx.Done()
+		return nats.Msg{}, ctx.Err()
+	}
+
+	msg := subscriber.msgs[0]
+	subscriber.msgs = subscriber.msgs[1:]
+
+	return msg, nil
+}
+
+func (subscriber *fakeSubscriber) Ack(_ context.Context, msg nats.Msg) error {
+	subscriber.acked = append(subscriber.acked, string(msg.Data))
+	return nil
+}
+
+type fakePublisher struct {
+	cancel    context.CancelFunc
+	published []udh.AssembledMessage
+	subjects  []string
+}
+
+func (publisher *fakePublisher) Publish(_ context.Context, msg nats.Msg) error {
+	var assembled udh.AssembledMessage
+
+	err := json.Unmarshal(msg.Data, &assembled)
+	if err != nil {
+		return err
+	}
+
+	publisher.subjects = append(publisher.subjects, msg.Subject)
+	publisher.published = append(publisher.published, assembled)
+	publisher.cancel()
+
+	return nil
+}
+
+func fragment(dataCoding, data string) nats.Msg {
+	return nats.Msg{
+		Subject: "sms.fragments",
+		Data:    []byte(data),
+		Header:  map[string][]string{nats.DefaultDataCodingHeader: {dataCoding}},
+	}
+}
+
+func TestBridgeRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	subscriber := &fakeSubscriber{msgs: []nats.Msg{
+		fragment("8", "0500030A02010068"),
+		fragment("1", "zz"),
+		fragment("8", "0500030A02020069"),
+	}}
+	publisher := &fakePublisher{cancel: cancel}
+	messages := udh.InitMessages(udh.WithTTL(time.Minute))
+
+	err := nats.NewBridge(subscriber, publisher, messages, "sms.assembled", nats.WithJanitor(time.Second)).Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"0500030A02010068", "zz", "0500030A02020069"}, subscriber.acked); diff != "" {
+		t.Errorf("unexpected acknowledgements (-want +got):\n%s", diff)
+	}
+
+	expected := []udh.AssembledMessage{{Reference: "0a", Encoding: udh.UCS2, Parts: 2, Text: "hi"}}
+	if diff := cmp.Diff(expected, publisher.published); diff != "" {
+		t.Errorf("unexpected published messages (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]string{"sms.assembled"}, publisher.subjects); diff != "" {
+		t.Errorf("unexpected subjects (-want +got):\n%s", diff)
+	}
+}
+
+func TestBridgeRetryableErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages func(t *testing.T) *udh.Messages
+		err      error
+	}{
+		{
+			name: "closed",
+			messages: func(t *testing.T) *udh.Messages {
+				messages := udh.InitMessages()
+
+				err := messages.Close(t.Context())
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return messages
+			},
+			err: udh.ErrClosed,
+		},
+		{
+			name: "rate limited",
+			messages: func(*testing.T) *udh.Messages {
+				return udh.InitMessages(udh.WithGlobalRateLimit(udh.RateLimit{Rate: 0.001, Burst: 0}))
+			},
+			err: udh.ErrRateLimited,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			subscriber := &fakeSubscriber{}
+			bridge := nats.NewBridge(subscriber, &fakePublisher{}, test.messages(t2), "sms.assembled")
+
+			err := bridge.Process(t2.Context(), fragment("8", "0500030A02010068"))
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have error %v, expected %v", err, test.err)
+			}
+
+			if len(subscriber.acked) != 0 {
+				t2.Errorf("have acknowledgements %v, expected none", subscriber.acked)
+			}
+		})
+	}
+}
+
+func TestBridgeRunRateLimited(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	subscriber := &fakeSubscriber{msgs: []nats.Msg{
+		fragment("8", "0500030A02010068"),
+		fragment("8", "0500030A02020069"),
+	}}
+	publisher := &fakePublisher{cancel: cancel}
+	messages := udh.InitMessages(udh.WithGlobalRateLimit(udh.RateLimit{Rate: 50, Burst: 1}))
+
+	err := nats.NewBridge(subscriber, publisher, messages, "sms.assembled").Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"0500030A02010068", "0500030A02020069"}, subscriber.acked); diff != "" {
+		t.Errorf("unexpected acknowledgements (-want +got):\n%s", diff)
+	}
+
+	if len(publisher.published) != 1 {
+		t.Errorf("have %d published messages, expected 1", len(publisher.published))
+	}
+}
@@ -0,0 +1,329 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Destination ports of Nokia Smart Messaging.
+const (
+	// PortRingingTone is the port of Nokia ringing tones
+	PortRingingTone uint16 = 5505
+
+	// PortOperatorLogo is the port of Nokia operator logos
+	PortOperatorLogo uint16 = 5506
+
+	// PortCLIIcon is the port of Nokia caller line identification icons
+	PortCLIIcon uint16 = 5507
+)
+
+// Nokia Smart Messaging commands and instructions of the ringing tone format.
+const (
+	ringingToneProgramming = 0x25
+	ringingToneSound       = 0x1D
+	ringingToneBasicSong   = 0x01
+
+	toneInstructionNote   = 0x01
+	toneInstructionScale  = 0x02
+	toneInstructionStyle  = 0x03
+	toneInstructionTempo  = 0x04
+	toneInstructionVolume = 0x05
+)
+
+// smartMessagingVersion is the optional version octet that starts newer operator logos and CLI icons.
+const smartMessagingVersion byte = '0'
+
+// toneNotes holds the names of the note values of the ringing tone format, "P" being a pause.
+var toneNotes = []string{"P", "C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+
+// toneTempos holds the beats per minute of the tempo values of the ringing tone format.
+var toneTempos = []int{
+	25, 28, 31, 35, 40, 45, 50, 56, 63, 70, 80, 90, 100, 112, 125, 140,
+	160, 180, 200, 225, 250, 285, 320, 355, 400, 450, 500, 565, 635, 715, 800, 900,
+}
+
+// toneSpecifiers holds the names of the duration specifiers of the ringing tone format.
+var toneSpecifiers = []string{"", "dotted", "double-dotted", "2/3"}
+
+// toneStyles holds the names of the styles of the ringing tone format.
+var toneStyles = []string{"natural", "continuous", "staccato", ""}
+
+// Bitmap is a monochrome OTA bitmap, as used by operator logos and CLI icons.
+type Bitmap struct {
+	// Width in pixels
+	Width int `json:"width"`
+
+	// Height in pixels
+	Height int `json:"height"`
+
+	// Pixels row by row, true for a black pixel
+	Pixels []bool `json:"pixels"`
+}
+
+// OperatorLogo is a Nokia operator logo, shown by the handset while it is registered at the given network.
+type OperatorLogo struct {
+	// Mobile Country Code of the network
+	MCC string `json:"mcc"`
+
+	// Mobile Network Code of the network
+	MNC string `json:"mnc"`
+
+	// The logo
+	Bitmap Bitmap `json:"bitmap"`
+}
+
+// ToneNote is a single note of a ringing tone.
+type ToneNote struct {
+	// Note name, from C to B with sharps, or P for a pause
+	Note string `json:"note"`
+
+	// Scale (octave) of the note, from 1 to 4, where A of scale 1 is 440Hz. Notes that precede the first scale
+	// instruction are at scale 2.
+	Scale int `json:"scale"`
+
+	// Duration as a fraction of a whole note: 1, 2, 4, 8, 16 or 32
+	Duration int `json:"duration"`
+
+	// Duration specifier: empty, dotted, double-dotted or 2/3
+	Specifier string `json:"specifier,omitempty"`
+
+	// Style of the note: natural, continuous or staccato
+	Style string `json:"style"`
+}
+
+// RingingTone is a Nokia ringing tone, in a form close to RTTTL.
+type RingingTone struct {
+	// Name of the tone
+	Title string `json:"title"`
+
+	// Beats per minute, as set by the first tempo instruction
+	Tempo int `json:"tempo"`
+
+	// Volume from 0 to 15, as set by the first volume instruction
+	Volume int `json:"volume"`
+
+	// The notes, in order, with repeated patterns listed once
+	Notes []ToneNote `json:"notes"`
+}
+
+// ParseOTABitmap parses an OTA bitmap: an info field, the width, the height and the depth octets, followed by the
+// pixels row by row, one bit per pixel.
+// Returns ErrInvalidContent if data is not a monochrome OTA bitmap.
+func ParseOTABitmap(data []byte) (*Bitmap, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("%w: OTA bitmap header is too short", ErrInvalidContent)
+	}
+
+	width, height, depth := int(data[1]), int(data[2]), data[3]
+	if depth != 1 {
+		return nil, fmt.Errorf("%w: unsupported OTA bitmap depth %d", ErrInvalidContent, depth)
+	}
+
+	pixels := width * height
+	if len(data)-4 < (pixels+7)/8 {
+		return nil, fmt.Errorf("%w: OTA bitmap data is too short", ErrInvalidContent)
+	}
+
+	bitmap := &Bitmap{Width: width, Height: height, Pixels: make([]bool, pixels)}
+	for idx := range bitmap.Pixels {
+		bitmap.Pixels[idx] = data[4+idx/8]&(0x80>>(idx%8)) != 0
+	}
+
+	return bitmap, nil
+}
+
+// ParseOperatorLogo parses a Nokia operator logo, as sent to port 5506: an optional version octet, the MCC and MNC
+// of the network in swapped BCD, a line feed, and an OTA bitmap.
+// Returns ErrInvalidContent if data is not an operator logo.
+func ParseOperatorLogo(data []byte) (*OperatorLogo, error) {
+	if len(data) > 0 && data[0] == smartMessagingVersion {
+		data = data[1:]
+	}
+
+	if len(data) < 4 || data[3] != '\n' {
+		return nil, fmt.Errorf("%w: operator logo header is invalid", ErrInvalidContent)
+	}
+
+	bitmap, err := ParseOTABitmap(data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &OperatorLogo{
+		MCC:    swappedBCD(data[:2]),
+		MNC:    swappedBCD(data[2:3]),
+		Bitmap: *bitmap,
+	}, nil
+}
+
+// ParseCLIIcon parses a Nokia CLI icon, as sent to port 5507: an optional version octet followed by an OTA
+// bitmap.
+// Returns ErrInvalidContent if data is not a CLI icon.
+func ParseCLIIcon(data []byte) (*Bitmap, error) {
+	if len(data) > 0 && data[0] == smartMessagingVersion {
+		data = data[1:]
+	}
+
+	return ParseOTABitmap(data)
+}
+
+// ParseRingingTone parses a Nokia ringing tone, as sent to port 5505.
+// Returns ErrInvalidContent if data is not a basic song ringing tone.
+func ParseRingingTone(data []byte) (*RingingTone, error) {
+	reader := &bitReader{data: data}
+
+	commands := reader.read(8)
+	programming := reader.read(7)
+	_ = reader.read(1) // filler
+	sound := reader.read(7)
+	songType := reader.read(3)
+
+	if reader.err != nil || commands < 2 || programming != ringingToneProgramming || sound != ringingToneSound {
+		return nil, fmt.Errorf("%w: not a ringing tone", ErrInvalidContent)
+	}
+
+	if songType != ringingToneBasicSong {
+		return nil, fmt.Errorf("%w: unsupported ringing tone song type %d", ErrInvalidContent, songType)
+	}
+
+	tone := &RingingTone{Tempo: toneTempos[8]}
+
+	title := make([]byte, reader.read(4))
+	for idx := range title {
+		title[idx] = byte(reader.read(8))
+	}
+
+	tone.Title = string(title)
+
+	err := tone.readPatterns(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return tone, nil
+}
+
+// readPatterns reads the song sequence of a basic song.
+func (tone *RingingTone) readPatterns(reader *bitReader) error {
+	scale, style := 2, toneStyles[0]
+	tempoSet, volumeSet := false, false
+
+	patterns := reader.read(8)
+
+	for range patterns {
+		_ = reader.read(3) // pattern header
+		_ = reader.read(2) // pattern id
+		_ = reader.read(4) // loop value
+		instructions := reader.read(8)
+
+		for range instructions {
+			switch reader.read(3) {
+			case toneInstructionNote:
+				note, duration, specifier := reader.read(4), reader.read(3), reader.read(2)
+				if note >= len(toneNotes) || duration > 5 {
+					return fmt.Errorf("%w: invalid ringing tone note", ErrInvalidContent)
+				}
+
+				tone.Notes = append(tone.Notes, ToneNote{
+					Note:      toneNotes[note],
+					Scale:     scale,
+					Duration:  1 << duration,
+					Specifier: toneSpecifiers[specifier],
+					Style:     style,
+				})
+			case toneInstructionScale:
+				scale = reader.read(2) + 1
+			case toneInstructionStyle:
+				style = toneStyles[reader.read(2)]
+			case toneInstructionTempo:
+				tempo := toneTempos[reader.read(5)]
+				if !tempoSet {
+					tone.Tempo, tempoSet = tempo, true
+				}
+			case toneInstructionVolume:
+				volume := reader.read(4)
+				if !volumeSet {
+					tone.Volume, volumeSet = volume, true
+				}
+			default:
+				return fmt.Errorf("%w: invalid ringing tone instruction", ErrInvalidContent)
+			}
+		}
+	}
+
+	if reader.err != nil {
+		return reader.err
+	}
+
+	return nil
+}
+
+// At reports whether the pixel at x, y is black.
+func (bitmap Bitmap) At(x, y int) bool {
+	if x < 0 || y < 0 || x >= bitmap.Width || y >= bitmap.Height {
+		return false
+	}
+
+	return bitmap.Pixels[y*bitmap.Width+x]
+}
+
+// Image returns the bitmap as a black and white image.
+func (bitmap Bitmap) Image() image.Image {
+	result := image.NewPaletted(image.Rect(0, 0, bitmap.Width, bitmap.Height), color.Palette{color.White, color.Black})
+
+	for idx, black := range bitmap.Pixels {
+		if black {
+			result.SetColorIndex(idx%bitmap.Width, idx/bitmap.Width, 1)
+		}
+	}
+
+	return result
+}
+
+// swappedBCD decodes semi-octets in swapped BCD, where 0xF marks a missing digit.
+func swappedBCD(data []byte) string {
+	digits := make([]byte, 0, len(data)*2)
+
+	for _, octet := range data {
+		for _, nibble := range []byte{octet & 0x0F, octet >> 4} {
+			if nibble <= 9 {
+				digits = append(digits, '0'+nibble)
+			}
+		}
+	}
+
+	return string(digits)
+}
+
+// bitReader reads big endian bit fields. Reading past the end sets err, and returns 0 from then on.
+type bitReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// read returns the next count bits.
+func (reader *bitReader) read(count int) int {
+	if reader.err != nil {
+		return 0
+	}
+
+	if reader.pos+count > len(reader.data)*8 {
+		reader.err = fmt.Errorf("%w: unexpected end of data", ErrInvalidContent)
+		return 0
+	}
+
+	result := 0
+	for range count {
+		bit := reader.data[reader.pos/8] >> (7 - reader.pos%8) & 1
+		result = result<<1 | int(bit)
+		reader.pos++
+	}
+
+	return result
+}
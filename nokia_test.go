@@ -0,0 +1,155 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"image/color"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+// bitWriter packs big endian bit fields, for building ringing tones.
+type bitWriter struct {
+	data []byte
+	bits int
+}
+
+func (writer *bitWriter) write(count int, value int) *bitWriter {
+	for idx := count - 1; idx >= 0; idx-- {
+		if writer.bits%8 == 0 {
+			writer.data = append(writer.data, 0)
+		}
+
+		if value>>idx&1 != 0 {
+			writer.data[len(writer.data)-1] |= 0x80 >> (writer.bits % 8)
+		}
+
+		writer.bits++
+	}
+
+	return writer
+}
+
+func TestParseOTABitmap(t *testing.T) {
+	bitmap, err := udh.ParseOTABitmap([]byte{0x00, 0x08, 0x02, 0x01, 0xAA, 0x55})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &udh.Bitmap{
+		Width:  8,
+		Height: 2,
+		Pixels: []bool{
+			true, false, true, false, true, false, true, false,
+			false, true, false, true, false, true, false, true,
+		},
+	}
+
+	if diff := cmp.Diff(expected, bitmap); diff != "" {
+		t.Errorf("mismatch (-expected +have):\n%s", diff)
+	}
+
+	if !bitmap.At(0, 0) || bitmap.At(1, 0) || !bitmap.At(1, 1) || bitmap.At(8, 0) {
+		t.Error("unexpected pixels returned by At")
+	}
+
+	if bitmap.Image().At(0, 0) != color.Black || bitmap.Image().At(1, 0) != color.White {
+		t.Error("unexpected pixels returned by Image")
+	}
+
+	for _, input := range [][]byte{{0x00, 0x08}, {0x00, 0x08, 0x02, 0x02, 0xAA, 0x55}, {0x00, 0x08, 0x02, 0x01, 0xAA}} {
+		_, err = udh.ParseOTABitmap(input)
+		if !errors.Is(err, udh.ErrInvalidContent) {
+			t.Errorf("% X: have err %v, expected %v", input, err, udh.ErrInvalidContent)
+		}
+	}
+}
+
+func TestParseOperatorLogo(t *testing.T) {
+	for _, prefix := range [][]byte{{}, {'0'}} {
+		data := append(prefix, 0x62, 0xF2, 0x10, '\n', 0x00, 0x08, 0x01, 0x01, 0xF0)
+
+		logo, err := udh.ParseOperatorLogo(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if logo.MCC != "262" || logo.MNC != "01" || logo.Bitmap.Width != 8 || !logo.Bitmap.At(3, 0) {
+			t.Errorf("unexpected logo: %+v", logo)
+		}
+	}
+
+	_, err := udh.ParseOperatorLogo([]byte{0x62, 0xF2, 0x10, 0x00})
+	if !errors.Is(err, udh.ErrInvalidContent) {
+		t.Errorf("have err %v, expected %v", err, udh.ErrInvalidContent)
+	}
+}
+
+func TestParseRingingTone(t *testing.T) {
+	writer := (&bitWriter{}).write(8, 2).write(7, 0x25).write(1, 0).write(7, 0x1D).write(3, 1)
+
+	writer.write(4, 3)
+	for _, ch := range "Hey" {
+		writer.write(8, int(ch))
+	}
+
+	writer.write(8, 1).write(3, 0).write(2, 0).write(4, 0).write(8, 8)
+	writer.write(3, 4).write(5, 11)                         // tempo 90
+	writer.write(3, 5).write(4, 10)                         // volume 10
+	writer.write(3, 3).write(2, 2)                          // staccato
+	writer.write(3, 2).write(2, 1)                          // scale 2
+	writer.write(3, 1).write(4, 5).write(3, 3).write(2, 1)  // dotted 1/8 E
+	writer.write(3, 1).write(4, 0).write(3, 2).write(2, 0)  // 1/4 pause
+	writer.write(3, 2).write(2, 2)                          // scale 3
+	writer.write(3, 1).write(4, 10).write(3, 2).write(2, 0) // 1/4 A
+
+	tone, err := udh.ParseRingingTone(writer.data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &udh.RingingTone{
+		Title:  "Hey",
+		Tempo:  90,
+		Volume: 10,
+		Notes: []udh.ToneNote{
+			{Note: "E", Scale: 2, Duration: 8, Specifier: "dotted", Style: "staccato"},
+			{Note: "P", Scale: 2, Duration: 4, Style: "staccato"},
+			{Note: "A", Scale: 3, Duration: 4, Style: "staccato"},
+		},
+	}
+
+	if diff := cmp.Diff(expected, tone); diff != "" {
+		t.Errorf("mismatch (-expected +have):\n%s", diff)
+	}
+
+	for _, input := range [][]byte{nil, {0x02, 0x4A}, {0x02, 0x4A, 0x3A, 0x40}, writer.data[:len(writer.data)-3]} {
+		_, err = udh.ParseRingingTone(input)
+		if !errors.Is(err, udh.ErrInvalidContent) {
+			t.Errorf("% X: have err %v, expected %v", input, err, udh.ErrInvalidContent)
+		}
+	}
+}
+
+func TestDetectContentTypeSmartMessaging(t *testing.T) {
+	data := []byte{0x00, 0x08, 0x01, 0x01, 0xFF}
+
+	contentType := udh.DetectContentType(&udh.Ports{Destination: udh.PortCLIIcon}, data)
+	if contentType != udh.ContentTypeCLIIcon {
+		t.Fatalf("have %q, expected %q", contentType, udh.ContentTypeCLIIcon)
+	}
+
+	content, err := udh.ParseContent(contentType, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if bitmap, ok := content.(*udh.Bitmap); !ok || bitmap.Width != 8 {
+		t.Errorf("unexpected content: %#v", content)
+	}
+}
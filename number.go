@@ -0,0 +1,165 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxE164Digits is the maximum number of digits of an E.164 number, including the country code.
+const maxE164Digits = 15
+
+// TON is the type of number of an address, as used by the addr_ton fields of SMPP and the type of address octet
+// of 3GPP TS 23.040.
+type TON byte
+
+// Types of number.
+const (
+	TONUnknown         TON = 0x00
+	TONInternational   TON = 0x01
+	TONNational        TON = 0x02
+	TONNetworkSpecific TON = 0x03
+	TONSubscriber      TON = 0x04
+	TONAlphanumeric    TON = 0x05
+	TONAbbreviated     TON = 0x06
+)
+
+// tonNames holds the names of the known types of number.
+var tonNames = map[TON]string{
+	TONUnknown:         "unknown",
+	TONInternational:   "international",
+	TONNational:        "national",
+	TONNetworkSpecific: "network specific",
+	TONSubscriber:      "subscriber",
+	TONAlphanumeric:    "alphanumeric",
+	TONAbbreviated:     "abbreviated",
+}
+
+// String returns the name of the type of number, or its value for unknown types.
+func (ton TON) String() string {
+	if name, found := tonNames[ton]; found {
+		return name
+	}
+
+	return "TON " + strconv.Itoa(int(ton))
+}
+
+// NPI is the numbering plan indicator of an address, as used by the addr_npi fields of SMPP and the type of address
+// octet of 3GPP TS 23.040.
+type NPI byte
+
+// Numbering plan indicators.
+const (
+	NPIUnknown    NPI = 0x00
+	NPIISDN       NPI = 0x01 // E.163/E.164
+	NPIData       NPI = 0x03 // X.121
+	NPITelex      NPI = 0x04 // F.69
+	NPILandMobile NPI = 0x06 // E.212
+	NPINational   NPI = 0x08
+	NPIPrivate    NPI = 0x09
+	NPIERMES      NPI = 0x0A
+	NPIInternet   NPI = 0x0E // IP address
+	NPIWAPClient  NPI = 0x12 // WAP client id, SMPP only
+)
+
+// npiNames holds the names of the known numbering plan indicators.
+var npiNames = map[NPI]string{
+	NPIUnknown:    "unknown",
+	NPIISDN:       "ISDN",
+	NPIData:       "data",
+	NPITelex:      "telex",
+	NPILandMobile: "land mobile",
+	NPINational:   "national",
+	NPIPrivate:    "private",
+	NPIERMES:      "ERMES",
+	NPIInternet:   "internet",
+	NPIWAPClient:  "WAP client",
+}
+
+// String returns the name of the numbering plan indicator, or its value for unknown indicators.
+func (npi NPI) String() string {
+	if name, found := npiNames[npi]; found {
+		return name
+	}
+
+	return "NPI " + strconv.Itoa(int(npi))
+}
+
+// Address is an SMPP or 3GPP TS 23.040 address, with its type of number and numbering plan indicator.
+type Address struct {
+	TON    TON    `json:"ton"`
+	NPI    NPI    `json:"npi"`
+	Number string `json:"number"`
+}
+
+// E164 returns the number of the address normalized by NormalizeE164.
+func (addr Address) E164(countryCode string) (string, error) {
+	return NormalizeE164(addr.Number, addr.TON, countryCode)
+}
+
+// EncodeTypeOfAddress returns the type of address octet of 3GPP TS 23.040 holding ton and npi. Returns an error
+// wrapping ErrInvalidAddress when ton does not fit at 3 bits, or npi at 4 bits, such as NPIWAPClient.
+func EncodeTypeOfAddress(ton TON, npi NPI) (byte, error) {
+	if ton > 0x07 {
+		return 0, fmt.Errorf("%w: type of number %d does not fit the type of address", ErrInvalidAddress, ton)
+	}
+
+	if npi > 0x0F {
+		return 0, fmt.Errorf("%w: numbering plan %d does not fit the type of address", ErrInvalidAddress, npi)
+	}
+
+	return toaExtension | byte(ton)<<4 | byte(npi), nil
+}
+
+// DecodeTypeOfAddress returns the type of number and the numbering plan indicator of a type of address octet.
+func DecodeTypeOfAddress(toa byte) (TON, NPI) {
+	return TON(toa >> 4 & 0x07), NPI(toa & 0x0F)
+}
+
+// NormalizeE164 returns number in the E.164 format, a plus sign followed by the country code and the subscriber
+// number, so the same number is given the same form no matter how the SMSC or the caller formatted it. Spaces,
+// dashes, dots and parentheses are dropped.
+//
+// Numbers starting with a plus sign or the 00 international prefix, and numbers of TONInternational, are taken as
+// international. Any other number is taken as national: a single leading trunk 0 is dropped, and countryCode is
+// prepended.
+//
+// Returns an error wrapping ErrInvalidAddress for alphanumeric and abbreviated numbers, national numbers without a
+// countryCode, and numbers that are not made of up to 15 digits.
+func NormalizeE164(number string, ton TON, countryCode string) (string, error) {
+	if ton == TONAlphanumeric || ton == TONAbbreviated {
+		return "", fmt.Errorf("%w: %s numbers cannot be normalized", ErrInvalidAddress, ton)
+	}
+
+	digits := strings.Map(func(ch rune) rune {
+		if strings.ContainsRune(" -.()", ch) {
+			return -1
+		}
+
+		return ch
+	}, number)
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case ton == TONInternational:
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	default:
+		if countryCode == "" {
+			return "", fmt.Errorf("%w: national number %q needs a country code", ErrInvalidAddress, number)
+		}
+
+		digits = strings.TrimPrefix(countryCode, "+") + strings.TrimPrefix(digits, "0")
+	}
+
+	if digits == "" || len(digits) > maxE164Digits || digits[0] == '0' || strings.Trim(digits, "0123456789") != "" {
+		return "", fmt.Errorf("%w: %q is not an E.164 number", ErrInvalidAddress, number)
+	}
+
+	return "+" + digits, nil
+}
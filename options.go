@@ -0,0 +1,87 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"log/slog"
+	"regexp"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ParseOption configures the parsing done by ParseElements and ParseElementsContext.
+type ParseOption func(*parseConfig)
+
+// parseConfig holds the settings gathered from ParseOption functions.
+type parseConfig struct {
+	logger            *slog.Logger
+	trace             bool
+	registry          *IEIRegistry
+	legacyDetection   bool
+	standaloneOnly    bool
+	udhRequired       bool
+	tracer            trace.Tracer
+	pooled            bool
+	strictHex         bool
+	octetSeparators   bool
+	maxInputLength    int
+	maxDecodedLength  int
+	controlCharacters ControlCharacters
+	normalization     *norm.Form
+	bidiIsolation     bool
+	decoders          map[Encoding]encoding.Encoding
+	ucs2Padding       UCS2Padding
+	packedGSM7        bool
+	sar               *SAR
+	messageClass      MessageClass
+	textMarkers       []*regexp.Regexp
+}
+
+// MessagesOption configures a Messages container created by InitMessages.
+type MessagesOption func(*Messages)
+
+// WithParseLogger sets a logger that receives debug records about the parsing decisions.
+func WithParseLogger(logger *slog.Logger) ParseOption {
+	return func(config *parseConfig) {
+		config.logger = logger
+	}
+}
+
+// WithLogger sets a logger that receives debug records about fragment arrival and assembly completion.
+// The logger is also used for parsing messages given to Add.
+func WithLogger(logger *slog.Logger) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.logger = logger
+	}
+}
+
+// WithParseOptions sets the ParseOption functions used by Add when parsing messages.
+func WithParseOptions(options ...ParseOption) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.parseOptions = append(msgs.parseOptions, options...)
+	}
+}
+
+// newParseConfig applies the options over a default configuration.
+func newParseConfig(options []ParseOption) parseConfig {
+	config := parseConfig{}
+
+	for _, option := range options {
+		option(&config)
+	}
+
+	return config
+}
+
+// debug emits a debug record when a logger was configured.
+func (config parseConfig) debug(msg string, args ...any) {
+	if config.logger == nil {
+		return
+	}
+
+	config.logger.Debug(msg, args...)
+}
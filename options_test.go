@@ -0,0 +1,49 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestLogger(t *testing.T) {
+	buffer := bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(&buffer, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	messages := udh.InitMessages(udh.WithLogger(logger))
+
+	err := messages.Add(udh.GSM, udh.Message("050003A5020265722074657374696E67"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = messages.Add(udh.GSM, udh.Message("050003A50201546869732069732061206C6F6E676572"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = udh.Message("776F726C64").ParseElements(udh.GSM, udh.WithParseLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := buffer.String()
+
+	for _, expected := range []string{
+		`msg="UDH detected"`,
+		`msg="fragment added" reference=a5 total_parts=2 current_part=2 received=1`,
+		`msg="message completed" reference=a5 parts=2`,
+		`msg="no UDH detected, falling back to standalone"`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected log output to contain %s, have:\n%s", expected, output)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// IEISIMToolkitSecurityHeaders is the IEI marking a short message that carries a GSM 03.48 (3GPP TS 23.048)
+// secured packet. It is not part of the DefaultIEIRegistry, since it is also used as an operator specific IEI;
+// register it using IEIRegistry.Register for detecting the UDH of SIM OTA messages.
+const IEISIMToolkitSecurityHeaders byte = 0x70
+
+// commandHeaderFixedLength is the length of the fixed part of the command header: SPI, KIc, KID, TAR, CNTR and
+// PCNTR.
+const commandHeaderFixedLength = 13
+
+// TAR is the Toolkit Application Reference, identifying the application on the SIM a secured packet is sent to.
+type TAR [3]byte
+
+// CommandPacket is the envelope of a GSM 03.48 secured command packet.
+//
+// Fields that follow KID and TAR are encrypted when Ciphered is true, and are returned as found.
+type CommandPacket struct {
+	// Security Parameter Indicator
+	SPI [2]byte `json:"spi"`
+
+	// Key and algorithm identifier for ciphering
+	KIc byte `json:"kic"`
+
+	// Key and algorithm identifier for the RC/CC/DS
+	KID byte `json:"kid"`
+
+	// Toolkit Application Reference
+	TAR TAR `json:"tar"`
+
+	// Counter
+	Counter [5]byte `json:"counter"`
+
+	// Number of padding octets added to the secured data
+	PaddingCounter byte `json:"padding_counter"`
+
+	// Redundancy Check, Cryptographic Checksum or Digital Signature
+	Checksum []byte `json:"checksum,omitempty"`
+
+	// The secured data, including the padding
+	Data []byte `json:"data"`
+
+	// True when SPI indicates that the counter, checksum and data are ciphered
+	Ciphered bool `json:"ciphered"`
+}
+
+// ParseCommandPacket parses the envelope of a GSM 03.48 secured command packet from the reassembled binary payload
+// of a SIM OTA message: the Command Packet Length (CPL), the Command Header Length (CHL) and the command header,
+// followed by the secured data. No cryptographic operation is performed.
+// Returns ErrInvalidCommandPacket if the lengths do not match data.
+func ParseCommandPacket(data []byte) (*CommandPacket, error) {
+	if len(data) < 3 {
+		return nil, ErrInvalidCommandPacket
+	}
+
+	packetLength := int(data[0])<<8 | int(data[1])
+	if packetLength != len(data)-2 {
+		return nil, ErrInvalidCommandPacket
+	}
+
+	headerLength := int(data[2])
+	if headerLength < commandHeaderFixedLength || 3+headerLength > len(data) {
+		return nil, ErrInvalidCommandPacket
+	}
+
+	header := data[3 : 3+headerLength]
+
+	packet := &CommandPacket{
+		SPI:            [2]byte{header[0], header[1]},
+		KIc:            header[2],
+		KID:            header[3],
+		TAR:            TAR{header[4], header[5], header[6]},
+		Counter:        [5]byte(header[7:12]),
+		PaddingCounter: header[12],
+		Ciphered:       header[0]&0x04 != 0,
+		Data:           append([]byte{}, data[3+headerLength:]...),
+	}
+
+	if headerLength > commandHeaderFixedLength {
+		packet.Checksum = append([]byte{}, header[commandHeaderFixedLength:]...)
+	}
+
+	return packet, nil
+}
+
+// String returns the TAR as upper case hex.
+func (tar TAR) String() string {
+	return strings.ToUpper(hex.EncodeToString(tar[:]))
+}
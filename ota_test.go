@@ -0,0 +1,78 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestParseCommandPacket(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *udh.CommandPacket
+		err      error
+	}{
+		{
+			name: "cryptographic checksum without ciphering",
+			// CPL, CHL, SPI, KIc, KID, TAR, CNTR, PCNTR, CC, data
+			input: "001A" + "15" + "1201" + "00" + "15" + "B00001" + "0000000001" + "00" + "1122334455667788" +
+				"A0A40000",
+			expected: &udh.CommandPacket{
+				SPI:      [2]byte{0x12, 0x01},
+				KID:      0x15,
+				TAR:      udh.TAR{0xB0, 0x00, 0x01},
+				Counter:  [5]byte{0, 0, 0, 0, 1},
+				Checksum: []byte{0x11, 0x22, 0x33, 0x44, 0x55, 0x66, 0x77, 0x88},
+				Data:     []byte{0xA0, 0xA4, 0x00, 0x00},
+			},
+		},
+		{
+			name:  "ciphered without checksum",
+			input: "0012" + "0D" + "0621" + "25" + "00" + "000000" + "AABBCCDDEE" + "03" + "01020304",
+			expected: &udh.CommandPacket{
+				SPI:            [2]byte{0x06, 0x21},
+				KIc:            0x25,
+				Counter:        [5]byte{0xAA, 0xBB, 0xCC, 0xDD, 0xEE},
+				PaddingCounter: 0x03,
+				Data:           []byte{0x01, 0x02, 0x03, 0x04},
+				Ciphered:       true,
+			},
+		},
+		{name: "too short", input: "0001", err: udh.ErrInvalidCommandPacket},
+		{name: "packet length mismatch", input: "0020" + "0D" + strings.Repeat("00", 13), err: udh.ErrInvalidCommandPacket},
+		{name: "header exceeds packet", input: "000E" + "20" + strings.Repeat("00", 13), err: udh.ErrInvalidCommandPacket},
+		{name: "header too short", input: "0003" + "02" + "0000", err: udh.ErrInvalidCommandPacket},
+	}
+
+	for _, test := range tests {
+		data, err := hex.DecodeString(test.input)
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		packet, err := udh.ParseCommandPacket(data)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: have err %v, expected %v", test.name, err, test.err)
+			continue
+		}
+
+		if diff := cmp.Diff(test.expected, packet); diff != "" {
+			t.Errorf("%s: mismatch (-expected +have):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestTARString(t *testing.T) {
+	if result := (udh.TAR{0xb0, 0x00, 0x0a}).String(); result != "B0000A" {
+		t.Errorf("have %q, expected %q", result, "B0000A")
+	}
+}
@@ -0,0 +1,73 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/hex"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// instrumentationName is the OpenTelemetry instrumentation scope of the package.
+const instrumentationName = "github.com/ik5/smudh"
+
+// Span attribute keys.
+const (
+	AttributeEncoding    = attribute.Key("smudh.encoding")
+	AttributeReference   = attribute.Key("smudh.reference")
+	AttributeTotalParts  = attribute.Key("smudh.total_parts")
+	AttributeCurrentPart = attribute.Key("smudh.current_part")
+	AttributeReceived    = attribute.Key("smudh.received")
+	AttributeComplete    = attribute.Key("smudh.complete")
+)
+
+// WithParseTracerProvider creates an OpenTelemetry span for every parsed message, using provider.
+func WithParseTracerProvider(provider trace.TracerProvider) ParseOption {
+	return func(config *parseConfig) {
+		config.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// WithTracerProvider creates OpenTelemetry spans for Add, AddContext and the parsing they do, using provider.
+// Use AddContext for making the spans part of an existing trace.
+func WithTracerProvider(provider trace.TracerProvider) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.tracer = provider.Tracer(instrumentationName)
+	}
+}
+
+// startSpan starts a span using tracer, or a span that does nothing when tracer is nil.
+func startSpan(
+	ctx context.Context, tracer trace.Tracer, name string, attributes ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	if tracer == nil {
+		return ctx, noop.Span{}
+	}
+
+	return tracer.Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// endSpan records err, when set, and ends span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// spanAttributes returns the span attributes describing info.
+func (elem *MessageElements) spanAttributes() []attribute.KeyValue {
+	return []attribute.KeyValue{
+		AttributeReference.String(hex.EncodeToString(elem.Reference)),
+		AttributeTotalParts.Int(int(elem.TotalParts)),
+		AttributeCurrentPart.Int(int(elem.CurrentPart)),
+	}
+}
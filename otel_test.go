@@ -0,0 +1,111 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestWithTracerProvider(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	messages := udh.InitMessages(udh.WithTracerProvider(provider))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "pipeline")
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50201546869732069732061206C"), // part 1 of 2
+		udh.Message("050003A5020265722074657374696E67"),   // part 2 of 2
+	} {
+		err := messages.AddContext(ctx, udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := messages.AddContext(ctx, udh.ASCII, udh.Message("050"))
+	if err == nil {
+		t.Fatal("expected an error for an odd length message")
+	}
+
+	_, err = messages.GetMessageFragments([]byte{0xA5}).AssembleContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parent.End()
+
+	type span struct {
+		Name       string
+		Parent     string
+		Failed     bool
+		Attributes map[attribute.Key]string
+	}
+
+	names := map[string]string{}
+	for _, recorded := range recorder.Ended() {
+		names[recorded.SpanContext().SpanID().String()] = recorded.Name()
+	}
+
+	have := []span{}
+
+	for _, recorded := range recorder.Ended() {
+		attributes := map[attribute.Key]string{}
+		for _, kv := range recorded.Attributes() {
+			attributes[kv.Key] = kv.Value.Emit()
+		}
+
+		have = append(have, span{
+			Name:       recorded.Name(),
+			Parent:     names[recorded.Parent().SpanID().String()],
+			Failed:     recorded.Status().Code == codes.Error,
+			Attributes: attributes,
+		})
+	}
+
+	part := func(current string) map[attribute.Key]string {
+		return map[attribute.Key]string{
+			udh.AttributeEncoding: "ASCII", udh.AttributeReference: "a5",
+			udh.AttributeTotalParts: "2", udh.AttributeCurrentPart: current,
+		}
+	}
+
+	added := func(current, received, complete string) map[attribute.Key]string {
+		attributes := part(current)
+		attributes[udh.AttributeReceived] = received
+		attributes[udh.AttributeComplete] = complete
+
+		return attributes
+	}
+
+	odd := map[attribute.Key]string{udh.AttributeEncoding: "ASCII"}
+
+	expected := []span{
+		{Name: "smudh.ParseElements", Parent: "smudh.Messages.Add", Attributes: part("1")},
+		{Name: "smudh.Messages.Add", Parent: "pipeline", Attributes: added("1", "1", "false")},
+		{Name: "smudh.ParseElements", Parent: "smudh.Messages.Add", Attributes: part("2")},
+		{Name: "smudh.Messages.Add", Parent: "pipeline", Attributes: added("2", "2", "true")},
+		{Name: "smudh.ParseElements", Parent: "smudh.Messages.Add", Failed: true, Attributes: odd},
+		{Name: "smudh.Messages.Add", Parent: "pipeline", Failed: true, Attributes: odd},
+		{
+			Name: "smudh.Assemble", Parent: "pipeline",
+			Attributes: map[attribute.Key]string{udh.AttributeReceived: "2", udh.AttributeReference: "a5"},
+		},
+		{Name: "pipeline", Attributes: map[attribute.Key]string{}},
+	}
+
+	if diff := cmp.Diff(expected, have); diff != "" {
+		t.Errorf("unexpected spans (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,40 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"crypto/sha256"
+)
+
+// PayloadBytes returns the payload of the message with its decoding applied: a copy of the raw octets for the
+// binary encodings, and the UTF-8 bytes of Message for every text encoding.
+// Unlike RawMessage, the caller does not need to know the Encoding in order to use the result.
+func (elem MessageElements) PayloadBytes() []byte {
+	if elem.Encoding == Binary8Bit1 || elem.Encoding == Binary8Bit2 {
+		return bytes.Clone(elem.RawMessage)
+	}
+
+	return []byte(elem.Message)
+}
+
+// PayloadBytes returns the payload of the full ordered MessageFragmentations, by joining the PayloadBytes of every
+// fragment. The slice is not modified, the same as Assembled.
+func (msgs *MessageFragmentations) PayloadBytes() []byte {
+	buffer := bytes.Buffer{}
+
+	for _, info := range msgs.ordered() {
+		_, _ = buffer.Write(info.PayloadBytes())
+	}
+
+	return buffer.Bytes()
+}
+
+// Checksum returns the SHA-256 digest of the PayloadBytes of the full ordered MessageFragmentations, for keying
+// deduplication and idempotent delivery on the content of a message. The digest depends only on the decoded
+// content, so the same text has the same checksum regardless of its reference number, encoding or segmentation.
+func (msgs *MessageFragmentations) Checksum() [sha256.Size]byte {
+	return sha256.Sum256(msgs.PayloadBytes())
+}
@@ -0,0 +1,117 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestPayloadBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements udh.MessageElements
+		expected []byte
+	}{
+		{
+			name:     "GSM",
+			elements: udh.MessageElements{Encoding: udh.GSM, RawMessage: []byte{0x68, 0x69, 0x11}, Message: "hi_"},
+			expected: []byte("hi_"),
+		},
+		{
+			name:     "UCS2",
+			elements: udh.MessageElements{Encoding: udh.UCS2, RawMessage: []byte{0x05, 0xE9}, Message: "ש"},
+			expected: []byte("ש"),
+		},
+		{
+			name:     "binary",
+			elements: udh.MessageElements{Encoding: udh.Binary8Bit2, RawMessage: []byte{0xCA, 0xFE}, Message: "cafe"},
+			expected: []byte{0xCA, 0xFE},
+		},
+	}
+
+	for _, test := range tests {
+		result := test.elements.PayloadBytes()
+		if diff := cmp.Diff(test.expected, result); diff != "" {
+			t.Errorf("%s: mismatch (-expected +have):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestPayloadBytesCopiesRawMessage(t *testing.T) {
+	elements := udh.MessageElements{Encoding: udh.Binary8Bit1, RawMessage: []byte{0x01, 0x02}}
+
+	result := elements.PayloadBytes()
+	result[0] = 0xFF
+
+	if elements.RawMessage[0] != 0x01 {
+		t.Errorf("RawMessage was modified: % X", elements.RawMessage)
+	}
+}
+
+func TestMessageFragmentationsPayloadBytes(t *testing.T) {
+	fragments := udh.MessageFragmentations{}
+
+	for _, msg := range []udh.Message{
+		udh.Message("0500030F020168656C6C6F20"),
+		udh.Message("0500030F0202776F726C64"),
+	} {
+		err := fragments.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if result := string(fragments.PayloadBytes()); result != "hello world" {
+		t.Errorf("have %q, expected %q", result, "hello world")
+	}
+}
+
+func TestMessageFragmentationsChecksum(t *testing.T) {
+	checksum := func(encoding udh.Encoding, msgs ...udh.Message) [32]byte {
+		fragments := udh.MessageFragmentations{}
+
+		for _, msg := range msgs {
+			err := fragments.Add(encoding, msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return fragments.Checksum()
+	}
+
+	expected := sha256.Sum256([]byte("hello world"))
+
+	tests := []struct {
+		name     string
+		checksum [32]byte
+		equal    bool
+	}{
+		{
+			name: "two parts", equal: true,
+			checksum: checksum(udh.ASCII, udh.Message("0500030F020168656C6C6F20"), udh.Message("0500030F0202776F726C64")),
+		},
+		{
+			name: "other reference and segmentation", equal: true,
+			checksum: checksum(udh.ASCII, udh.Message("050003A5020168656C6C6F"), udh.Message("050003A5020220776F726C64")),
+		},
+		{name: "standalone UCS2", equal: true, checksum: checksum(udh.UCS2, udh.Message(
+			"00680065006C006C006F00200077006F0072006C0064",
+		))},
+		{name: "other text", checksum: checksum(udh.ASCII, udh.Message("68656C6C6F"))},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if (test.checksum == expected) != test.equal {
+				t2.Errorf("have checksum %X, expected equal %t to %X", test.checksum, test.equal, expected)
+			}
+		})
+	}
+}
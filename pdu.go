@@ -0,0 +1,253 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// SMPP command_id values of the PDUs carrying a short message, which are understood by ParsePDU.
+const (
+	CommandSubmitSM  uint32 = 0x00000004
+	CommandDeliverSM uint32 = 0x00000005
+	CommandDataSM    uint32 = 0x00000103
+)
+
+// TagMessagePayload is the tag of the message_payload TLV, carrying the user data instead of short_message.
+const TagMessagePayload uint16 = 0x0424
+
+// ESMClassUDHI is the esm_class bit indicating that the user data starts with a UDH.
+const ESMClassUDHI byte = 0x40
+
+// pduHeaderLength is the length of the command_length, command_id, command_status and sequence_number fields.
+const pduHeaderLength = 16
+
+// maxPDULength is the largest command_length accepted by ParsePDU, which allows a 64KB message_payload.
+const maxPDULength = 0x11000
+
+// TLV is an optional parameter of an SMPP PDU.
+type TLV struct {
+	// Tag of the parameter
+	Tag uint16 `json:"tag"`
+
+	// Value of the parameter, without its length
+	Value []byte `json:"value"`
+}
+
+// PDU holds the fields of a submit_sm, deliver_sm or data_sm PDU that are needed for handling its short message,
+// as returned by ParsePDU.
+type PDU struct {
+	// The command_id, one of CommandSubmitSM, CommandDeliverSM and CommandDataSM
+	CommandID uint32 `json:"command_id"`
+
+	// The sequence_number of the PDU
+	SequenceNumber uint32 `json:"sequence_number"`
+
+	// The service_type field
+	ServiceType string `json:"service_type,omitempty"`
+
+	// Type of number, numbering plan indicator and value of the source address
+	SourceTON     byte   `json:"source_addr_ton"`
+	SourceNPI     byte   `json:"source_addr_npi"`
+	SourceAddress string `json:"source_addr"`
+
+	// Type of number, numbering plan indicator and value of the destination address
+	DestinationTON     byte   `json:"dest_addr_ton"`
+	DestinationNPI     byte   `json:"dest_addr_npi"`
+	DestinationAddress string `json:"destination_addr"`
+
+	// The esm_class field, holding the UDHI bit
+	ESMClass byte `json:"esm_class"`
+
+	// The protocol_id field, always 0 for data_sm which has no such field
+	ProtocolID byte `json:"protocol_id"`
+
+	// The data_coding field
+	DataCoding byte `json:"data_coding"`
+
+	// The short_message field, always empty for data_sm which has no such field
+	ShortMessage []byte `json:"short_message,omitempty"`
+
+	// The optional parameters, in order of appearance
+	TLVs []TLV `json:"tlvs,omitempty"`
+}
+
+// ParsePDU walks the mandatory fields of a raw submit_sm, deliver_sm or data_sm PDU, starting at its command_length,
+// such as captured by a packet capture or a wire-level proxy, without a full SMPP library.
+// Returns ErrUnsupportedPDU for other commands, and ErrInvalidPDU when the command_length does not match the
+// length of pdu, or the fields overrun it.
+func ParsePDU(pdu []byte) (*PDU, error) {
+	if len(pdu) < pduHeaderLength {
+		return nil, fmt.Errorf("%w: %d octets are too short for a header", ErrInvalidPDU, len(pdu))
+	}
+
+	length := binary.BigEndian.Uint32(pdu)
+	if length != uint32(len(pdu)) || length > maxPDULength {
+		return nil, fmt.Errorf("%w: command_length %d of a %d octets PDU", ErrInvalidPDU, length, len(pdu))
+	}
+
+	result := &PDU{
+		CommandID:      binary.BigEndian.Uint32(pdu[4:]),
+		SequenceNumber: binary.BigEndian.Uint32(pdu[12:]),
+	}
+
+	if result.CommandID != CommandSubmitSM && result.CommandID != CommandDeliverSM && result.CommandID != CommandDataSM {
+		return nil, fmt.Errorf("%w: command_id 0x%08X", ErrUnsupportedPDU, result.CommandID)
+	}
+
+	reader := &pduReader{data: pdu, offset: pduHeaderLength}
+
+	result.ServiceType = reader.cString("service_type", 6)
+	result.SourceTON, result.SourceNPI = reader.octet("source_addr_ton"), reader.octet("source_addr_npi")
+	result.SourceAddress = reader.cString("source_addr", 65)
+	result.DestinationTON, result.DestinationNPI = reader.octet("dest_addr_ton"), reader.octet("dest_addr_npi")
+	result.DestinationAddress = reader.cString("destination_addr", 65)
+	result.ESMClass = reader.octet("esm_class")
+
+	if result.CommandID == CommandDataSM {
+		reader.octet("registered_delivery")
+		result.DataCoding = reader.octet("data_coding")
+	} else {
+		result.ProtocolID = reader.octet("protocol_id")
+		reader.octet("priority_flag")
+		reader.cString("schedule_delivery_time", 17)
+		reader.cString("validity_period", 17)
+		reader.octet("registered_delivery")
+		reader.octet("replace_if_present_flag")
+		result.DataCoding = reader.octet("data_coding")
+		reader.octet("sm_default_msg_id")
+		result.ShortMessage = bytes.Clone(reader.octets("short_message", int(reader.octet("sm_length"))))
+	}
+
+	for reader.err == nil && reader.offset < len(pdu) {
+		tag := binary.BigEndian.Uint16(reader.octets("TLV tag", 2))
+		value := reader.octets("TLV value", int(binary.BigEndian.Uint16(reader.octets("TLV length", 2))))
+		result.TLVs = append(result.TLVs, TLV{Tag: tag, Value: bytes.Clone(value)})
+	}
+
+	if reader.err != nil {
+		return nil, reader.err
+	}
+
+	return result, nil
+}
+
+// TLV returns the value of the first optional parameter with the given tag.
+func (pdu *PDU) TLV(tag uint16) ([]byte, bool) {
+	for _, tlv := range pdu.TLVs {
+		if tlv.Tag == tag {
+			return tlv.Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Source returns the source address of the PDU.
+func (pdu *PDU) Source() Address {
+	return Address{TON: TON(pdu.SourceTON), NPI: NPI(pdu.SourceNPI), Number: pdu.SourceAddress}
+}
+
+// Destination returns the destination address of the PDU.
+func (pdu *PDU) Destination() Address {
+	return Address{TON: TON(pdu.DestinationTON), NPI: NPI(pdu.DestinationNPI), Number: pdu.DestinationAddress}
+}
+
+// UserData returns the short_message field, or the message_payload TLV when short_message is empty.
+func (pdu *PDU) UserData() []byte {
+	if len(pdu.ShortMessage) > 0 {
+		return pdu.ShortMessage
+	}
+
+	payload, _ := pdu.TLV(TagMessagePayload)
+
+	return payload
+}
+
+// HasUDH returns true when the UDHI bit of esm_class is set.
+func (pdu *PDU) HasUDH() bool {
+	return pdu.ESMClass&ESMClassUDHI != 0
+}
+
+// Message returns the UserData as an upper case hex encoded Message.
+func (pdu *PDU) Message() Message {
+	return Message(strings.ToUpper(hex.EncodeToString(pdu.UserData())))
+}
+
+// AddPDU parses a raw submit_sm, deliver_sm or data_sm PDU using ParsePDU, and adds its user data to the container
+// the same as AddContext, using the Encoding of its data_coding for the given SMPP interface version. A message
+// without a UDH is numbered using the SAR parameters of the PDU, see WithSAR, and the MessageClass of the fragment is
+// set from the data_coding.
+func (msgs *Messages) AddPDU(ctx context.Context, pdu []byte, version InterfaceVersion) error {
+	parsed, err := ParsePDU(pdu)
+	if err != nil {
+		return err
+	}
+
+	encoding, err := EncodingFromDataCodingVersion(parsed.DataCoding, version)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return msgs.addContext(ctx, encoding, parsed.Message(), parsed.parseOptions()...)
+}
+
+// pduReader reads the fields of a PDU, keeping the first error, so the fields can be read without checking every
+// one of them.
+type pduReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+// octet reads a single octet field.
+func (reader *pduReader) octet(field string) byte {
+	value := reader.octets(field, 1)
+	if len(value) == 0 {
+		return 0
+	}
+
+	return value[0]
+}
+
+// octets reads a field of length octets.
+func (reader *pduReader) octets(field string, length int) []byte {
+	if reader.err != nil {
+		return make([]byte, length)
+	}
+
+	if reader.offset+length > len(reader.data) {
+		reader.err = fmt.Errorf("%w: %s overruns the PDU", ErrInvalidPDU, field)
+		return make([]byte, length)
+	}
+
+	value := reader.data[reader.offset : reader.offset+length]
+	reader.offset += length
+
+	return value
+}
+
+// cString reads a NULL terminated field of up to maxLength octets, including the NULL.
+func (reader *pduReader) cString(field string, maxLength int) string {
+	if reader.err != nil {
+		return ""
+	}
+
+	end := bytes.IndexByte(reader.data[reader.offset:min(reader.offset+maxLength, len(reader.data))], 0)
+	if end < 0 {
+		reader.err = fmt.Errorf("%w: %s is not NULL terminated", ErrInvalidPDU, field)
+		return ""
+	}
+
+	value := string(reader.data[reader.offset : reader.offset+end])
+	reader.offset += end + 1
+
+	return value
+}
@@ -0,0 +1,129 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+// buildPDU renders a PDU out of its command_id and body, prefixing the header.
+func buildPDU(commandID uint32, body ...[]byte) []byte {
+	pdu := binary.BigEndian.AppendUint32(nil, 0)
+	pdu = binary.BigEndian.AppendUint32(pdu, commandID)
+	pdu = binary.BigEndian.AppendUint32(pdu, 0)
+	pdu = binary.BigEndian.AppendUint32(pdu, 7)
+
+	for _, part := range body {
+		pdu = append(pdu, part...)
+	}
+
+	binary.BigEndian.PutUint32(pdu, uint32(len(pdu)))
+
+	return pdu
+}
+
+// deliverSM renders a deliver_sm PDU with the given esm_class, data_coding, short_message and optional parameters.
+func deliverSM(esmClass, dataCoding byte, shortMessage []byte, tlvs ...byte) []byte {
+	return buildPDU(udh.CommandDeliverSM,
+		[]byte("CMT\x00"), []byte{0x01, 0x01}, []byte("972501234567\x00"), []byte{0x05, 0x00}, []byte("Bank\x00"),
+		[]byte{esmClass, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, dataCoding, 0x00, byte(len(shortMessage))}, shortMessage,
+		tlvs,
+	)
+}
+
+func TestParsePDU(t *testing.T) {
+	dataSM := buildPDU(udh.CommandDataSM,
+		[]byte{0x00, 0x01, 0x01}, []byte("123\x00"), []byte{0x01, 0x01}, []byte("456\x00"), []byte{0x00, 0x00, 0x08},
+		[]byte{0x04, 0x24, 0x00, 0x02, 0x05, 0xD0}, []byte{0x02, 0x0C, 0x00, 0x02, 0x00, 0x2A},
+	)
+
+	tests := []struct {
+		name     string
+		pdu      []byte
+		expected *udh.PDU
+		message  udh.Message
+		err      error
+	}{
+		{
+			name: "deliver_sm",
+			pdu:  deliverSM(0x40, 0x00, []byte{0x05, 0x00, 0x03, 0x2A, 0x02, 0x01, 0x41}),
+			expected: &udh.PDU{
+				CommandID: udh.CommandDeliverSM, SequenceNumber: 7, ServiceType: "CMT",
+				SourceTON: 0x01, SourceNPI: 0x01, SourceAddress: "972501234567",
+				DestinationTON: 0x05, DestinationAddress: "Bank",
+				ESMClass: 0x40, ShortMessage: []byte{0x05, 0x00, 0x03, 0x2A, 0x02, 0x01, 0x41},
+			},
+			message: udh.Message("0500032A020141"),
+		},
+		{
+			name: "data_sm",
+			pdu:  dataSM,
+			expected: &udh.PDU{
+				CommandID: udh.CommandDataSM, SequenceNumber: 7, SourceTON: 0x01, SourceNPI: 0x01, SourceAddress: "123",
+				DestinationTON: 0x01, DestinationNPI: 0x01, DestinationAddress: "456", DataCoding: 0x08,
+				TLVs: []udh.TLV{
+					{Tag: udh.TagMessagePayload, Value: []byte{0x05, 0xD0}}, {Tag: 0x020C, Value: []byte{0x00, 0x2A}},
+				},
+			},
+			message: udh.Message("05D0"),
+		},
+		{name: "enquire_link", pdu: buildPDU(0x00000015), err: udh.ErrUnsupportedPDU},
+		{name: "command_length", pdu: append(deliverSM(0x00, 0x00, []byte("A")), 0x00), err: udh.ErrInvalidPDU},
+		{name: "not terminated", pdu: buildPDU(udh.CommandDeliverSM, []byte("CMT")), err: udh.ErrInvalidPDU},
+		{name: "short TLV", pdu: deliverSM(0x00, 0x00, nil, 0x04, 0x24, 0x00, 0x02, 0x05), err: udh.ErrInvalidPDU},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			pdu, err := udh.ParsePDU(test.pdu)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			if diff := cmp.Diff(test.expected, pdu); diff != "" {
+				t2.Errorf("unexpected PDU (-want +got):\n%s", diff)
+			}
+
+			if pdu != nil && string(pdu.Message()) != string(test.message) {
+				t2.Errorf("have message %s, expected %s", pdu.Message(), test.message)
+			}
+		})
+	}
+}
+
+func TestMessagesAddPDU(t *testing.T) {
+	messages := udh.InitMessages()
+	ctx := context.Background()
+
+	for _, part := range [][]byte{
+		{0x05, 0x00, 0x03, 0x2A, 0x02, 0x02, 0x42},
+		{0x05, 0x00, 0x03, 0x2A, 0x02, 0x01, 0x41},
+	} {
+		err := messages.AddPDU(ctx, deliverSM(udh.ESMClassUDHI, 0x03, part), udh.SMPP34)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	text, err := messages.AssembledText([]byte{0x2A})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text != "AB" {
+		t.Errorf("have text %q, expected %q", text, "AB")
+	}
+
+	err = messages.AddPDU(ctx, deliverSM(0x00, 0x0F, []byte("A")), udh.SMPP34)
+	if err == nil {
+		t.Error("expected an error for a reserved data_coding")
+	}
+}
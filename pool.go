@@ -0,0 +1,54 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"sync"
+)
+
+// maxPooledBuffer is the largest decoded input kept by a pooled MessageElements, so a single huge message does not
+// pin its memory inside the pool.
+const maxPooledBuffer = 4096
+
+// elementsPool holds the MessageElements used by WithPooledElements.
+var elementsPool = sync.Pool{
+	New: func() any {
+		return &MessageElements{}
+	},
+}
+
+// WithPooledElements takes the returned MessageElements, and the buffer holding the decoded input, from a pool,
+// reducing allocations when parsing a high volume of messages.
+//
+// Reference and RawMessage share the pooled buffer, so once the MessageElements is no longer needed, call Release
+// for returning it to the pool, and do not use it, or anything taken from it, afterwards. Elements that are kept,
+// for example by adding them to Messages, must not be released; use Clone for keeping a copy.
+func WithPooledElements() ParseOption {
+	return func(config *parseConfig) {
+		config.pooled = true
+	}
+}
+
+// acquireElements returns an empty MessageElements from the pool.
+func acquireElements() *MessageElements {
+	elem, _ := elementsPool.Get().(*MessageElements)
+	return elem
+}
+
+// Release resets the MessageElements, and returns it to the pool used by WithPooledElements.
+// The MessageElements must not be used after calling Release.
+func (elem *MessageElements) Release() {
+	if elem == nil {
+		return
+	}
+
+	buffer := elem.buffer[:0]
+	if cap(buffer) > maxPooledBuffer {
+		buffer = nil
+	}
+
+	*elem = MessageElements{buffer: buffer}
+	elementsPool.Put(elem)
+}
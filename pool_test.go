@@ -0,0 +1,61 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	udh "github.com/ik5/smudh"
+)
+
+func TestWithPooledElements(t *testing.T) {
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50201546869732069732061206C"),
+		udh.Message("0608047539020165722074657374696E67"),
+		udh.Message("48656C6C6F"),
+	} {
+		expected, err := msg.ParseElements(udh.ASCII)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// parse twice, for having the second parse reuse a released MessageElements
+		for range 2 {
+			pooled, err := msg.ParseElements(udh.ASCII, udh.WithPooledElements())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(expected, pooled, cmpopts.IgnoreUnexported(udh.MessageElements{})); diff != "" {
+				t.Errorf("%s: pooled elements differ (-want +got):\n%s", msg, diff)
+			}
+
+			pooled.Release()
+		}
+	}
+}
+
+func BenchmarkParseElements(b *testing.B) {
+	msg := udh.Message("050003A50201546869732069732061206C")
+
+	b.Run("allocated", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for b.Loop() {
+			_, _ = msg.ParseElements(udh.ASCII)
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for b.Loop() {
+			elements, _ := msg.ParseElements(udh.ASCII, udh.WithPooledElements())
+			elements.Release()
+		}
+	})
+}
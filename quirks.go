@@ -0,0 +1,153 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"golang.org/x/text/encoding"
+)
+
+// QuirkProfile is a named bundle of the workarounds needed for a carrier or SMSC that deviates from the standard,
+// such as "vendorX-no-udhi" or "vendorY-padded-ucs2", so the workarounds live in configuration instead of the
+// application code.
+//
+// Built-in profiles are listed by QuirkProfiles. Other profiles are registered once using RegisterQuirkProfile.
+// Profiles are looked up by the name found at the configuration using LookupQuirkProfile, and selected for the
+// messages of a bind using WithQuirkProfile.
+type QuirkProfile struct {
+	// Name of the profile, used by LookupQuirkProfile
+	Name string
+
+	// Human readable description of the quirks
+	Description string
+
+	// Parse options applied before the detection and decoder settings of the profile
+	Options []ParseOption
+
+	// Restores the legacy UDH detection, see WithLegacyUDHDetection
+	LegacyDetection bool
+
+	// IEI registry used for detecting a UDH, see WithIEIRegistry. Nil keeps the registry of the container.
+	Registry *IEIRegistry
+
+	// Decoders substituted for the standard decoding of their encodings, see WithDecoder
+	Decoders map[Encoding]encoding.Encoding
+}
+
+// QuirkPaddedUCS2 is the name of the built-in profile of SMSCs that always pad UCS2 payloads after a UDH of an
+// odd length, see UCS2PaddingAlways.
+const QuirkPaddedUCS2 = "padded-ucs2"
+
+// QuirkNoUDH is the name of the built-in profile of SMSCs that never deliver a UDH, so payloads are not checked for
+// one, see WithoutUDHDetection.
+const QuirkNoUDH = "no-udh"
+
+// QuirkTextMarkers is the name of the built-in profile of aggregators that split long messages into plain text
+// parts marked with their numbering, see WithTextMarkers.
+const QuirkTextMarkers = "text-markers"
+
+// quirkProfiles holds the built-in profiles, and the ones registered using RegisterQuirkProfile.
+var (
+	quirkProfiles = map[string]QuirkProfile{
+		QuirkPaddedUCS2: {
+			Name:        QuirkPaddedUCS2,
+			Description: "UCS2 payloads are always padded to an even offset after a UDH of an odd length",
+			Options:     []ParseOption{WithUCS2Padding(UCS2PaddingAlways)},
+		},
+		QuirkNoUDH: {
+			Name:        QuirkNoUDH,
+			Description: "messages never carry a UDH, whatever their first octets look like",
+			Options:     []ParseOption{WithoutUDHDetection()},
+		},
+		QuirkTextMarkers: {
+			Name:        QuirkTextMarkers,
+			Description: `long messages are split without a UDH, into parts marked such as "(1/3)"`,
+			Options:     []ParseOption{WithTextMarkers()},
+		},
+	}
+	quirkProfilesMtx sync.RWMutex
+)
+
+// ParseOptions returns the settings of the profile as ParseOption functions: Options first, followed by the
+// detection settings and the decoders.
+func (profile QuirkProfile) ParseOptions() []ParseOption {
+	options := slices.Clone(profile.Options)
+
+	if profile.LegacyDetection {
+		options = append(options, WithLegacyUDHDetection())
+	}
+
+	if profile.Registry != nil {
+		options = append(options, WithIEIRegistry(profile.Registry))
+	}
+
+	for _, enc := range slices.Sorted(maps.Keys(profile.Decoders)) {
+		options = append(options, WithDecoder(enc, profile.Decoders[enc]))
+	}
+
+	return options
+}
+
+// RegisterQuirkProfile adds profile to the package wide registry of profiles. It returns an error wrapping
+// ErrInvalidQuirkProfile when the profile has no name, or when a profile with the same name was already registered.
+func RegisterQuirkProfile(profile QuirkProfile) error {
+	if profile.Name == "" {
+		return fmt.Errorf("%w: missing name", ErrInvalidQuirkProfile)
+	}
+
+	quirkProfilesMtx.Lock()
+	defer quirkProfilesMtx.Unlock()
+
+	if _, found := quirkProfiles[profile.Name]; found {
+		return fmt.Errorf("%w: %q is already registered", ErrInvalidQuirkProfile, profile.Name)
+	}
+
+	quirkProfiles[profile.Name] = profile
+
+	return nil
+}
+
+// LookupQuirkProfile returns the profile registered under name, or an error wrapping ErrUnknownQuirkProfile.
+func LookupQuirkProfile(name string) (QuirkProfile, error) {
+	quirkProfilesMtx.RLock()
+	defer quirkProfilesMtx.RUnlock()
+
+	profile, found := quirkProfiles[name]
+	if !found {
+		return QuirkProfile{}, fmt.Errorf("%w: %q", ErrUnknownQuirkProfile, name)
+	}
+
+	return profile, nil
+}
+
+// QuirkProfiles returns the names of the registered profiles, in ascending order.
+func QuirkProfiles() []string {
+	quirkProfilesMtx.RLock()
+	defer quirkProfilesMtx.RUnlock()
+
+	return slices.Sorted(maps.Keys(quirkProfiles))
+}
+
+// WithQuirkProfile applies profile to the messages added to namespace, "" being the default namespace, such as the
+// namespace of the SMPP bind of the carrier. The options of the profile are applied after the ones set by
+// WithParseOptions, so they take precedence. Using it again for the same namespace replaces the profile.
+func WithQuirkProfile(namespace string, profile QuirkProfile) MessagesOption {
+	return func(msgs *Messages) {
+		if msgs.quirks == nil {
+			msgs.quirks = map[string]QuirkProfile{}
+		}
+
+		msgs.quirks[namespace] = profile
+	}
+}
+
+// QuirkProfile returns the name of the profile applied to the messages of namespace by WithQuirkProfile, or "".
+func (msgs *Messages) QuirkProfile(namespace string) string {
+	return msgs.quirks[namespace].Name
+}
@@ -0,0 +1,109 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestQuirkProfileRegistry(t *testing.T) {
+	profile := udh.QuirkProfile{
+		Name:        "test-windows-1252",
+		Description: "Latin1 is sent as Windows-1252",
+		Decoders:    map[udh.Encoding]encoding.Encoding{udh.Latin1: charmap.Windows1252},
+	}
+
+	err := udh.RegisterQuirkProfile(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = udh.RegisterQuirkProfile(profile)
+	if !errors.Is(err, udh.ErrInvalidQuirkProfile) {
+		t.Errorf("expected registering twice to fail with ErrInvalidQuirkProfile, got %v", err)
+	}
+
+	err = udh.RegisterQuirkProfile(udh.QuirkProfile{})
+	if !errors.Is(err, udh.ErrInvalidQuirkProfile) {
+		t.Errorf("expected registering without a name to fail with ErrInvalidQuirkProfile, got %v", err)
+	}
+
+	found, err := udh.LookupQuirkProfile(profile.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.Description != profile.Description {
+		t.Errorf("have profile %+v, expected %+v", found, profile)
+	}
+
+	_, err = udh.LookupQuirkProfile("test-missing")
+	if !errors.Is(err, udh.ErrUnknownQuirkProfile) {
+		t.Errorf("expected an error wrapping ErrUnknownQuirkProfile, got %v", err)
+	}
+
+	if !slices.Contains(udh.QuirkProfiles(), profile.Name) {
+		t.Errorf("expected %q to be listed by QuirkProfiles, got %v", profile.Name, udh.QuirkProfiles())
+	}
+}
+
+func TestWithQuirkProfile(t *testing.T) {
+	profile := udh.QuirkProfile{
+		Name:     "windows-1252",
+		Decoders: map[udh.Encoding]encoding.Encoding{udh.Latin1: charmap.Windows1252},
+	}
+
+	messages := udh.InitMessages(
+		udh.WithParseOptions(udh.WithDecoder(udh.Latin1, charmap.ISO8859_15)),
+		udh.WithQuirkProfile("carrier", profile),
+	)
+
+	// 0xA4 is the euro sign at ISO-8859-15, and the currency sign at Windows-1252
+	tests := []struct {
+		name      string
+		namespace string
+		profile   string
+		expected  string
+	}{
+		{name: "default namespace", namespace: "", expected: "€"},
+		{name: "other namespace", namespace: "other", expected: "€"},
+		{name: "namespace of the profile", namespace: "carrier", profile: "windows-1252", expected: "¤"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if have := messages.QuirkProfile(test.namespace); have != test.profile {
+				t2.Errorf("have profile %q, expected %q", have, test.profile)
+			}
+
+			ns := messages.Namespace(test.namespace)
+
+			err := ns.Add(udh.Latin1, udh.Message("0500030A0101A4"))
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			fragments := ns.Snapshot([]byte{0x0A})
+			if len(fragments) != 1 || fragments[0].Message != test.expected {
+				t2.Errorf("have fragments %v, expected %q", fragments, test.expected)
+			}
+		})
+	}
+
+	elements, err := messages.Parse(udh.Latin1, udh.Message("35A4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Message != "5€" {
+		t.Errorf("have parsed message %q, expected %q", elements.Message, "5€")
+	}
+}
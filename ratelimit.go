@@ -0,0 +1,158 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// minSourceLimiters is the number of per source limiters kept before idle ones are removed.
+const minSourceLimiters = 1024
+
+// RateLimit is a token bucket limit: Rate fragments per second on average, with bursts of up to Burst fragments.
+type RateLimit struct {
+	// Fragments per second
+	Rate float64 `json:"rate"`
+
+	// Maximum number of fragments accepted at once
+	Burst int `json:"burst"`
+}
+
+// RateLimitError is the error returned for fragments over a rate limit. It wraps ErrRateLimited, and tells when
+// the limit accepts a fragment again, so callers can ask the sender to retry later.
+type RateLimitError struct {
+	// The limit that was reached, such as the global limit or the limit of a source
+	Limit string
+
+	// Time until the limit accepts a fragment again, zero when the limit never does, such as with a zero Burst
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (limitErr *RateLimitError) Error() string {
+	return ErrRateLimited.Error() + ": " + limitErr.Limit
+}
+
+// Unwrap returns ErrRateLimited.
+func (limitErr *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// rateLimiter holds the global limiter, and a limiter for every Source.
+type rateLimiter struct {
+	global      *rate.Limiter
+	sourceLimit *RateLimit
+	sources     map[string]*rate.Limiter
+	pruneAt     int
+	mtx         sync.Mutex
+}
+
+// WithGlobalRateLimit limits the rate of fragments added to the container, using Add, AddContext,
+// AddMessageElements or AddMessageElementsContext. Fragments over the limit are rejected with an error wrapping
+// ErrRateLimited, a *RateLimitError, before being parsed.
+func WithGlobalRateLimit(limit RateLimit) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.rateLimiter().global = rate.NewLimiter(rate.Limit(limit.Rate), limit.Burst)
+	}
+}
+
+// WithSourceRateLimit limits the rate of fragments added to the container by every Source, identified by its ID,
+// as attached to the context using ContextWithSource. Fragments without a Source are limited only by
+// WithGlobalRateLimit. Fragments over the limit are rejected with an error wrapping ErrRateLimited, a
+// *RateLimitError, before being parsed.
+func WithSourceRateLimit(limit RateLimit) MessagesOption {
+	return func(msgs *Messages) {
+		limiter := msgs.rateLimiter()
+		limiter.sourceLimit = &limit
+		limiter.sources = map[string]*rate.Limiter{}
+		limiter.pruneAt = minSourceLimiters
+	}
+}
+
+// rateLimiter returns the rate limiter of the container, creating it when needed.
+func (msgs *Messages) rateLimiter() *rateLimiter {
+	if msgs.limiter == nil {
+		msgs.limiter = &rateLimiter{}
+	}
+
+	return msgs.limiter
+}
+
+// allowFragment takes a token for a fragment from the limiters, using the Source of ctx.
+// Returns a *RateLimitError if a limit was reached.
+func (msgs *Messages) allowFragment(ctx context.Context) error {
+	if msgs.limiter == nil {
+		return nil
+	}
+
+	now := msgs.now()
+
+	var sourceReservation *rate.Reservation
+
+	if source, found := SourceFromContext(ctx); found && msgs.limiter.sourceLimit != nil {
+		sourceReservation = msgs.limiter.source(source.ID, now).ReserveN(now, 1)
+		if delay, ok := allowed(sourceReservation, now); !ok {
+			return &RateLimitError{Limit: fmt.Sprintf("source %q", source.ID), RetryAfter: delay}
+		}
+	}
+
+	if msgs.limiter.global != nil {
+		if delay, ok := allowed(msgs.limiter.global.ReserveN(now, 1), now); !ok {
+			if sourceReservation != nil {
+				sourceReservation.CancelAt(now)
+			}
+
+			return &RateLimitError{Limit: "global limit", RetryAfter: delay}
+		}
+	}
+
+	return nil
+}
+
+// source returns the limiter of a source, creating it when needed. Once there are many limiters, the ones with a
+// full bucket are removed, since a new limiter behaves the same.
+func (limiter *rateLimiter) source(id string, now time.Time) *rate.Limiter {
+	limiter.mtx.Lock()
+	defer limiter.mtx.Unlock()
+
+	if sourceLimiter, found := limiter.sources[id]; found {
+		return sourceLimiter
+	}
+
+	if len(limiter.sources) >= limiter.pruneAt {
+		for key, sourceLimiter := range limiter.sources {
+			if sourceLimiter.TokensAt(now) >= float64(sourceLimiter.Burst()) {
+				delete(limiter.sources, key)
+			}
+		}
+
+		limiter.pruneAt = max(2*len(limiter.sources), minSourceLimiters)
+	}
+
+	sourceLimiter := rate.NewLimiter(rate.Limit(limiter.sourceLimit.Rate), limiter.sourceLimit.Burst)
+	limiter.sources[id] = sourceLimiter
+
+	return sourceLimiter
+}
+
+// allowed reports whether reservation allows acting at now, cancelling it when it does not. When it does not, the
+// time until it would is returned as well, zero when it never would.
+func allowed(reservation *rate.Reservation, now time.Time) (time.Duration, bool) {
+	if !reservation.OK() {
+		return 0, false
+	}
+
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return delay, false
+	}
+
+	return 0, true
+}
@@ -0,0 +1,71 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestRateLimit(t *testing.T) {
+	flooder := udh.ContextWithSource(context.Background(), udh.Source{ID: "flooder"})
+	other := udh.ContextWithSource(context.Background(), udh.Source{ID: "other"})
+
+	tests := []struct {
+		name     string
+		options  []udh.MessagesOption
+		contexts []context.Context
+		limited  []bool
+	}{
+		{
+			name:     "unlimited",
+			contexts: []context.Context{flooder, flooder, flooder},
+			limited:  []bool{false, false, false},
+		},
+		{
+			name:     "per source",
+			options:  []udh.MessagesOption{udh.WithSourceRateLimit(udh.RateLimit{Rate: 0.001, Burst: 2})},
+			contexts: []context.Context{flooder, flooder, flooder, other, context.Background()},
+			limited:  []bool{false, false, true, false, false},
+		},
+		{
+			name:     "global",
+			options:  []udh.MessagesOption{udh.WithGlobalRateLimit(udh.RateLimit{Rate: 0.001, Burst: 2})},
+			contexts: []context.Context{flooder, other, context.Background()},
+			limited:  []bool{false, false, true},
+		},
+		{
+			name: "source rejected before global",
+			options: []udh.MessagesOption{
+				udh.WithSourceRateLimit(udh.RateLimit{Rate: 0.001, Burst: 1}),
+				udh.WithGlobalRateLimit(udh.RateLimit{Rate: 0.001, Burst: 2}),
+			},
+			contexts: []context.Context{flooder, flooder, other, context.Background()},
+			limited:  []bool{false, true, false, true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			messages := udh.InitMessages(test.options...)
+
+			for idx, ctx := range test.contexts {
+				err := messages.AddContext(ctx, udh.ASCII, udh.Message("050003A50201546869732069732061206C"))
+				if limited := errors.Is(err, udh.ErrRateLimited); limited != test.limited[idx] {
+					t2.Errorf("%d. have error %v, expected limited %t", idx, err, test.limited[idx])
+				}
+
+				var limitErr *udh.RateLimitError
+				if errors.As(err, &limitErr) && limitErr.RetryAfter < 999*time.Second {
+					t2.Errorf("%d. have retry after %s, expected about 1000s", idx, limitErr.RetryAfter)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// redactedHexByte replaces every payload byte at the output of DumpHex when using WithRedaction.
+const redactedHexByte = "**"
+
+// redactedValue replaces values of unknown length.
+const redactedValue = "[REDACTED]"
+
+// OutputOption configures the output of ToJSON and DumpHex.
+type OutputOption func(*outputConfig)
+
+// outputConfig holds the settings gathered from OutputOption functions.
+type outputConfig struct {
+	redact bool
+}
+
+// WithRedaction masks the message text and payload, and the values of the fragment Metadata, so the output can be
+// shared safely. The structural metadata, such as the UDH fields, reference, part numbers, encoding and ports, is
+// kept.
+func WithRedaction() OutputOption {
+	return func(config *outputConfig) {
+		config.redact = true
+	}
+}
+
+// newOutputConfig applies the options over a default configuration.
+func newOutputConfig(options []OutputOption) outputConfig {
+	config := outputConfig{}
+
+	for _, option := range options {
+		option(&config)
+	}
+
+	return config
+}
+
+// Redacted returns a copy of the fragment with its text and payload masked, keeping its structural metadata. The
+// length of the payload is kept at the mask. Extensions are dropped, since IE handlers may return content. The
+// keys of Metadata are kept, and its values are masked, since callers may attach any value, such as the number of
+// the sender.
+func (elem MessageElements) Redacted() MessageElements {
+	result := *elem.Clone()
+	result.Message = redactedText(len(elem.RawMessage))
+	result.RawMessage = nil
+	result.Extensions = nil
+	result.Metadata = redactedMetadata(elem.Metadata)
+
+	return result
+}
+
+// Redacted returns a copy of the fragments with their text and payload masked, as done by MessageElements.Redacted.
+func (msgs MessageFragmentations) Redacted() MessageFragmentations {
+	if msgs == nil {
+		return nil
+	}
+
+	result := make(MessageFragmentations, 0, len(msgs))
+	for _, info := range msgs {
+		redacted := info.Redacted()
+		result = append(result, &redacted)
+	}
+
+	return result
+}
+
+// Redacted returns a copy of the message with its text and the values of its Metadata masked, and its parsed
+// content and binary payload dropped, keeping its structural metadata.
+func (assembled AssembledMessage) Redacted() AssembledMessage {
+	assembled.Text = redactedText(len(assembled.Text))
+	assembled.Content = nil
+	assembled.Metadata = redactedMetadata(assembled.Metadata)
+
+	if assembled.Binary != nil {
+		binary := *assembled.Binary
+		binary.Payload = nil
+		assembled.Binary = &binary
+	}
+
+	return assembled
+}
+
+// Redacted returns a copy of the differences with the values of the RawMessage, Message and Metadata fields
+// masked.
+func (diffs Differences) Redacted() Differences {
+	result := make(Differences, 0, len(diffs))
+
+	for _, diff := range diffs {
+		if diff.Field == "RawMessage" || diff.Field == "Message" || diff.Field == "Metadata" {
+			diff.A, diff.B = redactedValue, redactedValue
+		}
+
+		result = append(result, diff)
+	}
+
+	return result
+}
+
+// NewRedactingHandler returns a slog.Handler that redacts the fragments and assembled messages found at the
+// attributes of log records, using their Redacted method, before handing the records over to handler.
+// Attributes holding MessageElements, MessageFragmentations or AssembledMessage values, or pointers to them, are
+// redacted, including inside groups.
+func NewRedactingHandler(handler slog.Handler) slog.Handler {
+	return redactingHandler{handler: handler}
+}
+
+// redactingHandler is the slog.Handler returned by NewRedactingHandler.
+type redactingHandler struct {
+	handler slog.Handler
+}
+
+// Enabled implements slog.Handler.
+func (handler redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return handler.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (handler redactingHandler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(attr))
+		return true
+	})
+
+	return handler.handler.Handle(ctx, redacted)
+}
+
+// WithAttrs implements slog.Handler.
+func (handler redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, attr := range attrs {
+		redacted = append(redacted, redactAttr(attr))
+	}
+
+	return redactingHandler{handler: handler.handler.WithAttrs(redacted)}
+}
+
+// WithGroup implements slog.Handler.
+func (handler redactingHandler) WithGroup(name string) slog.Handler {
+	return redactingHandler{handler: handler.handler.WithGroup(name)}
+}
+
+// redactAttr returns attr with the fragments and assembled messages it holds redacted.
+func redactAttr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+
+	if value.Kind() == slog.KindGroup {
+		group := value.Group()
+
+		redacted := make([]any, 0, len(group))
+		for _, member := range group {
+			redacted = append(redacted, redactAttr(member))
+		}
+
+		return slog.Group(attr.Key, redacted...)
+	}
+
+	if value.Kind() != slog.KindAny {
+		return attr
+	}
+
+	switch content := value.Any().(type) {
+	case MessageElements:
+		return slog.Any(attr.Key, content.Redacted())
+	case *MessageElements:
+		if content != nil {
+			return slog.Any(attr.Key, content.Redacted())
+		}
+	case MessageFragmentations:
+		return slog.Any(attr.Key, content.Redacted())
+	case *MessageFragmentations:
+		if content != nil {
+			return slog.Any(attr.Key, content.Redacted())
+		}
+	case AssembledMessage:
+		return slog.Any(attr.Key, content.Redacted())
+	case *AssembledMessage:
+		if content != nil {
+			return slog.Any(attr.Key, content.Redacted())
+		}
+	}
+
+	return attr
+}
+
+// redactedText returns the mask of a text or payload of length bytes.
+func redactedText(length int) string {
+	return fmt.Sprintf("[REDACTED %d bytes]", length)
+}
+
+// redactedMetadata returns a copy of metadata with its values masked, or nil when metadata is nil.
+func redactedMetadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return nil
+	}
+
+	result := make(map[string]string, len(metadata))
+	for key := range metadata {
+		result[key] = redactedValue
+	}
+
+	return result
+}
+
+// redactPayload replaces the payload of a hex dump, which starts at the byte offset.
+func redactPayload(dump string, offset int) string {
+	if offset*2 >= len(dump) {
+		return dump
+	}
+
+	return dump[:offset*2] + strings.Repeat(redactedHexByte, (len(dump)-offset*2)/2)
+}
@@ -0,0 +1,123 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestRedaction(t *testing.T) {
+	msg := udh.Message("050003A50201546869732069732061206C")
+
+	info, err := msg.ParseElements(udh.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info.Metadata = map[string]string{udh.MetadataSMSC: "This is the SMSC"}
+	fragments := udh.MessageFragmentations{info}
+
+	assembled, err := udh.NewAssembledMessage(udh.MessageFragmentations{
+		{
+			Reference: []byte{0}, TotalParts: 1, CurrentPart: 1, Message: "This is a l", Encoding: udh.ASCII,
+			Metadata: map[string]string{udh.MetadataBind: "This is the bind"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dump, err := udh.DumpHex(msg, udh.WithRedaction())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elementsJSON, err := info.ToJSON(udh.WithRedaction())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fragmentsJSON, err := fragments.ToJSON(udh.WithRedaction())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logs := bytes.Buffer{}
+	logger := slog.New(udh.NewRedactingHandler(slog.NewJSONHandler(&logs, nil)))
+	logger.With(slog.Any("fragment", info)).Info("received",
+		slog.Any("fragments", fragments),
+		slog.Group("delivery", slog.Any("message", assembled)),
+	)
+
+	tests := []struct {
+		name     string
+		output   string
+		contains []string
+	}{
+		{
+			name:     "dump",
+			output:   dump,
+			contains: []string{"050003A50201**********************\n", "Payload (11 bytes)"},
+		},
+		{
+			name:   "elements JSON",
+			output: elementsJSON,
+			contains: []string{
+				`"message":"[REDACTED 11 bytes]"`, `"reference":"pQ=="`, `"current_part":1`,
+				`"metadata":{"smsc":"[REDACTED]"}`,
+			},
+		},
+		{
+			name:     "fragments JSON",
+			output:   fragmentsJSON,
+			contains: []string{`"message":"[REDACTED 11 bytes]"`, `"total_parts":2`},
+		},
+		{
+			name:     "assembled",
+			output:   assembled.Redacted().Text,
+			contains: []string{"[REDACTED 11 bytes]"},
+		},
+		{
+			name:     "assembled metadata",
+			output:   assembled.Redacted().Metadata[udh.MetadataBind],
+			contains: []string{"[REDACTED]"},
+		},
+		{
+			name:   "diff",
+			output: udh.Diff(info, &udh.MessageElements{}).Redacted().String(),
+			contains: []string{
+				"Message: [REDACTED] != [REDACTED]", "TotalParts: 2 != 0", "Metadata: [REDACTED] != [REDACTED]",
+			},
+		},
+		{
+			name:     "log",
+			output:   logs.String(),
+			contains: []string{`"fragment":{`, `"fragments":[{`, `"delivery":{"message":{`, "[REDACTED 11 bytes]"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			if strings.Contains(test.output, "This is") || strings.Contains(test.output, "546869") {
+				t2.Errorf("the text was not redacted: %s", test.output)
+			}
+
+			for _, expected := range test.contains {
+				if !strings.Contains(test.output, expected) {
+					t2.Errorf("%q not found at %s", expected, test.output)
+				}
+			}
+		})
+	}
+
+	if info.Message != "This is a l" || info.Metadata[udh.MetadataSMSC] != "This is the SMSC" {
+		t.Errorf("redaction modified the fragment: %q, %v", info.Message, info.Metadata)
+	}
+}
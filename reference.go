@@ -0,0 +1,126 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"encoding/hex"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// ReferenceMode controls how Messages compares and groups fragments by their reference number.
+type ReferenceMode byte
+
+const (
+	// StrictReferences groups fragments only when their reference bytes are identical, so an 8-bit reference 0x0F
+	// and a 16-bit reference 0x000F belong to different messages.
+	StrictReferences ReferenceMode = iota
+
+	// NormalizedReferences groups fragments by the numeric value of their reference, regardless of its width.
+	NormalizedReferences
+)
+
+// WithReferenceMode sets how fragments are grouped by their reference number. The default is StrictReferences.
+func WithReferenceMode(mode ReferenceMode) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.referenceMode = mode
+	}
+}
+
+// WithReferenceDisambiguation detects references reused by unrelated messages, which happens quickly with 8-bit
+// references on busy senders. A fragment whose TotalParts differs from the fragments already held for its
+// reference, or that arrives when no fragment of its reference arrived for longer than window, starts a new message
+// instead of joining the existing one. A zero window checks only TotalParts.
+//
+// The existing message is kept under an internal key, and is still returned by Complete, DrainComplete, Incomplete
+// and ListAll, and removed by EvictExpired. Lookups by reference, such as Snapshot, return the newest message.
+func WithReferenceDisambiguation(window time.Duration) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.disambiguate = true
+		msgs.referenceWindow = window
+	}
+}
+
+// CanonicalReference returns the 16-bit big endian representation of a reference number, so references of
+// different widths but the same value are equal.
+// References longer than 2 bytes are returned as is.
+func CanonicalReference(reference []byte) []byte {
+	switch len(reference) {
+	case 0:
+		return []byte{0, 0}
+	case 1:
+		return []byte{0, reference[0]}
+	}
+
+	return bytes.Clone(reference)
+}
+
+// ReferencesEqual compares two reference numbers using the given mode.
+func ReferencesEqual(a, b []byte, mode ReferenceMode) bool {
+	if mode == NormalizedReferences {
+		return bytes.Equal(CanonicalReference(a), CanonicalReference(b))
+	}
+
+	return bytes.Equal(a, b)
+}
+
+// referenceKey returns the key of a reference number at the fragments map.
+func (msgs *Messages) referenceKey(reference []byte) string {
+	if msgs.referenceMode == NormalizedReferences {
+		return string(CanonicalReference(reference))
+	}
+
+	return string(reference)
+}
+
+// reusedReference reports whether info belongs to a new message that reuses the reference of set, according to
+// WithReferenceDisambiguation.
+func (msgs *Messages) reusedReference(set *fragmentSet, info *MessageElements, now time.Time) bool {
+	if !msgs.disambiguate || len(*set.fragments) == 0 {
+		return false
+	}
+
+	if (*set.fragments)[0].TotalParts != info.TotalParts {
+		return true
+	}
+
+	return msgs.referenceWindow > 0 && now.Sub(set.lastSeen) > msgs.referenceWindow
+}
+
+// retireSet moves the message at key to a key no reference can produce, so a new message can take key over. The
+// caller must hold the lock.
+func (msgs *Messages) retireSet(key string, set *fragmentSet) error {
+	var retiredKey string
+
+	for generation := 1; ; generation++ {
+		retiredKey = key + "\x00" + strconv.Itoa(generation)
+		if _, taken := msgs.fragments[retiredKey]; !taken {
+			break
+		}
+	}
+
+	err := msgs.logMove(key, retiredKey)
+	if err != nil {
+		return err
+	}
+
+	err = msgs.saveSet(retiredKey, set)
+	if err != nil {
+		return err
+	}
+
+	msgs.untrackSet(key)
+	msgs.deleteSet(key)
+	msgs.trackSet(retiredKey, set)
+
+	msgs.debug("reference reused by a new message",
+		slog.String("reference", hex.EncodeToString(set.fragments.Reference())),
+		slog.Int("received", len(*set.fragments)),
+	)
+
+	return nil
+}
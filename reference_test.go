@@ -0,0 +1,178 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestReferenceMode(t *testing.T) {
+	// the same message, with the first part using a 16-bit reference and the second an 8-bit one
+	parts := []udh.Message{
+		udh.Message("060804000F0201" + "68656C6C6F"),
+		udh.Message("0500030F0202" + "20776F726C64"),
+	}
+
+	tests := []struct {
+		name    string
+		mode    udh.ReferenceMode
+		buckets int
+	}{
+		{name: "strict", mode: udh.StrictReferences, buckets: 2},
+		{name: "normalized", mode: udh.NormalizedReferences, buckets: 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			messages := udh.InitMessages(udh.WithReferenceMode(test.mode))
+
+			for _, part := range parts {
+				err := messages.Add(udh.ASCII, part)
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			if all := messages.ListAll(); len(all) != test.buckets {
+				t2.Fatalf("have %d buckets, expected %d", len(all), test.buckets)
+			}
+
+			fragments := messages.GetMessageFragments([]byte{0x0F})
+			if fragments == nil {
+				t2.Fatal("expected fragments for reference 0x0F")
+			}
+
+			if test.mode == udh.NormalizedReferences && !fragments.HaveAllFragments() {
+				t2.Errorf("expected all fragments, have %d", len(*fragments))
+			}
+		})
+	}
+}
+
+func TestReferencesEqual(t *testing.T) {
+	if udh.ReferencesEqual([]byte{0x0F}, []byte{0x00, 0x0F}, udh.StrictReferences) {
+		t.Error("expected strict comparison to differ")
+	}
+
+	if !udh.ReferencesEqual([]byte{0x0F}, []byte{0x00, 0x0F}, udh.NormalizedReferences) {
+		t.Error("expected normalized comparison to match")
+	}
+
+	if udh.ReferencesEqual([]byte{0x0F}, []byte{0x01, 0x0F}, udh.NormalizedReferences) {
+		t.Error("expected different values to differ")
+	}
+}
+
+func TestReferenceDisambiguation(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   time.Duration
+		parts    []udh.Message
+		wait     time.Duration
+		buckets  int
+		complete []string
+	}{
+		{
+			name: "same message",
+			parts: []udh.Message{
+				udh.Message("050003A50201" + "68656C6C6F"),
+				udh.Message("050003A50202" + "20776F726C64"),
+			},
+			buckets:  1,
+			complete: []string{"hello world"},
+		},
+		{
+			name: "total parts mismatch",
+			parts: []udh.Message{
+				udh.Message("050003A50301" + "6F6C64"),
+				udh.Message("050003A50201" + "68656C6C6F"),
+				udh.Message("050003A50202" + "20776F726C64"),
+			},
+			buckets:  2,
+			complete: []string{"hello world"},
+		},
+		{
+			name:   "window elapsed",
+			window: 10 * time.Millisecond,
+			parts: []udh.Message{
+				udh.Message("050003A50201" + "6F6C64"),
+				udh.Message("050003A50201" + "68656C6C6F"),
+				udh.Message("050003A50202" + "20776F726C64"),
+			},
+			wait:     20 * time.Millisecond,
+			buckets:  2,
+			complete: []string{"hello world"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			messages := udh.InitMessages(udh.WithReferenceDisambiguation(test.window))
+
+			for idx, part := range test.parts {
+				if idx == 1 {
+					time.Sleep(test.wait)
+				}
+
+				err := messages.Add(udh.ASCII, part)
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			if all := messages.ListAll(); len(all) != test.buckets {
+				t2.Errorf("have %d buckets, expected %d", len(all), test.buckets)
+			}
+
+			complete := []string{}
+			for _, fragments := range messages.DrainComplete() {
+				complete = append(complete, fragments.String())
+			}
+
+			if !cmp.Equal(complete, test.complete) {
+				t2.Errorf("have complete messages %q, expected %q", complete, test.complete)
+			}
+
+			if incomplete := messages.Incomplete(); len(incomplete) != test.buckets-1 {
+				t2.Errorf("have %d incomplete messages, expected %d", len(incomplete), test.buckets-1)
+			}
+		})
+	}
+}
+
+func TestReferenceDisambiguationStore(t *testing.T) {
+	store := udh.NewMemoryStore()
+	messages := udh.InitMessages(udh.WithReferenceDisambiguation(0), udh.WithStore(store))
+
+	for _, part := range []udh.Message{
+		udh.Message("050003A50301" + "6F6C64"),
+		udh.Message("050003A50201" + "68656C6C6F"),
+	} {
+		err := messages.Add(udh.ASCII, part)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restored := udh.InitMessages(udh.WithReferenceDisambiguation(0), udh.WithStore(store))
+
+	err := restored.Restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if all := restored.ListAll(); len(all) != 2 {
+		t.Fatalf("have %d restored buckets, expected 2", len(all))
+	}
+
+	newest := restored.Snapshot([]byte{0xA5})
+	if text := newest.String(); text != "hello" {
+		t.Errorf("have newest message %q, expected hello", text)
+	}
+}
@@ -0,0 +1,107 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ik5/smudh/header"
+)
+
+// IEIRegistry holds the Information Element Identifiers recognized when detecting whether a message starts with
+// a UDH. It is the same type as header.Registry.
+type IEIRegistry = header.Registry
+
+// IEHandler receives an information element found while parsing a UDH, and returns a typed value that is attached
+// to MessageElements.Extensions under the IEI of the element. It is the same type as header.Handler.
+// Returning an error fails the parsing.
+type IEHandler = header.Handler
+
+// DefaultIEIRegistry is the registry used by the parser when no other registry was set using WithIEIRegistry.
+// It holds the IEIs defined by 3GPP TS 23.040, and can be extended by the caller. It is header.DefaultRegistry.
+var DefaultIEIRegistry = header.DefaultRegistry
+
+// NewIEIRegistry returns a new registry holding the IEIs defined by 3GPP TS 23.040.
+func NewIEIRegistry() *IEIRegistry {
+	return header.NewRegistry()
+}
+
+// WithIEIRegistry sets the registry used for detecting a UDH.
+func WithIEIRegistry(registry *IEIRegistry) ParseOption {
+	return func(config *parseConfig) {
+		config.registry = registry
+	}
+}
+
+// WithLegacyUDHDetection restores the detection used by earlier versions of the package, where a message is
+// considered to start with a UDH when the first IEI is below 0x20, regardless of the IEI registry.
+func WithLegacyUDHDetection() ParseOption {
+	return func(config *parseConfig) {
+		config.legacyDetection = true
+	}
+}
+
+// WithoutUDHDetection parses every message as standalone, regardless of its leading bytes, for feeds that never
+// carry a UDH, where the first payload byte may legitimately look like a UDH Length, such as binary protocols and
+// UCS2 text starting with a character below U+2000.
+func WithoutUDHDetection() ParseOption {
+	return func(config *parseConfig) {
+		config.standaloneOnly = true
+	}
+}
+
+// detectUDH decides whether binary starts with a UDH, and returns a description of the reason, using the
+// detection selected by the options.
+func (config parseConfig) detectUDH(binary []byte) (bool, string) {
+	if config.standaloneOnly {
+		return false, "UDH detection disabled"
+	}
+
+	if config.udhRequired {
+		return true, "UDH required"
+	}
+
+	if config.legacyDetection {
+		return header.DetectLegacy(binary)
+	}
+
+	return config.ieiRegistry().Detect(binary)
+}
+
+// ieiRegistry returns the registry set by WithIEIRegistry, or DefaultIEIRegistry.
+func (config parseConfig) ieiRegistry() *IEIRegistry {
+	if config.registry == nil {
+		return DefaultIEIRegistry
+	}
+
+	return config.registry
+}
+
+// handleIEs calls the registered handlers for the elements of headerBytes, and stores their results at Extensions.
+func (elem *MessageElements) handleIEs(config parseConfig, headerBytes []byte) error {
+	registry := config.ieiRegistry()
+
+	for _, element := range header.Split(headerBytes) {
+		handler := registry.Handler(element.Identifier)
+		if handler == nil {
+			continue
+		}
+
+		value, err := handler(InformationElement{Identifier: element.Identifier, Data: bytes.Clone(element.Data)})
+		if err != nil {
+			return fmt.Errorf("IEI 0x%02X handler: %w", element.Identifier, err)
+		}
+
+		if elem.Extensions == nil {
+			elem.Extensions = map[byte]any{}
+		}
+
+		elem.Extensions[element.Identifier] = value
+		elem.Trace.add("extension", "IEI 0x%02X handled by a registered handler", element.Identifier)
+	}
+
+	return nil
+}
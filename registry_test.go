@@ -0,0 +1,155 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestUDHDetection(t *testing.T) {
+	registry := udh.NewIEIRegistry()
+	registry.Register(0x70, "Operator specific")
+
+	tests := []struct {
+		name       string
+		input      udh.Message
+		options    []udh.ParseOption
+		standalone bool
+		err        error
+	}{
+		{
+			name:  "RFC 822 header IEI is detected",
+			input: udh.Message("0320010068656C6C6F"),
+		},
+		{
+			name:       "RFC 822 header IEI using legacy detection",
+			input:      udh.Message("0320010068656C6C6F"),
+			options:    []udh.ParseOption{udh.WithLegacyUDHDetection()},
+			standalone: true,
+		},
+		{
+			name:       "control bytes that do not form a header",
+			input:      udh.Message("050041424344454647"),
+			standalone: true,
+		},
+		{
+			name:    "control bytes using legacy detection",
+			input:   udh.Message("050041424344454647"),
+			options: []udh.ParseOption{udh.WithLegacyUDHDetection()},
+		},
+		{
+			name:       "unregistered IEI",
+			input:      udh.Message("03700100414243"),
+			standalone: true,
+		},
+		{
+			name:       "concatenation header with detection disabled",
+			input:      udh.Message("0500030A0201414243"),
+			options:    []udh.ParseOption{udh.WithoutUDHDetection()},
+			standalone: true,
+		},
+		{
+			name:       "RFC 822 header IEI with detection disabled",
+			input:      udh.Message("0320010068656C6C6F"),
+			options:    []udh.ParseOption{udh.WithoutUDHDetection(), udh.WithLegacyUDHDetection()},
+			standalone: true,
+		},
+		{
+			name:    "registered custom IEI",
+			input:   udh.Message("03700100414243"),
+			options: []udh.ParseOption{udh.WithIEIRegistry(registry)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			elements, err := test.input.ParseElements(udh.ASCII, test.options...)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if elements.Standalone != test.standalone {
+				t2.Errorf("have standalone %t, expected %t", elements.Standalone, test.standalone)
+			}
+		})
+	}
+
+	profile, err := udh.LookupQuirkProfile(udh.QuirkNoUDH)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elements, err := udh.Message("0500030A0201414243").ParseElements(udh.ASCII, profile.ParseOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !elements.Standalone || elements.Message != "\x05\x00\x03\n\x02\x01ABC" {
+		t.Errorf("have standalone %t with %q using the %s profile, expected the whole payload as text",
+			elements.Standalone, elements.Message, udh.QuirkNoUDH)
+	}
+}
+
+func TestIEIRegistry(t *testing.T) {
+	registry := udh.NewIEIRegistry()
+
+	if !registry.Known(udh.IEIConcatenated8Bit) {
+		t.Error("expected 0x00 to be known")
+	}
+
+	registry.Unregister(udh.IEIConcatenated8Bit)
+	if registry.Known(udh.IEIConcatenated8Bit) || registry.Name(udh.IEIConcatenated8Bit) != "" {
+		t.Error("expected 0x00 to be unknown")
+	}
+
+	if !udh.DefaultIEIRegistry.Known(udh.IEIConcatenated8Bit) {
+		t.Error("the default registry was modified")
+	}
+}
+
+type operatorInfo struct {
+	Code byte
+}
+
+func TestIEHandler(t *testing.T) {
+	registry := udh.NewIEIRegistry()
+	registry.RegisterHandler(0x70, "Operator info", func(element udh.InformationElement) (any, error) {
+		if len(element.Data) != 1 {
+			return nil, udh.ErrInputTooShortForUDH
+		}
+
+		return operatorInfo{Code: element.Data[0]}, nil
+	})
+
+	elements, err := udh.Message("087001AB0003120201"+"68656C6C6F").ParseElements(udh.ASCII, udh.WithIEIRegistry(registry))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Element != udh.IEIConcatenated8Bit || elements.TotalParts != 2 || elements.CurrentPart != 1 {
+		t.Errorf("unexpected concatenation: %+v", *elements)
+	}
+
+	info, ok := elements.Extensions[0x70].(operatorInfo)
+	if !ok || info.Code != 0xAB {
+		t.Errorf("unexpected extension: %#v", elements.Extensions)
+	}
+
+	if elements.Message != "hello" {
+		t.Errorf("have %q", elements.Message)
+	}
+
+	_, err = udh.Message("09700200AB0003120201"+"68656C6C6F").ParseElements(udh.ASCII, udh.WithIEIRegistry(registry))
+	if !errors.Is(err, udh.ErrInputTooShortForUDH) {
+		t.Errorf("have err: %v, expected: %v", err, udh.ErrInputTooShortForUDH)
+	}
+}
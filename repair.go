@@ -0,0 +1,141 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+)
+
+// WithRepair makes Messages repair messages whose fragments disagree about their numbering, as sent by some
+// handsets, using MessageFragmentations.Repaired after every added fragment. Repaired messages replace the
+// fragments they were made of, so they are completed, assembled and stored as any other message.
+//
+// Fragments replayed from a WAL are repaired again once another fragment of their message is added.
+func WithRepair() MessagesOption {
+	return func(msgs *Messages) {
+		msgs.repair = true
+	}
+}
+
+// Repaired returns a repaired copy of fragments whose numbering is inconsistent, choosing the most plausible
+// numbering, and true when the fragments were repaired. The following are repaired:
+//
+//   - Part numbers counted from zero, which are shifted by one.
+//   - Exact retransmissions of a part, which are removed.
+//   - Part numbers used by more than one fragment, where a fragment is moved to the next part number, keeping the
+//     order of arrival, and shifting the following parts by one.
+//   - Fragments that disagree about TotalParts.
+//
+// A repair is made only when the result is a complete message, with parts numbered from 1 to a number of parts
+// claimed by at least one of the fragments. Every fragment of the copy has its Repaired field set, and the changes
+// made to a fragment are added to its warnings.
+// Returns the receiver and false when the fragments are consistent, or cannot be repaired.
+func (msgs MessageFragmentations) Repaired() (MessageFragmentations, bool) {
+	if len(msgs) == 0 || msgs[0].Standalone || msgs.consistent() {
+		return msgs, false
+	}
+
+	fragments := slices.Clone(msgs.ordered())
+
+	// exact retransmissions
+	fragments = slices.CompactFunc(fragments, func(a, b *MessageElements) bool {
+		return fragmentDigest(a) == fragmentDigest(b)
+	})
+
+	parts := make([]int, len(fragments))
+	for idx, info := range fragments {
+		parts[idx] = int(info.CurrentPart)
+	}
+
+	if parts[0] == 0 {
+		for idx := range parts {
+			parts[idx]++
+		}
+	}
+
+	for idx := 1; idx < len(parts); idx++ {
+		if parts[idx] <= parts[idx-1] {
+			parts[idx] = parts[idx-1] + 1
+		}
+	}
+
+	total := len(fragments)
+
+	claimed := slices.ContainsFunc(fragments, func(info *MessageElements) bool {
+		return int(info.TotalParts) == total
+	})
+
+	if !claimed || parts[0] != 1 || parts[total-1] != total {
+		return msgs, false
+	}
+
+	repaired := make(MessageFragmentations, 0, total)
+
+	for idx, info := range fragments {
+		clone := info.Clone()
+		clone.Repaired = true
+
+		if int(clone.CurrentPart) != parts[idx] {
+			clone.Warnings = append(clone.Warnings, fmt.Sprintf("part %d renumbered to %d", clone.CurrentPart, parts[idx]))
+			clone.CurrentPart = byte(parts[idx])
+		}
+
+		if int(clone.TotalParts) != total {
+			clone.Warnings = append(clone.Warnings, fmt.Sprintf("total parts %d changed to %d", clone.TotalParts, total))
+			clone.TotalParts = byte(total)
+		}
+
+		repaired = append(repaired, clone)
+	}
+
+	return repaired, true
+}
+
+// consistent reports whether every fragment claims the same number of parts, and the parts are numbered from 1 to
+// that number, each used once.
+func (msgs MessageFragmentations) consistent() bool {
+	total := int(msgs[0].TotalParts)
+	if len(msgs) != total {
+		return false
+	}
+
+	for idx, info := range msgs.ordered() {
+		if int(info.TotalParts) != total || int(info.CurrentPart) != idx+1 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// repairSet replaces the fragments of set with their repaired copy when they can be repaired, and stores it. The
+// caller must hold the lock.
+func (msgs *Messages) repairSet(key string, set *fragmentSet) {
+	repaired, ok := set.fragments.Repaired()
+	if !ok {
+		return
+	}
+
+	unrepaired := *set.fragments
+	*set.fragments = repaired
+
+	err := msgs.saveSet(key, set)
+	if err != nil {
+		// the message stays as it was received, and is repaired again by the next fragment
+		*set.fragments = unrepaired
+
+		msgs.debug("repaired message not stored", slog.Any("error", err))
+
+		return
+	}
+
+	msgs.debug("message repaired",
+		slog.String("reference", hex.EncodeToString(repaired.Reference())),
+		slog.Int("parts", len(repaired)),
+	)
+}
@@ -0,0 +1,126 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestRepaired(t *testing.T) {
+	fragment := func(total, part byte, text string) *udh.MessageElements {
+		return &udh.MessageElements{
+			Reference: []byte{0xA5}, TotalParts: total, CurrentPart: part, Message: text, RawMessage: []byte(text),
+			Encoding: udh.ASCII,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		fragments udh.MessageFragmentations
+		repaired  bool
+		text      string
+	}{
+		{
+			name:      "consistent",
+			fragments: udh.MessageFragmentations{fragment(2, 1, "hello "), fragment(2, 2, "world")},
+		},
+		{
+			name:      "incomplete",
+			fragments: udh.MessageFragmentations{fragment(3, 1, "hello "), fragment(3, 2, "world")},
+		},
+		{
+			name:      "zero based",
+			fragments: udh.MessageFragmentations{fragment(2, 0, "hello "), fragment(2, 1, "world")},
+			repaired:  true, text: "hello world",
+		},
+		{
+			name: "duplicated part number",
+			fragments: udh.MessageFragmentations{
+				fragment(3, 1, "hello "), fragment(3, 2, "wor"), fragment(3, 2, "ld"),
+			},
+			repaired: true, text: "hello world",
+		},
+		{
+			name: "retransmission",
+			fragments: udh.MessageFragmentations{
+				fragment(2, 1, "hello "), fragment(2, 1, "hello "), fragment(2, 2, "world"),
+			},
+			repaired: true, text: "hello world",
+		},
+		{
+			name:      "total parts disagree",
+			fragments: udh.MessageFragmentations{fragment(2, 1, "hello "), fragment(3, 2, "world")},
+			repaired:  true, text: "hello world",
+		},
+		{
+			name:      "unclaimed total",
+			fragments: udh.MessageFragmentations{fragment(3, 0, "hello "), fragment(3, 1, "world")},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			original := test.fragments.Clone()
+
+			repaired, ok := test.fragments.Repaired()
+			if ok != test.repaired {
+				t2.Fatalf("have repaired %t, expected %t", ok, test.repaired)
+			}
+
+			if diff := cmp.Diff(original, test.fragments, cmp.AllowUnexported(udh.MessageElements{})); diff != "" {
+				t2.Errorf("the fragments were modified: %s", diff)
+			}
+
+			if !ok {
+				return
+			}
+
+			if !repaired.HaveAllFragments() || len(repaired.MissingParts()) != 0 {
+				t2.Errorf("repaired fragments %v are not complete", repaired)
+			}
+
+			if text := repaired.Assembled(); text != test.text {
+				t2.Errorf("have text %q, expected %q", text, test.text)
+			}
+
+			for _, info := range repaired {
+				if !info.Repaired {
+					t2.Errorf("part %d is not flagged as repaired", info.CurrentPart)
+				}
+			}
+		})
+	}
+}
+
+func TestMessagesRepair(t *testing.T) {
+	messages := udh.InitMessages(udh.WithRepair())
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50200" + "68656C6C6F20"),
+		udh.Message("050003A50201" + "776F726C64"),
+	} {
+		err := messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	complete := messages.DrainComplete()
+	if len(complete) != 1 {
+		t.Fatalf("have %d complete messages, expected 1", len(complete))
+	}
+
+	assembled, err := udh.NewAssembledMessage(*complete[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if assembled.Text != "hello world" || !assembled.Repaired {
+		t.Errorf("have assembled message %+v, expected a repaired \"hello world\"", assembled)
+	}
+}
@@ -0,0 +1,76 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ResegmentOptions holds the settings used by Resegment.
+type ResegmentOptions struct {
+	// Settings of the new segments, the same as for SegmentText, including their MaxUserDataLength
+	SegmentOptions
+
+	// Reuse the reference number and the application port addressing of the fragments, for the settings that are
+	// not set by SegmentOptions. Otherwise the UDH is generated from SegmentOptions alone.
+	PreserveHeader bool
+}
+
+// Resegment splits the content of a complete message into segments for a different target, such as a forwarding
+// gateway whose SMSC uses another encoding, reference numbering or segment size.
+//
+// Text messages are assembled and encoded again using enc. Binary messages keep their payload octets, and can be
+// resegmented only using a binary encoding.
+// Returns ErrMessageNotComplete if not all of the fragments exist, ErrUnsupportedEncoding when a binary message
+// targets a text encoding, or any error of SegmentText.
+func Resegment(fragments MessageFragmentations, enc Encoding, options ResegmentOptions) ([]Segment, error) {
+	if !fragments.HaveAllFragments() {
+		return nil, ErrMessageNotComplete
+	}
+
+	segmentOptions := options.SegmentOptions
+
+	if options.PreserveHeader {
+		if len(segmentOptions.Reference) == 0 {
+			segmentOptions.Reference = bytes.Clone(fragments.Reference())
+		}
+
+		for _, info := range fragments.ordered() {
+			if segmentOptions.Ports == nil && info.Ports != nil {
+				ports := *info.Ports
+				segmentOptions.Ports = &ports
+			}
+		}
+	}
+
+	if source := fragments[0].Encoding; source != Binary8Bit1 && source != Binary8Bit2 {
+		segments, err := SegmentText(fragments.Assembled(), enc, segmentOptions)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		return segments, nil
+	}
+
+	if enc != Binary8Bit1 && enc != Binary8Bit2 {
+		return nil, fmt.Errorf("%w: binary content cannot be resegmented as %s", ErrUnsupportedEncoding, enc)
+	}
+
+	if len(segmentOptions.Reference) > 2 {
+		return nil, ErrInvalidReferenceLength
+	}
+
+	if segmentOptions.MaxUserDataLength < 0 || segmentOptions.MaxUserDataLength > maxUserDataLength {
+		return nil, ErrInvalidSegmentSize
+	}
+
+	segments, err := segmentBinary(fragments.PayloadBytes(), enc, segmentOptions.forEncoding(enc))
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return segments, nil
+}
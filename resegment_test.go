@@ -0,0 +1,117 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestResegment(t *testing.T) {
+	text := strings.Repeat("forwarded message ", 12)
+
+	original, err := udh.SegmentText(text, udh.GSM, udh.SegmentOptions{
+		Reference: []byte{0x2A}, Ports: &udh.Ports{Destination: 0x1234, Source: 0x5678},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fragments := udh.MessageFragmentations{}
+	for _, segment := range original {
+		err = fragments.Add(udh.GSM, segment.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		fragments udh.MessageFragmentations
+		encoding  udh.Encoding
+		options   udh.ResegmentOptions
+		reference string
+		ports     *udh.Ports
+		payloads  []int
+		err       error
+	}{
+		{
+			name: "preserved header", fragments: fragments, encoding: udh.UCS2,
+			options:   udh.ResegmentOptions{PreserveHeader: true},
+			reference: "2a", ports: &udh.Ports{Destination: 0x1234, Source: 0x5678}, payloads: []int{128, 128, 128, 48},
+		},
+		{
+			name: "regenerated header", fragments: fragments, encoding: udh.GSM,
+			options: udh.ResegmentOptions{
+				SegmentOptions: udh.SegmentOptions{Reference: []byte{0x01, 0x02}, MaxUserDataLength: 100},
+			},
+			reference: "0102", payloads: []int{106, 106, 4},
+		},
+		{
+			name: "missing reference", fragments: fragments, encoding: udh.GSM, err: udh.ErrReferenceRequired,
+		},
+		{
+			name: "incomplete", fragments: fragments[:1], encoding: udh.GSM, err: udh.ErrMessageNotComplete,
+		},
+		{
+			name:      "binary as text",
+			fragments: udh.MessageFragmentations{{Encoding: udh.Binary8Bit2, RawMessage: []byte{0x01}, Standalone: true}},
+			encoding:  udh.GSM, err: udh.ErrUnsupportedEncoding,
+		},
+		{
+			name:      "binary",
+			fragments: udh.MessageFragmentations{{Encoding: udh.Binary8Bit2, RawMessage: []byte{0x01}, Standalone: true}},
+			encoding:  udh.Binary8Bit1, payloads: []int{1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			segments, err := udh.Resegment(test.fragments, test.encoding, test.options)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			payloads := []int(nil)
+			for _, segment := range segments {
+				payloads = append(payloads, len(segment.Payload))
+			}
+
+			if diff := cmp.Diff(test.payloads, payloads); diff != "" {
+				t2.Errorf("unexpected payload lengths (-want +got):\n%s", diff)
+			}
+
+			if len(segments) < 2 {
+				return
+			}
+
+			result := udh.MessageFragmentations{}
+			for _, segment := range segments {
+				err = result.Add(test.encoding, segment.Hex())
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			assembled, err := udh.NewAssembledMessage(result)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if assembled.Text != text || assembled.Reference != test.reference {
+				t2.Errorf("have text %q with reference %q, expected %q with %q",
+					assembled.Text, assembled.Reference, text, test.reference)
+			}
+
+			if diff := cmp.Diff(test.ports, assembled.Ports); diff != "" {
+				t2.Errorf("unexpected ports (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// Tags of the SMPP optional parameters describing a segment of a concatenated message, used instead of a UDH by
+// data_sm and by SMSCs that deliver message_payload.
+const (
+	TagSARMsgRefNum     uint16 = 0x020C
+	TagSARTotalSegments uint16 = 0x020E
+	TagSARSegmentSeqnum uint16 = 0x020F
+)
+
+// sarReferenceLength is the length of the sar_msg_ref_num parameter.
+const sarReferenceLength = 2
+
+// SAR holds the sar_msg_ref_num, sar_total_segments and sar_segment_seqnum optional parameters of a PDU.
+type SAR struct {
+	// The sar_msg_ref_num parameter
+	Reference uint16 `json:"reference"`
+
+	// The sar_total_segments parameter
+	TotalSegments byte `json:"total_segments"`
+
+	// The sar_segment_seqnum parameter
+	SegmentNumber byte `json:"segment_number"`
+}
+
+// WithSAR numbers a message that has no UDH using the SAR parameters of its PDU: its 16-bit Reference, TotalParts
+// and CurrentPart are set from sar, the same as a concatenation IE 0x08 would.
+//
+// Fragments of the same message keep a single reference bucket when some of them arrive with a UDH in
+// short_message and others with SAR parameters, such as after a carrier failover between binds. An 8-bit UDH
+// reference and the 16-bit SAR reference of the same value share a bucket only when using NormalizedReferences.
+// A message with a UDH keeps the numbering of its UDH.
+func WithSAR(sar SAR) ParseOption {
+	return func(config *parseConfig) {
+		config.sar = &sar
+	}
+}
+
+// applySAR numbers a message without a UDH using the parameters set by WithSAR.
+func (elem *MessageElements) applySAR(config parseConfig) {
+	if config.sar == nil || elem.HeaderLength > 0 {
+		return
+	}
+
+	elem.Standalone = false
+	elem.Element = IEIConcatenated16Bit
+	elem.ElementLength = sarReferenceLength + 2
+	elem.Reference = binary.BigEndian.AppendUint16(nil, config.sar.Reference)
+	elem.TotalParts = config.sar.TotalSegments
+	elem.CurrentPart = config.sar.SegmentNumber
+
+	elem.Trace.add("sar", "SAR parameters: reference %d, part %d of %d",
+		config.sar.Reference, config.sar.SegmentNumber, config.sar.TotalSegments)
+}
+
+// SAR returns the SAR parameters of the PDU, when all three of them exist and are well formed.
+func (pdu *PDU) SAR() (SAR, bool) {
+	reference, found := pdu.TLV(TagSARMsgRefNum)
+	if !found || len(reference) != sarReferenceLength {
+		return SAR{}, false
+	}
+
+	total, found := pdu.TLV(TagSARTotalSegments)
+	if !found || len(total) != 1 {
+		return SAR{}, false
+	}
+
+	number, found := pdu.TLV(TagSARSegmentSeqnum)
+	if !found || len(number) != 1 {
+		return SAR{}, false
+	}
+
+	return SAR{Reference: binary.BigEndian.Uint16(reference), TotalSegments: total[0], SegmentNumber: number[0]}, true
+}
+
+// Elements parses the UserData of the PDU using the Encoding of its data_coding for the given SMPP interface
+// version, and numbers it using its SAR parameters when it has no UDH, see WithSAR. The MessageClass is set from the
+// data_coding.
+func (pdu *PDU) Elements(version InterfaceVersion, options ...ParseOption) (*MessageElements, error) {
+	encoding, err := EncodingFromDataCodingVersion(pdu.DataCoding, version)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return pdu.Message().ParseElements(encoding, slices.Concat(options, pdu.parseOptions())...)
+}
+
+// parseOptions returns the options for parsing the UserData of the PDU.
+func (pdu *PDU) parseOptions() []ParseOption {
+	options := []ParseOption{WithMessageClass(MessageClassFromDataCoding(pdu.DataCoding))}
+
+	if sar, found := pdu.SAR(); found {
+		options = append(options, WithSAR(sar))
+	}
+
+	return options
+}
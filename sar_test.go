@@ -0,0 +1,132 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+// dataSM renders a data_sm PDU with the given data_coding and optional parameters.
+func dataSM(dataCoding byte, tlvs ...byte) []byte {
+	return buildPDU(udh.CommandDataSM,
+		[]byte{0x00, 0x01, 0x01}, []byte("123\x00"), []byte{0x01, 0x01}, []byte("456\x00"),
+		[]byte{0x00, 0x00, dataCoding}, tlvs,
+	)
+}
+
+func TestPDUSAR(t *testing.T) {
+	tests := []struct {
+		name     string
+		pdu      []byte
+		expected udh.SAR
+		found    bool
+	}{
+		{
+			name: "all parameters",
+			pdu: dataSM(0x03,
+				0x02, 0x0C, 0x00, 0x02, 0x01, 0x2A, 0x02, 0x0E, 0x00, 0x01, 0x03, 0x02, 0x0F, 0x00, 0x01, 0x02),
+			expected: udh.SAR{Reference: 0x012A, TotalSegments: 3, SegmentNumber: 2}, found: true,
+		},
+		{
+			name: "missing segment number",
+			pdu:  dataSM(0x03, 0x02, 0x0C, 0x00, 0x02, 0x01, 0x2A, 0x02, 0x0E, 0x00, 0x01, 0x03),
+		},
+		{
+			name: "short reference",
+			pdu:  dataSM(0x03, 0x02, 0x0C, 0x00, 0x01, 0x2A, 0x02, 0x0E, 0x00, 0x01, 0x03, 0x02, 0x0F, 0x00, 0x01, 0x02),
+		},
+		{name: "no parameters", pdu: dataSM(0x03)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			pdu, err := udh.ParsePDU(test.pdu)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			sar, found := pdu.SAR()
+			if found != test.found {
+				t2.Fatalf("have found %t, expected %t", found, test.found)
+			}
+
+			if diff := cmp.Diff(test.expected, sar); diff != "" {
+				t2.Errorf("unexpected SAR (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithSAR(t *testing.T) {
+	sar := udh.WithSAR(udh.SAR{Reference: 0x2A, TotalSegments: 2, SegmentNumber: 2})
+
+	elements, err := udh.Message("42").ParseElements(udh.Latin1, sar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Standalone || elements.TotalParts != 2 || elements.CurrentPart != 2 ||
+		!cmp.Equal(elements.Reference, []byte{0x00, 0x2A}) {
+		t.Errorf("have part %d of %d with reference %X, expected part 2 of 2 with reference 002A",
+			elements.CurrentPart, elements.TotalParts, elements.Reference)
+	}
+
+	elements, err = udh.Message("0500030B0201"+"41").ParseElements(udh.Latin1, sar)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.CurrentPart != 1 || !cmp.Equal(elements.Reference, []byte{0x0B}) {
+		t.Errorf("have part %d with reference %X, expected the UDH numbering", elements.CurrentPart, elements.Reference)
+	}
+}
+
+func TestMessagesAddPDUMixedSources(t *testing.T) {
+	messages := udh.InitMessages(udh.WithReferenceMode(udh.NormalizedReferences))
+	ctx := context.Background()
+
+	// the first part arrives with a UDH on one bind, and the second as a data_sm with SAR parameters on another
+	err := messages.AddPDU(ctx, deliverSM(udh.ESMClassUDHI, 0x03, []byte{0x05, 0x00, 0x03, 0x2A, 0x02, 0x01, 0x41}),
+		udh.SMPP34)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = messages.AddPDU(ctx, dataSM(0x03,
+		0x04, 0x24, 0x00, 0x02, 0x42, 0x43,
+		0x02, 0x0C, 0x00, 0x02, 0x00, 0x2A, 0x02, 0x0E, 0x00, 0x01, 0x02, 0x02, 0x0F, 0x00, 0x01, 0x02,
+	), udh.SMPP34)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	complete := messages.Complete()
+	if len(complete) != 1 {
+		t.Fatalf("have %d complete messages, expected 1", len(complete))
+	}
+
+	if text := complete[0].Assembled(); text != "ABC" {
+		t.Errorf("have text %q, expected %q", text, "ABC")
+	}
+
+	pdu, err := udh.ParsePDU(dataSM(0x03, 0x04, 0x24, 0x00, 0x02, 0x42, 0x43,
+		0x02, 0x0C, 0x00, 0x02, 0x00, 0x2A, 0x02, 0x0E, 0x00, 0x01, 0x02, 0x02, 0x0F, 0x00, 0x01, 0x02))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elements, err := pdu.Elements(udh.SMPP34)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Message != "BC" || elements.CurrentPart != 2 {
+		t.Errorf("have %q as part %d, expected %q as part 2", elements.Message, elements.CurrentPart, "BC")
+	}
+}
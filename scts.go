@@ -0,0 +1,121 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"time"
+)
+
+// SCTSLength is the length in octets of a service centre time stamp.
+const SCTSLength = 7
+
+const (
+	// sctsNegative is the bit of the time zone octet marking an offset west of UTC
+	sctsNegative = 0x08
+
+	// sctsQuarter is the unit of the time zone of a time stamp
+	sctsQuarter = 15 * time.Minute
+
+	// sctsMaxQuarters is the largest time zone offset that fits in the octet, in quarters of an hour
+	sctsMaxQuarters = 79
+)
+
+// DecodeSCTS decodes a service centre time stamp, the TP-SCTS of 3GPP TS 23.040 also used for the discharge time
+// of status reports: seven octets of swapped semi-octets holding the year, month, day, hour, minute, second and
+// the time zone in quarters of an hour, signed by bit 3 of its octet.
+//
+// The two digit year is taken to be between 2000 and 2099. The returned time uses a fixed zone of the offset of the
+// time stamp. Returns an error wrapping ErrInvalidSCTS when scts is not 7 octets long, or holds an invalid digit or
+// date.
+func DecodeSCTS(scts []byte) (time.Time, error) {
+	if len(scts) != SCTSLength {
+		return time.Time{}, fmt.Errorf("%w: %d octets, expected %d", ErrInvalidSCTS, len(scts), SCTSLength)
+	}
+
+	var fields [SCTSLength - 1]int
+
+	for idx := range fields {
+		value, ok := semiOctets(scts[idx])
+		if !ok {
+			return time.Time{}, fmt.Errorf("%w: octet %d is 0x%02X", ErrInvalidSCTS, idx, scts[idx])
+		}
+
+		fields[idx] = value
+	}
+
+	zone := scts[SCTSLength-1]
+
+	quarters, ok := semiOctets(zone &^ sctsNegative)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%w: time zone octet is 0x%02X", ErrInvalidSCTS, zone)
+	}
+
+	offset := time.Duration(quarters) * sctsQuarter
+	if zone&sctsNegative != 0 {
+		offset = -offset
+	}
+
+	year, month, day, hour, minute, second := 2000+fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4],
+		fields[5]
+
+	stamp := time.Date(year, month, day, hour, minute, second, 0, time.FixedZone("", int(offset/time.Second)))
+
+	if stamp.Month() != month || stamp.Day() != day || stamp.Hour() != hour || stamp.Minute() != minute ||
+		stamp.Second() != second {
+		return time.Time{}, fmt.Errorf("%w: %02d-%02d-%02d %02d:%02d:%02d is not a valid time", ErrInvalidSCTS,
+			fields[0], fields[1], fields[2], fields[3], fields[4], fields[5])
+	}
+
+	return stamp, nil
+}
+
+// EncodeSCTS encodes stamp as a service centre time stamp, the reverse of DecodeSCTS. The offset of its zone is
+// rounded down to a quarter of an hour. Returns an error wrapping ErrInvalidSCTS when the year is not between 2000
+// and 2099, or the offset does not fit in the time zone octet.
+func EncodeSCTS(stamp time.Time) ([]byte, error) {
+	if stamp.Year() < 2000 || stamp.Year() > 2099 {
+		return nil, fmt.Errorf("%w: year %d is out of range", ErrInvalidSCTS, stamp.Year())
+	}
+
+	_, offset := stamp.Zone()
+
+	zone := byte(0)
+	if offset < 0 {
+		zone = sctsNegative
+		offset = -offset
+	}
+
+	quarters := time.Duration(offset) * time.Second / sctsQuarter
+	if quarters > sctsMaxQuarters {
+		return nil, fmt.Errorf("%w: time zone offset of %s is out of range", ErrInvalidSCTS,
+			time.Duration(offset)*time.Second)
+	}
+
+	return []byte{
+		toSemiOctets(stamp.Year() - 2000),
+		toSemiOctets(int(stamp.Month())),
+		toSemiOctets(stamp.Day()),
+		toSemiOctets(stamp.Hour()),
+		toSemiOctets(stamp.Minute()),
+		toSemiOctets(stamp.Second()),
+		toSemiOctets(int(quarters)) | zone,
+	}, nil
+}
+
+// semiOctets returns the two digit value of a swapped semi-octet pair, where the low nibble is the first digit.
+func semiOctets(octet byte) (int, bool) {
+	tens, units := octet&0x0F, octet>>4
+	if tens > 9 || units > 9 {
+		return 0, false
+	}
+
+	return int(tens)*10 + int(units), true
+}
+
+// toSemiOctets returns the swapped semi-octet pair of a two digit value.
+func toSemiOctets(value int) byte {
+	return byte(value%10)<<4 | byte(value/10)
+}
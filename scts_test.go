@@ -0,0 +1,81 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestDecodeSCTS(t *testing.T) {
+	tests := []struct {
+		name     string
+		scts     []byte
+		expected time.Time
+		err      error
+	}{
+		{
+			name:     "east of UTC",
+			scts:     []byte{0x42, 0x30, 0x71, 0x21, 0x43, 0x50, 0x80},
+			expected: time.Date(2024, 3, 17, 12, 34, 5, 0, time.FixedZone("", 2*60*60)),
+		},
+		{
+			name:     "west of UTC",
+			scts:     []byte{0x21, 0x20, 0x11, 0x21, 0x43, 0x50, 0x8A},
+			expected: time.Date(2012, 2, 11, 12, 34, 5, 0, time.FixedZone("", -7*60*60)),
+		},
+		{
+			name:     "quarter hour offset",
+			scts:     []byte{0x52, 0x21, 0x13, 0x32, 0x95, 0x95, 0x32},
+			expected: time.Date(2025, 12, 31, 23, 59, 59, 0, time.FixedZone("", 5*60*60+45*60)),
+		},
+		{name: "too short", scts: []byte{0x42, 0x30, 0x71, 0x21, 0x43, 0x50}, err: udh.ErrInvalidSCTS},
+		{name: "invalid digit", scts: []byte{0x42, 0x30, 0x7A, 0x21, 0x43, 0x50, 0x80}, err: udh.ErrInvalidSCTS},
+		{name: "invalid time zone", scts: []byte{0x42, 0x30, 0x71, 0x21, 0x43, 0x50, 0xA0}, err: udh.ErrInvalidSCTS},
+		{name: "invalid date", scts: []byte{0x42, 0x20, 0x03, 0x21, 0x43, 0x50, 0x80}, err: udh.ErrInvalidSCTS},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			stamp, err := udh.DecodeSCTS(test.scts)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("expected error %v, got %v", test.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !stamp.Equal(test.expected) {
+				t2.Errorf("have %s, expected %s", stamp, test.expected)
+			}
+
+			_, haveOffset := stamp.Zone()
+			_, expectedOffset := test.expected.Zone()
+
+			if haveOffset != expectedOffset {
+				t2.Errorf("have offset %d, expected %d", haveOffset, expectedOffset)
+			}
+
+			encoded, err := udh.EncodeSCTS(stamp)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.scts, encoded); diff != "" {
+				t2.Errorf("unexpected encoded time stamp (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	_, err := udh.EncodeSCTS(time.Date(1999, 1, 1, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, udh.ErrInvalidSCTS) {
+		t.Errorf("expected an error wrapping ErrInvalidSCTS for 1999, got %v", err)
+	}
+}
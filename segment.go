@@ -0,0 +1,470 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ik5/smudh/charset"
+)
+
+// maxUserDataLength is the maximum number of octets of a short message user data, including the UDH.
+const maxUserDataLength = 140
+
+// minSegmentCapacity is the smallest number of payload units a segment must hold, so every character fits, including
+// a UTF-16 surrogate pair.
+const minSegmentCapacity = 4
+
+// maxSegments is the maximum number of parts a concatenated message may have.
+const maxSegments = 0xFF
+
+// Segment is a single outbound short message: an optional UDH followed by the encoded payload.
+type Segment struct {
+	// Full UDH including the UDH Length octet, empty when there is no UDH
+	Header []byte `json:"header"`
+
+	// Encoded payload
+	Payload []byte `json:"payload"`
+
+	// Number of payload units (septets for GSM 7-bit, octets otherwise) that fit alongside the header
+	Capacity int `json:"capacity"`
+}
+
+// SegmentOptions holds the settings used by SegmentText.
+type SegmentOptions struct {
+	// Reference number for the concatenation IE, 1 or 2 bytes long.
+	// Required only when the text does not fit into a single segment.
+	Reference []byte
+
+	// Optional application port addressing to add to every segment
+	Ports *Ports
+
+	// Replace characters that have no GSM 03.38 representation using TransliterateGSM, instead of failing.
+	// Used only with the GSM encodings.
+	Transliterate bool
+
+	// National language locking shift table replacing the default alphabet, announced by a 0x25 IE at every
+	// segment. Used only with the GSM encodings.
+	LockingShift NationalLanguage
+
+	// National language single shift table replacing the extension table, announced by a 0x24 IE at every
+	// segment. Used only with the GSM encodings.
+	SingleShift NationalLanguage
+
+	// Byte order of the payload. Used only with UCS2.
+	ByteOrder UCS2ByteOrder
+
+	// Which segments start with a byte order mark, taking 2 octets of their payload. Used only with UCS2.
+	BOM UCS2BOM
+
+	// Maximum number of octets of user data of every segment, including the UDH, for targets that accept less than
+	// a full short message. Zero means 140 octets, which is also the largest accepted value.
+	MaxUserDataLength int
+}
+
+// Bytes returns the full user data of the segment - the UDH followed by the payload.
+func (segment Segment) Bytes() []byte {
+	result := make([]byte, 0, len(segment.Header)+len(segment.Payload))
+	result = append(result, segment.Header...)
+	result = append(result, segment.Payload...)
+
+	return result
+}
+
+// PackedBytes returns the full user data of a GSM 7-bit segment, with its payload septet packed after the fill
+// bits that follow the UDH, the form used over the air. See PackGSM7.
+func (segment Segment) PackedBytes() []byte {
+	result := make([]byte, 0, len(segment.Header)+len(segment.Payload))
+	result = append(result, segment.Header...)
+
+	return append(result, PackGSM7(segment.Payload, len(segment.Header))...)
+}
+
+// Hex returns the full user data of the segment as an upper case hex encoded Message.
+func (segment Segment) Hex() Message {
+	return Message(strings.ToUpper(hex.EncodeToString(segment.Bytes())))
+}
+
+// SegmentText encodes text using the given encoding, and splits it into as many segments as needed.
+//
+// When the text fits into a single short message, a single segment without a concatenation IE is returned.
+// Otherwise every segment holds a concatenation IE using options.Reference.
+// When options select national language tables for a GSM encoding, every segment also holds their shift IEs, which
+// are counted against the capacity of the segment, and so is the byte order mark added to UCS2 segments by
+// options.BOM.
+// Characters are never split between segments: a GSM 7-bit escape sequence (such as the one of €) or a UTF-16
+// surrogate pair that does not fit at the end of a segment is moved as a whole to the next one, leaving the
+// segment shorter than its capacity.
+// Stateful encodings such as ISO2022JP are measured segment by segment, so every segment holds its own escape
+// sequences, and no more.
+// Returns an error if the text cannot be encoded, or requires more than 255 segments.
+func SegmentText(text string, enc Encoding, options SegmentOptions) ([]Segment, error) {
+	if len(options.Reference) > 2 {
+		return nil, ErrInvalidReferenceLength
+	}
+
+	if options.MaxUserDataLength < 0 || options.MaxUserDataLength > maxUserDataLength {
+		return nil, ErrInvalidSegmentSize
+	}
+
+	options = options.forEncoding(enc)
+
+	encoder, err := newSegmentEncoder(enc, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Transliterate && (enc == GSM || enc == GSMExtended) {
+		text, _ = transliterateGSM(text, encoder.table)
+	}
+
+	if enc == Binary8Bit1 || enc == Binary8Bit2 {
+		payload, err := EncodeText(text, enc)
+		if err != nil {
+			return nil, err
+		}
+
+		return segmentBinary(payload, enc, options)
+	}
+
+	if !measuredPerRune(enc) {
+		return segmentEncodedText(text, encoder, options)
+	}
+
+	units, err := runeUnits(text, enc, encoder.table)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, unit := range units {
+		total += unit
+	}
+
+	singleHeader, err := segmentHeader(options, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	singleCapacity := segmentCapacity(enc, options.userDataLength(), len(singleHeader))
+	if singleCapacity < minSegmentCapacity {
+		return nil, ErrInvalidSegmentSize
+	}
+
+	if total+encoder.overhead(0) <= singleCapacity {
+		payload, err := encoder.encode(text, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Segment{{Header: singleHeader, Payload: payload, Capacity: singleCapacity}}, nil
+	}
+
+	if len(options.Reference) == 0 {
+		return nil, ErrReferenceRequired
+	}
+
+	capacity, err := SegmentCapacity(enc, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []string
+	start, offset, used := 0, 0, encoder.overhead(0)
+
+	for idx, unit := range units {
+		if used+unit > capacity && offset > start {
+			chunks = append(chunks, text[start:offset])
+			start, used = offset, encoder.overhead(len(chunks))
+		}
+
+		used += unit
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+
+		if idx == len(units)-1 {
+			chunks = append(chunks, text[start:offset])
+		}
+	}
+
+	return buildSegments(chunks, encoder, options, capacity)
+}
+
+// segmentEncodedText splits text of an encoding that is not measured per rune into segments, measuring the
+// encoded length of every candidate chunk as a whole, so the escape sequences of stateful encodings such as
+// ISO2022JP are counted once per segment instead of once per character.
+func segmentEncodedText(text string, encoder segmentEncoder, options SegmentOptions) ([]Segment, error) {
+	payload, err := encoder.encode(text, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	singleHeader, err := segmentHeader(options, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	singleCapacity := segmentCapacity(encoder.enc, options.userDataLength(), len(singleHeader))
+	if singleCapacity < minSegmentCapacity {
+		return nil, ErrInvalidSegmentSize
+	}
+
+	if len(payload) <= singleCapacity {
+		return []Segment{{Header: singleHeader, Payload: payload, Capacity: singleCapacity}}, nil
+	}
+
+	if len(options.Reference) == 0 {
+		return nil, ErrReferenceRequired
+	}
+
+	capacity, err := SegmentCapacity(encoder.enc, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []string
+	start, offset := 0, 0
+
+	for offset < len(text) {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+
+		encoded, err := encoder.encode(text[start:offset+size], len(chunks))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(encoded) > capacity && offset > start {
+			chunks = append(chunks, text[start:offset])
+			start = offset
+
+			if len(chunks) > maxSegments {
+				return nil, ErrTooManySegments
+			}
+
+			continue
+		}
+
+		offset += size
+	}
+
+	return buildSegments(append(chunks, text[start:]), encoder, options, capacity)
+}
+
+// buildSegments encodes every chunk of text into a segment holding a concatenation IE.
+func buildSegments(chunks []string, encoder segmentEncoder, options SegmentOptions, capacity int) ([]Segment, error) {
+	if len(chunks) > maxSegments {
+		return nil, ErrTooManySegments
+	}
+
+	segments := make([]Segment, 0, len(chunks))
+
+	for idx, chunk := range chunks {
+		header, err := segmentHeader(options, byte(len(chunks)), byte(idx+1))
+		if err != nil {
+			return nil, err
+		}
+
+		payload, err := encoder.encode(chunk, idx)
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, Segment{Header: header, Payload: payload, Capacity: capacity})
+	}
+
+	return segments, nil
+}
+
+// segmentBinary splits an already encoded binary payload into segments.
+func segmentBinary(payload []byte, enc Encoding, options SegmentOptions) ([]Segment, error) {
+	singleHeader, err := segmentHeader(options, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	singleCapacity := segmentCapacity(enc, options.userDataLength(), len(singleHeader))
+	if singleCapacity < minSegmentCapacity {
+		return nil, ErrInvalidSegmentSize
+	}
+
+	if len(payload) <= singleCapacity {
+		return []Segment{{Header: singleHeader, Payload: payload, Capacity: singleCapacity}}, nil
+	}
+
+	if len(options.Reference) == 0 {
+		return nil, ErrReferenceRequired
+	}
+
+	capacity, err := SegmentCapacity(enc, options)
+	if err != nil {
+		return nil, err
+	}
+	totalParts := (len(payload) + capacity - 1) / capacity
+	if totalParts > maxSegments {
+		return nil, ErrTooManySegments
+	}
+
+	segments := make([]Segment, 0, totalParts)
+
+	for idx := 0; idx < totalParts; idx++ {
+		header, err := segmentHeader(options, byte(totalParts), byte(idx+1))
+		if err != nil {
+			return nil, err
+		}
+
+		end := min((idx+1)*capacity, len(payload))
+		segments = append(segments, Segment{Header: header, Payload: payload[idx*capacity : end], Capacity: capacity})
+	}
+
+	return segments, nil
+}
+
+// segmentHeader renders the UDH of a segment. When totalParts is 0, no concatenation IE is added.
+func segmentHeader(options SegmentOptions, totalParts, currentPart byte) ([]byte, error) {
+	builder := NewUDHBuilder()
+
+	if totalParts > 0 {
+		builder.AddConcatenation(options.Reference, totalParts, currentPart)
+	}
+
+	if options.Ports != nil {
+		builder.AddPorts(options.Ports.Destination, options.Ports.Source)
+	}
+
+	if options.LockingShift != DefaultLanguage || options.SingleShift != DefaultLanguage {
+		builder.AddNationalShift(options.LockingShift, options.SingleShift)
+	}
+
+	return builder.Bytes()
+}
+
+// forEncoding returns the options with the national language tables removed when enc is not a GSM encoding.
+func (options SegmentOptions) forEncoding(enc Encoding) SegmentOptions {
+	if enc != GSM && enc != GSMExtended {
+		options.LockingShift, options.SingleShift = DefaultLanguage, DefaultLanguage
+	}
+
+	return options
+}
+
+// SegmentCapacity returns how many payload units (septets for GSM 7-bit, octets otherwise) every part of a
+// concatenated message holds, after the UDH built from options: the concatenation IE using options.Reference
+// (6 or 7 octets) and any additional IE, such as options.Ports.
+//
+// For example, GSM 7-bit parts hold 153 septets with an 8-bit reference and 152 with a 16-bit one, while UCS2 parts
+// hold 134 octets (67 characters) with an 8-bit reference.
+// Returns an error if options.Reference is missing or longer than 2 bytes, or when options.MaxUserDataLength leaves
+// no room for the payload.
+func SegmentCapacity(enc Encoding, options SegmentOptions) (int, error) {
+	if len(options.Reference) == 0 {
+		return 0, ErrReferenceRequired
+	}
+
+	if len(options.Reference) > 2 {
+		return 0, ErrInvalidReferenceLength
+	}
+
+	if options.MaxUserDataLength < 0 || options.MaxUserDataLength > maxUserDataLength {
+		return 0, ErrInvalidSegmentSize
+	}
+
+	header, err := segmentHeader(options.forEncoding(enc), 1, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	capacity := segmentCapacity(enc, options.userDataLength(), len(header))
+	if capacity < minSegmentCapacity {
+		return 0, ErrInvalidSegmentSize
+	}
+
+	return capacity, nil
+}
+
+// userDataLength returns the number of octets of user data of every segment, including the UDH.
+func (options SegmentOptions) userDataLength() int {
+	if options.MaxUserDataLength == 0 {
+		return maxUserDataLength
+	}
+
+	return options.MaxUserDataLength
+}
+
+// segmentCapacity returns how many payload units (septets for GSM 7-bit, octets otherwise) fit in userData octets
+// alongside a UDH of headerLen octets.
+func segmentCapacity(enc Encoding, userData, headerLen int) int {
+	switch enc {
+	case GSM, GSMExtended:
+		// the UDH is padded to a septet boundary
+		return userData*8/7 - (headerLen*8+6)/7
+
+	case UCS2:
+		return (userData - headerLen) &^ 1
+	}
+
+	return userData - headerLen
+}
+
+// EffectiveLength returns the number of payload units text occupies in the given encoding, regardless of
+// segmentation: septets for the GSM encodings, where extension table characters count as two, and octets for every
+// other encoding, where UCS2 characters outside the Basic Multilingual Plane count as four.
+// Returns an error if text cannot be encoded.
+func EffectiveLength(text string, enc Encoding) (int, error) {
+	if !measuredPerRune(enc) {
+		encoded, err := EncodeText(text, enc)
+		if err != nil {
+			return 0, err
+		}
+
+		return len(encoded), nil
+	}
+
+	units, err := runeUnits(text, enc, charset.DefaultGSM7Table)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, unit := range units {
+		total += unit
+	}
+
+	return total, nil
+}
+
+// measuredPerRune reports whether the length of text in enc is the sum of the lengths of its runes, as measured by
+// runeUnits. Other encodings, such as the stateful ISO2022JP whose escape sequences are shared by consecutive
+// characters, are measured by encoding the text as a whole.
+func measuredPerRune(enc Encoding) bool {
+	return enc == GSM || enc == GSMExtended || enc == UCS2
+}
+
+// runeUnits returns the number of payload units each rune of text occupies in one of the encodings measured per
+// rune, using table for the GSM encodings.
+func runeUnits(text string, enc Encoding, table charset.GSM7Table) ([]int, error) {
+	units := make([]int, 0, len(text))
+
+	for _, ch := range text {
+		switch enc {
+		case GSM, GSMExtended:
+			septets := table.EncodeRune(ch)
+			if septets == nil {
+				return nil, ErrCharacterNotRepresentable
+			}
+			units = append(units, len(septets))
+
+		case UCS2:
+			if ch > 0xFFFF {
+				units = append(units, 4)
+			} else {
+				units = append(units, 2)
+			}
+		}
+	}
+
+	return units, nil
+}
@@ -0,0 +1,357 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestSegmentText(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		encoding    udh.Encoding
+		options     udh.SegmentOptions
+		segments    int
+		payloadLens []int
+		err         error
+	}{
+		{
+			name:        "single GSM",
+			text:        strings.Repeat("a", 160),
+			encoding:    udh.GSM,
+			segments:    1,
+			payloadLens: []int{160},
+		},
+		{
+			name:        "two GSM with 8-bit reference",
+			text:        strings.Repeat("a", 161),
+			encoding:    udh.GSM,
+			options:     udh.SegmentOptions{Reference: []byte{0x01}},
+			segments:    2,
+			payloadLens: []int{153, 8},
+		},
+		{
+			name:        "two GSM with 16-bit reference",
+			text:        strings.Repeat("a", 161),
+			encoding:    udh.GSM,
+			options:     udh.SegmentOptions{Reference: []byte{0x01, 0x02}},
+			segments:    2,
+			payloadLens: []int{152, 9},
+		},
+		{
+			name:        "single UCS2",
+			text:        strings.Repeat("ש", 70),
+			encoding:    udh.UCS2,
+			segments:    1,
+			payloadLens: []int{140},
+		},
+		{
+			name:        "two UCS2",
+			text:        strings.Repeat("ש", 71),
+			encoding:    udh.UCS2,
+			options:     udh.SegmentOptions{Reference: []byte{0x01}},
+			segments:    2,
+			payloadLens: []int{134, 8},
+		},
+		{
+			name:        "binary",
+			text:        strings.Repeat("00", 141),
+			encoding:    udh.Binary8Bit2,
+			options:     udh.SegmentOptions{Reference: []byte{0x01}},
+			segments:    2,
+			payloadLens: []int{134, 7},
+		},
+		{
+			name:        "smaller user data",
+			text:        strings.Repeat("ש", 71),
+			encoding:    udh.UCS2,
+			options:     udh.SegmentOptions{Reference: []byte{0x01}, MaxUserDataLength: 70},
+			segments:    3,
+			payloadLens: []int{64, 64, 14},
+		},
+		{
+			name:     "invalid user data length",
+			text:     "hello",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{MaxUserDataLength: 141},
+			err:      udh.ErrInvalidSegmentSize,
+		},
+		{
+			name:     "missing reference",
+			text:     strings.Repeat("a", 161),
+			encoding: udh.GSM,
+			err:      udh.ErrReferenceRequired,
+		},
+		{
+			name:     "not representable",
+			text:     "שלום",
+			encoding: udh.GSM,
+			err:      udh.ErrCharacterNotRepresentable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			segments, err := udh.SegmentText(test.text, test.encoding, test.options)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			if len(segments) != test.segments {
+				t2.Fatalf("have %d segments, expected %d", len(segments), test.segments)
+			}
+
+			for idx, segment := range segments {
+				if len(segment.Payload) != test.payloadLens[idx] {
+					t2.Errorf("%d. payload length %d, expected %d", idx, len(segment.Payload), test.payloadLens[idx])
+				}
+
+				if len(segment.Payload) > segment.Capacity {
+					t2.Errorf("%d. payload length %d exceeds capacity %d", idx, len(segment.Payload), segment.Capacity)
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentTextRoundTrip(t *testing.T) {
+	text := strings.Repeat("הודעה ארוכה בעברית ", 10)
+
+	segments, err := udh.SegmentText(text, udh.UCS2, udh.SegmentOptions{Reference: []byte{0x42}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fragments := udh.MessageFragmentations{}
+	for _, segment := range segments {
+		err = fragments.Add(udh.UCS2, segment.Hex())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !fragments.HaveAllFragments() {
+		t.Fatal("expected all fragments")
+	}
+
+	if result := fragments.String(); result != text {
+		t.Errorf("have %q, expected %q", result, text)
+	}
+}
+
+func TestEffectiveLength(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		enc      udh.Encoding
+		expected int
+		err      error
+	}{
+		{name: "gsm basic", text: "hello", enc: udh.GSM, expected: 5},
+		{name: "gsm extension", text: "€5 {x}", enc: udh.GSM, expected: 9},
+		{name: "gsm not representable", text: "שלום", enc: udh.GSM, err: udh.ErrCharacterNotRepresentable},
+		{name: "ucs2", text: "שלום", enc: udh.UCS2, expected: 8},
+		{name: "ucs2 surrogate pair", text: "hi 😀", enc: udh.UCS2, expected: 10},
+		{name: "latin1", text: "café", enc: udh.Latin1, expected: 4},
+		{name: "iso2022jp", text: "日本語", enc: udh.ISO2022JP, expected: 12},
+		{name: "iso2022jp mixed", text: "a日b本", enc: udh.ISO2022JP, expected: 18},
+		{name: "empty", text: "", enc: udh.GSM, expected: 0},
+	}
+
+	for _, test := range tests {
+		length, err := udh.EffectiveLength(test.text, test.enc)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: have error %v, expected %v", test.name, err, test.err)
+			continue
+		}
+
+		if length != test.expected {
+			t.Errorf("%s: have %d, expected %d", test.name, length, test.expected)
+		}
+	}
+}
+
+func TestSegmentTextISO2022JP(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		payloadLens []int
+	}{
+		{name: "single segment", text: strings.Repeat("日", 60), payloadLens: []int{126}},
+		{name: "two segments", text: strings.Repeat("日", 100), payloadLens: []int{134, 78}},
+		{name: "mixed", text: strings.Repeat("日本 go ", 30), payloadLens: []int{134, 134, 130, 28}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			segments, err := udh.SegmentText(test.text, udh.ISO2022JP, udh.SegmentOptions{Reference: []byte{0x07}})
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			payloadLens := make([]int, 0, len(segments))
+			fragments := udh.MessageFragmentations{}
+
+			for _, segment := range segments {
+				payloadLens = append(payloadLens, len(segment.Payload))
+
+				err = fragments.Add(udh.ISO2022JP, segment.Hex())
+				if err != nil {
+					t2.Fatal(err)
+				}
+			}
+
+			if diff := cmp.Diff(test.payloadLens, payloadLens); diff != "" {
+				t2.Errorf("payload lengths mismatch (-expected +have):\n%s", diff)
+			}
+
+			if result := fragments.String(); result != test.text {
+				t2.Errorf("have %q, expected %q", result, test.text)
+			}
+		})
+	}
+}
+
+func TestSegmentTextBoundaries(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		encoding    udh.Encoding
+		payloadLens []int
+	}{
+		{
+			name:        "GSM escape sequence at the boundary",
+			text:        strings.Repeat("a", 152) + "€" + strings.Repeat("b", 10),
+			encoding:    udh.GSM,
+			payloadLens: []int{152, 12},
+		},
+		{
+			name:        "GSM escape sequence before the boundary",
+			text:        strings.Repeat("a", 151) + "€" + strings.Repeat("b", 10),
+			encoding:    udh.GSM,
+			payloadLens: []int{153, 10},
+		},
+		{
+			name:        "UCS2 surrogate pair at the boundary",
+			text:        strings.Repeat("ש", 66) + "😀" + strings.Repeat("ם", 5),
+			encoding:    udh.UCS2,
+			payloadLens: []int{132, 14},
+		},
+		{
+			name:        "UCS2 surrogate pair before the boundary",
+			text:        strings.Repeat("ש", 65) + "😀" + strings.Repeat("ם", 5),
+			encoding:    udh.UCS2,
+			payloadLens: []int{134, 10},
+		},
+		{
+			name:        "UCS2 surrogate pairs only",
+			text:        strings.Repeat("😀", 40),
+			encoding:    udh.UCS2,
+			payloadLens: []int{132, 28},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			segments, err := udh.SegmentText(test.text, test.encoding, udh.SegmentOptions{Reference: []byte{0x07}})
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if len(segments) != len(test.payloadLens) {
+				t2.Fatalf("have %d segments, expected %d", len(segments), len(test.payloadLens))
+			}
+
+			payload := []byte{}
+
+			for idx, segment := range segments {
+				if len(segment.Payload) != test.payloadLens[idx] {
+					t2.Errorf("%d. payload length %d, expected %d", idx, len(segment.Payload), test.payloadLens[idx])
+				}
+
+				if danglingPrefix(segment.Payload, test.encoding) {
+					t2.Errorf("%d. payload ends in the middle of a character: % X", idx, segment.Payload)
+				}
+
+				payload = append(payload, segment.Payload...)
+			}
+
+			expected, err := udh.EncodeText(test.text, test.encoding)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if !bytes.Equal(payload, expected) {
+				t2.Errorf("have % X, expected % X", payload, expected)
+			}
+		})
+	}
+}
+
+// danglingPrefix returns true when payload ends with the first half of a GSM-7 escape sequence or of a UTF-16
+// surrogate pair.
+func danglingPrefix(payload []byte, enc udh.Encoding) bool {
+	if len(payload) == 0 {
+		return false
+	}
+
+	if enc == udh.UCS2 {
+		high := payload[len(payload)-2]
+		return high >= 0xD8 && high <= 0xDB
+	}
+
+	return payload[len(payload)-1] == 0x1B
+}
+
+func TestSegmentCapacity(t *testing.T) {
+	reference8 := udh.SegmentOptions{Reference: []byte{0x01}}
+	reference16 := udh.SegmentOptions{Reference: []byte{0x01, 0x02}}
+
+	tests := []struct {
+		name     string
+		encoding udh.Encoding
+		options  udh.SegmentOptions
+		expected int
+		err      error
+	}{
+		{name: "GSM 8-bit reference", encoding: udh.GSM, options: reference8, expected: 153},
+		{name: "GSM 16-bit reference", encoding: udh.GSM, options: reference16, expected: 152},
+		{name: "UCS2 8-bit reference", encoding: udh.UCS2, options: reference8, expected: 134},
+		{name: "UCS2 16-bit reference", encoding: udh.UCS2, options: reference16, expected: 132},
+		{name: "binary 8-bit reference", encoding: udh.Binary8Bit2, options: reference8, expected: 134},
+		{
+			name:     "GSM with ports",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{Reference: []byte{0x01}, Ports: &udh.Ports{Destination: 5505, Source: 0}},
+			expected: 146,
+		},
+		{name: "missing reference", encoding: udh.GSM, err: udh.ErrReferenceRequired},
+		{
+			name:     "long reference",
+			encoding: udh.GSM,
+			options:  udh.SegmentOptions{Reference: []byte{1, 2, 3}},
+			err:      udh.ErrInvalidReferenceLength,
+		},
+	}
+
+	for _, test := range tests {
+		capacity, err := udh.SegmentCapacity(test.encoding, test.options)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: have error %v, expected %v", test.name, err, test.err)
+			continue
+		}
+
+		if capacity != test.expected {
+			t.Errorf("%s: have %d, expected %d", test.name, capacity, test.expected)
+		}
+	}
+}
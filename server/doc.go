@@ -0,0 +1,25 @@
+/*
+Package server exposes a smudh Messages container over HTTP, turning the package into a reassembly sidecar.
+
+The Server type is an http.Handler with the following endpoints:
+
+	POST /fragments                     submit a fragment: {"encoding": "GSM-7", "message": "050003..."}, 429 Too
+	                                    Many Requests with a Retry-After header over the limits of
+	                                    smudh.WithGlobalRateLimit
+	GET  /messages/{reference}          assembly status of a message, reference is hex encoded
+	GET  /messages/{reference}/text     the smudh.AssembledMessage, 409 Conflict while parts are missing
+	GET  /healthz                       liveness probe, 503 Service Unavailable when a janitor is stalled
+	GET  /readyz                        readiness probe, 503 as well when the container is closed, the Store is
+	                                    unreachable or the backlog is over WithMaxBacklog
+
+Both probes respond with a HealthStatus, reporting the Store connectivity, the janitor liveness and the backlog
+size of the container.
+
+Failed requests respond with an ErrorResponse, holding the error message and its smudh.ErrorCode, for clients that
+map failures without matching messages.
+
+Close closes the container during a graceful shutdown, once the http.Server stopped accepting requests.
+
+Persistence is configured on the Messages container itself, using smudh.WithStore.
+*/
+package server
@@ -0,0 +1,103 @@
+package server
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ik5/smudh"
+)
+
+// Values of the fields of HealthStatus.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusNone        = "none"
+	StatusRunning     = "running"
+	StatusStalled     = "stalled"
+	StatusStopped     = "stopped"
+)
+
+// HealthStatus is the response of GET /healthz and GET /readyz.
+type HealthStatus struct {
+	// "ok", or "unavailable" when the check failed
+	Status string `json:"status"`
+
+	// "ok", "none" when no Store was set, or the error of pinging the Store
+	Store string `json:"store"`
+
+	// "running", "stalled" when a janitor did not sweep for twice its interval, or "stopped"
+	Janitor string `json:"janitor"`
+
+	// Time of the last janitor sweep, omitted when no janitor ever ran
+	LastSweep *time.Time `json:"last_sweep,omitempty"`
+
+	// Number of messages held by the container
+	Backlog int `json:"backlog"`
+
+	// True once the container was closed, omitted otherwise
+	Closed bool `json:"closed,omitempty"`
+}
+
+// WithMaxBacklog makes GET /readyz fail once the container holds more than maxBacklog messages, so a loaded
+// instance stops receiving traffic. Zero, the default, never fails on the backlog.
+func WithMaxBacklog(maxBacklog int) Option {
+	return func(srv *Server) {
+		srv.maxBacklog = maxBacklog
+	}
+}
+
+// healthz is the liveness probe, failing only when a janitor is stalled.
+func (srv *Server) healthz(w http.ResponseWriter, r *http.Request) {
+	health := srv.messages.Health(r.Context())
+	srv.writeHealth(w, health, !health.JanitorStalled)
+}
+
+// readyz is the readiness probe, failing as well when the container is closed, when the Store is unreachable, or
+// when the backlog is too large.
+func (srv *Server) readyz(w http.ResponseWriter, r *http.Request) {
+	health := srv.messages.Health(r.Context())
+	ready := !health.Closed && !health.JanitorStalled && health.StoreErr == nil &&
+		(srv.maxBacklog <= 0 || health.Backlog <= srv.maxBacklog)
+
+	srv.writeHealth(w, health, ready)
+}
+
+func (srv *Server) writeHealth(w http.ResponseWriter, health smudh.Health, ok bool) {
+	status := HealthStatus{
+		Status:  StatusOK,
+		Store:   StatusOK,
+		Janitor: StatusStopped,
+		Backlog: health.Backlog,
+		Closed:  health.Closed,
+	}
+
+	switch {
+	case health.StoreErr != nil:
+		status.Store = health.StoreErr.Error()
+	case !health.HasStore:
+		status.Store = StatusNone
+	}
+
+	switch {
+	case health.JanitorStalled:
+		status.Janitor = StatusStalled
+	case health.Janitors > 0:
+		status.Janitor = StatusRunning
+	}
+
+	if !health.LastSweep.IsZero() {
+		status.LastSweep = &health.LastSweep
+	}
+
+	code := http.StatusOK
+	if !ok {
+		status.Status = StatusUnavailable
+		code = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, status)
+}
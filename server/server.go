@@ -0,0 +1,222 @@
+package server
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/ik5/smudh"
+)
+
+// maxRequestBody limits the size of a submitted fragment request.
+const maxRequestBody = 64 * 1024
+
+// FragmentRequest is the body of POST /fragments.
+type FragmentRequest struct {
+	// Encoding of the message
+	Encoding smudh.Encoding `json:"encoding"`
+
+	// Hex encoded short_message, including the UDH
+	Message string `json:"message"`
+}
+
+// Status is the response of GET /messages/{reference}, and of a successful POST /fragments.
+type Status struct {
+	// Hex encoded reference number
+	Reference string `json:"reference"`
+
+	// Total number of parts, 0 for single messages
+	TotalParts byte `json:"total_parts"`
+
+	// Number of parts received so far
+	Received int `json:"received"`
+
+	// Part numbers that were not received yet
+	MissingParts []int `json:"missing_parts"`
+
+	// Part numbers of fragments that keep the message from being complete, such as a part received twice, see
+	// smudh.MessageFragmentations.ExtraParts
+	ExtraParts []int `json:"extra_parts,omitempty"`
+
+	// True when all of the parts were received
+	Complete bool `json:"complete"`
+}
+
+// ErrorResponse is returned on every failed request.
+type ErrorResponse struct {
+	// Description of the error
+	Error string `json:"error"`
+
+	// Machine-readable code of the error, see smudh.CodeOf
+	Code smudh.ErrorCode `json:"code"`
+}
+
+// Server serves the Messages container over HTTP.
+type Server struct {
+	messages   *smudh.Messages
+	mux        *http.ServeMux
+	maxBacklog int
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// New returns a Server backed by messages.
+func New(messages *smudh.Messages, options ...Option) *Server {
+	srv := &Server{messages: messages, mux: http.NewServeMux()}
+
+	for _, option := range options {
+		option(srv)
+	}
+
+	srv.mux.HandleFunc("POST /fragments", srv.addFragment)
+	srv.mux.HandleFunc("GET /messages/{reference}", srv.status)
+	srv.mux.HandleFunc("GET /messages/{reference}/text", srv.text)
+	srv.mux.HandleFunc("GET /healthz", srv.healthz)
+	srv.mux.HandleFunc("GET /readyz", srv.readyz)
+
+	return srv
+}
+
+// Close closes the Messages container, so fragments submitted during a shutdown are refused with 503 Service
+// Unavailable, and /readyz fails. Shut the http.Server down first, so requests in flight are completed.
+func (srv *Server) Close(ctx context.Context) error {
+	err := srv.messages.Close(ctx)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+func (srv *Server) addFragment(w http.ResponseWriter, r *http.Request) {
+	var request FragmentRequest
+
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxRequestBody))
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(&request)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	info, err := srv.messages.Parse(request.Encoding, smudh.Message(request.Message))
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	err = srv.messages.AddMessageElements(info)
+	if errors.Is(err, smudh.ErrStore) || errors.Is(err, smudh.ErrClosed) {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	var limitErr *smudh.RateLimitError
+	if errors.As(err, &limitErr) {
+		if limitErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+		}
+
+		writeError(w, http.StatusTooManyRequests, err)
+
+		return
+	}
+
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, newStatus(info.Reference, srv.messages.Snapshot(info.Reference)))
+}
+
+func (srv *Server) status(w http.ResponseWriter, r *http.Request) {
+	reference, fragments, ok := srv.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newStatus(reference, fragments))
+}
+
+func (srv *Server) text(w http.ResponseWriter, r *http.Request) {
+	_, fragments, ok := srv.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	assembled, err := srv.messages.Assemble(r.Context(), fragments)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, assembled)
+}
+
+// lookup finds the message of the reference path value, and writes the error response when it is not usable.
+func (srv *Server) lookup(w http.ResponseWriter, r *http.Request) ([]byte, smudh.MessageFragmentations, bool) {
+	reference, err := hex.DecodeString(r.PathValue("reference"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, nil, false
+	}
+
+	fragments := srv.messages.Snapshot(reference)
+	if fragments == nil {
+		writeError(w, http.StatusNotFound, smudh.ErrMessageNotFound)
+		return nil, nil, false
+	}
+
+	return reference, fragments, true
+}
+
+func newStatus(reference []byte, fragments smudh.MessageFragmentations) Status {
+	status := Status{
+		Reference:    hex.EncodeToString(reference),
+		Received:     len(fragments),
+		MissingParts: []int{},
+		Complete:     fragments.HaveAllFragments(),
+	}
+
+	for _, part := range fragments.MissingParts() {
+		status.MissingParts = append(status.MissingParts, int(part))
+	}
+
+	for _, part := range fragments.ExtraParts() {
+		status.ExtraParts = append(status.ExtraParts, int(part))
+	}
+
+	if len(fragments) > 0 {
+		status.TotalParts = fragments[0].TotalParts
+	}
+
+	return status
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, ErrorResponse{Error: err.Error(), Code: smudh.CodeOf(err)})
+}
+
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,300 @@
+package server_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/server"
+)
+
+func request(t *testing.T, handler http.Handler, method, path, body string) (int, map[string]any) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	var result map[string]any
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &result)
+	if err != nil {
+		t.Fatalf("invalid response %q: %s", recorder.Body.String(), err)
+	}
+
+	return recorder.Code, result
+}
+
+func TestServer(t *testing.T) {
+	srv := server.New(udh.InitMessages())
+
+	type step struct {
+		name     string
+		method   string
+		path     string
+		body     string
+		code     int
+		expected map[string]any
+	}
+
+	steps := []step{
+		{
+			name:   "first part",
+			method: http.MethodPost, path: "/fragments",
+			body: `{"encoding": "ASCII", "message": "0500030A020168656C6C6F20"}`,
+			code: http.StatusAccepted,
+			expected: map[string]any{
+				"reference": "0a", "total_parts": 2.0, "received": 1.0, "missing_parts": []any{2.0}, "complete": false,
+			},
+		},
+		{
+			name:   "text of incomplete",
+			method: http.MethodGet, path: "/messages/0a/text",
+			code: http.StatusConflict,
+			expected: map[string]any{
+				"error": udh.ErrMessageNotComplete.Error(), "code": string(udh.CodeMessageNotComplete),
+			},
+		},
+		{
+			name:   "second part",
+			method: http.MethodPost, path: "/fragments",
+			body: `{"encoding": 1, "message": "0500030A0202776F726C64"}`,
+			code: http.StatusAccepted,
+			expected: map[string]any{
+				"reference": "0a", "total_parts": 2.0, "received": 2.0, "missing_parts": []any{}, "complete": true,
+			},
+		},
+		{
+			name:   "status",
+			method: http.MethodGet, path: "/messages/0a",
+			code: http.StatusOK,
+			expected: map[string]any{
+				"reference": "0a", "total_parts": 2.0, "received": 2.0, "missing_parts": []any{}, "complete": true,
+			},
+		},
+		{
+			name:   "text",
+			method: http.MethodGet, path: "/messages/0a/text",
+			code:     http.StatusOK,
+			expected: map[string]any{"reference": "0a", "encoding": "ASCII", "parts": 2.0, "text": "hello world"},
+		},
+		{
+			name:   "part of another message",
+			method: http.MethodPost, path: "/fragments",
+			body: `{"encoding": "ASCII", "message": "0500030B020168656C6C6F20"}`,
+			code: http.StatusAccepted,
+			expected: map[string]any{
+				"reference": "0b", "total_parts": 2.0, "received": 1.0, "missing_parts": []any{2.0}, "complete": false,
+			},
+		},
+		{
+			name:   "same part again",
+			method: http.MethodPost, path: "/fragments",
+			body: `{"encoding": "ASCII", "message": "0500030B020168656C6C6F20"}`,
+			code: http.StatusAccepted,
+			expected: map[string]any{
+				"reference": "0b", "total_parts": 2.0, "received": 2.0, "missing_parts": []any{2.0},
+				"extra_parts": []any{1.0}, "complete": false,
+			},
+		},
+		{
+			name:   "unknown reference",
+			method: http.MethodGet, path: "/messages/ff",
+			code:     http.StatusNotFound,
+			expected: map[string]any{"error": "message not found", "code": "message_not_found"},
+		},
+	}
+
+	for _, step := range steps {
+		code, result := request(t, srv, step.method, step.path, step.body)
+		if code != step.code {
+			t.Errorf("%s: expected status %d, got %d", step.name, step.code, code)
+		}
+
+		if diff := cmp.Diff(step.expected, result); diff != "" {
+			t.Errorf("%s: unexpected response (-want +got):\n%s", step.name, diff)
+		}
+	}
+}
+
+func TestServerBadRequests(t *testing.T) {
+	srv := server.New(udh.InitMessages())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{name: "invalid json", method: http.MethodPost, path: "/fragments", body: "{", code: http.StatusBadRequest},
+		{
+			name: "invalid message", method: http.MethodPost, path: "/fragments",
+			body: `{"encoding": 1, "message": "zz"}`, code: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "unknown encoding", method: http.MethodPost, path: "/fragments",
+			body: `{"encoding": "EBCDIC", "message": "00"}`, code: http.StatusBadRequest,
+		},
+		{name: "invalid reference", method: http.MethodGet, path: "/messages/zz", code: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		code, result := request(t, srv, test.method, test.path, test.body)
+		if code != test.code {
+			t.Errorf("%s: expected status %d, got %d", test.name, test.code, code)
+		}
+
+		if result["error"] == nil {
+			t.Errorf("%s: expected an error message, got %v", test.name, result)
+		}
+	}
+}
+
+func TestServerHealth(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+
+	store, err := udh.NewDirStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := udh.InitMessages(udh.WithStore(store))
+	srv := server.New(messages, server.WithMaxBacklog(1))
+
+	healthy := map[string]any{"status": "ok", "store": "ok", "janitor": "stopped", "backlog": 0.0}
+
+	type step struct {
+		name     string
+		path     string
+		body     string
+		code     int
+		expected map[string]any
+	}
+
+	steps := []step{
+		{name: "alive", path: "/healthz", code: http.StatusOK, expected: healthy},
+		{name: "ready", path: "/readyz", code: http.StatusOK, expected: healthy},
+		{
+			name: "ready under the backlog limit", path: "/readyz",
+			body:     `{"encoding": "ASCII", "message": "0500030A020168656C6C6F20"}`,
+			code:     http.StatusOK,
+			expected: map[string]any{"status": "ok", "store": "ok", "janitor": "stopped", "backlog": 1.0},
+		},
+		{
+			name: "not ready over the backlog limit", path: "/readyz",
+			body:     `{"encoding": "ASCII", "message": "0500030B020168656C6C6F20"}`,
+			code:     http.StatusServiceUnavailable,
+			expected: map[string]any{"status": "unavailable", "store": "ok", "janitor": "stopped", "backlog": 2.0},
+		},
+		{
+			name: "alive over the backlog limit", path: "/healthz",
+			code:     http.StatusOK,
+			expected: map[string]any{"status": "ok", "store": "ok", "janitor": "stopped", "backlog": 2.0},
+		},
+	}
+
+	for _, step := range steps {
+		if step.body != "" {
+			code, result := request(t, srv, http.MethodPost, "/fragments", step.body)
+			if code != http.StatusAccepted {
+				t.Fatalf("%s: adding a fragment failed with %d: %v", step.name, code, result)
+			}
+		}
+
+		code, result := request(t, srv, http.MethodGet, step.path, "")
+		if code != step.code {
+			t.Errorf("%s: expected status %d, got %d", step.name, step.code, code)
+		}
+
+		if diff := cmp.Diff(step.expected, result); diff != "" {
+			t.Errorf("%s: unexpected response (-want +got):\n%s", step.name, diff)
+		}
+	}
+
+	err = os.RemoveAll(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, result := request(t, server.New(messages), http.MethodGet, "/readyz", "")
+	if code != http.StatusServiceUnavailable || result["status"] != "unavailable" || result["store"] == "ok" {
+		t.Errorf("expected the removed store to fail the readiness, got %d: %v", code, result)
+	}
+
+	code, result = request(t, srv, http.MethodGet, "/healthz", "")
+	if code != http.StatusOK {
+		t.Errorf("expected the removed store to keep the liveness, got %d: %v", code, result)
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	srv := server.New(udh.InitMessages())
+
+	err := srv.Close(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, result := request(t, srv, http.MethodPost, "/fragments",
+		`{"encoding": "ASCII", "message": "0500030A020168656C6C6F20"}`)
+	if code != http.StatusServiceUnavailable || result["error"] == nil {
+		t.Errorf("expected a closed server to refuse fragments, got %d: %v", code, result)
+	}
+
+	code, result = request(t, srv, http.MethodGet, "/readyz", "")
+
+	expected := map[string]any{
+		"status": "unavailable", "store": "none", "janitor": "stopped", "backlog": 0.0, "closed": true,
+	}
+	if code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("unexpected response (-want +got):\n%s", diff)
+	}
+}
+
+func TestServerRateLimit(t *testing.T) {
+	srv := server.New(udh.InitMessages(udh.WithGlobalRateLimit(udh.RateLimit{Rate: 0.5, Burst: 1})))
+
+	body := `{"encoding": "ASCII", "message": "0500030A020168656C6C6F20"}`
+
+	code, _ := request(t, srv, http.MethodPost, "/fragments", body)
+	if code != http.StatusAccepted {
+		t.Fatalf("expected status %d, got %d", http.StatusAccepted, code)
+	}
+
+	recorder := httptest.NewRecorder()
+	srv.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/fragments", strings.NewReader(body)))
+
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, recorder.Code)
+	}
+
+	if retryAfter := recorder.Header().Get("Retry-After"); retryAfter != "2" {
+		t.Errorf("expected Retry-After 2, got %q", retryAfter)
+	}
+
+	var result map[string]any
+
+	err := json.Unmarshal(recorder.Body.Bytes(), &result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result["code"] != string(udh.CodeRateLimited) {
+		t.Errorf("expected code %q, got %v", udh.CodeRateLimited, result["code"])
+	}
+}
@@ -0,0 +1,205 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"runtime"
+)
+
+// shardsPerProcessor is the number of shards created for every processor, unless set by NewShardedMessages.
+const shardsPerProcessor = 4
+
+// ShardedMessages is a container of fragments split into shards, each of them a Messages instance with its own
+// lock, selected by hashing the namespace and the reference number of a fragment.
+//
+// Fragments of different references rarely wait for the same lock, so ShardedMessages scales with the number of
+// goroutines adding mostly-disjoint references, such as a gateway handling tens of thousands of fragments per
+// second. Use Messages when fragments are added by a single goroutine, since it is cheaper.
+//
+// The options are applied to every shard. The values given to options, such as the AuditSink, are shared by the
+// shards, and so are the limits of WithGlobalRateLimit and WithSourceRateLimit. The metrics hooks, including the
+// ones of WithExpvar, are created once and report the activity of every shard, with hooks that implement
+// ContainerMetricsHooks observing the ShardedMessages. Quotas set by
+// WithNamespaceQuota, namespace stats, the TTL and watchers belong to every shard on its own.
+//
+// WithStore, WithWAL and WithFuzzyReassembly are not supported, since the shards would overwrite the content of
+// each other in a shared Store or WAL, and a fragment can be merged only into messages of its own shard.
+type ShardedMessages struct {
+	shards []*Messages
+	seed   maphash.Seed
+}
+
+// NewShardedMessages creates a ShardedMessages with the given number of shards, using MessagesOption to set every
+// shard. When shards is not positive, four shards are created for every processor.
+// Returns an error wrapping ErrUnsupportedShardOption when the options set a Store, a WAL or fuzzy reassembly.
+func NewShardedMessages(shards int, options ...MessagesOption) (*ShardedMessages, error) {
+	if shards <= 0 {
+		shards = shardsPerProcessor * runtime.GOMAXPROCS(0)
+	}
+
+	sharded := &ShardedMessages{
+		shards: make([]*Messages, shards),
+		seed:   maphash.MakeSeed(),
+	}
+
+	first := newMessages(options...)
+
+	err := first.checkShardable()
+	if err != nil {
+		return nil, err
+	}
+
+	sharded.shards[0] = first
+
+	for i := 1; i < shards; i++ {
+		sharded.shards[i] = newMessages(options...)
+		sharded.shards[i].limiter = first.limiter
+		sharded.shards[i].metrics = first.metrics
+	}
+
+	first.observe(sharded)
+
+	return sharded, nil
+}
+
+// checkShardable returns an error wrapping ErrUnsupportedShardOption when msgs was set with an option that
+// ShardedMessages does not support.
+func (msgs *Messages) checkShardable() error {
+	switch {
+	case msgs.store != nil:
+		return fmt.Errorf("%w: WithStore", ErrUnsupportedShardOption)
+	case msgs.wal != nil:
+		return fmt.Errorf("%w: WithWAL", ErrUnsupportedShardOption)
+	case msgs.fuzzy != nil:
+		return fmt.Errorf("%w: WithFuzzyReassembly", ErrUnsupportedShardOption)
+	}
+
+	return nil
+}
+
+// Shards returns the number of shards.
+func (sharded *ShardedMessages) Shards() int {
+	return len(sharded.shards)
+}
+
+// Add is the same as Messages.Add.
+func (sharded *ShardedMessages) Add(encoding Encoding, message Message) error {
+	return sharded.AddContext(context.Background(), encoding, message)
+}
+
+// AddContext is the same as Messages.AddContext. Parsing and the middleware take place before a shard is locked.
+func (sharded *ShardedMessages) AddContext(ctx context.Context, encoding Encoding, message Message) (err error) {
+	first := sharded.shards[0]
+
+	ctx, span := startSpan(ctx, first.tracer, "smudh.ShardedMessages.Add", AttributeEncoding.String(encoding.String()))
+	defer func() { endSpan(span, err) }()
+
+	info, err := first.prepareFragment(ctx, encoding, message)
+	if err != nil {
+		return err
+	}
+
+	span.SetAttributes(info.spanAttributes()...)
+
+	shard := sharded.shard(NamespaceFromContext(ctx), info.Reference)
+
+	shard.mtx.Lock()
+	defer shard.mtx.Unlock()
+
+	return shard.addMessageElements(ctx, info)
+}
+
+// AddMessageElements is the same as Messages.AddMessageElements.
+func (sharded *ShardedMessages) AddMessageElements(info *MessageElements) error {
+	return sharded.AddMessageElementsContext(context.Background(), info)
+}
+
+// AddMessageElementsContext is the same as Messages.AddMessageElementsContext.
+func (sharded *ShardedMessages) AddMessageElementsContext(ctx context.Context, info *MessageElements) error {
+	return sharded.shard(NamespaceFromContext(ctx), info.Reference).AddMessageElementsContext(ctx, info)
+}
+
+// Snapshot is the same as Messages.Snapshot.
+func (sharded *ShardedMessages) Snapshot(reference []byte) MessageFragmentations {
+	return sharded.shard("", reference).Snapshot(reference)
+}
+
+// ListAll is the same as Messages.ListAll.
+func (sharded *ShardedMessages) ListAll() []*MessageFragmentations {
+	return sharded.collect((*Messages).ListAll)
+}
+
+// Complete is the same as Messages.Complete, with the messages ordered by shard, and then by reference number.
+func (sharded *ShardedMessages) Complete() []*MessageFragmentations {
+	return sharded.collect((*Messages).Complete)
+}
+
+// DrainComplete is the same as Messages.DrainComplete, with the messages ordered by shard, and then by reference
+// number. Every shard is locked on its own, so the result is not a snapshot of the whole container.
+func (sharded *ShardedMessages) DrainComplete() []*MessageFragmentations {
+	return sharded.collect((*Messages).DrainComplete)
+}
+
+// Incomplete is the same as Messages.Incomplete, with the messages ordered by shard.
+func (sharded *ShardedMessages) Incomplete() []IncompleteMessage {
+	results := []IncompleteMessage{}
+
+	for _, shard := range sharded.shards {
+		results = append(results, shard.Incomplete()...)
+	}
+
+	return results
+}
+
+// EvictExpired is the same as Messages.EvictExpired, evicting the expired messages of every shard.
+func (sharded *ShardedMessages) EvictExpired() int {
+	evicted := 0
+
+	for _, shard := range sharded.shards {
+		evicted += shard.EvictExpired()
+	}
+
+	return evicted
+}
+
+// Close is the same as Messages.Close, closing every shard.
+func (sharded *ShardedMessages) Close(ctx context.Context) error {
+	var errs []error
+
+	for _, shard := range sharded.shards {
+		err := shard.Close(ctx)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// shard returns the shard holding the messages of a reference number of a namespace.
+func (sharded *ShardedMessages) shard(namespace string, reference []byte) *Messages {
+	if len(sharded.shards) == 1 {
+		return sharded.shards[0]
+	}
+
+	key := sharded.shards[0].namespacedKey(namespace, reference)
+
+	return sharded.shards[maphash.String(sharded.seed, key)%uint64(len(sharded.shards))]
+}
+
+// collect returns the results of fn for every shard, ordered by shard.
+func (sharded *ShardedMessages) collect(fn func(*Messages) []*MessageFragmentations) []*MessageFragmentations {
+	results := []*MessageFragmentations{}
+
+	for _, shard := range sharded.shards {
+		results = append(results, fn(shard)...)
+	}
+
+	return results
+}
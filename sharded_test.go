@@ -0,0 +1,169 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	udh "github.com/ik5/smudh"
+)
+
+// twoPartMessage returns the fragments of a message of 2 parts using a 16 bit reference number.
+func twoPartMessage(reference []byte) [2]*udh.MessageElements {
+	fragments := [2]*udh.MessageElements{}
+
+	for idx := range fragments {
+		fragments[idx] = &udh.MessageElements{
+			Reference: reference, TotalParts: 2, CurrentPart: byte(idx + 1), Message: "part", Encoding: udh.ASCII,
+		}
+	}
+
+	return fragments
+}
+
+func TestShardedMessages(t *testing.T) {
+	messages, err := udh.NewShardedMessages(8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wg := sync.WaitGroup{}
+
+	for worker := range 16 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for idx := range 64 {
+				for _, fragment := range twoPartMessage([]byte{byte(worker), byte(idx)}) {
+					err := messages.AddMessageElements(fragment)
+					if err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	err = messages.Add(udh.ASCII, udh.Message("050003A50201546869732069732061206C"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snapshot := messages.Snapshot([]byte{0xA5}); len(snapshot) != 1 {
+		t.Errorf("have %d fragments for reference A5, expected 1", len(snapshot))
+	}
+
+	if incomplete := messages.Incomplete(); len(incomplete) != 1 {
+		t.Errorf("have %d incomplete messages, expected 1", len(incomplete))
+	}
+
+	if complete := messages.DrainComplete(); len(complete) != 16*64 {
+		t.Errorf("have %d complete messages, expected %d", len(complete), 16*64)
+	}
+
+	if all := messages.ListAll(); len(all) != 1 {
+		t.Errorf("have %d messages after draining, expected 1", len(all))
+	}
+}
+
+func TestShardedMessagesSharedRateLimit(t *testing.T) {
+	messages, err := udh.NewShardedMessages(8, udh.WithGlobalRateLimit(udh.RateLimit{Rate: 0.001, Burst: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for idx, limited := range []bool{false, false, true, true} {
+		err := messages.AddMessageElements(twoPartMessage([]byte{byte(idx)})[0])
+		if errors.Is(err, udh.ErrRateLimited) != limited {
+			t.Errorf("%d. have error %v, expected limited %t", idx, err, limited)
+		}
+	}
+}
+
+func TestShardedMessagesUnsupportedOptions(t *testing.T) {
+	wal, err := udh.OpenWAL(filepath.Join(t.TempDir(), "fragments.wal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer wal.Close()
+
+	tests := []struct {
+		name   string
+		option udh.MessagesOption
+	}{
+		{name: "store", option: udh.WithStore(udh.NewMemoryStore())},
+		{name: "wal", option: udh.WithWAL(wal)},
+		{name: "fuzzy reassembly", option: udh.WithFuzzyReassembly(udh.FuzzyReassembly{Window: time.Minute})},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			sharded, err := udh.NewShardedMessages(4, test.option)
+			if !errors.Is(err, udh.ErrUnsupportedShardOption) {
+				t2.Errorf("have err %v, expected %v", err, udh.ErrUnsupportedShardOption)
+			}
+
+			if sharded != nil {
+				t2.Error("expected no container")
+			}
+		})
+	}
+}
+
+func BenchmarkMessagesParallel(b *testing.B) {
+	type container struct {
+		name  string
+		add   func(info *udh.MessageElements) error
+		drain func() []*udh.MessageFragmentations
+	}
+
+	mutex := udh.InitMessages()
+	sharded, err := udh.NewShardedMessages(0)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	containers := []container{
+		{name: "mutex", add: mutex.AddMessageElements, drain: mutex.DrainComplete},
+		{name: "sharded", add: sharded.AddMessageElements, drain: sharded.DrainComplete},
+	}
+
+	for _, container := range containers {
+		b.Run(container.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			workers := atomic.Uint32{}
+
+			b.RunParallel(func(pb *testing.PB) {
+				// every worker uses its own references, completing 256 messages before draining
+				worker := byte(workers.Add(1))
+				fragments := make([]*udh.MessageElements, 0, 512)
+
+				for idx := range 256 {
+					message := twoPartMessage([]byte{worker, byte(idx)})
+					fragments = append(fragments, message[:]...)
+				}
+
+				for idx := 0; pb.Next(); idx++ {
+					_ = container.add(fragments[idx%len(fragments)])
+
+					if idx%len(fragments) == len(fragments)-1 {
+						container.drain()
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package smudhprom
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/ik5/smudh"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// subsystem is the name shared by all of the metrics.
+const subsystem = "smudh"
+
+// Collector is a prometheus.Collector, fed by the smudh.MetricsHooks of a Messages container.
+type Collector struct {
+	smudh.NopMetrics
+
+	fragments  *prometheus.CounterVec
+	completed  *prometheus.CounterVec
+	parseErrs  *prometheus.CounterVec
+	parsed     *prometheus.CounterVec
+	ieis       *prometheus.CounterVec
+	evictions  prometheus.Counter
+	incomplete *prometheus.Desc
+	container  atomic.Pointer[smudh.IncompleteLister]
+}
+
+// NewCollector returns a Collector whose metrics are prefixed by namespace, which may be empty.
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		fragments: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: "fragments_received_total",
+			Help: "Number of fragments added to the container.",
+		}, []string{"encoding"}),
+		completed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: "messages_completed_total",
+			Help: "Number of messages that have all of their fragments.",
+		}, []string{"encoding"}),
+		parseErrs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: "parse_errors_total",
+			Help: "Number of messages that could not be parsed.",
+		}, []string{"type"}),
+		parsed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: "messages_parsed_total",
+			Help: "Number of messages parsed by the container.",
+		}, []string{"encoding"}),
+		ieis: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: "information_elements_total",
+			Help: "Number of information elements found at the UDH of parsed messages.",
+		}, []string{"iei"}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace, Subsystem: subsystem, Name: "evictions_total",
+			Help: "Number of messages removed after their TTL expired.",
+		}),
+		incomplete: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "incomplete_messages"),
+			"Number of messages that are still waiting for fragments.",
+			nil, nil,
+		),
+	}
+}
+
+// Option returns the smudh.MessagesOption that connects the Collector to the Messages container created by
+// smudh.InitMessages, or to the ShardedMessages created by smudh.NewShardedMessages. A Collector serves a single
+// container.
+func (collector *Collector) Option() smudh.MessagesOption {
+	return smudh.WithMetrics(collector)
+}
+
+// Observe implements smudh.ContainerMetricsHooks.
+func (collector *Collector) Observe(container smudh.IncompleteLister) {
+	collector.container.Store(&container)
+}
+
+// FragmentReceived implements smudh.MetricsHooks.
+func (collector *Collector) FragmentReceived(encoding smudh.Encoding) {
+	collector.fragments.WithLabelValues(encoding.String()).Inc()
+}
+
+// MessageCompleted implements smudh.MetricsHooks.
+func (collector *Collector) MessageCompleted(encoding smudh.Encoding) {
+	collector.completed.WithLabelValues(encoding.String()).Inc()
+}
+
+// ParseError implements smudh.MetricsHooks.
+func (collector *Collector) ParseError(err error) {
+	collector.parseErrs.WithLabelValues(string(smudh.CodeOf(err))).Inc()
+}
+
+// Evicted implements smudh.MetricsHooks.
+func (collector *Collector) Evicted(count int) {
+	collector.evictions.Add(float64(count))
+}
+
+// MessageParsed implements smudh.ParseMetricsHooks.
+func (collector *Collector) MessageParsed(encoding smudh.Encoding, ieis []byte) {
+	collector.parsed.WithLabelValues(encoding.String()).Inc()
+
+	for _, iei := range ieis {
+		collector.ieis.WithLabelValues(fmt.Sprintf("0x%02X", iei)).Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (collector *Collector) Describe(ch chan<- *prometheus.Desc) {
+	collector.fragments.Describe(ch)
+	collector.completed.Describe(ch)
+	collector.parseErrs.Describe(ch)
+	collector.parsed.Describe(ch)
+	collector.ieis.Describe(ch)
+	collector.evictions.Describe(ch)
+	ch <- collector.incomplete
+}
+
+// Collect implements prometheus.Collector.
+func (collector *Collector) Collect(ch chan<- prometheus.Metric) {
+	collector.fragments.Collect(ch)
+	collector.completed.Collect(ch)
+	collector.parseErrs.Collect(ch)
+	collector.parsed.Collect(ch)
+	collector.ieis.Collect(ch)
+	collector.evictions.Collect(ch)
+
+	incomplete := 0
+	if container := collector.container.Load(); container != nil {
+		incomplete = len((*container).Incomplete())
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.incomplete, prometheus.GaugeValue, float64(incomplete))
+}
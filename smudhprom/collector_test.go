@@ -0,0 +1,106 @@
+package smudhprom_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhprom"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	collector := smudhprom.NewCollector("sms")
+	messages := udh.InitMessages(collector.Option(),
+		udh.WithParseOptions(udh.WithStrictHex(), udh.WithMaxInputLength(40)))
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50201546869732069732061206C"), // part 1 of 2
+		udh.Message("050003A5020265722074657374696E67"),   // part 2 of 2
+		udh.Message("050003B70502002005E905DC"),           // part 2 of 5
+		udh.Message("050"),                                // odd length
+		udh.Message("zz"),                                 // invalid hex
+		udh.Message("0x050003C5020161"),                   // hex prefix
+		udh.Message(strings.Repeat("61", 21)),             // longer than 40 characters
+	} {
+		_ = messages.Add(udh.ASCII, msg)
+	}
+
+	expected := `
+# HELP sms_smudh_fragments_received_total Number of fragments added to the container.
+# TYPE sms_smudh_fragments_received_total counter
+sms_smudh_fragments_received_total{encoding="ASCII"} 3
+# HELP sms_smudh_information_elements_total Number of information elements found at the UDH of parsed messages.
+# TYPE sms_smudh_information_elements_total counter
+sms_smudh_information_elements_total{iei="0x00"} 3
+# HELP sms_smudh_incomplete_messages Number of messages that are still waiting for fragments.
+# TYPE sms_smudh_incomplete_messages gauge
+sms_smudh_incomplete_messages 1
+# HELP sms_smudh_messages_completed_total Number of messages that have all of their fragments.
+# TYPE sms_smudh_messages_completed_total counter
+sms_smudh_messages_completed_total{encoding="ASCII"} 1
+# HELP sms_smudh_messages_parsed_total Number of messages parsed by the container.
+# TYPE sms_smudh_messages_parsed_total counter
+sms_smudh_messages_parsed_total{encoding="ASCII"} 3
+# HELP sms_smudh_parse_errors_total Number of messages that could not be parsed.
+# TYPE sms_smudh_parse_errors_total counter
+sms_smudh_parse_errors_total{type="odd_hex_length"} 1
+sms_smudh_parse_errors_total{type="invalid_hex"} 1
+sms_smudh_parse_errors_total{type="length_limit_exceeded"} 1
+sms_smudh_parse_errors_total{type="non_canonical_hex"} 1
+`
+
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"sms_smudh_fragments_received_total",
+		"sms_smudh_incomplete_messages",
+		"sms_smudh_information_elements_total",
+		"sms_smudh_messages_completed_total",
+		"sms_smudh_messages_parsed_total",
+		"sms_smudh_parse_errors_total",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorSharded(t *testing.T) {
+	collector := smudhprom.NewCollector("sms")
+
+	messages, err := udh.NewShardedMessages(8, collector.Option())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for reference := range 50 {
+		err = messages.Add(udh.ASCII, udh.Message(fmt.Sprintf("050003%02X020161", reference)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := `
+# HELP sms_smudh_fragments_received_total Number of fragments added to the container.
+# TYPE sms_smudh_fragments_received_total counter
+sms_smudh_fragments_received_total{encoding="ASCII"} 50
+# HELP sms_smudh_incomplete_messages Number of messages that are still waiting for fragments.
+# TYPE sms_smudh_incomplete_messages gauge
+sms_smudh_incomplete_messages 50
+# HELP sms_smudh_messages_parsed_total Number of messages parsed by the container.
+# TYPE sms_smudh_messages_parsed_total counter
+sms_smudh_messages_parsed_total{encoding="ASCII"} 50
+`
+
+	err = testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"sms_smudh_fragments_received_total",
+		"sms_smudh_incomplete_messages",
+		"sms_smudh_messages_parsed_total",
+	)
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,18 @@
+/*
+Package smudhprom exports the activity of a smudh Messages container as Prometheus metrics.
+
+	collector := smudhprom.NewCollector("sms")
+	messages := smudh.InitMessages(collector.Option())
+	prometheus.MustRegister(collector)
+
+The Collector exposes the following metrics, prefixed by the namespace given to NewCollector:
+
+	smudh_fragments_received_total{encoding}   fragments added to the container
+	smudh_messages_completed_total{encoding}   messages that have all of their fragments
+	smudh_parse_errors_total{type}             messages that could not be parsed, by smudh.CodeOf the error
+	smudh_messages_parsed_total{encoding}      messages parsed by the container, by their encoding
+	smudh_information_elements_total{iei}      information elements at the UDH of parsed messages, such as "0x00"
+	smudh_evictions_total                      messages removed after their TTL expired
+	smudh_incomplete_messages                  messages that are still waiting for fragments
+*/
+package smudhprom
@@ -0,0 +1,94 @@
+package smudhtest
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ik5/smudh"
+)
+
+// FakeClock is a smudh.Clock whose time moves only when Advance is called, for testing TTLs, reference windows and
+// RunJanitor without waiting.
+//
+// A FakeClock is safe for concurrent use.
+type FakeClock struct {
+	mtx     sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// NewFakeClock returns a FakeClock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now implements smudh.Clock.
+func (clock *FakeClock) Now() time.Time {
+	clock.mtx.Lock()
+	defer clock.mtx.Unlock()
+
+	return clock.now
+}
+
+// NewTicker implements smudh.Clock. The ticker ticks when Advance moves the clock past its next tick.
+func (clock *FakeClock) NewTicker(d time.Duration) smudh.Ticker {
+	if d <= 0 {
+		panic("smudhtest: non-positive interval for NewTicker")
+	}
+
+	clock.mtx.Lock()
+	defer clock.mtx.Unlock()
+
+	ticker := &fakeTicker{clock: clock, interval: d, next: clock.now.Add(d), ch: make(chan time.Time, 1)}
+	clock.tickers = append(clock.tickers, ticker)
+
+	return ticker
+}
+
+// Advance moves the clock forward by d, and delivers the ticks of the tickers that became due. As time.Ticker does,
+// a ticker whose previous tick was not received yet drops the ticks.
+func (clock *FakeClock) Advance(d time.Duration) {
+	clock.mtx.Lock()
+	defer clock.mtx.Unlock()
+
+	clock.now = clock.now.Add(d)
+
+	for _, ticker := range clock.tickers {
+		for !ticker.next.After(clock.now) {
+			select {
+			case ticker.ch <- ticker.next:
+			default:
+			}
+
+			ticker.next = ticker.next.Add(ticker.interval)
+		}
+	}
+}
+
+// fakeTicker is a smudh.Ticker of a FakeClock.
+type fakeTicker struct {
+	clock    *FakeClock
+	interval time.Duration
+	next     time.Time
+	ch       chan time.Time
+}
+
+// Chan implements smudh.Ticker.
+func (ticker *fakeTicker) Chan() <-chan time.Time {
+	return ticker.ch
+}
+
+// Stop implements smudh.Ticker.
+func (ticker *fakeTicker) Stop() {
+	ticker.clock.mtx.Lock()
+	defer ticker.clock.mtx.Unlock()
+
+	ticker.clock.tickers = slices.DeleteFunc(ticker.clock.tickers, func(existing *fakeTicker) bool {
+		return existing == ticker
+	})
+}
@@ -0,0 +1,256 @@
+package smudhtest
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ik5/smudh"
+)
+
+// Categories of the conformance vectors.
+const (
+	// CategoryEncoding holds the vectors of every supported encoding, as standalone and fragmented messages
+	CategoryEncoding = "encoding"
+
+	// CategoryIEI holds the vectors of the information elements of the UDH
+	CategoryIEI = "iei"
+
+	// CategoryQuirk holds the vectors of the built-in quirk profiles
+	CategoryQuirk = "quirk"
+
+	// CategoryError holds the vectors of malformed input
+	CategoryError = "error"
+)
+
+// ErrConformance is wrapped by the errors of vectors whose results differ from the expected ones.
+var ErrConformance = errors.New("conformance mismatch")
+
+//go:embed conformance/*.json
+var conformanceFiles embed.FS
+
+// Vector is a single conformance test vector: a short_message, how it is parsed, and the expected result.
+type Vector struct {
+	// Unique name of the vector inside its category
+	Name string `json:"name"`
+
+	// Category of the vector, such as CategoryEncoding
+	Category string `json:"category"`
+
+	// Human readable description of what the vector covers
+	Description string `json:"description,omitempty"`
+
+	// Encoding used for parsing the input
+	Encoding smudh.Encoding `json:"encoding"`
+
+	// Name of the quirk profile applied when parsing the input, see smudh.LookupQuirkProfile
+	Quirk string `json:"quirk,omitempty"`
+
+	// The hex encoded short_message content, including the UDH
+	Input string `json:"input"`
+
+	// The expected result
+	Expected Expectation `json:"expected"`
+}
+
+// Expectation is the expected result of parsing the input of a Vector. Only the fields listed here are compared,
+// so vectors keep passing when MessageElements gains new fields.
+type Expectation struct {
+	// Code of the expected error, see smudh.CodeOf. The other fields are ignored when set.
+	Error smudh.ErrorCode `json:"error,omitempty"`
+
+	// True when the input has no UDH
+	Standalone bool `json:"standalone,omitempty"`
+
+	// Hex encoded reference number
+	Reference string `json:"reference,omitempty"`
+
+	// Total number of parts
+	TotalParts byte `json:"total_parts,omitempty"`
+
+	// Number of the part
+	CurrentPart byte `json:"current_part,omitempty"`
+
+	// Application port addressing of the UDH
+	Ports *smudh.Ports `json:"ports,omitempty"`
+
+	// The decoded text
+	Text string `json:"text,omitempty"`
+}
+
+// String returns the category and name of the vector, as used for the subtests of RunConformance.
+func (vector Vector) String() string {
+	return vector.Category + "/" + vector.Name
+}
+
+// ReadVectors reads a JSON array of vectors from r, so carrier specific vectors can be kept next to the ones of
+// ConformanceVectors.
+func ReadVectors(r io.Reader) ([]Vector, error) {
+	var vectors []Vector
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	err := decoder.Decode(&vectors)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return vectors, nil
+}
+
+// ConformanceVectors returns the vectors of the corpus embedded in the package, ordered by category and name.
+func ConformanceVectors() ([]Vector, error) {
+	paths, err := fs.Glob(conformanceFiles, "conformance/*.json")
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	var vectors []Vector
+
+	for _, path := range paths {
+		file, err := conformanceFiles.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		loaded, err := ReadVectors(file)
+		_ = file.Close()
+
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		vectors = append(vectors, loaded...)
+	}
+
+	slices.SortFunc(vectors, func(a, b Vector) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	return vectors, nil
+}
+
+// Check parses the input of the vector, and compares the result with the expected one. The options are applied
+// before the ones of the quirk profile of the vector, such as the IEI registry of a carrier.
+//
+// Returns an error wrapping ErrConformance describing the differences, or the error of looking the quirk profile
+// up.
+func (vector Vector) Check(options ...smudh.ParseOption) error {
+	if vector.Quirk != "" {
+		profile, err := smudh.LookupQuirkProfile(vector.Quirk)
+		if err != nil {
+			return fmt.Errorf("%w", err)
+		}
+
+		options = slices.Concat(options, profile.ParseOptions())
+	}
+
+	elements, err := smudh.Message(vector.Input).ParseElements(vector.Encoding, options...)
+	if code := smudh.CodeOf(err); code != vector.Expected.Error {
+		return fmt.Errorf("%w: have error code %q, expected %q: %v", ErrConformance, code, vector.Expected.Error, err)
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	have := Expectation{
+		Standalone:  elements.Standalone,
+		Reference:   hex.EncodeToString(elements.Reference),
+		TotalParts:  elements.TotalParts,
+		CurrentPart: elements.CurrentPart,
+		Ports:       elements.Ports,
+		Text:        elements.Message,
+	}
+
+	if diffs := vector.Expected.differences(have); len(diffs) > 0 {
+		return fmt.Errorf("%w: %s", ErrConformance, strings.Join(diffs, ", "))
+	}
+
+	return nil
+}
+
+// differences describes the fields of have that differ from the expected ones.
+func (expected Expectation) differences(have Expectation) []string {
+	var diffs []string
+
+	compare := func(field string, want, got any) {
+		if want != got {
+			diffs = append(diffs, fmt.Sprintf("%s is %#v, expected %#v", field, got, want))
+		}
+	}
+
+	compare("standalone", expected.Standalone, have.Standalone)
+	compare("reference", expected.Reference, have.Reference)
+	compare("total_parts", expected.TotalParts, have.TotalParts)
+	compare("current_part", expected.CurrentPart, have.CurrentPart)
+	compare("text", expected.Text, have.Text)
+
+	if expected.Ports == nil || have.Ports == nil {
+		compare("ports", expected.Ports == nil, have.Ports == nil)
+	} else {
+		compare("ports", *expected.Ports, *have.Ports)
+	}
+
+	return diffs
+}
+
+// Failure is a vector that did not pass CheckVectors.
+type Failure struct {
+	Vector Vector
+	Err    error
+}
+
+// CheckVectors checks every vector using Vector.Check, and returns the ones that failed, so the corpus can be
+// verified outside of tests, such as by a deployment checking a new carrier configuration.
+func CheckVectors(vectors []Vector, options ...smudh.ParseOption) []Failure {
+	var failures []Failure
+
+	for _, vector := range vectors {
+		err := vector.Check(options...)
+		if err != nil {
+			failures = append(failures, Failure{Vector: vector, Err: err})
+		}
+	}
+
+	return failures
+}
+
+// RunConformance runs the vectors returned by ConformanceVectors as subtests named after Vector.String, using
+// Vector.Check with the given options.
+func RunConformance(t *testing.T, options ...smudh.ParseOption) {
+	t.Helper()
+
+	vectors, err := ConformanceVectors()
+	if err != nil {
+		t.Fatalf("unable to load the conformance vectors: %s", err)
+	}
+
+	RunVectors(t, vectors, options...)
+}
+
+// RunVectors runs vectors as subtests named after Vector.String, using Vector.Check with the given options.
+func RunVectors(t *testing.T, vectors []Vector, options ...smudh.ParseOption) {
+	t.Helper()
+
+	for _, vector := range vectors {
+		t.Run(vector.String(), func(t2 *testing.T) {
+			err := vector.Check(options...)
+			if err != nil {
+				t2.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package smudhtest_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+func TestRunConformance(t *testing.T) {
+	smudhtest.RunConformance(t)
+}
+
+func TestConformanceVectors(t *testing.T) {
+	vectors, err := smudhtest.ConformanceVectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]bool{}
+	categories := map[string]int{}
+
+	for _, vector := range vectors {
+		if names[vector.String()] {
+			t.Errorf("duplicate vector %s", vector)
+		}
+
+		names[vector.String()] = true
+		categories[vector.Category]++
+	}
+
+	for _, category := range []string{
+		smudhtest.CategoryEncoding, smudhtest.CategoryIEI, smudhtest.CategoryQuirk, smudhtest.CategoryError,
+	} {
+		if categories[category] == 0 {
+			t.Errorf("no vectors of category %s", category)
+		}
+	}
+
+	if len(vectors) < 300 {
+		t.Errorf("have %d vectors, expected at least 300", len(vectors))
+	}
+}
+
+func TestCheckVectors(t *testing.T) {
+	vectors, err := smudhtest.ReadVectors(strings.NewReader(`[
+		{"name": "match", "category": "carrier", "encoding": "ASCII", "input": "0500030A020168656C6C6F",
+			"expected": {"reference": "0a", "total_parts": 2, "current_part": 1, "text": "hello"}},
+		{"name": "text", "category": "carrier", "encoding": "ASCII", "input": "0500030A020168656C6C6F",
+			"expected": {"reference": "0a", "total_parts": 2, "current_part": 1, "text": "world"}},
+		{"name": "error", "category": "carrier", "encoding": "ASCII", "input": "0500030A02016",
+			"expected": {"error": "invalid_hex"}},
+		{"name": "quirk", "category": "carrier", "encoding": "ASCII", "quirk": "no-such-quirk", "input": "00",
+			"expected": {}}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failures := smudhtest.CheckVectors(vectors, smudh.WithMaxInputLength(1024))
+
+	expected := map[string]error{
+		"carrier/text":  smudhtest.ErrConformance,
+		"carrier/error": smudhtest.ErrConformance,
+		"carrier/quirk": smudh.ErrUnknownQuirkProfile,
+	}
+	if len(failures) != len(expected) {
+		t.Fatalf("have %d failures, expected %d: %v", len(failures), len(expected), failures)
+	}
+
+	for _, failure := range failures {
+		if !errors.Is(failure.Err, expected[failure.Vector.String()]) {
+			t.Errorf("%s: have %v, expected %v", failure.Vector, failure.Err, expected[failure.Vector.String()])
+		}
+	}
+
+	_, err = smudhtest.ReadVectors(strings.NewReader(`[{"name": "x", "unknown": true}]`))
+	if err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,33 @@
+/*
+Package smudhtest provides utilities for testing code that relies on the smudh package.
+
+RunGoldenDir runs a directory of golden files as subtests, so real-world samples can be added as regression fixtures
+without writing new test code. Every fixture is made out of two files sharing the same base name:
+
+	carrier-a-part1.hex  - the hex encoded short_message content
+	carrier-a-part1.json - the expected MessageElements, in the form produced by MessageElements.ToJSON
+
+The encoding used for parsing the input is taken from the expected JSON.
+
+RunConformance runs the conformance corpus embedded in the package, hundreds of vectors per encoding, per IEI, per
+built-in quirk profile and for malformed input, so upgrades of the package and carrier specific parse options can be
+verified against them:
+
+	func TestConformance(t *testing.T) {
+		smudhtest.RunConformance(t, smudh.WithIEIRegistry(carrierRegistry))
+	}
+
+Outside of tests, ConformanceVectors and CheckVectors return the vectors that fail, and ReadVectors loads additional
+vectors of a carrier in the same JSON form.
+
+FakeClock is a smudh.Clock that moves only when told to, for testing TTLs and RunJanitor without waiting:
+
+	clock := smudhtest.NewFakeClock(time.Now())
+	messages := smudh.InitMessages(smudh.WithClock(clock), smudh.WithTTL(time.Minute))
+	clock.Advance(time.Minute)
+*/
+package smudhtest
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
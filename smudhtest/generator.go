@@ -0,0 +1,150 @@
+package smudhtest
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"math/rand/v2"
+
+	"github.com/ik5/smudh"
+)
+
+// alphabets holds the characters used for generating text per encoding.
+var alphabets = map[smudh.Encoding][]rune{
+	smudh.GSM:      []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 .,!?"),
+	smudh.ASCII:    []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 .,!?"),
+	smudh.Latin1:   []rune("abcdefghijklmnopqrstuvwxyzàáâãäåæçèéêëìíîïñòóôõöøùúûüý .,!?"),
+	smudh.Cyrillic: []rune("абвгдежзийклмнопрстуфхцчшщъыьэюяАБВГДЕЖЗИЙКЛМНОП .,!?"),
+	smudh.Hebrew:   []rune("אבגדהוזחטיכךלמםנןסעפףצץקרשת .,!?"),
+	smudh.UCS2:     []rune("abcdefghijklmnopqrstuvwxyzאבגדהוזחטיכךלמםנןסעפףצץקרשת0123456789 .,!?"),
+}
+
+// DefaultEncodings are the encodings a Generator uses when none were set.
+var DefaultEncodings = []smudh.Encoding{
+	smudh.GSM, smudh.ASCII, smudh.Latin1, smudh.Cyrillic, smudh.Hebrew, smudh.UCS2,
+}
+
+// Generator creates random valid message sets. The same seed always generates the same sets.
+//
+// A Generator is not safe for concurrent use.
+type Generator struct {
+	// Encodings to pick from, DefaultEncodings when empty
+	Encodings []smudh.Encoding
+
+	// Maximum number of parts for a set, 5 when zero
+	MaxParts int
+
+	// When true, the messages of a set are returned in random order
+	Shuffle bool
+
+	rnd *rand.Rand
+}
+
+// GeneratedSet is a single generated message, split into its fragments.
+type GeneratedSet struct {
+	// The encoding of the messages
+	Encoding smudh.Encoding
+
+	// The reference number, 1 or 2 bytes long
+	Reference []byte
+
+	// Application port addressing, when added to the UDH
+	Ports *smudh.Ports
+
+	// The full text of the message
+	Text string
+
+	// The hex encoded fragments
+	Messages []smudh.Message
+}
+
+// NewGenerator returns a Generator using the given seed.
+func NewGenerator(seed uint64) *Generator {
+	return &Generator{
+		rnd: rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// Next generates a new random message set.
+// Returns an error if one of the encodings is not supported by the generator.
+func (gen *Generator) Next() (*GeneratedSet, error) {
+	encodings := gen.Encodings
+	if len(encodings) == 0 {
+		encodings = DefaultEncodings
+	}
+
+	maxParts := gen.MaxParts
+	if maxParts <= 0 {
+		maxParts = 5
+	}
+
+	set := &GeneratedSet{
+		Encoding:  encodings[gen.rnd.IntN(len(encodings))],
+		Reference: make([]byte, 1+gen.rnd.IntN(2)),
+	}
+
+	alphabet, found := alphabets[set.Encoding]
+	if !found {
+		return nil, fmt.Errorf("%w: %s", smudh.ErrUnsupportedEncoding, set.Encoding)
+	}
+
+	for idx := range set.Reference {
+		set.Reference[idx] = byte(gen.rnd.UintN(0x100))
+	}
+
+	parts := 1 + gen.rnd.IntN(maxParts)
+
+	// port addressing is only kept on fragmented sets, since a UDH must start with a concatenation IE
+	if parts > 1 && gen.rnd.IntN(4) == 0 {
+		set.Ports = &smudh.Ports{Destination: uint16(gen.rnd.UintN(0x10000)), Source: uint16(gen.rnd.UintN(0x10000))}
+	}
+
+	// a rough number of characters per part
+	perPart := 60
+	if set.Encoding == smudh.UCS2 {
+		perPart = 30
+	}
+
+	length := 1 + gen.rnd.IntN(perPart)
+	if parts > 1 {
+		length = (parts-1)*perPart*2 + 1 + gen.rnd.IntN(perPart)
+	}
+
+	text := make([]rune, length)
+	for idx := range text {
+		text[idx] = alphabet[gen.rnd.IntN(len(alphabet))]
+	}
+
+	set.Text = string(text)
+
+	segments, err := smudh.SegmentText(set.Text, set.Encoding, smudh.SegmentOptions{
+		Reference: set.Reference,
+		Ports:     set.Ports,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	if len(segments) == 1 && set.Ports != nil {
+		set.Ports = nil
+
+		segments, err = smudh.SegmentText(set.Text, set.Encoding, smudh.SegmentOptions{Reference: set.Reference})
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+	}
+
+	for _, segment := range segments {
+		set.Messages = append(set.Messages, segment.Hex())
+	}
+
+	if gen.Shuffle {
+		gen.rnd.Shuffle(len(set.Messages), func(i, j int) {
+			set.Messages[i], set.Messages[j] = set.Messages[j], set.Messages[i]
+		})
+	}
+
+	return set, nil
+}
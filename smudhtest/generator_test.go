@@ -0,0 +1,66 @@
+package smudhtest_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+func TestGenerator(t *testing.T) {
+	gen := smudhtest.NewGenerator(42)
+	gen.Shuffle = true
+
+	for idx := 0; idx < 200; idx++ {
+		set, err := gen.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fragments := smudh.MessageFragmentations{}
+		for _, msg := range set.Messages {
+			err = fragments.Add(set.Encoding, msg)
+			if err != nil {
+				t.Fatalf("%d. %s: %s", idx, msg, err)
+			}
+		}
+
+		if !fragments.HaveAllFragments() {
+			t.Fatalf("%d. incomplete set: %+v", idx, set)
+		}
+
+		text := ""
+		for part := 1; part <= len(fragments); part++ {
+			for _, info := range fragments {
+				if int(info.CurrentPart) == part {
+					text += info.Message
+				}
+			}
+		}
+
+		if text != set.Text {
+			t.Fatalf("%d. have %q, expected %q", idx, text, set.Text)
+		}
+	}
+}
+
+func TestGeneratorSeed(t *testing.T) {
+	a, err := smudhtest.NewGenerator(7).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := smudhtest.NewGenerator(7).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(a, b); diff != "" {
+		t.Errorf("same seed generated different sets: %s", diff)
+	}
+}
@@ -0,0 +1,160 @@
+package smudhtest
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ik5/smudh"
+)
+
+const (
+	inputExtension    = ".hex"
+	expectedExtension = ".json"
+	metadataExtension = ".metadata.json"
+)
+
+// RunGoldenDir runs every fixture found in dir as a subtest named after the fixture.
+// A fixture fails when its input cannot be parsed, or the parsed MessageElements differ from the expected ones.
+//
+// A fixture may carry metadata at a JSON object next to its input, with the .metadata.json extension. Its input
+// is then added using smudh.ContextWithMetadata to a smudh.Messages backed by a smudh.MemoryStore, and the
+// fragment restored from the store is compared, so the metadata is checked after going through storage. Such
+// inputs must hold a fragment of an incomplete message.
+func RunGoldenDir(t *testing.T, dir string) {
+	t.Helper()
+
+	inputs, err := filepath.Glob(filepath.Join(dir, "*"+inputExtension))
+	if err != nil {
+		t.Fatalf("unable to list %s: %s", dir, err)
+	}
+
+	if len(inputs) == 0 {
+		t.Fatalf("no %s fixtures found at %s", inputExtension, dir)
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), inputExtension)
+
+		t.Run(name, func(t2 *testing.T) {
+			runGoldenFile(t2, input, strings.TrimSuffix(input, inputExtension)+expectedExtension)
+		})
+	}
+}
+
+// WriteGolden parses msg using the given encoding, and writes it as a fixture named name inside dir, so the
+// current behavior of the package can be recorded for a new sample.
+func WriteGolden(dir, name string, msg smudh.Message, encoding smudh.Encoding) error {
+	elements, err := msg.ParseElements(encoding)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	expected, err := elements.ToJSON()
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, name+inputExtension), append([]byte(msg), '\n'), 0o644)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dir, name+expectedExtension), []byte(expected+"\n"), 0o644)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+func runGoldenFile(t *testing.T, inputPath, expectedPath string) {
+	t.Helper()
+
+	input, err := os.ReadFile(inputPath)
+	if err != nil {
+		t.Fatalf("unable to read input: %s", err)
+	}
+
+	rawExpected, err := os.ReadFile(expectedPath)
+	if err != nil {
+		t.Fatalf("unable to read expected result: %s", err)
+	}
+
+	expected, err := smudh.MessageElementFromJSON(string(rawExpected))
+	if err != nil {
+		t.Fatalf("invalid expected result: %s", err)
+	}
+
+	msg := smudh.Message(strings.TrimSpace(string(input)))
+	metadataPath := strings.TrimSuffix(inputPath, inputExtension) + metadataExtension
+
+	rawMetadata, err := os.ReadFile(metadataPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("unable to read metadata: %s", err)
+	}
+
+	var elements *smudh.MessageElements
+
+	if rawMetadata == nil {
+		elements, err = msg.ParseElements(expected.Encoding)
+	} else {
+		elements, err = storedFragment(msg, expected.Encoding, rawMetadata)
+	}
+
+	if err != nil {
+		t.Fatalf("unable to parse input: %s", err)
+	}
+
+	if diffs := smudh.Diff(expected, elements); !diffs.Equal() {
+		t.Errorf("parsed elements differ from %s:\n%s", filepath.Base(expectedPath), diffs)
+	}
+}
+
+// storedFragment adds msg to a Messages backed by a MemoryStore, using a context carrying the metadata of
+// rawMetadata, and returns the fragment restored from the store into a new Messages.
+func storedFragment(msg smudh.Message, encoding smudh.Encoding, rawMetadata []byte) (*smudh.MessageElements, error) {
+	var metadata map[string]string
+
+	err := json.Unmarshal(rawMetadata, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("invalid metadata: %w", err)
+	}
+
+	store := smudh.NewMemoryStore()
+	ctx := smudh.ContextWithMetadata(context.Background(), metadata)
+
+	err = smudh.InitMessages(smudh.WithStore(store)).AddContext(ctx, encoding, msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	elements, err := msg.ParseElements(encoding)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	restored := smudh.InitMessages(smudh.WithStore(store))
+
+	err = restored.Restore()
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	for _, fragment := range restored.Snapshot(elements.Reference) {
+		if fragment.CurrentPart == elements.CurrentPart {
+			return fragment, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: part %d was not stored", ErrConformance, elements.CurrentPart)
+}
@@ -0,0 +1,27 @@
+package smudhtest_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+func TestRunGoldenDir(t *testing.T) {
+	smudhtest.RunGoldenDir(t, "testdata/golden")
+}
+
+func TestWriteGolden(t *testing.T) {
+	dir := t.TempDir()
+
+	err := smudhtest.WriteGolden(dir, "hebrew", smudh.Message("05000313010105E905DC05D505DD002005E205D505DC05DD"), smudh.UCS2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	smudhtest.RunGoldenDir(t, dir)
+}
@@ -0,0 +1,318 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StoredSet is the persisted form of the fragments of a single message.
+type StoredSet struct {
+	// The fragments received so far
+	Fragments MessageFragmentations `json:"fragments"`
+
+	// Arrival time of the first fragment
+	FirstSeen time.Time `json:"first_seen"`
+
+	// Arrival time of the last fragment
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Store persists the fragments held by Messages, so they survive a restart of the process.
+//
+// Messages keeps working from memory, and writes through to the Store on every change: a fragment is accepted only
+// after Save succeeded, otherwise the add fails with an error wrapping ErrStore. Keys are opaque strings created by
+// Messages.
+type Store interface {
+	// Save stores the current state of a message, replacing any previous state of the same key
+	Save(key string, set StoredSet) error
+
+	// Delete removes a message. Deleting a missing key is not an error.
+	Delete(key string) error
+
+	// LoadAll returns all of the stored messages, keyed by their key
+	LoadAll() (map[string]StoredSet, error)
+}
+
+// StorePinger is implemented by Stores that can check their connectivity without changing their content, for
+// health checks.
+type StorePinger interface {
+	// Ping returns an error when the Store cannot be used
+	Ping(ctx context.Context) error
+}
+
+// WithStore sets the Store that Messages writes through to. Use Restore for loading its content. Not supported by
+// ShardedMessages.
+func WithStore(store Store) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.store = store
+	}
+}
+
+// Restore loads the content of the Store set by WithStore into the container, replacing messages with the same
+// key. It does nothing when no Store was set.
+func (msgs *Messages) Restore() error {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	if msgs.store == nil {
+		return nil
+	}
+
+	sets, err := msgs.store.LoadAll()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	for key, stored := range sets {
+		if len(stored.Fragments) == 0 {
+			continue
+		}
+
+		fragments := stored.Fragments.Clone()
+		fragments.Sort()
+		msgs.trackSet(key, &fragmentSet{fragments: &fragments, firstSeen: stored.FirstSeen, lastSeen: stored.LastSeen})
+	}
+
+	msgs.debug("store restored", slog.Int("messages", len(sets)))
+
+	return nil
+}
+
+// saveSet writes a message through to the store. The caller must hold the lock.
+func (msgs *Messages) saveSet(key string, set *fragmentSet) error {
+	if msgs.store == nil {
+		return nil
+	}
+
+	err := msgs.store.Save(key, StoredSet{
+		Fragments: set.fragments.Clone(),
+		FirstSeen: set.firstSeen,
+		LastSeen:  set.lastSeen,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	return nil
+}
+
+// deleteSet removes a message from the store and the write-ahead log. Failures are logged, since the message was
+// already removed from memory. The caller must hold the lock.
+func (msgs *Messages) deleteSet(key string) {
+	msgs.logDelete(key)
+
+	if msgs.store == nil {
+		return
+	}
+
+	err := msgs.store.Delete(key)
+	if err != nil && msgs.logger != nil {
+		msgs.logger.Warn("unable to delete message from store",
+			slog.String("key", hex.EncodeToString([]byte(key))),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// revertSet saves a message to the store again once a fragment was rolled back after being saved, or deletes it
+// when no fragment is left. Failures are logged, since the add already failed. The caller must hold the lock.
+func (msgs *Messages) revertSet(key string, set *fragmentSet) {
+	if msgs.store == nil {
+		return
+	}
+
+	var err error
+
+	if len(*set.fragments) == 0 {
+		err = msgs.store.Delete(key)
+	} else {
+		err = msgs.saveSet(key, set)
+	}
+
+	if err != nil && msgs.logger != nil {
+		msgs.logger.Warn("unable to revert message at the store",
+			slog.String("key", hex.EncodeToString([]byte(key))),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// MemoryStore is an in-memory Store, mostly useful for testing.
+type MemoryStore struct {
+	sets map[string]StoredSet
+	mtx  sync.Mutex
+}
+
+// NewMemoryStore returns a new empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{sets: map[string]StoredSet{}}
+}
+
+// Save implements Store.
+func (store *MemoryStore) Save(key string, set StoredSet) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	store.sets[key] = StoredSet{Fragments: set.Fragments.Clone(), FirstSeen: set.FirstSeen, LastSeen: set.LastSeen}
+	return nil
+}
+
+// Delete implements Store.
+func (store *MemoryStore) Delete(key string) error {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	delete(store.sets, key)
+	return nil
+}
+
+// LoadAll implements Store.
+func (store *MemoryStore) LoadAll() (map[string]StoredSet, error) {
+	store.mtx.Lock()
+	defer store.mtx.Unlock()
+
+	return maps.Clone(store.sets), nil
+}
+
+// DirStore is a Store that keeps every message as a JSON file inside a directory.
+type DirStore struct {
+	dir string
+}
+
+const dirStoreExtension = ".json"
+
+// NewDirStore returns a DirStore using dir, creating it when needed.
+func NewDirStore(dir string) (*DirStore, error) {
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	return &DirStore{dir: dir}, nil
+}
+
+// Save implements Store. The file is replaced atomically.
+func (store *DirStore) Save(key string, set StoredSet) error {
+	content, err := json.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	tmp, err := os.CreateTemp(store.dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), store.path(key))
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// Delete implements Store.
+func (store *DirStore) Delete(key string) error {
+	err := os.Remove(store.path(key))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// LoadAll implements Store.
+func (store *DirStore) LoadAll() (map[string]StoredSet, error) {
+	paths, err := filepath.Glob(filepath.Join(store.dir, "*"+dirStoreExtension))
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	result := make(map[string]StoredSet, len(paths))
+
+	for _, path := range paths {
+		key, err := hex.DecodeString(strings.TrimSuffix(filepath.Base(path), dirStoreExtension))
+		if err != nil {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		var set StoredSet
+
+		err = json.Unmarshal(content, &set)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		result[string(key)] = set
+	}
+
+	return result, nil
+}
+
+// Ping implements StorePinger, by checking that the directory is still there.
+func (store *DirStore) Ping(context.Context) error {
+	info, err := os.Stat(store.dir)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%w: %s is not a directory", fs.ErrInvalid, store.dir)
+	}
+
+	return nil
+}
+
+// path returns the file of a key. Keys are hex encoded, since they hold binary content.
+func (store *DirStore) path(key string) string {
+	return filepath.Join(store.dir, hex.EncodeToString([]byte(key))+dirStoreExtension)
+}
+
+// pingStore pings store when it implements StorePinger. Other Stores are assumed to be reachable.
+func pingStore(ctx context.Context, store Store) error {
+	pinger, ok := store.(StorePinger)
+	if !ok {
+		return nil
+	}
+
+	err := pinger.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	return nil
+}
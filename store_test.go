@@ -0,0 +1,88 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+var errStoreFailed = errors.New("store failed")
+
+type failingStore struct {
+	*udh.MemoryStore
+}
+
+func (failingStore) Save(string, udh.StoredSet) error {
+	return errStoreFailed
+}
+
+func TestStoreRestore(t *testing.T) {
+	dirStore, err := udh.NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stores := map[string]udh.Store{
+		"memory": udh.NewMemoryStore(),
+		"dir":    dirStore,
+	}
+
+	for name, store := range stores {
+		messages := udh.InitMessages(udh.WithStore(store))
+
+		for _, msg := range []udh.Message{
+			udh.Message("050003A50201546869732069732061206C"), // part 1 of 2
+			udh.Message("050003B70502002005E905DC"),           // part 2 of 5
+			udh.Message("05000313010105E905DC05D505DD"),       // part 1 of 1
+		} {
+			err := messages.Add(udh.ASCII, msg)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+		}
+
+		drained := messages.DrainComplete()
+		if len(drained) != 1 {
+			t.Fatalf("%s: have %d drained messages, expected 1", name, len(drained))
+		}
+
+		restored := udh.InitMessages(udh.WithStore(store))
+
+		err := restored.Restore()
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		if all := restored.ListAll(); len(all) != 2 {
+			t.Fatalf("%s: have %d restored messages, expected 2", name, len(all))
+		}
+
+		err = restored.Add(udh.ASCII, udh.Message("050003A5020265722074657374696E67")) // part 2 of 2
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+
+		fragments := restored.Snapshot([]byte{0xA5})
+		if text := fragments.String(); text != "This is a ler testing" {
+			t.Errorf("%s: have %q, expected %q", name, text, "This is a ler testing")
+		}
+	}
+}
+
+func TestStoreSaveFailure(t *testing.T) {
+	messages := udh.InitMessages(udh.WithStore(failingStore{udh.NewMemoryStore()}))
+
+	err := messages.Add(udh.ASCII, udh.Message("050003A50201546869732069732061206C"))
+	if !errors.Is(err, errStoreFailed) {
+		t.Fatalf("have error %v, expected %v", err, errStoreFailed)
+	}
+
+	if all := messages.ListAll(); len(all) != 0 {
+		t.Errorf("have %d messages, expected the failed fragment to be rolled back", len(all))
+	}
+}
@@ -0,0 +1,116 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ik5/smudh/charset"
+	"golang.org/x/text/transform"
+)
+
+// streamChunk is the number of hex characters DecodeStream handles at a time.
+const streamChunk = 4096
+
+// streamTransformer returns the transformer DecodeStream uses for enc.
+func streamTransformer(enc Encoding) (transform.Transformer, error) {
+	switch enc {
+	case GSM, GSMExtended:
+		return charset.GSM7Decoder{}, nil
+	case ASCII, UTF8:
+		return transform.Nop, nil
+	case Pictogram, Reserved1, Reserved2, Binary8Bit1, Binary8Bit2:
+		return nil, ErrUnsupportedEncoding
+	}
+
+	decoder := charset.Decoder(enc)
+	if decoder == nil {
+		return nil, ErrUnknownEncoding
+	}
+
+	return decoder, nil
+}
+
+// DecodeStream reads hex encoded text from src, and writes it to dst as UTF-8 decoded using enc, holding only a
+// small chunk of the input in memory at a time. Returns the number of UTF-8 bytes written.
+//
+// It is meant for large inputs without a UDH, such as the message_payload TLV that may carry up to 64KB. The whole
+// input is treated as text, and GSM 7-bit input is decoded from unpacked septets. Binary encodings are not
+// supported, since they have no text representation.
+//
+// Decoding stops with the context error when ctx is done.
+func DecodeStream(ctx context.Context, dst io.Writer, src io.Reader, enc Encoding) (int64, error) {
+	transformer, err := streamTransformer(enc)
+	if err != nil {
+		return 0, err
+	}
+
+	counter := &countingWriter{writer: dst}
+	writer := transform.NewWriter(counter, transformer)
+
+	hexChunk := make([]byte, streamChunk)
+	binary := make([]byte, streamChunk/2)
+	pending := 0 // a hex character left over from the previous chunk
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return counter.written, fmt.Errorf("%w", err)
+		}
+
+		read, readErr := src.Read(hexChunk[pending:])
+		available := pending + read
+		usable := available &^ 1
+
+		decoded, err := decodeHex(binary, hexChunk[:usable])
+		if err != nil {
+			return counter.written, fmt.Errorf("%w", err)
+		}
+
+		_, err = writer.Write(binary[:decoded])
+		if err != nil {
+			return counter.written, fmt.Errorf("%w", err)
+		}
+
+		pending = available - usable
+		if pending > 0 {
+			hexChunk[0] = hexChunk[usable]
+		}
+
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+
+		if readErr != nil {
+			return counter.written, fmt.Errorf("%w", readErr)
+		}
+	}
+
+	if pending > 0 {
+		return counter.written, ErrHexStringMustHaveAnEvenNumberOfChars
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return counter.written, fmt.Errorf("%w", err)
+	}
+
+	return counter.written, nil
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	writer  io.Writer
+	written int64
+}
+
+func (writer *countingWriter) Write(p []byte) (int, error) {
+	written, err := writer.writer.Write(p)
+	writer.written += int64(written)
+
+	return written, err
+}
@@ -0,0 +1,71 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	udh "github.com/ik5/smudh"
+)
+
+func TestDecodeStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		encoding udh.Encoding
+		expected string
+		err      error
+	}{
+		{name: "ascii", input: "68656C6C6F", encoding: udh.ASCII, expected: "hello"},
+		{name: "gsm basic", input: "48656C6C6F", encoding: udh.GSM, expected: "Hello"},
+		{name: "gsm extension", input: "1B6532301B3C", encoding: udh.GSM, expected: "€20["},
+		{name: "gsm unknown extension", input: "1B41", encoding: udh.GSM, expected: "A"},
+		{name: "gsm trailing escape", input: "411B", encoding: udh.GSM, expected: "A "},
+		{name: "ucs2", input: "05E905DC05D505DD", encoding: udh.UCS2, expected: "שלום"},
+		{
+			name: "large ucs2", input: strings.Repeat("05E9", 5000), encoding: udh.UCS2,
+			expected: strings.Repeat("ש", 5000),
+		},
+		{
+			name: "large gsm extension", input: strings.Repeat("1B65", 3000), encoding: udh.GSM,
+			expected: strings.Repeat("€", 3000),
+		},
+		{name: "latin1", input: "E9", encoding: udh.Latin1, expected: "é"},
+		{name: "odd length", input: "68656", encoding: udh.ASCII, err: udh.ErrHexStringMustHaveAnEvenNumberOfChars},
+		{name: "binary", input: "0102", encoding: udh.Binary8Bit2, err: udh.ErrUnsupportedEncoding},
+	}
+
+	for _, test := range tests {
+		readers := map[string]io.Reader{
+			"whole": strings.NewReader(test.input),
+			// one byte at a time, splitting every possible chunk boundary
+			"bytes": iotest.OneByteReader(strings.NewReader(test.input)),
+		}
+
+		for readerName, reader := range readers {
+			var output bytes.Buffer
+
+			written, err := udh.DecodeStream(t.Context(), &output, reader, test.encoding)
+			if !errors.Is(err, test.err) {
+				t.Errorf("%s/%s: have error %v, expected %v", test.name, readerName, err, test.err)
+				continue
+			}
+
+			if err != nil {
+				continue
+			}
+
+			if output.String() != test.expected || written != int64(len(test.expected)) {
+				t.Errorf("%s/%s: have %q (%d bytes), expected %q", test.name, readerName, output.String(), written,
+					test.expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,12 @@
+/*
+Package submit builds the SMPP submit_sm fields needed to send a text message - the outbound counterpart of what the
+smudh package parses inbound.
+
+Given a destination, the text and Options, Build returns the ready-to-send short_message byte slices (one per
+submit_sm PDU), together with the matching esm_class and data_coding values.
+*/
+package submit
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
@@ -0,0 +1,110 @@
+package submit
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+
+	"github.com/ik5/smudh"
+)
+
+// ESMClassUDHI is the esm_class bit indicating that short_message starts with a UDH.
+const ESMClassUDHI = smudh.ESMClassUDHI
+
+// Options holds the settings used for building the payload.
+type Options struct {
+	// Encoding of the text
+	Encoding smudh.Encoding
+
+	// Reference number for fragmented messages, 1 or 2 bytes long
+	Reference []byte
+
+	// Optional application port addressing
+	Ports *smudh.Ports
+
+	// Base esm_class value (messaging mode and type) - the UDHI bit is added when needed
+	ESMClass byte
+
+	// Replace characters that have no GSM 03.38 representation when using a GSM encoding, see
+	// smudh.TransliterateGSM
+	Transliterate bool
+
+	// National language tables used with a GSM encoding, see smudh.SegmentOptions
+	LockingShift smudh.NationalLanguage
+	SingleShift  smudh.NationalLanguage
+
+	// Byte order and byte order mark policy used with UCS2, see smudh.SegmentOptions
+	ByteOrder smudh.UCS2ByteOrder
+	BOM       smudh.UCS2BOM
+}
+
+// Payload holds the submit_sm fields of a message.
+type Payload struct {
+	// Destination address
+	Destination string `json:"destination"`
+
+	// The short_message field of each submit_sm PDU, in order
+	ShortMessages [][]byte `json:"short_messages"`
+
+	// The esm_class field of the PDUs
+	ESMClass byte `json:"esm_class"`
+
+	// The data_coding field of the PDUs
+	DataCoding byte `json:"data_coding"`
+
+	// Characters replaced when using Options.Transliterate
+	Replacements []smudh.Replacement `json:"replacements,omitempty"`
+}
+
+// Build encodes and segments text for the given destination.
+// Returns an error if the text cannot be encoded or segmented using the given options.
+func Build(destination, text string, options Options) (*Payload, error) {
+	if destination == "" {
+		return nil, ErrMissingDestination
+	}
+
+	dataCoding, err := options.Encoding.DataCoding()
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	var replacements []smudh.Replacement
+	if options.Transliterate && (options.Encoding == smudh.GSM || options.Encoding == smudh.GSMExtended) {
+		text, replacements, err = smudh.TransliterateNational(text, options.LockingShift, options.SingleShift)
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+	}
+
+	segments, err := smudh.SegmentText(text, options.Encoding, smudh.SegmentOptions{
+		Reference:    options.Reference,
+		Ports:        options.Ports,
+		LockingShift: options.LockingShift,
+		SingleShift:  options.SingleShift,
+		ByteOrder:    options.ByteOrder,
+		BOM:          options.BOM,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	payload := &Payload{
+		Destination:   destination,
+		ShortMessages: make([][]byte, 0, len(segments)),
+		ESMClass:      options.ESMClass,
+		DataCoding:    dataCoding,
+		Replacements:  replacements,
+	}
+
+	for _, segment := range segments {
+		if len(segment.Header) > 0 {
+			payload.ESMClass |= ESMClassUDHI
+		}
+
+		payload.ShortMessages = append(payload.ShortMessages, segment.Bytes())
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,98 @@
+package submit_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ik5/smudh"
+	"github.com/ik5/smudh/submit"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		options    submit.Options
+		parts      int
+		esmClass   byte
+		dataCoding byte
+	}{
+		{
+			name:       "single GSM",
+			text:       "hello world",
+			options:    submit.Options{Encoding: smudh.GSM},
+			parts:      1,
+			esmClass:   0x00,
+			dataCoding: 0x00,
+		},
+		{
+			name:       "fragmented UCS2",
+			text:       strings.Repeat("שלום ", 20),
+			options:    submit.Options{Encoding: smudh.UCS2, Reference: []byte{0x10}},
+			parts:      2,
+			esmClass:   submit.ESMClassUDHI,
+			dataCoding: 0x08,
+		},
+		{
+			name:       "ports on a single message",
+			text:       "hello",
+			options:    submit.Options{Encoding: smudh.Latin1, Ports: &smudh.Ports{Destination: 0x158A}},
+			parts:      1,
+			esmClass:   submit.ESMClassUDHI,
+			dataCoding: 0x03,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			payload, err := submit.Build("972501234567", test.text, test.options)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if len(payload.ShortMessages) != test.parts {
+				t2.Errorf("have %d parts, expected %d", len(payload.ShortMessages), test.parts)
+			}
+
+			if payload.ESMClass != test.esmClass {
+				t2.Errorf("have esm_class 0x%02X, expected 0x%02X", payload.ESMClass, test.esmClass)
+			}
+
+			if payload.DataCoding != test.dataCoding {
+				t2.Errorf("have data_coding 0x%02X, expected 0x%02X", payload.DataCoding, test.dataCoding)
+			}
+		})
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	_, err := submit.Build("", "hello", submit.Options{})
+	if !errors.Is(err, submit.ErrMissingDestination) {
+		t.Errorf("have err: %v, expected: %v", err, submit.ErrMissingDestination)
+	}
+
+	_, err = submit.Build("972501234567", "hello", submit.Options{Encoding: smudh.UTF8})
+	if !errors.Is(err, smudh.ErrUnsupportedEncoding) {
+		t.Errorf("have err: %v, expected: %v", err, smudh.ErrUnsupportedEncoding)
+	}
+}
+
+func TestBuildTransliterate(t *testing.T) {
+	payload, err := submit.Build("972501234567", "it’s done", submit.Options{Encoding: smudh.GSM, Transliterate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(payload.ShortMessages[0]) != "it's done" {
+		t.Errorf("have %q, expected %q", payload.ShortMessages[0], "it's done")
+	}
+
+	if len(payload.Replacements) != 1 || payload.Replacements[0].Original != '’' {
+		t.Errorf("have replacements %v, expected a single replaced ’", payload.Replacements)
+	}
+}
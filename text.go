@@ -0,0 +1,103 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ControlCharacters sets what the parser does with the C0 and C1 control characters found in the decoded text.
+type ControlCharacters byte
+
+const (
+	// KeepControlCharacters leaves the control characters in place, the default
+	KeepControlCharacters ControlCharacters = iota
+
+	// StripControlCharacters removes the control characters
+	StripControlCharacters
+
+	// EscapeControlCharacters replaces every control character with a \xHH escape sequence
+	EscapeControlCharacters
+)
+
+// WithControlCharacters sets what happens to the C0 (U+0000 to U+001F, and U+007F) and C1 (U+0080 to U+009F)
+// control characters some handsets embed in the text. CR and LF are always kept.
+func WithControlCharacters(mode ControlCharacters) ParseOption {
+	return func(config *parseConfig) {
+		config.controlCharacters = mode
+	}
+}
+
+// WithUnicodeNormalization applies the form, usually norm.NFC or norm.NFKC, to the decoded text, so messages
+// holding decomposed characters compare equal to their composed counterparts.
+// Normalization takes place after WithControlCharacters.
+func WithUnicodeNormalization(form norm.Form) ParseOption {
+	return func(config *parseConfig) {
+		config.normalization = &form
+	}
+}
+
+// processText applies the text options of the configuration to the decoded Message.
+func (config parseConfig) processText(elements *MessageElements) {
+	if config.controlCharacters != KeepControlCharacters {
+		text, changed := sanitizeControl(elements.Message, config.controlCharacters)
+		if changed > 0 {
+			elements.Trace.add("text", "%d control characters sanitized", changed)
+			elements.Message = text
+		}
+	}
+
+	if config.normalization != nil && !config.normalization.IsNormalString(elements.Message) {
+		elements.Message = config.normalization.String(elements.Message)
+		elements.Trace.add("text", "unicode normalization applied")
+	}
+
+	if config.bidiIsolation {
+		text, wrapped := isolateRightToLeft(elements.Message)
+		if wrapped > 0 {
+			elements.Trace.add("text", "%d right-to-left runs isolated", wrapped)
+			elements.Message = text
+		}
+	}
+}
+
+// isSanitizedControl reports whether ch is a control character handled by WithControlCharacters.
+func isSanitizedControl(ch rune) bool {
+	if ch == '\r' || ch == '\n' {
+		return false
+	}
+
+	return ch < 0x20 || (ch >= 0x7F && ch <= 0x9F)
+}
+
+// sanitizeControl strips or escapes the control characters of text, and returns how many were found.
+func sanitizeControl(text string, mode ControlCharacters) (string, int) {
+	if strings.IndexFunc(text, isSanitizedControl) < 0 {
+		return text, 0
+	}
+
+	builder := strings.Builder{}
+	builder.Grow(len(text))
+
+	changed := 0
+
+	for _, ch := range text {
+		if !isSanitizedControl(ch) {
+			_, _ = builder.WriteRune(ch)
+			continue
+		}
+
+		changed++
+
+		if mode == EscapeControlCharacters {
+			_, _ = fmt.Fprintf(&builder, "\\x%02X", ch)
+		}
+	}
+
+	return builder.String(), changed
+}
@@ -0,0 +1,64 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	udh "github.com/ik5/smudh"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestWithControlCharacters(t *testing.T) {
+	// "a", NUL, "b", CR, LF, TAB, "c", U+0085 (NEL), "d"
+	msg := udh.Message("00610000006200" + "0D000A0009006300850064")
+
+	tests := []struct {
+		name     string
+		mode     udh.ControlCharacters
+		expected string
+	}{
+		{name: "keep", mode: udh.KeepControlCharacters, expected: "a\x00b\r\n\tc\u0085d"},
+		{name: "strip", mode: udh.StripControlCharacters, expected: "ab\r\ncd"},
+		{name: "escape", mode: udh.EscapeControlCharacters, expected: `a\x00b` + "\r\n" + `\x09c\x85d`},
+	}
+
+	for _, test := range tests {
+		elements, err := msg.ParseElements(udh.UCS2, udh.WithControlCharacters(test.mode))
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if elements.Message != test.expected {
+			t.Errorf("%s: have %q, expected %q", test.name, elements.Message, test.expected)
+		}
+	}
+}
+
+func TestWithUnicodeNormalization(t *testing.T) {
+	// "e" followed by a combining acute accent, and the "fi" ligature
+	msg := udh.Message("00650301FB01")
+
+	tests := []struct {
+		name     string
+		options  []udh.ParseOption
+		expected string
+	}{
+		{name: "none", expected: "e\u0301\ufb01"},
+		{name: "nfc", options: []udh.ParseOption{udh.WithUnicodeNormalization(norm.NFC)}, expected: "\u00e9\ufb01"},
+		{name: "nfkc", options: []udh.ParseOption{udh.WithUnicodeNormalization(norm.NFKC)}, expected: "\u00e9fi"},
+	}
+
+	for _, test := range tests {
+		elements, err := msg.ParseElements(udh.UCS2, test.options...)
+		if err != nil {
+			t.Fatalf("%s: %s", test.name, err)
+		}
+
+		if elements.Message != test.expected {
+			t.Errorf("%s: have %q, expected %q", test.name, elements.Message, test.expected)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"log/slog"
+	"regexp"
+	"strconv"
+)
+
+// TextMarkerReference is the first octet of the 16-bit reference given to messages numbered by WithTextMarkers. The
+// second octet is the total number of parts, since the text holds no reference number.
+const TextMarkerReference byte = 0xFF
+
+// DefaultTextMarkers are the patterns used by WithTextMarkers when none are given: "(1/3)", "[1/3]" and "1/3"
+// prefixes followed by a separator, "(1/3)" and "[1/3]" suffixes, and "Part 1 of 3" prefixes.
+var DefaultTextMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`^\s*[(\[](?P<part>\d{1,3})\s*/\s*(?P<total>\d{1,3})[)\]][\s:.-]*`),
+	regexp.MustCompile(`^\s*(?P<part>\d{1,3})/(?P<total>\d{1,3})(?:[:.-]\s*|\s+)`),
+	regexp.MustCompile(`\s*[(\[](?P<part>\d{1,3})\s*/\s*(?P<total>\d{1,3})[)\]]\s*$`),
+	regexp.MustCompile(`(?i)^\s*part\s+(?P<part>\d{1,3})\s+of\s+(?P<total>\d{1,3})[\s:.-]*`),
+}
+
+// WithTextMarkers numbers messages that have no UDH, but are split by their sender into plain text parts marked
+// with their numbering, such as "(1/3) Hello", as done by some low-end aggregators. The marker is removed from the
+// text, and the message gets TotalParts and CurrentPart from it, with a reference made of TextMarkerReference and
+// the total number of parts.
+//
+// Every pattern must have the named groups "part" and "total", and the first pattern that matches is used. When no
+// patterns are given, DefaultTextMarkers are used. Since markers have no reference number, concurrent messages of
+// the same number of parts are told apart only by their namespace, such as a namespace per sender.
+func WithTextMarkers(patterns ...*regexp.Regexp) ParseOption {
+	if len(patterns) == 0 {
+		patterns = DefaultTextMarkers
+	}
+
+	return func(config *parseConfig) {
+		config.textMarkers = patterns
+	}
+}
+
+// applyTextMarkers numbers a standalone message using the first of the patterns set by WithTextMarkers that
+// matches its text.
+func (elem *MessageElements) applyTextMarkers(config parseConfig) {
+	if !elem.Standalone || elem.Encoding == Binary8Bit1 || elem.Encoding == Binary8Bit2 {
+		return
+	}
+
+	for _, pattern := range config.textMarkers {
+		match := pattern.FindStringSubmatchIndex(elem.Message)
+		if match == nil {
+			continue
+		}
+
+		part := markerNumber(pattern, elem.Message, match, "part")
+		total := markerNumber(pattern, elem.Message, match, "total")
+		if part < 1 || total < 2 || part > total || total > maxSegments {
+			continue
+		}
+
+		elem.Standalone = false
+		elem.Reference = []byte{TextMarkerReference, byte(total)}
+		elem.TotalParts = byte(total)
+		elem.CurrentPart = byte(part)
+		elem.Message = elem.Message[:match[0]] + elem.Message[match[1]:]
+
+		elem.Trace.add("marker", "text marker %q: part %d of %d", pattern.String(), part, total)
+		config.debug("text marker found", slog.Int("total_parts", total), slog.Int("current_part", part))
+
+		return
+	}
+}
+
+// markerNumber returns the value of a named group of a match, or 0 when the group did not match.
+func markerNumber(pattern *regexp.Regexp, text string, match []int, name string) int {
+	group := pattern.SubexpIndex(name)
+	if group < 0 || match[2*group] < 0 {
+		return 0
+	}
+
+	number, err := strconv.Atoi(text[match[2*group]:match[2*group+1]])
+	if err != nil {
+		return 0
+	}
+
+	return number
+}
@@ -0,0 +1,125 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/hex"
+	"regexp"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestWithTextMarkers(t *testing.T) {
+	type result struct {
+		Message     string
+		Standalone  bool
+		Reference   []byte
+		TotalParts  byte
+		CurrentPart byte
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		options  []udh.ParseOption
+		expected result
+	}{
+		{
+			name: "parentheses prefix", text: "(1/3) Your code is", options: []udh.ParseOption{udh.WithTextMarkers()},
+			expected: result{Message: "Your code is", Reference: []byte{0xFF, 0x03}, TotalParts: 3, CurrentPart: 1},
+		},
+		{
+			name: "brackets prefix", text: "[2/3]ready to use", options: []udh.ParseOption{udh.WithTextMarkers()},
+			expected: result{Message: "ready to use", Reference: []byte{0xFF, 0x03}, TotalParts: 3, CurrentPart: 2},
+		},
+		{
+			name: "bare prefix", text: "2/2: see you", options: []udh.ParseOption{udh.WithTextMarkers()},
+			expected: result{Message: "see you", Reference: []byte{0xFF, 0x02}, TotalParts: 2, CurrentPart: 2},
+		},
+		{
+			name: "suffix", text: "Thanks for waiting (3/4)", options: []udh.ParseOption{udh.WithTextMarkers()},
+			expected: result{Message: "Thanks for waiting", Reference: []byte{0xFF, 0x04}, TotalParts: 4, CurrentPart: 3},
+		},
+		{
+			name: "part of", text: "PART 1 OF 2: Hello", options: []udh.ParseOption{udh.WithTextMarkers()},
+			expected: result{Message: "Hello", Reference: []byte{0xFF, 0x02}, TotalParts: 2, CurrentPart: 1},
+		},
+		{
+			name: "custom pattern", text: "Hello #1#2",
+			options: []udh.ParseOption{
+				udh.WithTextMarkers(regexp.MustCompile(`\s*#(?P<part>\d+)#(?P<total>\d+)$`)),
+			},
+			expected: result{Message: "Hello", Reference: []byte{0xFF, 0x02}, TotalParts: 2, CurrentPart: 1},
+		},
+		{
+			name: "part beyond total", text: "(3/2) Hello", options: []udh.ParseOption{udh.WithTextMarkers()},
+			expected: result{Message: "(3/2) Hello", Standalone: true, Reference: []byte{0}, TotalParts: 1, CurrentPart: 1},
+		},
+		{
+			name: "single part", text: "(1/1) Hello", options: []udh.ParseOption{udh.WithTextMarkers()},
+			expected: result{Message: "(1/1) Hello", Standalone: true, Reference: []byte{0}, TotalParts: 1, CurrentPart: 1},
+		},
+		{
+			name: "without the option", text: "(1/3) Hello",
+			expected: result{Message: "(1/3) Hello", Standalone: true, Reference: []byte{0}, TotalParts: 1, CurrentPart: 1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			elements, err := udh.Message(hex.EncodeToString([]byte(test.text))).ParseElements(udh.Latin1, test.options...)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			have := result{
+				Message: elements.Message, Standalone: elements.Standalone, Reference: elements.Reference,
+				TotalParts: elements.TotalParts, CurrentPart: elements.CurrentPart,
+			}
+
+			if diff := cmp.Diff(test.expected, have); diff != "" {
+				t2.Errorf("unexpected elements (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMessagesTextMarkers(t *testing.T) {
+	messages := udh.InitMessages(udh.WithParseOptions(udh.WithTextMarkers()))
+
+	for _, text := range []string{"(2/2) world", "(1/2) Hello "} {
+		err := messages.Add(udh.Latin1, udh.Message(hex.EncodeToString([]byte(text))))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	text, err := messages.AssembledText([]byte{udh.TextMarkerReference, 0x02})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text != "Hello world" {
+		t.Errorf("have %q, expected %q", text, "Hello world")
+	}
+
+	profile, err := udh.LookupQuirkProfile(udh.QuirkTextMarkers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	elements, err := udh.Message(hex.EncodeToString([]byte("Hi [1/2]"))).ParseElements(udh.Latin1,
+		profile.ParseOptions()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Message != "Hi" || elements.CurrentPart != 1 {
+		t.Errorf("have %q as part %d using the %s profile, expected %q as part 1",
+			elements.Message, elements.CurrentPart, udh.QuirkTextMarkers, "Hi")
+	}
+}
@@ -0,0 +1,83 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TraceStep is a single parsing decision recorded in a Trace.
+type TraceStep struct {
+	// The stage of the parsing the decision belongs to (input, detection, element, header, decoder)
+	Stage string `json:"stage"`
+
+	// Human readable description of the decision
+	Detail string `json:"detail"`
+}
+
+// Trace records the decisions taken while parsing a Message, for diagnosing mis-parsed messages.
+type Trace struct {
+	Steps []TraceStep `json:"steps"`
+}
+
+// WithTrace makes the parser record each of its decisions into the Trace field of the resulting MessageElements.
+func WithTrace() ParseOption {
+	return func(config *parseConfig) {
+		config.trace = true
+	}
+}
+
+// String returns the steps of the trace, one per line.
+func (trace *Trace) String() string {
+	if trace == nil {
+		return ""
+	}
+
+	builder := strings.Builder{}
+
+	for idx, step := range trace.Steps {
+		_, _ = fmt.Fprintf(&builder, "%d. [%s] %s\n", idx+1, step.Stage, step.Detail)
+	}
+
+	return builder.String()
+}
+
+// add records a step. It does nothing on a nil trace, so callers do not need to check whether tracing is enabled.
+func (trace *Trace) add(stage, format string, args ...any) {
+	if trace == nil {
+		return
+	}
+
+	trace.Steps = append(trace.Steps, TraceStep{Stage: stage, Detail: fmt.Sprintf(format, args...)})
+}
+
+// decoderName returns a description of the decoder used for an encoding.
+func decoderName(enc Encoding) string {
+	switch enc {
+	case GSM, GSMExtended:
+		return "GSM 03.38 table"
+	case ASCII, UTF8:
+		return "raw bytes"
+	case Latin1:
+		return "ISO-8859-1"
+	case Binary8Bit1, Binary8Bit2:
+		return "hex encoding"
+	case UCS2:
+		return "UTF-16BE"
+	case Cyrillic:
+		return "ISO-8859-5"
+	case Hebrew:
+		return "ISO-8859-8"
+	case ISO2022JP:
+		return "ISO-2022-JP"
+	case KSC5601:
+		return "EUC-KR"
+	case JIS, EXTJIS:
+		return "EUC-JP"
+	}
+
+	return "none"
+}
@@ -0,0 +1,65 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    udh.Message
+		encoding udh.Encoding
+		expected []string
+	}{
+		{
+			name:     "8-bit reference",
+			input:    udh.Message("05000312010168656C6C6F20776F726C64"),
+			encoding: udh.GSM,
+			expected: []string{"input", "detection", "element", "header", "decoder"},
+		},
+		{
+			name:     "standalone",
+			input:    udh.Message("776F726C64"),
+			encoding: udh.ASCII,
+			expected: []string{"input", "detection", "decoder"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			elements, err := test.input.ParseElements(test.encoding, udh.WithTrace())
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if elements.Trace == nil {
+				t2.Fatal("expected a trace")
+			}
+
+			stages := []string{}
+			for _, step := range elements.Trace.Steps {
+				stages = append(stages, step.Stage)
+			}
+
+			if diff := cmp.Diff(test.expected, stages); diff != "" {
+				t2.Errorf("stages diff: %s\n%s", diff, elements.Trace)
+			}
+		})
+	}
+
+	elements, err := udh.Message("776F726C64").ParseElements(udh.ASCII)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Trace != nil {
+		t.Error("expected no trace without the WithTrace option")
+	}
+}
@@ -0,0 +1,120 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ik5/smudh/charset"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Replacement is a character replaced by TransliterateGSM.
+type Replacement struct {
+	// Byte offset of the character in the original text
+	Offset int `json:"offset"`
+
+	// The replaced character
+	Original rune `json:"original"`
+
+	// The text it was replaced with, may be empty
+	Replacement string `json:"replacement"`
+}
+
+// gsm7Transliterations holds the replacements of common characters that have no GSM 03.38 representation, and
+// cannot be handled by removing diacritics.
+var gsm7Transliterations = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'", '`': "'", '´': "'",
+	'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`, '«': `"`, '»': `"`,
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...", '•': "*", '·': ".",
+	'™': "TM", '©': "(C)", '®': "(R)",
+	'œ': "oe", 'Œ': "OE", 'ł': "l", 'Ł': "L", 'đ': "d", 'Đ': "D", 'ı': "i",
+	'\u00A0': " ", '\u2002': " ", '\u2003': " ", '\u2009': " ", '\u200A': " ", '\u202F': " ", '\u3000': " ",
+	'\u200B': "", '\u200C': "", '\u200D': "", '\uFEFF': "",
+}
+
+// TransliterateGSM replaces the characters of text that have no GSM 03.38 representation with close GSM
+// equivalents: smart quotes become ASCII quotes, dashes become hyphens, diacritics that GSM lacks are removed
+// ("á" becomes "a", while "é", which GSM has, is kept) and so on.
+//
+// It returns the new text, and the list of replaced characters. Characters without a known equivalent are left
+// in place, so encoding the result may still fail with ErrCharacterNotRepresentable.
+func TransliterateGSM(text string) (string, []Replacement) {
+	return transliterateGSM(text, charset.DefaultGSM7Table)
+}
+
+// TransliterateNational is the same as TransliterateGSM, but keeps the characters that the given national language
+// locking shift and single shift tables represent, such as the Turkish "ş".
+// Returns ErrUnsupportedNationalLanguage if either table is not supported.
+func TransliterateNational(
+	text string, lockingShift, singleShift NationalLanguage,
+) (string, []Replacement, error) {
+	table, err := charset.NationalGSM7Table(lockingShift, singleShift)
+	if err != nil {
+		return "", nil, err
+	}
+
+	result, replacements := transliterateGSM(text, table)
+
+	return result, replacements, nil
+}
+
+// transliterateGSM is TransliterateGSM, replacing the characters that have no representation in table.
+func transliterateGSM(text string, table charset.GSM7Table) (string, []Replacement) {
+	var (
+		builder      strings.Builder
+		replacements []Replacement
+	)
+
+	builder.Grow(len(text))
+
+	for offset, ch := range text {
+		if table.EncodeRune(ch) != nil {
+			_, _ = builder.WriteRune(ch)
+			continue
+		}
+
+		replacement, found := transliterateGSMRune(ch, table)
+		if !found {
+			_, _ = builder.WriteRune(ch)
+			continue
+		}
+
+		_, _ = builder.WriteString(replacement)
+		replacements = append(replacements, Replacement{Offset: offset, Original: ch, Replacement: replacement})
+	}
+
+	return builder.String(), replacements
+}
+
+// transliterateGSMRune returns the replacement of ch that table can represent.
+func transliterateGSMRune(ch rune, table charset.GSM7Table) (string, bool) {
+	if replacement, found := gsm7Transliterations[ch]; found {
+		return replacement, true
+	}
+
+	// remove the combining marks of the canonical decomposition, "á" is "a" followed by U+0301
+	base := strings.Map(func(ch rune) rune {
+		if unicode.Is(unicode.Mn, ch) {
+			return -1
+		}
+
+		return ch
+	}, norm.NFD.String(string(ch)))
+
+	if base == string(ch) || base == "" {
+		return "", false
+	}
+
+	for _, baseCh := range base {
+		if table.EncodeRune(baseCh) == nil {
+			return "", false
+		}
+	}
+
+	return base, true
+}
@@ -0,0 +1,72 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestTransliterateGSM(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		expected     string
+		replacements []udh.Replacement
+	}{
+		{name: "representable", text: "Café è ñ ß {€}", expected: "Café è ñ ß {€}"},
+		{
+			name: "quotes and dashes", text: "“Hi” — it’s…", expected: `"Hi" - it's...`,
+			replacements: []udh.Replacement{
+				{Offset: 0, Original: '“', Replacement: `"`},
+				{Offset: 5, Original: '”', Replacement: `"`},
+				{Offset: 9, Original: '—', Replacement: "-"},
+				{Offset: 15, Original: '’', Replacement: "'"},
+				{Offset: 19, Original: '…', Replacement: "..."},
+			},
+		},
+		{
+			name: "diacritics", text: "áçœ", expected: "acoe",
+			replacements: []udh.Replacement{
+				{Offset: 0, Original: 'á', Replacement: "a"},
+				{Offset: 2, Original: 'ç', Replacement: "c"},
+				{Offset: 4, Original: 'œ', Replacement: "oe"},
+			},
+		},
+		{
+			name: "unknown", text: "a☺", expected: "a☺",
+		},
+	}
+
+	for _, test := range tests {
+		text, replacements := udh.TransliterateGSM(test.text)
+		if text != test.expected {
+			t.Errorf("%s: have %q, expected %q", test.name, text, test.expected)
+		}
+
+		if diff := cmp.Diff(test.replacements, replacements); diff != "" {
+			t.Errorf("%s: unexpected replacements (-want +got):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestSegmentTextTransliterate(t *testing.T) {
+	_, err := udh.SegmentText("it’s", udh.GSM, udh.SegmentOptions{})
+	if !errors.Is(err, udh.ErrCharacterNotRepresentable) {
+		t.Fatalf("have error %v, expected %v", err, udh.ErrCharacterNotRepresentable)
+	}
+
+	segments, err := udh.SegmentText("it’s", udh.GSM, udh.SegmentOptions{Transliterate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hex := segments[0].Hex(); string(hex) != "69742773" {
+		t.Errorf("have %s, expected 69742773", hex)
+	}
+}
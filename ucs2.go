@@ -0,0 +1,198 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/ik5/smudh/charset"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// UCS2ByteOrder is the byte order of outbound UCS2 payloads.
+type UCS2ByteOrder byte
+
+const (
+	// UCS2BigEndian is the byte order GSM 03.38 requires, and the default
+	UCS2BigEndian UCS2ByteOrder = iota
+
+	// UCS2LittleEndian is used by SMSCs that expect little endian UTF-16
+	UCS2LittleEndian
+)
+
+// UCS2BOM sets which outbound UCS2 payloads start with a byte order mark.
+// Most SMSCs forbid a BOM, while some require one.
+type UCS2BOM byte
+
+const (
+	// BOMNone never adds a byte order mark, and is the default
+	BOMNone UCS2BOM = iota
+
+	// BOMFirstSegment adds a byte order mark only to the first segment
+	BOMFirstSegment
+
+	// BOMEverySegment adds a byte order mark to every segment
+	BOMEverySegment
+)
+
+// UCS2Padding sets how inbound UCS2 payloads that follow a UDH of an odd length are read. Some SMSCs insert a
+// padding octet after such a UDH, so the characters start at an even offset of the user data, while others don't.
+type UCS2Padding byte
+
+const (
+	// UCS2PaddingAuto drops the first payload octet when it is 0x00, the UDH has an odd length, and the payload has
+	// an odd length, which no UCS2 text has. This is the default.
+	UCS2PaddingAuto UCS2Padding = iota
+
+	// UCS2PaddingNone never drops an octet, for SMSCs that never pad
+	UCS2PaddingNone
+
+	// UCS2PaddingAlways drops the first payload octet whenever the UDH has an odd length, for SMSCs that always pad
+	UCS2PaddingAlways
+)
+
+// bomLength is the length in octets of a UTF-16 byte order mark.
+const bomLength = 2
+
+// String returns the name of the byte order.
+func (order UCS2ByteOrder) String() string {
+	switch order {
+	case UCS2BigEndian:
+		return "BigEndian"
+	case UCS2LittleEndian:
+		return "LittleEndian"
+	}
+
+	return "Unknown"
+}
+
+// String returns the name of the BOM policy.
+func (bom UCS2BOM) String() string {
+	switch bom {
+	case BOMNone:
+		return "None"
+	case BOMFirstSegment:
+		return "FirstSegment"
+	case BOMEverySegment:
+		return "EverySegment"
+	}
+
+	return "Unknown"
+}
+
+// String returns the name of the padding mode.
+func (padding UCS2Padding) String() string {
+	switch padding {
+	case UCS2PaddingAuto:
+		return "Auto"
+	case UCS2PaddingNone:
+		return "None"
+	case UCS2PaddingAlways:
+		return "Always"
+	}
+
+	return "Unknown"
+}
+
+// WithUCS2Padding sets how a padding octet after a UDH of an odd length is handled for UCS2 payloads.
+func WithUCS2Padding(padding UCS2Padding) ParseOption {
+	return func(config *parseConfig) {
+		config.ucs2Padding = padding
+	}
+}
+
+// stripUCS2Padding drops the padding octet that follows a UDH of headerLength octets, including the UDHL, from
+// the UCS2 payload, as selected by WithUCS2Padding.
+func (elem *MessageElements) stripUCS2Padding(config parseConfig, headerLength int) {
+	if elem.Encoding != UCS2 || headerLength%2 == 0 || len(elem.RawMessage) == 0 {
+		return
+	}
+
+	switch config.ucs2Padding {
+	case UCS2PaddingNone:
+		return
+	case UCS2PaddingAuto:
+		if len(elem.RawMessage)%2 == 0 || elem.RawMessage[0] != 0x00 {
+			return
+		}
+	}
+
+	elem.RawMessage = elem.RawMessage[1:]
+	elem.Trace.add("header", "UCS2 padding octet after a UDH of %d octets dropped", headerLength)
+	config.debug("UCS2 padding dropped", slog.Int("header_length", headerLength))
+}
+
+// segmentEncoder encodes the text of every segment produced by SegmentText.
+type segmentEncoder struct {
+	enc       Encoding
+	table     charset.GSM7Table
+	byteOrder UCS2ByteOrder
+	bom       UCS2BOM
+}
+
+// newSegmentEncoder returns the encoder for enc using options, which must already be adjusted by forEncoding.
+func newSegmentEncoder(enc Encoding, options SegmentOptions) (segmentEncoder, error) {
+	table, err := charset.NationalGSM7Table(options.LockingShift, options.SingleShift)
+	if err != nil {
+		return segmentEncoder{}, err
+	}
+
+	if options.ByteOrder > UCS2LittleEndian || options.BOM > BOMEverySegment {
+		return segmentEncoder{}, ErrInvalidUCS2Options
+	}
+
+	return segmentEncoder{enc: enc, table: table, byteOrder: options.ByteOrder, bom: options.BOM}, nil
+}
+
+// overhead returns the number of payload octets the byte order mark takes at the given zero based part.
+func (encoder segmentEncoder) overhead(part int) int {
+	if encoder.enc != UCS2 {
+		return 0
+	}
+
+	switch encoder.bom {
+	case BOMFirstSegment:
+		if part == 0 {
+			return bomLength
+		}
+	case BOMEverySegment:
+		return bomLength
+	}
+
+	return 0
+}
+
+// encode encodes the text of the given zero based part.
+func (encoder segmentEncoder) encode(text string, part int) ([]byte, error) {
+	switch encoder.enc {
+	case GSM, GSMExtended:
+		return encoder.table.Encode(text)
+
+	case UCS2:
+		endianness := unicode.BigEndian
+		if encoder.byteOrder == UCS2LittleEndian {
+			endianness = unicode.LittleEndian
+		}
+
+		payload, err := unicode.UTF16(endianness, unicode.IgnoreBOM).NewEncoder().Bytes([]byte(text))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrCharacterNotRepresentable, err)
+		}
+
+		if encoder.overhead(part) == 0 {
+			return payload, nil
+		}
+
+		bom := []byte{0xFE, 0xFF}
+		if encoder.byteOrder == UCS2LittleEndian {
+			bom = []byte{0xFF, 0xFE}
+		}
+
+		return append(bom, payload...), nil
+	}
+
+	return EncodeText(text, encoder.enc)
+}
@@ -0,0 +1,182 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestSegmentTextUCS2Options(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		options  udh.SegmentOptions
+		payloads [][]byte
+		err      error
+	}{
+		{
+			name:     "big endian without BOM",
+			text:     "שלום",
+			payloads: [][]byte{{0x05, 0xE9, 0x05, 0xDC, 0x05, 0xD5, 0x05, 0xDD}},
+		},
+		{
+			name:     "little endian",
+			text:     "שלום",
+			options:  udh.SegmentOptions{ByteOrder: udh.UCS2LittleEndian},
+			payloads: [][]byte{{0xE9, 0x05, 0xDC, 0x05, 0xD5, 0x05, 0xDD, 0x05}},
+		},
+		{
+			name:     "big endian with BOM",
+			text:     "hi",
+			options:  udh.SegmentOptions{BOM: udh.BOMFirstSegment},
+			payloads: [][]byte{{0xFE, 0xFF, 0x00, 0x68, 0x00, 0x69}},
+		},
+		{
+			name:     "little endian with BOM",
+			text:     "hi",
+			options:  udh.SegmentOptions{ByteOrder: udh.UCS2LittleEndian, BOM: udh.BOMEverySegment},
+			payloads: [][]byte{{0xFF, 0xFE, 0x68, 0x00, 0x69, 0x00}},
+		},
+		{
+			name:     "surrogate pair little endian",
+			text:     "😀",
+			options:  udh.SegmentOptions{ByteOrder: udh.UCS2LittleEndian},
+			payloads: [][]byte{{0x3D, 0xD8, 0x00, 0xDE}},
+		},
+		{
+			name:    "invalid byte order",
+			text:    "hi",
+			options: udh.SegmentOptions{ByteOrder: 9},
+			err:     udh.ErrInvalidUCS2Options,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			segments, err := udh.SegmentText(test.text, udh.UCS2, test.options)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("have err: %v, expected: %v", err, test.err)
+			}
+
+			payloads := make([][]byte, 0, len(segments))
+			for _, segment := range segments {
+				payloads = append(payloads, segment.Payload)
+			}
+
+			if test.err == nil {
+				if diff := cmp.Diff(test.payloads, payloads); diff != "" {
+					t2.Errorf("payload mismatch (-expected +have):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestSegmentTextBOMCapacity(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		bom         udh.UCS2BOM
+		payloadLens []int
+	}{
+		{name: "fits without BOM", text: strings.Repeat("ש", 70), bom: udh.BOMNone, payloadLens: []int{140}},
+		{name: "BOM pushes to two", text: strings.Repeat("ש", 70), bom: udh.BOMFirstSegment, payloadLens: []int{134, 8}},
+		{name: "first segment", text: strings.Repeat("ש", 134), bom: udh.BOMFirstSegment, payloadLens: []int{134, 134, 2}},
+		{name: "every segment", text: strings.Repeat("ש", 134), bom: udh.BOMEverySegment, payloadLens: []int{134, 134, 6}},
+	}
+
+	for _, test := range tests {
+		segments, err := udh.SegmentText(test.text, udh.UCS2, udh.SegmentOptions{Reference: []byte{0x01}, BOM: test.bom})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+
+		lengths := make([]int, 0, len(segments))
+		for _, segment := range segments {
+			lengths = append(lengths, len(segment.Payload))
+
+			if len(segment.Payload) > segment.Capacity {
+				t.Errorf("%s: payload length %d exceeds capacity %d", test.name, len(segment.Payload), segment.Capacity)
+			}
+		}
+
+		if diff := cmp.Diff(test.payloadLens, lengths); diff != "" {
+			t.Errorf("%s: payload lengths mismatch (-expected +have):\n%s", test.name, diff)
+		}
+	}
+}
+
+func TestWithUCS2Padding(t *testing.T) {
+	// 060804ABCD0201 is a UDH of 7 octets, holding a concatenation with a 16-bit reference
+	tests := []struct {
+		name     string
+		message  string
+		padding  udh.UCS2Padding
+		expected string
+		err      error
+	}{
+		{name: "auto without padding", message: "060804ABCD0201" + "00680069", expected: "hi"},
+		{name: "auto with padding", message: "060804ABCD0201" + "00" + "00680069", expected: "hi"},
+		{
+			name: "auto with a non-zero odd octet", message: "060804ABCD0201" + "01" + "00680069",
+			err: udh.ErrBinaryTextLengthIsNotEvenForUTF16Decoding,
+		},
+		{
+			name: "none with padding", message: "060804ABCD0201" + "00" + "00680069", padding: udh.UCS2PaddingNone,
+			err: udh.ErrBinaryTextLengthIsNotEvenForUTF16Decoding,
+		},
+		{
+			name: "always with padding", message: "060804ABCD0201" + "00" + "00680069", padding: udh.UCS2PaddingAlways,
+			expected: "hi",
+		},
+		{
+			name: "always without padding", message: "060804ABCD0201" + "00680069", padding: udh.UCS2PaddingAlways,
+			err: udh.ErrBinaryTextLengthIsNotEvenForUTF16Decoding,
+		},
+		{
+			name: "always after an even UDH", message: "050003A50201" + "00680069", padding: udh.UCS2PaddingAlways,
+			expected: "hi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			elements, err := udh.Message(test.message).ParseElements(udh.UCS2, udh.WithUCS2Padding(test.padding))
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("expected error %v, got %v", test.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if elements.Message != test.expected {
+				t2.Errorf("have message %q, expected %q", elements.Message, test.expected)
+			}
+		})
+	}
+
+	profile, err := udh.LookupQuirkProfile(udh.QuirkPaddedUCS2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := udh.InitMessages(udh.WithQuirkProfile("", profile))
+
+	elements, err := messages.Parse(udh.UCS2, udh.Message("060804ABCD0201"+"00"+"00680069"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if elements.Message != "hi" {
+		t.Errorf("have message %q using the %s profile, expected %q", elements.Message, udh.QuirkPaddedUCS2, "hi")
+	}
+}
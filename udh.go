@@ -6,21 +6,20 @@ package smudh
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"io"
+	"log/slog"
+	"maps"
 	"slices"
-	"strings"
 	"sync"
+	"time"
 
-	"github.com/ik5/gostrutils"
-	"golang.org/x/text/encoding"
-	"golang.org/x/text/encoding/charmap"
-	"golang.org/x/text/encoding/japanese"
-	"golang.org/x/text/encoding/korean"
-	"golang.org/x/text/encoding/unicode"
-	"golang.org/x/text/transform"
+	"github.com/ik5/smudh/charset"
+	"github.com/ik5/smudh/header"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Message represents a hex-encoded SMS message as a byte slice.
@@ -57,199 +56,404 @@ type MessageElements struct {
 
 	// True if message is standalone
 	Standalone bool `json:"standalone"`
+
+	// Parsing decisions, available only when parsed using the WithTrace option
+	Trace *Trace `json:"trace,omitempty"`
+
+	// Values returned by IEHandler functions, keyed by IEI
+	Extensions map[byte]any `json:"extensions,omitempty"`
+
+	// Non fatal issues found while parsing
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Application port addressing of the UDH (IEI 0x04 or 0x05), nil when there is none
+	Ports *Ports `json:"ports,omitempty"`
+
+	// True when the numbering of the fragment was repaired, see MessageFragmentations.Repaired
+	Repaired bool `json:"repaired,omitempty"`
+
+	// Message class of the data_coding of the fragment, set only when using WithMessageClass
+	MessageClass MessageClass `json:"message_class,omitempty"`
+
+	// Key/value metadata attached to the fragment when it was added, see ContextWithMetadata
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// The decoded input, kept for reuse by elements parsed using WithPooledElements
+	buffer []byte
+
+	// The IEIs of the UDH, in order of appearance, reported to ParseMetricsHooks
+	ieis []byte
+
+	// The languages of the national language shift IEs of the UDH, used for decoding GSM 7-bit text
+	lockingShift, singleShift charset.NationalLanguage
 }
 
 // MessageFragmentations a slice container of MessageElements pointers - for fragmentation gathering for a specific message.
 type MessageFragmentations []*MessageElements
 
+// fragmentSet holds the fragments of a single message inside Messages, together with their arrival times.
+type fragmentSet struct {
+	fragments *MessageFragmentations
+	firstSeen time.Time
+	lastSeen  time.Time
+
+	// identifier of the Source of the first fragment, used by WithFuzzyReassembly
+	source string
+
+	// the assembled text of a complete message, valid while cached is true
+	text   string
+	cached bool
+}
+
 // Messages manages a collection of message fragmentations, grouped by reference number.
 type Messages struct {
-	fragments map[string]*MessageFragmentations
-	mtx       sync.Mutex
+	fragments     map[string]*fragmentSet
+	mtx           sync.Mutex
+	logger        *slog.Logger
+	parseOptions  []ParseOption
+	referenceMode ReferenceMode
+	ttl           time.Duration
+	watchBuffer   int
+	watchers      []*watcher
+	watchMtx      sync.Mutex
+	store         Store
+	metrics       []MetricsHooks
+	tracer        trace.Tracer
+
+	disambiguate    bool
+	referenceWindow time.Duration
+
+	expiryPolicy   ExpiryPolicy
+	expiredHandler ExpiredHandler
+
+	wal         *WAL
+	audit       AuditSink
+	limiter     *rateLimiter
+	deduplicate bool
+	clock       Clock
+	fuzzy       *FuzzyReassembly
+	repair      bool
+	janitor     janitorState
+	closing     chan struct{}
+	closed      bool
+
+	quotas         map[string]int
+	pendingCounts  map[string]int
+	quirks         map[string]QuirkProfile
+	namespaceStats map[string]*NamespaceStats
+	statsMtx       sync.Mutex
+
+	middleware          []Middleware
+	assembledMiddleware []AssembledMiddleware
 }
 
-const rfc822Element byte = 0x20
-
 // ParseElements parses the hexadecimal content of a Message into its structural components, using the provided
 // encoding from the SMPP protocol.
 // On success, it returns a MessageElements struct.
 // For standalone text (no UDH), the Standalone flag is set to true, TotalParts and CurrentPart are set to 1, and
-// Reference is set to `0x00`.
-// Returns an error for invalid content.
-func (msg Message) ParseElements(encoding Encoding) (*MessageElements, error) {
-	if len(msg)%2 != 0 {
-		return nil, ErrHexStringMustHaveAnEvenNumberOfChars
+// Reference is set to `0x00`. A UDH without a concatenation IE, such as one holding only application ports, is
+// parsed the same way, as a single part message.
+// GSM 7-bit text is decoded using the tables of the national language shift IEs (0x24 and 0x25) of the UDH, when
+// there are any, unless a decoder is set by WithDecoder.
+// Returns an error for invalid content, or one wrapping ErrUnsupportedNationalLanguage for unknown tables.
+func (msg Message) ParseElements(encoding Encoding, options ...ParseOption) (*MessageElements, error) {
+	return msg.ParseElementsContext(context.Background(), encoding, options...)
+}
+
+// ParseElementsContext is the same as ParseElements, but stops decoding the message and returns the context error
+// when ctx is canceled or its deadline is exceeded.
+func (msg Message) ParseElementsContext(
+	ctx context.Context, encoding Encoding, options ...ParseOption,
+) (*MessageElements, error) {
+	config := newParseConfig(options)
+
+	ctx, span := startSpan(ctx, config.tracer, "smudh.ParseElements", AttributeEncoding.String(encoding.String()))
+
+	elements := &MessageElements{}
+	if config.pooled {
+		elements = acquireElements()
 	}
 
-	binary, err := hex.DecodeString(string(msg))
+	_, err := msg.parseElements(ctx, encoding, config, elements)
 	if err != nil {
+		if config.pooled {
+			elements.Release()
+		}
+
+		endSpan(span, err)
+
+		return nil, err
+	}
+
+	if span.IsRecording() {
+		span.SetAttributes(elements.spanAttributes()...)
+	}
+
+	endSpan(span, nil)
+
+	return elements, nil
+}
+
+// parseElements does the work of ParseElementsContext and ParseUserDataContext, and returns the information
+// elements of the UDH, if any.
+func (msg Message) parseElements(
+	ctx context.Context, encoding Encoding, config parseConfig, elements *MessageElements,
+) ([]InformationElement, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, fmt.Errorf("%w", err)
 	}
 
-	var elements MessageElements
+	binary, err := msg.decodeInto(config, elements.buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.pooled {
+		elements.buffer = binary
+	}
+
 	elements.Encoding = encoding
 
+	if config.trace {
+		elements.Trace = &Trace{}
+	}
+
+	elements.Trace.add("input", "%d hex characters, %d bytes", len(msg), len(binary))
+
+	err = config.checkUDHForm(binary)
+	if err != nil {
+		return nil, err
+	}
+
+	var ies []InformationElement
+
+	detected, reason := false, "input is too short for a UDH"
 	if len(binary) >= 2 {
+		detected, reason = config.detectUDH(binary)
+	}
+
+	if detected {
+		elements.Trace.add("detection", "UDH detected: %s", reason)
+
 		tmpLength := int(binary[0])
-		if tmpLength > 0 && tmpLength < len(binary)-1 && binary[1] < rfc822Element {
-			if tmpLength+1 > len(binary) {
-				return nil, ErrUDHLengthExceedsInputLength
-			}
-			elements.HeaderLength = binary[0]
-			elements.Element = binary[1]
-			elements.ElementLength = binary[2]
-			switch elements.Element {
-			case 0x00: // 8-bit reference
-				elements.Reference = []byte{binary[3]}
-				elements.TotalParts = binary[4]
-				elements.CurrentPart = binary[5]
-			case 0x08: // 16-bit reference
-				if tmpLength < 6 { // Need at least 6 bytes for UDH
-					return nil, ErrInputTooShortForUDH
-				}
-				elements.Reference = binary[3:5] // 2 bytes
-				elements.TotalParts = binary[5]
-				elements.CurrentPart = binary[6]
-			default:
-				return nil, ErrUnsupportedIEI
+		if tmpLength+1 > len(binary) {
+			return nil, ErrUDHLengthExceedsInputLength
+		}
+
+		if tmpLength < 2 { // Need at least an IEI and its length
+			return nil, ErrInputTooShortForUDH
+		}
+
+		elements.HeaderLength = binary[0]
+		elements.Element = binary[1]
+		elements.ElementLength = binary[2]
+
+		ies = header.Split(binary[1 : tmpLength+1])
+		for _, ie := range ies {
+			elements.ieis = append(elements.ieis, ie.Identifier)
+		}
+
+		if warning := header.ConcatenationConflict(ies); warning != "" {
+			elements.Warnings = append(elements.Warnings, warning)
+			elements.Trace.add("element", "%s", warning)
+			config.debug("conflicting concatenation IEs", slog.String("warning", warning))
+		}
+
+		if ports, found := header.FindPorts(ies); found {
+			elements.Ports = &ports
+			elements.Trace.add("element", "application ports %d -> %d", ports.Source, ports.Destination)
+		}
+
+		elements.lockingShift, elements.singleShift = header.FindNationalShift(ies)
+		if elements.lockingShift != charset.DefaultLanguage || elements.singleShift != charset.DefaultLanguage {
+			elements.Trace.add("element", "national language locking shift %d, single shift %d",
+				elements.lockingShift, elements.singleShift)
+		}
+
+		concatenation, found := header.FindConcatenation(ies)
+
+		switch {
+		case found:
+			if concatenation.Identifier == IEIConcatenated16Bit {
+				elements.Trace.add("element", "IEI 0x08: concatenation with 16-bit reference")
+			} else {
+				elements.Trace.add("element", "IEI 0x00: concatenation with 8-bit reference")
 			}
 
-			elements.RawMessage = binary[tmpLength+1:]
-		} else {
-			elements.Standalone = true
+			elements.Element = concatenation.Identifier
+			elements.ElementLength = byte(len(concatenation.Data))
+			referenceLength := len(concatenation.Data) - 2
+			elements.Reference = concatenation.Data[:referenceLength]
+			elements.TotalParts = concatenation.Data[referenceLength]
+			elements.CurrentPart = concatenation.Data[referenceLength+1]
+		case elements.Element == 0x00: // 8-bit reference, malformed element length
+			elements.Trace.add("element", "IEI 0x00: concatenation with 8-bit reference")
+			if tmpLength < 5 { // Need at least 5 bytes for UDH
+				return nil, ErrInputTooShortForUDH
+			}
+			elements.Reference = []byte{binary[3]}
+			elements.TotalParts = binary[4]
+			elements.CurrentPart = binary[5]
+		case elements.Element == 0x08: // 16-bit reference, malformed element length
+			elements.Trace.add("element", "IEI 0x08: concatenation with 16-bit reference")
+			if tmpLength < 6 { // Need at least 6 bytes for UDH
+				return nil, ErrInputTooShortForUDH
+			}
+			elements.Reference = binary[3:5] // 2 bytes
+			elements.TotalParts = binary[5]
+			elements.CurrentPart = binary[6]
+		default:
+			// a single message addressed by its other elements, such as application ports
+			elements.Trace.add("element", "IEI 0x%02X: no concatenation, single part message", elements.Element)
 			elements.Reference = []byte{0}
 			elements.TotalParts = 0x01
 			elements.CurrentPart = 0x01
-			elements.RawMessage = binary
 		}
+
+		err = elements.handleIEs(config, binary[1:tmpLength+1])
+		if err != nil {
+			return nil, err
+		}
+
+		elements.RawMessage = binary[tmpLength+1:]
+		elements.Trace.add("header", "%d header bytes consumed, %d payload bytes left",
+			tmpLength+1, len(elements.RawMessage))
+		elements.stripUCS2Padding(config, tmpLength+1)
+
+		config.debug("UDH detected",
+			slog.Int("header_length", tmpLength),
+			slog.Int("element", int(elements.Element)),
+			slog.String("reference", hex.EncodeToString(elements.Reference)),
+			slog.Int("total_parts", int(elements.TotalParts)),
+			slog.Int("current_part", int(elements.CurrentPart)),
+		)
+	} else {
+		config.debug("no UDH detected, falling back to standalone", slog.Int("length", len(binary)))
+		elements.Trace.add("detection", "no UDH detected: %s", reason)
+
+		elements.Standalone = true
+		elements.Reference = []byte{0}
+		elements.TotalParts = 0x01
+		elements.CurrentPart = 0x01
+		elements.RawMessage = binary
 	}
 
-	err = elements.encodeMessage()
+	elements.applySAR(config)
+	elements.MessageClass = config.messageClass
+	elements.unpackGSM7(config)
+
+	err = elements.encodeMessage(ctx, config)
 	if err != nil {
+		config.debug("decoding failed", slog.String("encoding", encoding.String()), slog.Any("error", err))
 		return nil, fmt.Errorf("%w", err)
 	}
 
-	return &elements, nil
-}
+	elements.applyTextMarkers(config)
+	config.processText(elements)
 
-// setTransformCharmap translates the given RawMessage based on a given decoder.
-// If successful, than the function sets the elem.Message, otherwise an error is returned.
-func (elem *MessageElements) setTransformCharmap(decoder *encoding.Decoder) error {
-	var (
-		err       error
-		reader    *transform.Reader
-		utf8Bytes []byte
-	)
-	reader = transform.NewReader(strings.NewReader(string(elem.RawMessage)), decoder)
-	utf8Bytes, err = io.ReadAll(reader)
+	err = config.checkDecodedLength(elements.Message)
 	if err != nil {
-		return fmt.Errorf("%w", err)
+		return nil, err
 	}
 
-	elem.Message = string(utf8Bytes)
-	return nil
+	return ies, nil
 }
 
-// encodeMessage looks over the encoding element, and try to decode the RawMessage element accordingly.
-//
-// At this time the Pictogram encoding is not supported, as well as the Reserved1 and Reserved2 encoding.
-// If found, an error will return.
-// If the encoding is unknown, then an error is returned on that.
-// Any other error is based on the encoding decoder streaming.
-func (elem *MessageElements) encodeMessage() error {
-	var (
-		decoder *encoding.Decoder
-		err     error
-	)
-
-	switch elem.Encoding {
-	case GSM, GSMExtended:
-		elem.Message = gostrutils.GSM0338ToUTF8(string(elem.RawMessage))
-
-	case ASCII, UTF8:
-		elem.Message = string(elem.RawMessage)
+// decode returns the binary content of the hex encoded Message.
+func (msg Message) decode(config parseConfig) ([]byte, error) {
+	return msg.decodeInto(config, nil)
+}
 
-	case Latin1:
-		decoder = charmap.ISO8859_1.NewDecoder()
+// decodeInto is the same as decode, but reuses the capacity of buffer when it is large enough.
+func (msg Message) decodeInto(config parseConfig, buffer []byte) ([]byte, error) {
+	err := config.checkInputLength(msg)
+	if err != nil {
+		return nil, err
+	}
 
-		err = elem.setTransformCharmap(decoder)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+	msg, err = config.normalizeHex(msg)
+	if err != nil {
+		return nil, err
+	}
 
-	case Binary8Bit1, Binary8Bit2:
-		elem.Message = hex.EncodeToString(elem.RawMessage)
-		err = elem.setTransformCharmap(decoder)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+	if cap(buffer) < len(msg)/2 {
+		buffer = make([]byte, len(msg)/2)
+	}
 
-	case UCS2:
-		if len(elem.RawMessage)%2 != 0 {
-			return ErrBinaryTextLengthIsNotEvenForUTF16Decoding
-		}
+	binary := buffer[:len(msg)/2]
 
-		decoder = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder()
-		err = elem.setTransformCharmap(decoder)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+	_, err = decodeHex(binary, msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
 
-	case Cyrillic:
-		decoder = charmap.ISO8859_5.NewDecoder()
-		err = elem.setTransformCharmap(decoder)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+	return binary, nil
+}
 
-	case Hebrew:
-		decoder = charmap.ISO8859_8.NewDecoder()
-		err = elem.setTransformCharmap(decoder)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+// encodeMessage decodes the RawMessage element into the Message element according to the encoding element, using
+// charset.Decode, or the decoder set for the encoding by WithDecoder or SetDecoder.
+func (elem *MessageElements) encodeMessage(ctx context.Context, config parseConfig) error {
+	override := config.decoderOverride(elem.Encoding)
 
-	case ISO2022JP:
-		decoder = japanese.ISO2022JP.NewDecoder()
-		err = elem.setTransformCharmap(decoder)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+	name := decoderName(elem.Encoding)
+	if override != nil {
+		name = overrideName(override)
+	}
 
-	case KSC5601:
-		decoder = korean.EUCKR.NewDecoder()
-		err = elem.setTransformCharmap(decoder)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+	elem.Trace.add("decoder", "decoding %d bytes as %s using %s", len(elem.RawMessage), elem.Encoding, name)
 
-	case JIS, EXTJIS:
-		decoder = japanese.EUCJP.NewDecoder()
-		err = elem.setTransformCharmap(decoder)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+	var (
+		message string
+		err     error
+	)
 
-	case Pictogram, Reserved1, Reserved2:
-		// TODO: support these as well
-		return ErrUnsupportedEncoding
+	national := override == nil && (elem.Encoding == GSM || elem.Encoding == GSMExtended) &&
+		(elem.lockingShift != charset.DefaultLanguage || elem.singleShift != charset.DefaultLanguage)
 
+	switch {
+	case override != nil:
+		message, err = charset.DecodeUsing(ctx, override, elem.RawMessage)
+	case national:
+		message, err = elem.decodeNational()
 	default:
-		return ErrUnknownEncoding
+		message, err = charset.Decode(ctx, elem.Encoding, elem.RawMessage)
+	}
 
+	if err != nil {
+		return fmt.Errorf("%w", err)
 	}
 
+	elem.Message = message
+
 	return nil
 }
 
+// decodeNational decodes the GSM 7-bit RawMessage using the tables of the national language shift IEs of the UDH.
+// Returns an error wrapping ErrUnsupportedNationalLanguage when the tables of a language are unknown.
+func (elem *MessageElements) decodeNational() (string, error) {
+	table, err := charset.NationalGSM7Table(elem.lockingShift, elem.singleShift)
+	if err != nil {
+		return "", fmt.Errorf("%w", err)
+	}
+
+	elem.Trace.add("decoder", "using the national language tables of locking shift %d, single shift %d",
+		elem.lockingShift, elem.singleShift)
+
+	return table.Decode(elem.RawMessage), nil
+}
+
 // IsSingleMessage returns true when message is standalone or is not fragmented.
 func (elem MessageElements) IsSingleMessage() bool {
 	return elem.Standalone || elem.TotalParts == 1
 }
 
-// ToJSON Serializes a MessageElements struct to JSON.
+// ToJSON Serializes a MessageElements struct to JSON. Use WithRedaction for masking the text and payload.
 // Returns an error if serialization fails.
-func (elem MessageElements) ToJSON() (string, error) {
+func (elem MessageElements) ToJSON(options ...OutputOption) (string, error) {
+	if newOutputConfig(options).redact {
+		elem = elem.Redacted()
+	}
+
 	result, err := json.Marshal(elem)
 
 	if err != nil {
@@ -272,9 +476,13 @@ func MessageElementFromJSON(rawJSON string) (*MessageElements, error) {
 	return &result, nil
 }
 
-// ToJSON serializes the MessageFragmentations slice to JSON.
+// ToJSON serializes the MessageFragmentations slice to JSON. Use WithRedaction for masking the text and payload.
 // Returns an error if serialization fails.
-func (msgs MessageFragmentations) ToJSON() (string, error) {
+func (msgs MessageFragmentations) ToJSON(options ...OutputOption) (string, error) {
+	if newOutputConfig(options).redact {
+		msgs = msgs.Redacted()
+	}
+
 	result, err := json.Marshal(msgs)
 
 	if err != nil {
@@ -284,35 +492,35 @@ func (msgs MessageFragmentations) ToJSON() (string, error) {
 	return string(result), nil
 }
 
-// FromJSON de-serialize a JSON string into a MessageFragmentations slice. Returns an error if the JSON is invalid.
+// FromJSON de-serialize a JSON string into a MessageFragmentations slice, sorted by CurrentPart. Returns an error if
+// the JSON is invalid.
 func (msgs *MessageFragmentations) FromJSON(rawJSON string) error {
 	err := json.Unmarshal([]byte(rawJSON), msgs)
 	if err != nil {
 		return fmt.Errorf("%w", err)
 	}
 
-	return nil
+	msgs.Sort()
 
+	return nil
 }
 
-// Sort sorts the MessageFragmentations slice in ascending order based on CurrentPart.
+// Sort sorts the MessageFragmentations slice in ascending order based on CurrentPart, keeping the order of
+// fragments with the same part number.
+//
+// AddMessageElements keeps the slice sorted, so Sort is needed only after modifying the slice directly.
 func (msgs MessageFragmentations) Sort() {
-	slices.SortFunc(msgs, func(a, b *MessageElements) int {
-		if b.Element > a.Element {
-			return -1
-		}
-		if a.Element > b.Element {
-			return 1
-		}
-
-		return 0
+	slices.SortStableFunc(msgs, func(a, b *MessageElements) int {
+		return cmp.Compare(a.CurrentPart, b.CurrentPart)
 	})
 }
 
 // HaveAllFragments returns true if the MessageFragmentations contains all parts of a fragmented message or is standalone.
+// Every part number from 1 to TotalParts must be held exactly once, so a fragmented message is complete exactly when
+// both MissingParts and ExtraParts are empty. A message holding the same part twice, as added without
+// WithDeduplication, is not complete.
 func (msgs MessageFragmentations) HaveAllFragments() bool {
-	msgsLen := len(msgs)
-	if msgsLen == 0 {
+	if len(msgs) == 0 {
 		return false
 	}
 
@@ -322,32 +530,53 @@ func (msgs MessageFragmentations) HaveAllFragments() bool {
 		return true
 	}
 
-	if first.TotalParts == 0 && first.CurrentPart == 0 && len(first.Message) > 0 {
-		return true
+	if first.TotalParts == 0 {
+		return first.CurrentPart == 0 && len(first.Message) > 0
 	}
 
-	return msgsLen == int(first.TotalParts)
+	return len(msgs.MissingParts()) == 0 && len(msgs.ExtraParts()) == 0
 }
 
-// String returns a string representation of the full ordered MessageFragmentations.
-//
-// IMPORTANT: The function calls Sort method before collecting all of the messages.
+// String returns a string representation of the full ordered MessageFragmentations. It is the same as Assembled.
 func (msgs *MessageFragmentations) String() string {
-	msgs.Sort()
+	return msgs.Assembled()
+}
 
+// Assembled returns the text of the full ordered MessageFragmentations, without modifying the slice. Fragments added
+// using AddMessageElements are already in order, and a slice that was modified directly is ordered on a copy.
+//
+// The fragments of a message that is still held by Messages may change while being read, use Messages.Snapshot for
+// reading them.
+func (msgs MessageFragmentations) Assembled() string {
 	buffer := bytes.Buffer{}
 
-	for _, info := range *msgs {
+	for _, info := range msgs.ordered() {
 		_, _ = buffer.WriteString(info.Message)
 	}
 
 	return buffer.String()
 }
 
-// Add parses a raw Message using the specified encoding and appends the resulting MessageElements to the MessageFragmentations slice.
-// The method does not reorder elements. Returns an error if parsing fails.
-func (msgs *MessageFragmentations) Add(encoding Encoding, message Message) error {
-	info, err := message.ParseElements(encoding)
+// ordered returns msgs when it is sorted by CurrentPart, or a sorted copy of it.
+func (msgs MessageFragmentations) ordered() MessageFragmentations {
+	isSorted := slices.IsSortedFunc(msgs, func(a, b *MessageElements) int {
+		return cmp.Compare(a.CurrentPart, b.CurrentPart)
+	})
+
+	if isSorted {
+		return msgs
+	}
+
+	sorted := slices.Clone(msgs)
+	sorted.Sort()
+
+	return sorted
+}
+
+// Add parses a raw Message using the specified encoding and inserts the resulting MessageElements to the
+// MessageFragmentations slice, the same way AddMessageElements does. Returns an error if parsing fails.
+func (msgs *MessageFragmentations) Add(encoding Encoding, message Message, options ...ParseOption) error {
+	info, err := message.ParseElements(encoding, options...)
 	if err != nil {
 		return fmt.Errorf("%w", err)
 	}
@@ -360,22 +589,37 @@ func (msgs *MessageFragmentations) Add(encoding Encoding, message Message) error
 	return nil
 }
 
-// AddMessageElements appends a MessageElements instance to the MessageFragmentations slice.
-// The method does not reorder elements. Returns an error if addition fails.
+// AddMessageElements inserts a MessageElements instance to the MessageFragmentations slice, keeping the slice
+// sorted by CurrentPart. A fragment with a part number that already exists is inserted after the existing ones.
+// Returns an error if addition fails.
 func (msgs *MessageFragmentations) AddMessageElements(info *MessageElements) error {
-	if len(*msgs) == 0 {
-		*msgs = append(*msgs, info)
-		return nil
+	_, err := msgs.addMessageElements(info, StrictReferences)
+	return err
+}
+
+// addMessageElements inserts info, comparing its reference using the given mode, and returns its index.
+func (msgs *MessageFragmentations) addMessageElements(info *MessageElements, mode ReferenceMode) (int, error) {
+	if len(*msgs) > 0 && !ReferencesEqual((*msgs)[0].Reference, info.Reference, mode) {
+		return 0, ErrInvalidReferenceNumber
 	}
 
-	first := (*msgs)[0]
+	return msgs.insert(info), nil
+}
 
-	if bytes.Equal(first.Reference, info.Reference) {
-		*msgs = append(*msgs, info)
-		return nil
-	}
+// insert adds info after the fragments whose part number is lower or the same, using a binary search, and returns
+// its index.
+func (msgs *MessageFragmentations) insert(info *MessageElements) int {
+	idx, _ := slices.BinarySearchFunc(*msgs, info.CurrentPart, func(existing *MessageElements, part byte) int {
+		if existing.CurrentPart <= part {
+			return -1
+		}
+
+		return 1
+	})
+
+	*msgs = slices.Insert(*msgs, idx, info)
 
-	return ErrInvalidReferenceNumber
+	return idx
 }
 
 // Reference returns the reference number of the message fragments.
@@ -389,94 +633,355 @@ func (msgs MessageFragmentations) Reference() []byte {
 	return first.Reference
 }
 
+// Get returns the fragment of a part number, or nil when the part does not exist. When the part was added more than
+// once, the first fragment added is returned.
+func (msgs MessageFragmentations) Get(part byte) *MessageElements {
+	idx := slices.IndexFunc(msgs, func(info *MessageElements) bool {
+		return info.CurrentPart == part
+	})
+
+	if idx < 0 {
+		return nil
+	}
+
+	return msgs[idx]
+}
+
 // InitMessages	initializes and returns a new Messages instance.
-func InitMessages() *Messages {
+func InitMessages(options ...MessagesOption) *Messages {
+	messages := newMessages(options...)
+	messages.observe(messages)
+
+	return messages
+}
+
+// newMessages creates a Messages container set by options, without handing it over to the metrics hooks.
+func newMessages(options ...MessagesOption) *Messages {
 	messages := &Messages{
-		fragments: make(map[string]*MessageFragmentations),
+		fragments: make(map[string]*fragmentSet),
 		mtx:       sync.Mutex{},
+		closing:   make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(messages)
 	}
 
 	return messages
 }
 
-// AddMessageElements adds a MessageElements instance to the Messages container.
-// Returns an error if the addition is invalid.
+// AddMessageElements adds a MessageElements instance to the Messages container, after running the middleware set by
+// WithMiddleware on it.
+// Returns an error if the addition is invalid or was rejected by the middleware.
 // The function does not re-order the elements.
 func (msgs *Messages) AddMessageElements(info *MessageElements) error {
-	msgs.mtx.Lock()
-	defer msgs.mtx.Unlock()
-
-	var err error
-
-	strRefer := string(info.Reference)
-
-	if _, found := msgs.fragments[strRefer]; found {
-		err = msgs.fragments[strRefer].AddMessageElements(info)
-		if err != nil {
-			return fmt.Errorf("%w", err)
-		}
+	return msgs.AddMessageElementsContext(context.Background(), info)
+}
 
-		return nil
+// AddMessageElementsContext is the same as AddMessageElements, with ctx handed over to the middleware set by
+// WithMiddleware, and holding the Source recorded by WithAudit.
+func (msgs *Messages) AddMessageElementsContext(ctx context.Context, info *MessageElements) error {
+	err := msgs.allowFragment(ctx)
+	if err != nil {
+		msgs.recordAttempt(ctx, info.Encoding, info, AuditRejected, err)
+		return err
 	}
 
-	fragments := &MessageFragmentations{}
-	err = fragments.AddMessageElements(info)
+	err = msgs.runMiddleware(ctx, info)
 	if err != nil {
-		return fmt.Errorf("%w", err)
+		msgs.recordAttempt(ctx, info.Encoding, info, AuditRejected, err)
+		return err
 	}
 
-	msgs.fragments[strRefer] = fragments
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
 
-	return nil
+	return msgs.addMessageElements(ctx, info)
 }
 
 // Add Parses a raw Message using the specified encoding and adds it to the Messages container.
 // Returns an error if parsing fails.
 // The function does not re-order the elements.
 func (msgs *Messages) Add(encoding Encoding, message Message) error {
+	return msgs.AddContext(context.Background(), encoding, message)
+}
+
+// AddContext is the same as Add, but parsing stops when ctx is done, and the spans created when using
+// WithTracerProvider are children of the span in ctx. The middleware set by WithMiddleware runs after parsing, and
+// receives ctx.
+func (msgs *Messages) AddContext(ctx context.Context, encoding Encoding, message Message) error {
+	return msgs.addContext(ctx, encoding, message)
+}
+
+// addContext does the work of AddContext, parsing message using the additional options.
+func (msgs *Messages) addContext(
+	ctx context.Context, encoding Encoding, message Message, options ...ParseOption,
+) (err error) {
+	ctx, span := startSpan(ctx, msgs.tracer, "smudh.Messages.Add", AttributeEncoding.String(encoding.String()))
+	defer func() { endSpan(span, err) }()
+
+	info, err := msgs.prepareFragment(ctx, encoding, message, options...)
+	if err != nil {
+		return err
+	}
+
+	span.SetAttributes(info.spanAttributes()...)
+
 	msgs.mtx.Lock()
 	defer msgs.mtx.Unlock()
 
-	info, err := message.ParseElements(encoding)
+	err = msgs.addMessageElements(ctx, info)
 	if err != nil {
-		return fmt.Errorf("%w", err)
+		return err
+	}
+
+	if set, found := msgs.fragments[msgs.namespacedKey(NamespaceFromContext(ctx), info.Reference)]; found {
+		span.SetAttributes(
+			AttributeReceived.Int(len(*set.fragments)),
+			AttributeComplete.Bool(set.fragments.HaveAllFragments()),
+		)
+	}
+
+	return nil
+}
+
+// prepareFragment takes the steps of AddContext that take place before locking the container: rate limiting,
+// parsing and running the middleware. options are added to the parser options of the namespace. Failures are
+// recorded using the sink set by WithAudit.
+func (msgs *Messages) prepareFragment(
+	ctx context.Context, encoding Encoding, message Message, options ...ParseOption,
+) (*MessageElements, error) {
+	err := msgs.allowFragment(ctx)
+	if err != nil {
+		msgs.recordAttempt(ctx, encoding, nil, AuditRejected, err)
+		return nil, err
+	}
+
+	info, err := message.ParseElementsContext(ctx, encoding,
+		slices.Concat(msgs.parserOptions(NamespaceFromContext(ctx)), options)...)
+	if err != nil {
+		msgs.parseError(err)
+		msgs.recordAttempt(ctx, encoding, nil, AuditRejected, err)
+
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	msgs.messageParsed(info)
+
+	err = msgs.runMiddleware(ctx, info)
+	if err != nil {
+		msgs.recordAttempt(ctx, encoding, info, AuditRejected, err)
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// Parse parses a raw Message using the specified encoding and the options set by WithParseOptions, without adding
+// it to the Messages container. The profile set by WithQuirkProfile for the default namespace is applied.
+func (msgs *Messages) Parse(encoding Encoding, message Message) (*MessageElements, error) {
+	info, err := message.ParseElements(encoding, msgs.parserOptions("")...)
+	if err != nil {
+		msgs.parseError(err)
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	msgs.messageParsed(info)
+
+	return info, nil
+}
+
+// addMessageElements adds info to the container, and records the outcome using the sink set by WithAudit. The
+// caller must hold the lock.
+func (msgs *Messages) addMessageElements(ctx context.Context, info *MessageElements) (err error) {
+	namespace := NamespaceFromContext(ctx)
+	outcome := AuditAccepted
+
+	defer func() {
+		if err != nil && outcome != AuditConflict {
+			outcome = AuditRejected
+		}
+
+		msgs.recordAttempt(ctx, info.Encoding, info, outcome, err)
+	}()
+
+	err = msgs.checkOpen()
+	if err != nil {
+		return err
 	}
 
-	strRefer := string(info.Reference)
+	info.attachMetadata(ctx)
+
+	strRefer := msgs.namespacedKey(namespace, info.Reference)
+
+	now := msgs.now()
 
-	if _, found := msgs.fragments[strRefer]; found {
-		err = msgs.fragments[strRefer].AddMessageElements(info)
+	set, found := msgs.fragments[strRefer]
+	if found && msgs.reusedReference(set, info, now) {
+		err = msgs.retireSet(strRefer, set)
 		if err != nil {
-			return fmt.Errorf("%w", err)
+			return err
 		}
 
-		return nil
+		found = false
+	}
+
+	if !found && msgs.fuzzy != nil {
+		if key, orphan, merged := msgs.mergeOrphan(ctx, namespace, info, now); orphan != nil {
+			strRefer, set, info, found = key, orphan, merged, true
+		}
+	}
+
+	if found && msgs.deduplicate {
+		outcome, err = checkRetransmission(*set.fragments, info)
+		if err != nil || outcome == AuditRetransmission {
+			return err
+		}
 	}
 
-	fragments := &MessageFragmentations{}
-	err = fragments.AddMessageElements(info)
+	if found && containsPart(*set.fragments, info) {
+		outcome = AuditDuplicate
+	}
+
+	if !found {
+		err = msgs.checkQuota(namespace)
+		if err != nil {
+			return err
+		}
+
+		set = &fragmentSet{fragments: &MessageFragmentations{}, firstSeen: now, source: sourceID(ctx)}
+	}
+
+	fragments := set.fragments
+
+	idx, err := fragments.addMessageElements(info, msgs.referenceMode)
 	if err != nil {
 		return fmt.Errorf("%w", err)
 	}
 
-	msgs.fragments[strRefer] = fragments
+	set.cached = false
+
+	previousLastSeen := set.lastSeen
+	set.lastSeen = now
+
+	err = msgs.saveSet(strRefer, set)
+
+	stored := err == nil
+	if stored {
+		err = msgs.logAdd(strRefer, info, now)
+	}
+
+	if err != nil {
+		// roll back, the fragment is accepted only once it was stored and recorded
+		*fragments = slices.Delete(*fragments, idx, idx+1)
+		set.lastSeen = previousLastSeen
+
+		if stored {
+			msgs.revertSet(strRefer, set)
+		}
+
+		return err
+	}
+
+	msgs.trackSet(strRefer, set)
+
+	if msgs.repair {
+		msgs.repairSet(strRefer, set)
+	}
+
+	msgs.debug("fragment added",
+		slog.String("reference", hex.EncodeToString(info.Reference)),
+		slog.Int("total_parts", int(info.TotalParts)),
+		slog.Int("current_part", int(info.CurrentPart)),
+		slog.Int("received", len(*fragments)),
+	)
+
+	complete := fragments.HaveAllFragments()
+	if complete {
+		msgs.countCompleted(namespace)
+
+		msgs.debug("message completed",
+			slog.String("reference", hex.EncodeToString(info.Reference)),
+			slog.Int("parts", len(*fragments)),
+		)
+	}
+
+	for _, hooks := range msgs.metrics {
+		hooks.FragmentReceived(info.Encoding)
+
+		if complete {
+			hooks.MessageCompleted(info.Encoding)
+		}
+	}
+
+	if msgs.hasWatchers() {
+		snapshot := fragments.Clone()
+		msgs.publish(Event{
+			Type: FragmentAdded, Namespace: namespace, Reference: bytes.Clone(info.Reference), Fragment: info.Clone(),
+			Fragments: snapshot, Time: now,
+		})
+
+		if complete {
+			msgs.publish(Event{
+				Type: MessageCompleted, Namespace: namespace, Reference: bytes.Clone(info.Reference),
+				Fragments: snapshot, Time: now,
+			})
+		}
+	}
 
 	return nil
 }
 
+// parserOptions returns the ParseOption functions used for parsing the messages of namespace, including the
+// container logger and the quirk profile of the namespace.
+func (msgs *Messages) parserOptions(namespace string) []ParseOption {
+	profile, quirky := msgs.quirks[namespace]
+
+	if msgs.logger == nil && msgs.tracer == nil && !quirky {
+		return msgs.parseOptions
+	}
+
+	options := make([]ParseOption, 0, len(msgs.parseOptions)+2)
+
+	if msgs.logger != nil {
+		options = append(options, WithParseLogger(msgs.logger))
+	}
+
+	if msgs.tracer != nil {
+		tracer := msgs.tracer
+		options = append(options, func(config *parseConfig) { config.tracer = tracer })
+	}
+
+	options = append(options, msgs.parseOptions...)
+
+	if quirky {
+		options = append(options, profile.ParseOptions()...)
+	}
+
+	return options
+}
+
+// debug emits a debug record when a logger was configured.
+func (msgs *Messages) debug(msg string, args ...any) {
+	if msgs.logger == nil {
+		return
+	}
+
+	msgs.logger.Debug(msg, args...)
+}
+
 // GetMessageFragments retrieves the MessageFragmentations for a given reference number, returning an ordered slice.
 // Returns nil if the reference is not found.
 func (msgs *Messages) GetMessageFragments(reference []byte) *MessageFragmentations {
 	msgs.mtx.Lock()
 	defer msgs.mtx.Unlock()
 
-	messages, found := msgs.fragments[string(reference)]
+	set, found := msgs.fragments[msgs.referenceKey(reference)]
 	if !found {
 		return nil
 	}
 
-	messages.Sort()
-	return messages
+	return set.fragments
 }
 
 // ListAll returns a slice of all MessageFragmentations in the Messages container, unsorted.
@@ -486,9 +991,71 @@ func (msgs *Messages) ListAll() []*MessageFragmentations {
 
 	results := []*MessageFragmentations{}
 
-	for _, fragmentations := range msgs.fragments {
+	for _, set := range msgs.fragments {
+		results = append(results, set.fragments)
+	}
+
+	return results
+}
+
+// Complete returns the MessageFragmentations in the Messages container that have all of their fragments, ordered
+// by their reference number, with every MessageFragmentations sorted.
+func (msgs *Messages) Complete() []*MessageFragmentations {
+	return msgs.collectComplete(nil, false)
+}
+
+// DrainComplete removes the MessageFragmentations that have all of their fragments from the Messages container,
+// and returns them in a single locked pass, ordered by their reference number, with every MessageFragmentations
+// sorted.
+func (msgs *Messages) DrainComplete() []*MessageFragmentations {
+	return msgs.collectComplete(nil, true)
+}
+
+// collectComplete returns the complete messages whose key is accepted by match, or all of them for a nil match,
+// removing them from the container when drain is true.
+func (msgs *Messages) collectComplete(match func(key string) bool, drain bool) []*MessageFragmentations {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	results := []*MessageFragmentations{}
+
+	for _, key := range slices.Sorted(maps.Keys(msgs.fragments)) {
+		if match != nil && !match(key) {
+			continue
+		}
+
+		fragmentations := msgs.fragments[key].fragments
+		if !fragmentations.HaveAllFragments() {
+			continue
+		}
+
+		if drain {
+			msgs.untrackSet(key)
+			msgs.deleteSet(key)
+		}
+
 		results = append(results, fragmentations)
 	}
 
 	return results
 }
+
+// Snapshot returns a sorted deep copy of the MessageFragmentations for a given reference number, which is safe to
+// use while other fragments are being added.
+// Returns nil if the reference is not found.
+func (msgs *Messages) Snapshot(reference []byte) MessageFragmentations {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	return msgs.snapshot(msgs.referenceKey(reference))
+}
+
+// snapshot returns a sorted deep copy of the message of key. The caller must hold the lock.
+func (msgs *Messages) snapshot(key string) MessageFragmentations {
+	set, found := msgs.fragments[key]
+	if !found {
+		return nil
+	}
+
+	return set.fragments.Clone()
+}
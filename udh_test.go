@@ -5,6 +5,7 @@ package smudh_test
 // file, You can obtain one at https://mozilla.org/MPL/2.0/.
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -384,3 +385,46 @@ func TestMessageParsing(t *testing.T) {
 		}
 	})
 }
+
+func TestParseElementsMalformedHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  udh.Message
+		err  error
+	}{
+		{name: "header of a single byte", msg: udh.Message("010000"), err: udh.ErrInputTooShortForUDH},
+		{name: "short 8-bit concatenation", msg: udh.Message("0300020304"), err: udh.ErrInputTooShortForUDH},
+		{name: "short 8-bit concatenation header", msg: udh.Message("02000100"), err: udh.ErrInputTooShortForUDH},
+		{name: "short 16-bit concatenation", msg: udh.Message("04080203040506"), err: udh.ErrInputTooShortForUDH},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			_, err := test.msg.ParseElements(udh.ASCII, udh.WithLegacyUDHDetection())
+			if !errors.Is(err, test.err) {
+				t2.Errorf("have error %v, expected %v", err, test.err)
+			}
+		})
+	}
+}
+
+func FuzzParseElements(f *testing.F) {
+	for _, seed := range []string{
+		"050003A50201546869732069732061206C", "060804A5B70201", "0500", "050003", "0400", "0508",
+		"0B0504158200000003A50201", "0000", "FF00", "",
+	} {
+		for _, encoding := range []udh.Encoding{udh.GSM, udh.ASCII, udh.Binary8Bit1, udh.UCS2, udh.UTF8 + 1} {
+			f.Add(seed, byte(encoding))
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, msg string, encoding byte) {
+		// any input must either parse or fail with an error, without panicking
+		_, _ = udh.Message(msg).ParseElements(udh.Encoding(encoding))
+		_, _ = udh.Message(msg).ParseElements(udh.Encoding(encoding), udh.WithLegacyUDHDetection(), udh.WithTrace())
+		_, _ = udh.Message(msg).ParseElements(udh.Encoding(encoding), udh.WithPackedGSM7(),
+			udh.WithUCS2Padding(udh.UCS2PaddingAlways), udh.WithTextMarkers(), udh.WithPooledElements())
+		_, _ = udh.Message(msg).ParseUDH(udh.Encoding(encoding))
+		_, _ = udh.Message(msg).ParseUserData(udh.Encoding(encoding), udh.WithTrace())
+	})
+}
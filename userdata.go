@@ -0,0 +1,173 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+
+	"github.com/ik5/smudh/header"
+)
+
+// UserData represents a parsed short message, with its UDH represented as the full list of its information
+// elements, instead of the single concatenation element held by MessageElements.
+type UserData struct {
+	// UDHL - UDH Length, 0 when there is no UDH
+	HeaderLength byte `json:"header_length"`
+
+	// Information elements of the UDH, in the order they appear
+	Elements []InformationElement `json:"elements"`
+
+	// Raw message payload
+	RawMessage []byte `json:"raw_message"`
+
+	// Decoded UTF-8 message
+	Message string `json:"message"`
+
+	// Message encoding
+	Encoding Encoding `json:"encoding"`
+
+	// Parsing decisions, available only when parsed using the WithTrace option
+	Trace *Trace `json:"trace,omitempty"`
+
+	// Values returned by IEHandler functions, keyed by IEI
+	Extensions map[byte]any `json:"extensions,omitempty"`
+
+	// Non fatal issues found while parsing
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// Concatenation holds the content of a concatenation IE (0x00 or 0x08).
+type Concatenation struct {
+	// Reference number, 1 or 2 bytes long
+	Reference []byte `json:"reference"`
+
+	// Total number of parts
+	TotalParts byte `json:"total_parts"`
+
+	// Current part number
+	CurrentPart byte `json:"current_part"`
+}
+
+// ParseUserData parses the hexadecimal content of a Message into a UserData, using the provided encoding from the
+// SMPP protocol. The message is parsed the same way as by ParseElements, honoring the same options.
+func (msg Message) ParseUserData(encoding Encoding, options ...ParseOption) (*UserData, error) {
+	return msg.ParseUserDataContext(context.Background(), encoding, options...)
+}
+
+// ParseUserDataContext is the same as ParseUserData, but stops decoding the message and returns the context error
+// when ctx is canceled or its deadline is exceeded.
+func (msg Message) ParseUserDataContext(
+	ctx context.Context, encoding Encoding, options ...ParseOption,
+) (*UserData, error) {
+	elements := MessageElements{}
+
+	ies, err := msg.parseElements(ctx, encoding, newParseConfig(options), &elements)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserData{
+		HeaderLength: elements.HeaderLength,
+		Elements:     ies,
+		RawMessage:   elements.RawMessage,
+		Message:      elements.Message,
+		Encoding:     encoding,
+		Trace:        elements.Trace,
+		Extensions:   elements.Extensions,
+		Warnings:     elements.Warnings,
+	}, nil
+}
+
+// Element returns the first information element with the given IEI.
+func (data *UserData) Element(identifier byte) (InformationElement, bool) {
+	for _, element := range data.Elements {
+		if element.Identifier == identifier {
+			return element, true
+		}
+	}
+
+	return InformationElement{}, false
+}
+
+// Concatenation returns the content of the first well formed concatenation IE, preferring a 16-bit reference IE
+// over an 8-bit one.
+func (data *UserData) Concatenation() (Concatenation, bool) {
+	element, found := header.FindConcatenation(data.Elements)
+	if !found {
+		return Concatenation{}, false
+	}
+
+	referenceLength := len(element.Data) - 2
+
+	return Concatenation{
+		Reference:   element.Data[:referenceLength],
+		TotalParts:  element.Data[referenceLength],
+		CurrentPart: element.Data[referenceLength+1],
+	}, true
+}
+
+// Reference returns the reference number of the concatenation IE, or nil if there is none.
+func (data *UserData) Reference() []byte {
+	concatenation, found := data.Concatenation()
+	if !found {
+		return nil
+	}
+
+	return concatenation.Reference
+}
+
+// Ports returns the application port addressing of the UDH (IEI 0x04 or 0x05).
+func (data *UserData) Ports() (Ports, bool) {
+	return header.FindPorts(data.Elements)
+}
+
+// IsSingleMessage returns true when the message is not part of a concatenated message.
+func (data *UserData) IsSingleMessage() bool {
+	concatenation, found := data.Concatenation()
+	return !found || concatenation.TotalParts == 1
+}
+
+// MessageElements converts the UserData into a MessageElements.
+// A message without a UDH becomes a standalone message, while a UDH without a concatenation IE becomes a single
+// part message described by its first element.
+func (data *UserData) MessageElements() *MessageElements {
+	elements := &MessageElements{
+		HeaderLength: data.HeaderLength,
+		RawMessage:   data.RawMessage,
+		Message:      data.Message,
+		Encoding:     data.Encoding,
+		Trace:        data.Trace,
+		Extensions:   data.Extensions,
+		Warnings:     data.Warnings,
+		Reference:    []byte{0},
+		TotalParts:   0x01,
+		CurrentPart:  0x01,
+	}
+
+	if len(data.Elements) == 0 {
+		elements.Standalone = true
+		return elements
+	}
+
+	element, found := header.FindConcatenation(data.Elements)
+	if !found {
+		element = data.Elements[0]
+	}
+
+	elements.Element = element.Identifier
+	elements.ElementLength = byte(len(element.Data))
+
+	if ports, found := data.Ports(); found {
+		elements.Ports = &ports
+	}
+
+	if concatenation, found := data.Concatenation(); found {
+		elements.Reference = concatenation.Reference
+		elements.TotalParts = concatenation.TotalParts
+		elements.CurrentPart = concatenation.CurrentPart
+	}
+
+	return elements
+}
@@ -0,0 +1,239 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/smudhtest"
+)
+
+func TestParseUserData(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         udh.Message
+		elements      []udh.InformationElement
+		concatenation *udh.Concatenation
+		ports         *udh.Ports
+		message       string
+	}{
+		{
+			name:  "concatenation and ports",
+			input: udh.Message("0B000312020105040B8423F0" + "68656C6C6F"),
+			elements: []udh.InformationElement{
+				{Identifier: udh.IEIConcatenated8Bit, Data: []byte{0x12, 0x02, 0x01}},
+				{Identifier: udh.IEIApplicationPort16Bit, Data: []byte{0x0B, 0x84, 0x23, 0xF0}},
+			},
+			concatenation: &udh.Concatenation{Reference: []byte{0x12}, TotalParts: 2, CurrentPart: 1},
+			ports:         &udh.Ports{Destination: 0x0B84, Source: 0x23F0},
+			message:       "hello",
+		},
+		{
+			name:  "ports only",
+			input: udh.Message("0605040B8423F0" + "68656C6C6F"),
+			elements: []udh.InformationElement{
+				{Identifier: udh.IEIApplicationPort16Bit, Data: []byte{0x0B, 0x84, 0x23, 0xF0}},
+			},
+			ports:   &udh.Ports{Destination: 0x0B84, Source: 0x23F0},
+			message: "hello",
+		},
+		{
+			name:    "standalone",
+			input:   udh.Message("776F726C64"),
+			message: "world",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			data, err := test.input.ParseUserData(udh.ASCII)
+			if err != nil {
+				t2.Fatal(err)
+			}
+
+			if diff := cmp.Diff(test.elements, data.Elements); diff != "" {
+				t2.Errorf("elements diff: %s", diff)
+			}
+
+			concatenation, found := data.Concatenation()
+			if found != (test.concatenation != nil) || (found && !cmp.Equal(*test.concatenation, concatenation)) {
+				t2.Errorf("unexpected concatenation: %+v", concatenation)
+			}
+
+			ports, found := data.Ports()
+			if found != (test.ports != nil) || (found && ports != *test.ports) {
+				t2.Errorf("unexpected ports: %+v", ports)
+			}
+
+			if data.Message != test.message {
+				t2.Errorf("have %q, expected %q", data.Message, test.message)
+			}
+		})
+	}
+}
+
+func TestParseUserDataOptions(t *testing.T) {
+	// 0605040B8423F0 is a UDH of 7 octets, holding 16-bit application ports, followed by "hello world" packed as
+	// GSM 7-bit septets. 060804ABCD0201 is a UDH of 7 octets, holding a concatenation with a 16-bit reference.
+	tests := []struct {
+		name     string
+		input    udh.Message
+		encoding udh.Encoding
+		options  []udh.ParseOption
+		expected string
+		err      error
+	}{
+		{
+			name:     "packed GSM7",
+			input:    udh.Message("0605040B8423F0" + "E8329BFD06DDDF723619"),
+			encoding: udh.GSM,
+			options:  []udh.ParseOption{udh.WithPackedGSM7()},
+			expected: "hello world",
+		},
+		{
+			name:     "UCS2 padding auto",
+			input:    udh.Message("060804ABCD0201" + "00" + "00680069"),
+			encoding: udh.UCS2,
+			expected: "hi",
+		},
+		{
+			name:     "UCS2 padding always",
+			input:    udh.Message("060804ABCD0201" + "00" + "00680069"),
+			encoding: udh.UCS2,
+			options:  []udh.ParseOption{udh.WithUCS2Padding(udh.UCS2PaddingAlways)},
+			expected: "hi",
+		},
+		{
+			name:     "UCS2 padding always after an even UDH",
+			input:    udh.Message("050003A50201" + "00680069"),
+			encoding: udh.UCS2,
+			options:  []udh.ParseOption{udh.WithUCS2Padding(udh.UCS2PaddingAlways)},
+			expected: "hi",
+		},
+		{
+			name:     "UCS2 padding none",
+			input:    udh.Message("060804ABCD0201" + "00" + "00680069"),
+			encoding: udh.UCS2,
+			options:  []udh.ParseOption{udh.WithUCS2Padding(udh.UCS2PaddingNone)},
+			err:      udh.ErrBinaryTextLengthIsNotEvenForUTF16Decoding,
+		},
+		{
+			name:     "UCS2 padding always without padding",
+			input:    udh.Message("060804ABCD0201" + "00680069"),
+			encoding: udh.UCS2,
+			options:  []udh.ParseOption{udh.WithUCS2Padding(udh.UCS2PaddingAlways)},
+			err:      udh.ErrBinaryTextLengthIsNotEvenForUTF16Decoding,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t2 *testing.T) {
+			data, err := test.input.ParseUserData(test.encoding, test.options...)
+			if !errors.Is(err, test.err) {
+				t2.Fatalf("expected error %v, got %v", test.err, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if data.Message != test.expected {
+				t2.Errorf("have message %q, expected %q", data.Message, test.expected)
+			}
+		})
+	}
+}
+
+func TestUserDataMessageElements(t *testing.T) {
+	input := udh.Message("05000312010168656C6C6F20776F726C64")
+
+	data, err := input.ParseUserData(udh.GSM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := input.ParseElements(udh.GSM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diffs := udh.Diff(expected, data.MessageElements()); !diffs.Equal() {
+		t.Errorf("differences:\n%s", diffs)
+	}
+}
+
+func TestParseUserDataParity(t *testing.T) {
+	vectors, err := smudhtest.ConformanceVectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	optionSets := map[string][]udh.ParseOption{
+		"default":      nil,
+		"trace":        {udh.WithTrace()},
+		"text markers": {udh.WithTextMarkers(), udh.WithTrace()},
+		"SAR":          {udh.WithSAR(udh.SAR{Reference: 0x1234, TotalSegments: 2, SegmentNumber: 1}), udh.WithTrace()},
+		"UCS2 padding": {udh.WithUCS2Padding(udh.UCS2PaddingAlways)},
+		"packed GSM7":  {udh.WithPackedGSM7()},
+		"pooled":       {udh.WithPooledElements()},
+	}
+
+	for name, options := range optionSets {
+		t.Run(name, func(t2 *testing.T) {
+			for _, vector := range vectors {
+				vectorOptions := options
+
+				if vector.Quirk != "" {
+					profile, err := udh.LookupQuirkProfile(vector.Quirk)
+					if err != nil {
+						t2.Fatal(err)
+					}
+
+					vectorOptions = slices.Concat(options, profile.ParseOptions())
+				}
+
+				msg := udh.Message(vector.Input)
+
+				elements, elementsErr := msg.ParseElements(vector.Encoding, vectorOptions...)
+				data, dataErr := msg.ParseUserData(vector.Encoding, vectorOptions...)
+
+				if udh.CodeOf(elementsErr) != udh.CodeOf(dataErr) {
+					t2.Errorf("%s: have errors %v and %v", vector, elementsErr, dataErr)
+					continue
+				}
+
+				if elementsErr != nil {
+					continue
+				}
+
+				expected := &udh.UserData{
+					HeaderLength: elements.HeaderLength,
+					Elements:     data.Elements,
+					RawMessage:   elements.RawMessage,
+					Message:      elements.Message,
+					Encoding:     elements.Encoding,
+					Trace:        elements.Trace,
+					Extensions:   elements.Extensions,
+					Warnings:     elements.Warnings,
+				}
+
+				if diff := cmp.Diff(expected, data); diff != "" {
+					t2.Errorf("%s: user data mismatch (-elements +user data):\n%s", vector, diff)
+				}
+
+				concatenation, found := data.Concatenation()
+				if found && elements.CurrentPart != concatenation.CurrentPart {
+					t2.Errorf("%s: have part %d and %d", vector, elements.CurrentPart, concatenation.CurrentPart)
+				}
+
+				elements.Release()
+			}
+		})
+	}
+}
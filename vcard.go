@@ -0,0 +1,158 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "strings"
+
+// VCard is a minimal representation of a vCard, as sent to port 9204 for phone book push.
+type VCard struct {
+	// vCard version, usually 2.1 or 3.0
+	Version string `json:"version"`
+
+	// Structured name (N), with its components separated by semicolons
+	Name string `json:"name"`
+
+	// Formatted name (FN)
+	FormattedName string `json:"formatted_name"`
+
+	// Phone numbers (TEL), in order
+	Phones []string `json:"phones,omitempty"`
+
+	// Email addresses (EMAIL), in order
+	Emails []string `json:"emails,omitempty"`
+
+	// Organization (ORG)
+	Organization string `json:"organization,omitempty"`
+}
+
+// VEvent is a minimal representation of a vCalendar event.
+type VEvent struct {
+	// Title of the event (SUMMARY)
+	Summary string `json:"summary"`
+
+	// Start time as written (DTSTART), for example 20240131T090000Z
+	Start string `json:"start"`
+
+	// End time as written (DTEND)
+	End string `json:"end,omitempty"`
+
+	// Location of the event (LOCATION)
+	Location string `json:"location,omitempty"`
+
+	// Description of the event (DESCRIPTION)
+	Description string `json:"description,omitempty"`
+}
+
+// VCalendar is a minimal representation of a vCalendar, as sent to port 9205.
+type VCalendar struct {
+	// vCalendar version, usually 1.0 or 2.0
+	Version string `json:"version"`
+
+	// Events of the calendar, in order
+	Events []VEvent `json:"events"`
+}
+
+// contentLine is a single unfolded "NAME;PARAM=VALUE:value" line of a vCard or a vCalendar.
+type contentLine struct {
+	name  string
+	value string
+}
+
+// ParseVCard parses the first vCard found at data.
+// Properties other than the ones held by VCard are ignored.
+// Returns ErrInvalidContent if data does not hold a complete vCard.
+func ParseVCard(data []byte) (*VCard, error) {
+	var (
+		card    VCard
+		started bool
+	)
+
+	for _, line := range contentLines(data) {
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VCARD"):
+			started = true
+		case !started:
+		case line.name == "END" && strings.EqualFold(line.value, "VCARD"):
+			return &card, nil
+		case line.name == "VERSION":
+			card.Version = line.value
+		case line.name == "N":
+			card.Name = line.value
+		case line.name == "FN":
+			card.FormattedName = line.value
+		case line.name == "TEL":
+			card.Phones = append(card.Phones, line.value)
+		case line.name == "EMAIL":
+			card.Emails = append(card.Emails, line.value)
+		case line.name == "ORG":
+			card.Organization = line.value
+		}
+	}
+
+	return nil, ErrInvalidContent
+}
+
+// ParseVCalendar parses the first vCalendar found at data, and the events it holds.
+// Properties other than the ones held by VCalendar and VEvent are ignored.
+// Returns ErrInvalidContent if data does not hold a complete vCalendar.
+func ParseVCalendar(data []byte) (*VCalendar, error) {
+	var (
+		calendar VCalendar
+		event    *VEvent
+		started  bool
+	)
+
+	for _, line := range contentLines(data) {
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VCALENDAR"):
+			started = true
+		case !started:
+		case line.name == "END" && strings.EqualFold(line.value, "VCALENDAR"):
+			return &calendar, nil
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VEVENT"):
+			event = &VEvent{}
+		case line.name == "END" && strings.EqualFold(line.value, "VEVENT") && event != nil:
+			calendar.Events = append(calendar.Events, *event)
+			event = nil
+		case line.name == "VERSION" && event == nil:
+			calendar.Version = line.value
+		case event == nil:
+		case line.name == "SUMMARY":
+			event.Summary = line.value
+		case line.name == "DTSTART":
+			event.Start = line.value
+		case line.name == "DTEND":
+			event.End = line.value
+		case line.name == "LOCATION":
+			event.Location = line.value
+		case line.name == "DESCRIPTION":
+			event.Description = line.value
+		}
+	}
+
+	return nil, ErrInvalidContent
+}
+
+// contentLines unfolds data and splits it into content lines. The names are returned in upper case, without their
+// parameters, and lines without a colon are skipped.
+func contentLines(data []byte) []contentLine {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\n ", "")
+	text = strings.ReplaceAll(text, "\n\t", "")
+
+	var lines []contentLine
+
+	for _, raw := range strings.Split(text, "\n") {
+		name, value, found := strings.Cut(strings.TrimRight(raw, "\r"), ":")
+		if !found {
+			continue
+		}
+
+		name, _, _ = strings.Cut(name, ";")
+		lines = append(lines, contentLine{name: strings.ToUpper(strings.TrimSpace(name)), value: value})
+	}
+
+	return lines
+}
@@ -0,0 +1,391 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Operations recorded at the write-ahead log.
+const (
+	walAdd    = "add"
+	walDelete = "delete"
+	walMove   = "move"
+)
+
+// walRecord is a single line of the write-ahead log.
+type walRecord struct {
+	Op       string           `json:"op"`
+	Key      []byte           `json:"key"`
+	To       []byte           `json:"to,omitempty"`
+	Fragment *MessageElements `json:"fragment,omitempty"`
+	Time     time.Time        `json:"time,omitzero"`
+}
+
+// WAL is an append-only write-ahead log of the changes made to Messages, stored as JSON lines in a single file.
+//
+// Unlike a Store, which rewrites the whole message on every change, the log only appends the accepted fragment,
+// and records the removal of messages, so a process that crashed before completing its messages recovers their
+// fragments using Messages.ReplayWAL. Use Messages.CompactWAL from time to time for dropping the records of
+// removed messages.
+type WAL struct {
+	path  string
+	file  *os.File
+	fsync bool
+	mtx   sync.Mutex
+}
+
+// WALOption is a functional option for OpenWAL.
+type WALOption func(*WAL)
+
+// WithWALSync syncs the log file to the disk after every record, so records survive a power loss and not only a
+// crash of the process, at the cost of slower additions.
+func WithWALSync() WALOption {
+	return func(wal *WAL) {
+		wal.fsync = true
+	}
+}
+
+// OpenWAL opens the write-ahead log at path for appending, creating it when needed.
+func OpenWAL(path string, options ...WALOption) (*WAL, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	wal := &WAL{path: path, file: file}
+
+	for _, option := range options {
+		option(wal)
+	}
+
+	if wal.fsync {
+		// the log file may have just been created
+		err = syncDir(path)
+		if err != nil {
+			_ = file.Close()
+			return nil, err
+		}
+	}
+
+	return wal, nil
+}
+
+// WithWAL sets the write-ahead log that Messages records its changes to. A fragment is accepted only after it was
+// recorded, otherwise the add fails with an error wrapping ErrStore. Use ReplayWAL for loading the log content.
+// Not supported by ShardedMessages.
+func WithWAL(wal *WAL) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.wal = wal
+	}
+}
+
+// Close closes the log file.
+func (wal *WAL) Close() error {
+	wal.mtx.Lock()
+	defer wal.mtx.Unlock()
+
+	err := wal.file.Close()
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// sync commits the content of the log to disk.
+func (wal *WAL) sync() error {
+	wal.mtx.Lock()
+	defer wal.mtx.Unlock()
+
+	err := wal.file.Sync()
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// ReplayWAL loads the messages recorded at the write-ahead log set by WithWAL into the container, replacing
+// messages with the same key. It does nothing when no log was set.
+//
+// A record cut short by a crash at the end of the log is dropped, and the log is truncated before it, so new
+// records are appended after the last complete one.
+func (msgs *Messages) ReplayWAL() error {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	if msgs.wal == nil {
+		return nil
+	}
+
+	sets, err := msgs.wal.replay()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	for key, set := range sets {
+		msgs.trackSet(key, set)
+	}
+
+	msgs.debug("write-ahead log replayed", slog.Int("messages", len(sets)))
+
+	return nil
+}
+
+// CompactWAL rewrites the write-ahead log set by WithWAL, keeping only the fragments of the messages currently
+// held by the container. The log is replaced atomically. It does nothing when no log was set.
+func (msgs *Messages) CompactWAL() error {
+	msgs.mtx.Lock()
+	defer msgs.mtx.Unlock()
+
+	if msgs.wal == nil {
+		return nil
+	}
+
+	buffer := bytes.Buffer{}
+	encoder := json.NewEncoder(&buffer)
+
+	for key, set := range msgs.fragments {
+		for idx, info := range *set.fragments {
+			seen := set.lastSeen
+			if idx == 0 {
+				seen = set.firstSeen
+			}
+
+			err := encoder.Encode(walRecord{Op: walAdd, Key: []byte(key), Fragment: info, Time: seen})
+			if err != nil {
+				return fmt.Errorf("%w: %w", ErrStore, err)
+			}
+		}
+	}
+
+	err := msgs.wal.replace(buffer.Bytes())
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	return nil
+}
+
+// logAdd records an accepted fragment. The caller must hold the lock.
+func (msgs *Messages) logAdd(key string, info *MessageElements, now time.Time) error {
+	if msgs.wal == nil {
+		return nil
+	}
+
+	err := msgs.wal.append(walRecord{Op: walAdd, Key: []byte(key), Fragment: info, Time: now})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	return nil
+}
+
+// logMove records that a message moved to another key. The caller must hold the lock.
+func (msgs *Messages) logMove(key, to string) error {
+	if msgs.wal == nil {
+		return nil
+	}
+
+	err := msgs.wal.append(walRecord{Op: walMove, Key: []byte(key), To: []byte(to)})
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrStore, err)
+	}
+
+	return nil
+}
+
+// logDelete records the removal of a message. Failures are logged, since the message was already removed from
+// memory. The caller must hold the lock.
+func (msgs *Messages) logDelete(key string) {
+	if msgs.wal == nil {
+		return
+	}
+
+	err := msgs.wal.append(walRecord{Op: walDelete, Key: []byte(key)})
+	if err != nil && msgs.logger != nil {
+		msgs.logger.Warn("unable to record message removal at the write-ahead log", slog.Any("error", err))
+	}
+}
+
+// append writes a single record at the end of the log.
+func (wal *WAL) append(record walRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	wal.mtx.Lock()
+	defer wal.mtx.Unlock()
+
+	_, err = wal.file.Write(append(line, '\n'))
+	if err == nil && wal.fsync {
+		err = wal.file.Sync()
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// replay reads the log from its start, and returns the messages it holds, keyed by their key.
+func (wal *WAL) replay() (map[string]*fragmentSet, error) {
+	wal.mtx.Lock()
+	defer wal.mtx.Unlock()
+
+	_, err := wal.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, fmt.Errorf("%w", err)
+	}
+
+	sets := map[string]*fragmentSet{}
+	reader := bufio.NewReader(wal.file)
+	offset := int64(0)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				// the last record was cut short
+				err = wal.file.Truncate(offset)
+				if err == nil && wal.fsync {
+					err = wal.file.Sync()
+				}
+
+				if err != nil {
+					return nil, fmt.Errorf("%w", err)
+				}
+			}
+
+			return sets, nil
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("%w", err)
+		}
+
+		var record walRecord
+
+		err = json.Unmarshal(line, &record)
+		if err != nil {
+			return nil, fmt.Errorf("write-ahead log offset %d: %w", offset, err)
+		}
+
+		applyWALRecord(sets, record)
+
+		offset += int64(len(line))
+	}
+}
+
+// replace atomically replaces the content of the log. The new content and its directory entry are synced to the
+// disk before the log is used again, so a crash never leaves a partial or missing log behind.
+func (wal *WAL) replace(content []byte) error {
+	wal.mtx.Lock()
+	defer wal.mtx.Unlock()
+
+	tmp, err := os.OpenFile(wal.path+".tmp", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	_, err = tmp.Write(content)
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	closeErr := tmp.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), wal.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("%w", err)
+	}
+
+	err = syncDir(wal.path)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(wal.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	_ = wal.file.Close()
+	wal.file = file
+
+	return nil
+}
+
+// syncDir syncs the directory holding path to the disk, so the creation or the renaming of path survives a power
+// loss.
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	err = dir.Sync()
+
+	closeErr := dir.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	return nil
+}
+
+// applyWALRecord applies a single record to sets.
+func applyWALRecord(sets map[string]*fragmentSet, record walRecord) {
+	key := string(record.Key)
+
+	switch record.Op {
+	case walAdd:
+		if record.Fragment == nil {
+			return
+		}
+
+		set, found := sets[key]
+		if !found {
+			set = &fragmentSet{fragments: &MessageFragmentations{}, firstSeen: record.Time}
+			sets[key] = set
+		}
+
+		set.fragments.insert(record.Fragment)
+		set.lastSeen = record.Time
+
+	case walMove:
+		if set, found := sets[key]; found {
+			delete(sets, key)
+			sets[string(record.To)] = set
+		}
+
+	case walDelete:
+		delete(sets, key)
+	}
+}
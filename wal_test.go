@@ -0,0 +1,204 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	udh "github.com/ik5/smudh"
+)
+
+// walMessages returns a Messages container using the write-ahead log at path, with its content replayed.
+func walMessages(t *testing.T, path string, options ...udh.MessagesOption) *udh.Messages {
+	t.Helper()
+
+	wal, err := udh.OpenWAL(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = wal.Close() })
+
+	messages := udh.InitMessages(append(options, udh.WithWAL(wal))...)
+
+	err = messages.ReplayWAL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return messages
+}
+
+func TestWALReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fragments.wal")
+	messages := walMessages(t, path)
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003B70502002005E905DC"),           // part 2 of 5
+		udh.Message("050003A50201546869732069732061206C"), // part 1 of 2
+		udh.Message("050003A5020265722074657374696E67"),   // part 2 of 2
+		udh.Message("050003B70504002005E905DC"),           // part 4 of 5
+	} {
+		err := messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if drained := messages.DrainComplete(); len(drained) != 1 {
+		t.Fatalf("have %d drained messages, expected 1", len(drained))
+	}
+
+	// a crash in the middle of writing a record
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = file.WriteString(`{"op":"add","key":`)
+	if err == nil {
+		err = file.Close()
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := walMessages(t, path)
+
+	incomplete := replayed.Incomplete()
+	if len(incomplete) != 1 || !bytes.Equal(incomplete[0].MissingParts, []byte{1, 3, 5}) {
+		t.Fatalf("have incomplete messages %+v, expected B7 missing 1, 3 and 5", incomplete)
+	}
+
+	if replayed.Snapshot([]byte{0xA5}) != nil {
+		t.Error("drained message was replayed")
+	}
+
+	// records appended after the truncated one are replayed
+	err = replayed.Add(udh.ASCII, udh.Message("050003B70501002005E905DC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fragments := walMessages(t, path).Snapshot([]byte{0xB7}); len(fragments) != 3 {
+		t.Errorf("have %d fragments after the second replay, expected 3", len(fragments))
+	}
+}
+
+func TestWALCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fragments.wal")
+	messages := walMessages(t, path, udh.WithReferenceDisambiguation(0))
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A50301" + "6F6C64"),
+		udh.Message("050003A50201546869732069732061206C"),
+		udh.Message("050003A5020265722074657374696E67"),
+		udh.Message("050003B70502002005E905DC"),
+	} {
+		err := messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	messages.DrainComplete()
+
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = messages.CompactWAL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := bytes.Count(after, []byte{'\n'}); lines != 2 || len(after) >= len(before) {
+		t.Errorf("have %d records after compaction, expected 2", lines)
+	}
+
+	err = messages.Add(udh.ASCII, udh.Message("050003B70501002005E905DC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := walMessages(t, path, udh.WithReferenceDisambiguation(0))
+	if all := replayed.ListAll(); len(all) != 2 {
+		t.Errorf("have %d replayed messages, expected 2", len(all))
+	}
+
+	if fragments := replayed.Snapshot([]byte{0xB7}); len(fragments) != 2 {
+		t.Errorf("have %d fragments of B7, expected 2", len(fragments))
+	}
+}
+
+func TestWALStoreFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fragments.wal")
+	messages := walMessages(t, path, udh.WithStore(failingStore{udh.NewMemoryStore()}))
+
+	err := messages.Add(udh.ASCII, udh.Message("050003A50201546869732069732061206C"))
+	if !errors.Is(err, udh.ErrStore) {
+		t.Fatalf("have err %v, expected %v", err, udh.ErrStore)
+	}
+
+	if all := walMessages(t, path).ListAll(); len(all) != 0 {
+		t.Errorf("have %d replayed messages, expected none", len(all))
+	}
+}
+
+func TestWALFailureRevertsStore(t *testing.T) {
+	wal, err := udh.OpenWAL(filepath.Join(t.TempDir(), "fragments.wal"), udh.WithWALSync())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := udh.NewMemoryStore()
+	messages := udh.InitMessages(udh.WithStore(store), udh.WithWAL(wal))
+
+	err = messages.Add(udh.ASCII, udh.Message("050003A50201546869732069732061206C"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = wal.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A5020265722074657374696E67"), // completes A5
+		udh.Message("050003B70502002005E905DC"),         // starts B7
+	} {
+		err = messages.Add(udh.ASCII, msg)
+		if !errors.Is(err, udh.ErrStore) {
+			t.Fatalf("have err %v, expected %v", err, udh.ErrStore)
+		}
+	}
+
+	sets, err := store.LoadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sets) != 1 {
+		t.Fatalf("have %d stored messages, expected 1", len(sets))
+	}
+
+	for _, set := range sets {
+		if len(set.Fragments) != 1 || set.Fragments[0].CurrentPart != 1 {
+			t.Errorf("have stored fragments %+v, expected part 1 only", set.Fragments)
+		}
+	}
+}
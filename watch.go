@@ -0,0 +1,147 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"time"
+)
+
+// EventType is the kind of a reassembly lifecycle Event.
+type EventType byte
+
+const (
+	// FragmentAdded is sent for every fragment added to the container
+	FragmentAdded EventType = iota + 1
+
+	// MessageCompleted is sent when a message has all of its fragments
+	MessageCompleted
+
+	// SetEvicted is sent when a message is removed after its TTL expired
+	SetEvicted
+)
+
+// defaultWatchBuffer is the size of the channel returned by Watch, unless set by WithWatchBuffer.
+const defaultWatchBuffer = 64
+
+// Event describes a change at the Messages container.
+type Event struct {
+	// Kind of the event
+	Type EventType `json:"type"`
+
+	// Namespace of the message, empty for the default namespace
+	Namespace string `json:"namespace,omitempty"`
+
+	// Reference number of the message
+	Reference []byte `json:"reference"`
+
+	// The added fragment, set only for FragmentAdded events
+	Fragment *MessageElements `json:"fragment,omitempty"`
+
+	// A copy of the fragments of the message at the time of the event
+	Fragments MessageFragmentations `json:"fragments"`
+
+	// When the event took place
+	Time time.Time `json:"time"`
+}
+
+// watcher is a single subscriber created by Watch.
+type watcher struct {
+	events chan Event
+}
+
+// WithWatchBuffer sets the size of the channels returned by Watch. The default size is 64.
+func WithWatchBuffer(size int) MessagesOption {
+	return func(msgs *Messages) {
+		msgs.watchBuffer = size
+	}
+}
+
+// String returns the name of the event type.
+func (eventType EventType) String() string {
+	switch eventType {
+	case FragmentAdded:
+		return "FragmentAdded"
+	case MessageCompleted:
+		return "MessageCompleted"
+	case SetEvicted:
+		return "SetEvicted"
+	}
+
+	return "Unknown"
+}
+
+// Watch returns a channel that receives the reassembly lifecycle events of the container, until ctx is done or the
+// container is closed, and the channel is closed.
+//
+// Events are never blocked on: when the consumer falls behind and the channel buffer is full, new events for that
+// consumer are dropped.
+func (msgs *Messages) Watch(ctx context.Context) <-chan Event {
+	size := msgs.watchBuffer
+	if size <= 0 {
+		size = defaultWatchBuffer
+	}
+
+	subscriber := &watcher{events: make(chan Event, size)}
+
+	msgs.watchMtx.Lock()
+	msgs.watchers = append(msgs.watchers, subscriber)
+	msgs.watchMtx.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-msgs.closing:
+		}
+
+		msgs.watchMtx.Lock()
+		defer msgs.watchMtx.Unlock()
+
+		// the channel was already closed by Close when the subscriber is gone
+		for idx, current := range msgs.watchers {
+			if current == subscriber {
+				msgs.watchers = append(msgs.watchers[:idx], msgs.watchers[idx+1:]...)
+				close(subscriber.events)
+
+				break
+			}
+		}
+	}()
+
+	return subscriber.events
+}
+
+// publish sends event to all of the watchers without blocking.
+func (msgs *Messages) publish(event Event) {
+	msgs.watchMtx.Lock()
+	defer msgs.watchMtx.Unlock()
+
+	for _, subscriber := range msgs.watchers {
+		select {
+		case subscriber.events <- event:
+		default:
+		}
+	}
+}
+
+// hasWatchers returns true when at least one watcher exists, so events are built only when needed.
+func (msgs *Messages) hasWatchers() bool {
+	msgs.watchMtx.Lock()
+	defer msgs.watchMtx.Unlock()
+
+	return len(msgs.watchers) > 0
+}
+
+// closeWatchers closes the channels of all of the watchers.
+func (msgs *Messages) closeWatchers() {
+	msgs.watchMtx.Lock()
+	defer msgs.watchMtx.Unlock()
+
+	for _, subscriber := range msgs.watchers {
+		close(subscriber.events)
+	}
+
+	msgs.watchers = nil
+}
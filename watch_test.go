@@ -0,0 +1,71 @@
+package smudh_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+)
+
+func TestWatch(t *testing.T) {
+	messages := udh.InitMessages(udh.WithTTL(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := messages.Watch(ctx)
+
+	for _, msg := range []udh.Message{
+		udh.Message("050003A5020265722074657374696E67"),
+		udh.Message("050003A50201546869732069732061206C"),
+		udh.Message("050003B70502002005E905DC"),
+	} {
+		err := messages.Add(udh.ASCII, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.Sleep(2 * time.Millisecond)
+
+	if evicted := messages.EvictExpired(); evicted != 2 {
+		t.Errorf("have %d evicted messages, expected 2", evicted)
+	}
+
+	cancel()
+
+	types := []udh.EventType{}
+	for event := range events {
+		types = append(types, event.Type)
+	}
+
+	expected := []udh.EventType{
+		udh.FragmentAdded, udh.FragmentAdded, udh.MessageCompleted, udh.FragmentAdded, udh.SetEvicted, udh.SetEvicted,
+	}
+
+	if diff := cmp.Diff(expected, types); diff != "" {
+		t.Errorf("events diff: %s", diff)
+	}
+}
+
+func TestRunJanitor(t *testing.T) {
+	messages := udh.InitMessages(udh.WithTTL(time.Millisecond))
+
+	err := messages.Add(udh.ASCII, udh.Message("050003B70502002005E905DC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	messages.RunJanitor(ctx, 5*time.Millisecond)
+
+	if len(messages.ListAll()) != 0 {
+		t.Error("expected the janitor to evict the message")
+	}
+}
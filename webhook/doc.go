@@ -0,0 +1,11 @@
+/*
+Package webhook POSTs completed messages to an HTTP endpoint, so systems that do not integrate the Go API still
+receive complete messages.
+
+The body of every request is a JSON encoded smudh.AssembledMessage. When a secret is set using WithSecret, the
+request carries the hex encoded HMAC-SHA256 of the body in the X-Smudh-Signature header, prefixed with "sha256=",
+and receivers should verify it using the same secret (see Verify).
+
+Failed deliveries (network errors, 429 and 5xx responses) are retried with an exponential backoff.
+*/
+package webhook
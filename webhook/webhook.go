@@ -0,0 +1,217 @@
+package webhook
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ik5/smudh"
+)
+
+// SignatureHeader is the request header holding the HMAC signature of the body.
+const SignatureHeader = "X-Smudh-Signature"
+
+// signaturePrefix names the algorithm of the signature.
+const signaturePrefix = "sha256="
+
+// Default retry settings.
+const (
+	DefaultMaxAttempts = 5
+	DefaultBackoff     = 500 * time.Millisecond
+	maxBackoff         = 30 * time.Second
+)
+
+var (
+	// ErrDeliveryFailed is returned when the endpoint did not accept the message after all of the attempts.
+	ErrDeliveryFailed = errors.New("webhook delivery failed")
+
+	// ErrRejected is returned when the endpoint rejected the message with a status that is not retried.
+	ErrRejected = errors.New("webhook rejected the message")
+)
+
+// Option configures a Dispatcher.
+type Option func(*Dispatcher)
+
+// WithClient sets the HTTP client used for the requests. Defaults to a client with a 10 seconds timeout.
+func WithClient(client *http.Client) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.client = client
+	}
+}
+
+// WithSecret sets the secret used for signing the requests.
+func WithSecret(secret []byte) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.secret = secret
+	}
+}
+
+// WithRetries sets the number of delivery attempts, and the delay before the first retry, which doubles on every
+// following retry.
+func WithRetries(maxAttempts int, backoff time.Duration) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.maxAttempts = maxAttempts
+		dispatcher.backoff = backoff
+	}
+}
+
+// WithLogger sets the logger used for reporting failed deliveries during Run.
+func WithLogger(logger *slog.Logger) Option {
+	return func(dispatcher *Dispatcher) {
+		dispatcher.logger = logger
+	}
+}
+
+// Dispatcher delivers completed messages to a webhook URL.
+type Dispatcher struct {
+	url         string
+	client      *http.Client
+	secret      []byte
+	maxAttempts int
+	backoff     time.Duration
+	logger      *slog.Logger
+}
+
+// NewDispatcher returns a Dispatcher posting to url.
+func NewDispatcher(url string, options ...Option) *Dispatcher {
+	dispatcher := &Dispatcher{
+		url:         url,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+	}
+
+	for _, option := range options {
+		option(dispatcher)
+	}
+
+	return dispatcher
+}
+
+// Run delivers every message completed at messages until ctx is done, using Messages.Watch.
+//
+// Messages are assembled using Messages.Assemble, and the ones rejected by its middleware are not delivered.
+// Deliveries take place one after the other, and failed deliveries are logged. Since Watch drops events once its
+// buffer is full, size the buffer using smudh.WithWatchBuffer according to the expected delivery latency.
+func (dispatcher *Dispatcher) Run(ctx context.Context, messages *smudh.Messages) {
+	for event := range messages.Watch(ctx) {
+		if event.Type != smudh.MessageCompleted {
+			continue
+		}
+
+		assembled, err := messages.Assemble(ctx, event.Fragments)
+		if err == nil {
+			err = dispatcher.Send(ctx, assembled)
+		}
+
+		if err != nil && !errors.Is(err, smudh.ErrRejected) && dispatcher.logger != nil {
+			dispatcher.logger.Error("webhook delivery failed",
+				slog.String("reference", hex.EncodeToString(event.Reference)),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+// Send delivers a single message, retrying failed attempts.
+func (dispatcher *Dispatcher) Send(ctx context.Context, assembled smudh.AssembledMessage) error {
+	body, err := json.Marshal(assembled)
+	if err != nil {
+		return fmt.Errorf("%w", err)
+	}
+
+	backoff := dispatcher.backoff
+
+	var lastErr error
+
+	for attempt := range max(dispatcher.maxAttempts, 1) {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w", ctx.Err())
+			case <-time.After(backoff):
+			}
+
+			backoff = min(backoff*2, maxBackoff)
+		}
+
+		retry, err := dispatcher.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry {
+			return err
+		}
+
+		lastErr = err
+	}
+
+	return fmt.Errorf("%w: %w", ErrDeliveryFailed, lastErr)
+}
+
+// post makes a single delivery attempt, and reports whether a failure should be retried.
+func (dispatcher *Dispatcher) post(ctx context.Context, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, dispatcher.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("%w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	if len(dispatcher.secret) > 0 {
+		request.Header.Set(SignatureHeader, Sign(dispatcher.secret, body))
+	}
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("%w", err)
+	}
+
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+
+	switch {
+	case response.StatusCode >= 200 && response.StatusCode < 300:
+		return false, nil
+	case response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500:
+		return true, fmt.Errorf("%w: %s", ErrDeliveryFailed, response.Status)
+	}
+
+	return false, fmt.Errorf("%w: %s", ErrRejected, response.Status)
+}
+
+// Sign returns the value of the SignatureHeader for body.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid SignatureHeader value of body.
+func Verify(secret, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+
+	return hmac.Equal(mac.Sum(nil), expected)
+}
@@ -0,0 +1,104 @@
+package webhook_test
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	udh "github.com/ik5/smudh"
+	"github.com/ik5/smudh/webhook"
+)
+
+var secret = []byte("secret")
+
+func TestDispatcherSend(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		attempts int32
+		err      error
+	}{
+		{name: "accepted", statuses: []int{http.StatusOK}, attempts: 1},
+		{
+			name:     "retried",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusNoContent},
+			attempts: 3,
+		},
+		{
+			name:     "exhausted",
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			attempts: 3, err: webhook.ErrDeliveryFailed,
+		},
+		{name: "rejected", statuses: []int{http.StatusBadRequest}, attempts: 1, err: webhook.ErrRejected},
+	}
+
+	assembled := udh.AssembledMessage{Reference: "0a", Encoding: udh.ASCII, Parts: 2, Text: "hello world"}
+
+	for _, test := range tests {
+		var attempts atomic.Int32
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+
+			if !webhook.Verify(secret, body, r.Header.Get(webhook.SignatureHeader)) {
+				t.Errorf("%s: invalid signature %q", test.name, r.Header.Get(webhook.SignatureHeader))
+			}
+
+			var received udh.AssembledMessage
+
+			err := json.Unmarshal(body, &received)
+			if err != nil {
+				t.Errorf("%s: %s", test.name, err)
+			}
+
+			if diff := cmp.Diff(assembled, received); diff != "" {
+				t.Errorf("%s: unexpected body (-want +got):\n%s", test.name, diff)
+			}
+
+			w.WriteHeader(test.statuses[attempts.Add(1)-1])
+		}))
+
+		dispatcher := webhook.NewDispatcher(server.URL,
+			webhook.WithSecret(secret),
+			webhook.WithRetries(3, time.Millisecond),
+		)
+
+		err := dispatcher.Send(t.Context(), assembled)
+		if !errors.Is(err, test.err) || (err != nil) != (test.err != nil) {
+			t.Errorf("%s: have error %v, expected %v", test.name, err, test.err)
+		}
+
+		if have := attempts.Load(); have != test.attempts {
+			t.Errorf("%s: have %d attempts, expected %d", test.name, have, test.attempts)
+		}
+
+		server.Close()
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"text":"hello"}`)
+	signature := webhook.Sign(secret, body)
+
+	if !webhook.Verify(secret, body, signature) {
+		t.Error("valid signature was not verified")
+	}
+
+	if webhook.Verify([]byte("other"), body, signature) {
+		t.Error("signature of another secret was verified")
+	}
+
+	if webhook.Verify(secret, body, signature[len("sha256="):]) {
+		t.Error("signature without a prefix was verified")
+	}
+}
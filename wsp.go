@@ -0,0 +1,320 @@
+package smudh
+
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at https://mozilla.org/MPL/2.0/.
+
+import "fmt"
+
+// PortWAPPush is the WDP destination port of connectionless WAP push, used for MMS notifications and service
+// indications.
+const PortWAPPush uint16 = 2948
+
+// wspPushPDU is the WSP PDU type of a push.
+const wspPushPDU byte = 0x06
+
+// wspContentTypes holds the well known WSP content types found on WAP push messages.
+var wspContentTypes = map[byte]string{
+	0x03: "text/plain",
+	0x06: "text/x-vcalendar",
+	0x07: "text/x-vcard",
+	0x2E: "application/vnd.wap.sic",
+	0x30: "application/vnd.wap.slc",
+	0x3E: "application/vnd.wap.mms-message",
+}
+
+// WAPPush is a connectionless WSP push PDU, as sent to port 2948.
+type WAPPush struct {
+	// WSP transaction identifier
+	TransactionID byte `json:"transaction_id"`
+
+	// Content type of the body, for example application/vnd.wap.mms-message
+	ContentType string `json:"content_type"`
+
+	// Encoded headers that follow the content type, such as X-Wap-Application-Id
+	Headers []byte `json:"headers,omitempty"`
+
+	// The pushed content
+	Body []byte `json:"body"`
+}
+
+// ParseWAPPush parses a connectionless WSP push PDU from the reassembled binary payload of a WAP push message.
+// Returns ErrInvalidContent if data is not a well formed push PDU.
+func ParseWAPPush(data []byte) (*WAPPush, error) {
+	reader := &wspReader{data: data}
+
+	transactionID, err := reader.readOctet()
+	if err != nil {
+		return nil, err
+	}
+
+	pduType, err := reader.readOctet()
+	if err != nil {
+		return nil, err
+	}
+
+	if pduType != wspPushPDU {
+		return nil, fmt.Errorf("%w: WSP PDU type 0x%02X is not a push", ErrInvalidContent, pduType)
+	}
+
+	headersLength, err := reader.uintvar()
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := reader.next(int(headersLength))
+	if err != nil {
+		return nil, err
+	}
+
+	headersReader := &wspReader{data: headers}
+
+	contentType, err := headersReader.contentType()
+	if err != nil {
+		return nil, err
+	}
+
+	return &WAPPush{
+		TransactionID: transactionID,
+		ContentType:   contentType,
+		Headers:       append([]byte{}, headersReader.rest()...),
+		Body:          append([]byte{}, reader.rest()...),
+	}, nil
+}
+
+// wspReader reads the values of the WSP and MMS binary encodings (WAP-230 and OMA-MMS-ENC).
+type wspReader struct {
+	data   []byte
+	offset int
+}
+
+// done returns true when all of the data was read.
+func (reader *wspReader) done() bool {
+	return reader.offset >= len(reader.data)
+}
+
+// rest returns the data that was not read yet.
+func (reader *wspReader) rest() []byte {
+	return reader.data[min(reader.offset, len(reader.data)):]
+}
+
+// peek returns the next octet without reading it.
+func (reader *wspReader) peek() (byte, error) {
+	if reader.done() {
+		return 0, fmt.Errorf("%w: unexpected end of data", ErrInvalidContent)
+	}
+
+	return reader.data[reader.offset], nil
+}
+
+// readOctet reads a single octet.
+func (reader *wspReader) readOctet() (byte, error) {
+	value, err := reader.peek()
+	if err != nil {
+		return 0, err
+	}
+
+	reader.offset++
+
+	return value, nil
+}
+
+// next reads length octets.
+func (reader *wspReader) next(length int) ([]byte, error) {
+	if length < 0 || reader.offset+length > len(reader.data) {
+		return nil, fmt.Errorf("%w: value exceeds the data", ErrInvalidContent)
+	}
+
+	result := reader.data[reader.offset : reader.offset+length]
+	reader.offset += length
+
+	return result, nil
+}
+
+// uintvar reads a variable length unsigned integer, 7 bits per octet with the high bit marking continuation.
+func (reader *wspReader) uintvar() (uint32, error) {
+	var result uint32
+
+	for idx := 0; idx < 5; idx++ {
+		octet, err := reader.readOctet()
+		if err != nil {
+			return 0, err
+		}
+
+		result = result<<7 | uint32(octet&0x7F)
+		if octet&0x80 == 0 {
+			return result, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: uintvar is too long", ErrInvalidContent)
+}
+
+// valueLength reads a Value-length: a short length (0-30), or a length quote (31) followed by a uintvar.
+func (reader *wspReader) valueLength() (int, error) {
+	octet, err := reader.readOctet()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case octet < 31:
+		return int(octet), nil
+	case octet == 31:
+		length, err := reader.uintvar()
+		return int(length), err
+	}
+
+	return 0, fmt.Errorf("%w: 0x%02X is not a value length", ErrInvalidContent, octet)
+}
+
+// textString reads a null terminated Text-string, dropping the quote octet it may start with.
+func (reader *wspReader) textString() (string, error) {
+	if octet, err := reader.peek(); err == nil && octet == 0x7F {
+		reader.offset++
+	}
+
+	for end := reader.offset; end < len(reader.data); end++ {
+		if reader.data[end] == 0 {
+			result := string(reader.data[reader.offset:end])
+			reader.offset = end + 1
+
+			return result, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: unterminated text string", ErrInvalidContent)
+}
+
+// encodedString reads an Encoded-string-value: a Text-string, or a Value-length followed by a character set and a
+// Text-string. The character set is ignored, and the text is returned as is.
+func (reader *wspReader) encodedString() (string, error) {
+	octet, err := reader.peek()
+	if err != nil {
+		return "", err
+	}
+
+	if octet > 31 {
+		return reader.textString()
+	}
+
+	length, err := reader.valueLength()
+	if err != nil {
+		return "", err
+	}
+
+	value, err := reader.next(length)
+	if err != nil {
+		return "", err
+	}
+
+	inner := &wspReader{data: value}
+	if _, err = inner.integer(); err != nil {
+		return "", err
+	}
+
+	return inner.textString()
+}
+
+// integer reads an Integer-value: a Short-integer (0x80-0xFF), or a Long-integer made of a short length followed
+// by up to 8 big endian octets.
+func (reader *wspReader) integer() (uint64, error) {
+	octet, err := reader.readOctet()
+	if err != nil {
+		return 0, err
+	}
+
+	if octet&0x80 != 0 {
+		return uint64(octet & 0x7F), nil
+	}
+
+	if octet == 0 || octet > 8 {
+		return 0, fmt.Errorf("%w: invalid long integer length %d", ErrInvalidContent, octet)
+	}
+
+	value, err := reader.next(int(octet))
+	if err != nil {
+		return 0, err
+	}
+
+	var result uint64
+	for _, current := range value {
+		result = result<<8 | uint64(current)
+	}
+
+	return result, nil
+}
+
+// contentType reads a Content-type-value: a well known Short-integer, a Text-string, or a Value-length followed by
+// either of them and parameters, which are skipped.
+func (reader *wspReader) contentType() (string, error) {
+	octet, err := reader.peek()
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case octet&0x80 != 0:
+		reader.offset++
+		return wellKnownContentType(octet & 0x7F), nil
+	case octet > 31:
+		return reader.textString()
+	}
+
+	length, err := reader.valueLength()
+	if err != nil {
+		return "", err
+	}
+
+	value, err := reader.next(length)
+	if err != nil {
+		return "", err
+	}
+
+	inner := &wspReader{data: value}
+	if octet, err = inner.peek(); err == nil && octet&0x80 == 0 && octet > 31 {
+		return inner.textString()
+	}
+
+	code, err := inner.integer()
+	if err != nil {
+		return "", err
+	}
+
+	return wellKnownContentType(byte(code)), nil
+}
+
+// skipValue skips a header value of unknown meaning, using the generic rules of the encoding.
+func (reader *wspReader) skipValue() error {
+	octet, err := reader.peek()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case octet&0x80 != 0:
+		reader.offset++
+		return nil
+	case octet > 31:
+		_, err = reader.textString()
+		return err
+	}
+
+	length, err := reader.valueLength()
+	if err != nil {
+		return err
+	}
+
+	_, err = reader.next(length)
+
+	return err
+}
+
+// wellKnownContentType returns the name of a well known WSP content type.
+func wellKnownContentType(code byte) string {
+	if name, found := wspContentTypes[code]; found {
+		return name
+	}
+
+	return fmt.Sprintf("application/x-wap-content-type-0x%02X", code)
+}